@@ -0,0 +1,117 @@
+// Command evaluate-detector replays a dataset exported by the detections
+// export through the detector and reports the precision and the recall of
+// the end dates by the printed formats. The samples are recognized on their
+// images by the OCR backend, or only parsed from their stored OCR text with
+// -text, which measures the changes of the parser alone.
+//
+//	evaluate-detector -file detections.jsonl -text
+//	evaluate-detector -file detections.jsonl -backend http -url http://ocr:8080/recognize
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+)
+
+func main() {
+	var (
+		path      string
+		text      bool
+		asJSON    bool
+		languages string
+		cfg       = new(config.Config)
+	)
+	flag.StringVar(&path, "file", "", "path of the JSON lines of the exported detections")
+	flag.BoolVar(&text, "text", false, "parse the stored OCR text instead of recognizing the images")
+	flag.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	flag.StringVar(&cfg.OCR.Backend, "backend", ocr.BackendTesseract, "OCR backend: tesseract or http")
+	flag.StringVar(&cfg.OCR.URL, "url", "", "URL of the OCR service of the http backend")
+	flag.StringVar(&languages, "lang", "", "Tesseract language packs, such as eng+rus")
+	flag.IntVar(&cfg.OCR.Workers, "workers", 1, "number of the Tesseract clients")
+	flag.DurationVar(&cfg.OCR.Timeout, "timeout", 30*time.Second, "timeout of the recognition of an image")
+	flag.StringVar(&cfg.OCR.Passes, "passes", "", "Tesseract passes in the OCR_PASSES format")
+	flag.Parse()
+	if path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	samples, err := readSamples(path)
+	if err != nil {
+		log.Fatalf("read dataset: %v", err)
+	}
+	var backend ocr.Backend
+	if !text {
+		if languages != "" {
+			cfg.OCR.Languages = strings.Split(languages, "+")
+		}
+		cfg.OCR.Queue = cfg.OCR.Workers
+		if backend, err = ocr.New(cfg); err != nil {
+			log.Fatalf("ocr backend create: %v", err)
+		}
+		defer backend.Close()
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report, err := detection.Evaluate(ctx, sldetector.New(backend), samples, text)
+	if err != nil {
+		log.Fatalf("evaluate: %v", err)
+	}
+	if asJSON {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	printReport(report)
+}
+
+// readSamples decodes the JSON lines of the samples.
+func readSamples(path string) ([]params.DetectionSample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var samples []params.DetectionSample
+	dec := json.NewDecoder(file)
+	for {
+		var sample params.DetectionSample
+		err := dec.Decode(&sample)
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", len(samples)+1, err)
+		}
+		samples = append(samples, sample)
+	}
+}
+
+// printReport prints the scores of the formats as a table.
+func printReport(report params.DetectionReport) {
+	fmt.Printf("%d samples, %d skipped\n\n", report.Samples, report.Skipped)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "format\ttp\tfp\tfn\tprecision\trecall\t")
+	for _, s := range append(report.Formats, report.Total) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.3f\t%.3f\t\n",
+			s.Format, s.TruePositives, s.FalsePositives, s.FalseNegatives, s.Precision, s.Recall)
+	}
+	w.Flush()
+}
@@ -0,0 +1,69 @@
+// Command import-products imports the products of an Open Food Facts dump.
+// The import is saved by chunks, an interrupted import continues from the
+// last chunk when it is started again with the same dump.
+//
+//	import-products -file openfoodfacts-products.jsonl.gz -lang ru -categories categories.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+	"github.com/romankravchuk/muerta/internal/services/catalog"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/catalog"
+)
+
+func main() {
+	payload := new(params.ImportProducts)
+	flag.StringVar(&payload.Path, "file", "", "path of the JSONL or CSV dump, may be gzipped")
+	flag.StringVar(&payload.Format, "format", "", "format of the dump: jsonl or csv, detected by the extension if empty")
+	flag.StringVar(&payload.Language, "lang", "", "language of the product names")
+	flag.StringVar(&payload.Categories, "categories", "", "JSON file mapping the category tags onto the category names")
+	flag.BoolVar(&payload.DryRun, "dry-run", false, "count the changes and roll them back")
+	flag.BoolVar(&payload.Restart, "restart", false, "ignore the checkpoint of the dump")
+	flag.IntVar(&payload.ChunkSize, "chunk", 0, "number of the records merged in one transaction")
+	flag.Parse()
+	if payload.Path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.New()
+	if err != nil {
+		log.Fatalf("config create: %v", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client, err := postgres.New(ctx, 5, cfg)
+	if err != nil {
+		log.Fatalf("database connection: %v", err)
+	}
+	defer client.Close()
+
+	svc := catalog.New(repository.New(client))
+	result, err := svc.ImportProducts(ctx, payload, func(state params.FindImport) {
+		fmt.Fprintf(
+			os.Stderr,
+			"%d records, %.1f%%, %d created, %d matched by barcode, %d matched by name\n",
+			state.Position,
+			state.Progress,
+			state.Stats.Created,
+			state.Stats.MatchedBarcode,
+			state.Stats.MatchedName,
+		)
+	})
+	data, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(data))
+	if err != nil {
+		log.Fatalf("import: %v", err)
+	}
+}
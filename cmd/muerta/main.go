@@ -13,14 +13,21 @@ import (
 	"github.com/romankravchuk/muerta/internal/api"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	detectionjob "github.com/romankravchuk/muerta/internal/services/detection-job"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	detections "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
 	"github.com/romankravchuk/muerta/internal/storage/redis"
+	"github.com/romankravchuk/muerta/internal/storage/redis/job"
 )
 
 var (
-	client *pgxpool.Pool
-	cache  redis.Client
-	cfg    *config.Config
+	client  *pgxpool.Pool
+	cache   redis.Client
+	backend ocr.Backend
+	cfg     *config.Config
 )
 
 func init() {
@@ -41,6 +48,10 @@ func init() {
 	if err != nil {
 		log.Fatalf("redis connection: %v", err)
 	}
+	backend, err = ocr.New(cfg)
+	if err != nil {
+		log.Fatalf("ocr backend create: %v", err)
+	}
 }
 
 // main start point of the application
@@ -57,14 +68,35 @@ func init() {
 //	@name						Authrization
 func main() {
 	logger := logger.New()
-	api := api.New(cfg, client, cache, logger)
+	api := api.New(cfg, client, cache, backend, logger)
+	jobs := detectionjob.New(
+		job.New(cache),
+		sldetector.New(backend),
+		detection.New(detections.New(client)),
+		cfg,
+		logger,
+	)
+	ctx, stopJobs := context.WithCancel(context.Background())
+	jobsDone := make(chan struct{})
+	go func() {
+		jobs.Run(ctx)
+		close(jobsDone)
+	}()
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
 		log.Println("Gracefully shutting down...")
-		cfg.ShutdownShelfDetectorChan <- struct{}{}
 		_ = api.Shutdown()
 	}()
-	log.Fatalf("api run: %v", api.Run())
+	err := api.Run()
+	// the running jobs are queued again before the backend is closed
+	stopJobs()
+	<-jobsDone
+	if err := backend.Close(); err != nil {
+		log.Printf("ocr backend close: %v", err)
+	}
+	if err != nil {
+		log.Fatalf("api run: %v", err)
+	}
 }
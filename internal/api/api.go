@@ -6,6 +6,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/redis"
 )
@@ -19,10 +20,11 @@ func New(
 	cfg *config.Config,
 	client postgres.Client,
 	cache redis.Client,
+	backend ocr.Backend,
 	logger logger.Logger,
 ) *API {
 	return &API{
-		router:     router.NewV1(cfg, client, cache, logger),
+		router:     router.NewV1(cfg, client, cache, backend, logger),
 		listenAddr: fmt.Sprintf("0.0.0.0:%s", cfg.API.Port),
 	}
 }
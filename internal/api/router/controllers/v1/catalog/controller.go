@@ -0,0 +1,84 @@
+package catalog
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/catalog"
+)
+
+type CatalogController struct {
+	svc service.CatalogServicer
+	log logger.Logger
+}
+
+func New(svc service.CatalogServicer, log logger.Logger) *CatalogController {
+	return &CatalogController{svc: svc, log: log}
+}
+
+// StartImport starts the import of a product catalog
+//
+//	@Summary		Import products
+//	@Description	Start the import of the products, the categories and the barcodes from an Open Food Facts JSONL or CSV dump on the server. The import continues from the last checkpoint of the dump, the dry run is rolled back.
+//	@Tags			Catalog
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.ImportProducts	true	"Dump to import"
+//	@Success		202		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		409		{object}	handlers.HTTPError
+//	@Router			/catalog/imports [post]
+//	@Security		Bearer
+func (h *CatalogController) StartImport(ctx *fiber.Ctx) error {
+	payload := new(params.ImportProducts)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.StartImport(payload)
+	if errors.Is(err, service.ErrInvalidSource) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if errors.Is(err, service.ErrImportRunning) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusConflict).
+			JSON(controllers.HTTPError{Error: fiber.ErrConflict.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.Status(http.StatusAccepted).
+		JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"import": result}})
+}
+
+// FindImports finds the imports of product catalogs
+//
+//	@Summary		Find imports
+//	@Description	Find the progress and the statistics of the imports started since the start of the server
+//	@Tags			Catalog
+//	@Produce		json
+//	@Success		200	{object}	handlers.HTTPSuccess
+//	@Router			/catalog/imports [get]
+//	@Security		Bearer
+func (h *CatalogController) FindImports(ctx *fiber.Ctx) error {
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"imports": h.svc.FindImports()},
+	})
+}
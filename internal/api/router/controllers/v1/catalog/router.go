@@ -0,0 +1,28 @@
+package catalog
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/catalog"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/catalog"
+)
+
+func NewRouter(
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	router := fiber.New()
+	repo := repository.New(client)
+	svc := service.New(repo)
+	handler := New(svc, log)
+	router.Route("/imports", func(router fiber.Router) {
+		router.Use(jware.DeserializeUser, access.AdminOnly(log))
+		router.Get("/", handler.FindImports)
+		router.Post("/", handler.StartImport)
+	})
+	return router
+}
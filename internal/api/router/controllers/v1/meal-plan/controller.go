@@ -0,0 +1,226 @@
+package mealplan
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/meal-plan"
+	recipesvc "github.com/romankravchuk/muerta/internal/services/recipe"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/meal-plan"
+)
+
+type MealPlanController struct {
+	svc service.MealPlanServicer
+	log logger.Logger
+}
+
+func New(svc service.MealPlanServicer, log logger.Logger) *MealPlanController {
+	return &MealPlanController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// FindMany godoc
+//
+//	@Summary		Find meal plans
+//	@Description	Find meal plans of the user ordered by date
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			filter	query		dto.MealPlanFilter	true	"filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/meal-plans [get]
+//	@Security		Bearer
+func (h *MealPlanController) FindMany(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	filter := new(params.MealPlanFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.FindMealPlans(ctx.Context(), userID, filter)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	count, err := h.svc.Count(ctx.Context(), userID, *filter)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"meal-plans": result, "count": count},
+	})
+}
+
+// Create godoc
+//
+//	@Summary		Create meal plan
+//	@Description	Schedule the recipe and reserve the ingredients from the soonest-expiring shelf lives
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.CreateMealPlan	true	"meal plan"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/meal-plans [post]
+//	@Security		Bearer
+func (h *MealPlanController) Create(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*params.TokenPayload)
+	payload := new(params.CreateMealPlan)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.CreateMealPlan(
+		ctx.Context(), user.UserID, payload, access.HasRole(user, "moderator", "admin"),
+	)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"meal-plan": result},
+	})
+}
+
+// FindOne godoc
+//
+//	@Summary		Find meal plan by id
+//	@Description	Find meal plan with reservations and warnings by id
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_meal_plan	path		int	true	"meal plan id"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/meal-plans/{id_meal_plan} [get]
+//	@Security		Bearer
+func (h *MealPlanController) FindOne(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.MealPlanID).(int)
+	result, err := h.svc.FindMealPlanByID(ctx.Context(), userID, id)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"meal-plan": result},
+	})
+}
+
+// Update godoc
+//
+//	@Summary		Update meal plan
+//	@Description	Reschedule the meal or change the servings, the ingredients are reserved again
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_meal_plan	path		int					true	"meal plan id"
+//	@Param			payload			body		dto.UpdateMealPlan	true	"meal plan"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/meal-plans/{id_meal_plan} [put]
+//	@Security		Bearer
+func (h *MealPlanController) Update(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.MealPlanID).(int)
+	payload := new(params.UpdateMealPlan)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.UpdateMealPlan(ctx.Context(), userID, id, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"meal-plan": result},
+	})
+}
+
+// Delete godoc
+//
+//	@Summary		Delete meal plan
+//	@Description	Delete meal plan and release its reservations
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_meal_plan	path		int	true	"meal plan id"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/meal-plans/{id_meal_plan} [delete]
+//	@Security		Bearer
+func (h *MealPlanController) Delete(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.MealPlanID).(int)
+	if err := h.svc.DeleteMealPlan(ctx.Context(), userID, id); err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// Cook godoc
+//
+//	@Summary		Cook meal plan
+//	@Description	Mark the meal as cooked and consume the reserved quantities of the shelf lives
+//	@Tags			Meal Plans
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_meal_plan	path		int	true	"meal plan id"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/meal-plans/{id_meal_plan}/cook [post]
+//	@Security		Bearer
+func (h *MealPlanController) Cook(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.MealPlanID).(int)
+	result, err := h.svc.CookMealPlan(ctx.Context(), userID, id)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"meal-plan": result},
+	})
+}
+
+func (h *MealPlanController) badRequest(ctx *fiber.Ctx, err error) error {
+	if err, ok := err.(validator.ValidationErrors); ok {
+		h.log.Error(ctx, logger.Validation, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	h.log.Error(ctx, logger.Client, err)
+	return ctx.Status(http.StatusBadRequest).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+}
+
+func (h *MealPlanController) serviceError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, recipesvc.ErrNotVisible) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if errors.Is(err, repository.ErrCooked) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusConflict).
+			JSON(controllers.HTTPError{Error: fiber.ErrConflict.Error()})
+	}
+	h.log.Error(ctx, logger.Server, err)
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
@@ -0,0 +1,35 @@
+package mealplan
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/meal-plan"
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/meal-plan"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+)
+
+func NewRouter(
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	router := fiber.New()
+	repo := repository.New(client, measure.Convert)
+	svc := service.New(repo, recipes.New(client))
+	handler := New(svc, log)
+	router.Use(jware.DeserializeUser)
+	router.Get("/", handler.FindMany)
+	router.Post("/", handler.Create)
+	router.Route(context.MealPlanID.Path(), func(router fiber.Router) {
+		router.Use(context.New(log, context.MealPlanID))
+		router.Get("/", handler.FindOne)
+		router.Put("/", handler.Update)
+		router.Delete("/", handler.Delete)
+		router.Post("/cook", handler.Cook)
+	})
+	return router
+}
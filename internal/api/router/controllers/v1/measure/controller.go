@@ -1,6 +1,7 @@
 package measure
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -186,3 +187,46 @@ func (h *MeasureController) Delete(ctx *fiber.Ctx) error {
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// Convert converts a quantity of a product between measures.
+//
+//	@Summary		Convert a quantity
+//	@Description	Converts a quantity from one measure to another, the product is required to convert between dimensions.
+//	@Tags			Measures
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	query		dto.ConvertMeasure	true	"Quantity and measures"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/measures/convert [get]
+func (h *MeasureController) Convert(ctx *fiber.Ctx) error {
+	payload := new(params.ConvertMeasure)
+	if err := utils.ParseFilterAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.ConvertMeasure(ctx.Context(), payload)
+	if errors.Is(err, service.ErrIncompatibleMeasures) ||
+		errors.Is(err, service.ErrUnknownDimension) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"conversion": result},
+	})
+}
@@ -9,6 +9,7 @@ import (
 	service "github.com/romankravchuk/muerta/internal/services/measure"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 )
 
 func NewRouter(
@@ -18,9 +19,10 @@ func NewRouter(
 ) *fiber.App {
 	router := fiber.New()
 	repo := repository.New(client)
-	svc := service.New(repo)
+	svc := service.New(repo, product.New(client))
 	handler := New(svc, log)
 	router.Get("/", handler.FindMany)
+	router.Get("/convert", handler.Convert)
 	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
 	router.Route(context.MeasureID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.MeasureID))
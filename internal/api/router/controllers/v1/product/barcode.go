@@ -0,0 +1,142 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/barcode"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/product"
+)
+
+// FindByBarcode finds a product by barcode
+//
+//	@Summary		Find a product by barcode
+//	@Description	Find the product of the EAN-13 or UPC-A barcode, a suggestion to create the product is returned for an unknown barcode
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			barcode	path		string	true	"EAN-13 or UPC-A code"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/products/barcodes/{barcode} [get]
+func (h *ProductController) FindByBarcode(ctx *fiber.Ctx) error {
+	result, err := h.svc.FindProductByBarcode(ctx.Context(), ctx.Params("barcode"))
+	if err != nil {
+		return h.barcodeError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"barcode": result}})
+}
+
+// ScanBarcode decodes a barcode from image and finds its product
+//
+//	@Summary		Scan a barcode
+//	@Description	Decode the EAN-13 or UPC-A barcode from the JPEG, PNG or WebP image and find its product, a suggestion to create the product is returned for an unknown barcode
+//	@Tags			Products
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			fileToScan	formData	file	true	"image of the barcode"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		413			{object}	handlers.HTTPError
+//	@Failure		415			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/barcodes/scan [post]
+//	@Security		Bearer
+func (h *ProductController) ScanBarcode(ctx *fiber.Ctx) error {
+	data, err := utils.ReadImage(ctx, "fileToScan", h.limitSize)
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	result, err := h.svc.ScanBarcode(ctx.Context(), data)
+	if err != nil {
+		return h.barcodeError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"barcode": result}})
+}
+
+// AddBarcode adds barcode to product
+//
+//	@Summary		Add a barcode to a product
+//	@Description	Add the EAN-13 or UPC-A barcode to the product, UPC-A codes are stored as EAN-13
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		int					true	"Product ID"
+//	@Param			payload		body		dto.ProductBarcode	true	"Barcode"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		409			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/barcodes [post]
+//	@Security		Bearer
+func (h *ProductController) AddBarcode(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ProductID).(int)
+	payload := new(params.ProductBarcode)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	if err := h.svc.AddBarcode(ctx.Context(), id, payload); err != nil {
+		return h.barcodeError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// RemoveBarcode removes barcode from product
+//
+//	@Summary		Remove a barcode from a product
+//	@Description	Remove the barcode from the product
+//	@Tags			Products
+//	@Param			product_id	path		int		true	"Product ID"
+//	@Param			barcode		path		string	true	"EAN-13 or UPC-A code"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/barcodes/{barcode} [delete]
+//	@Security		Bearer
+func (h *ProductController) RemoveBarcode(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ProductID).(int)
+	if err := h.svc.RemoveBarcode(ctx.Context(), id, ctx.Params("barcode")); err != nil {
+		return h.barcodeError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// barcodeError responds with the status of the barcode error.
+func (h *ProductController) barcodeError(ctx *fiber.Ctx, err error) error {
+	var e *fiber.Error
+	switch {
+	case errors.Is(err, barcode.ErrInvalid), errors.Is(err, imaging.ErrCorrupt):
+		e = fiber.ErrBadRequest
+	case errors.Is(err, imaging.ErrTooLarge):
+		e = fiber.ErrRequestEntityTooLarge
+	case errors.Is(err, barcode.ErrNotFound):
+		e = fiber.ErrUnprocessableEntity
+	case errors.Is(err, repo.ErrBarcodeTaken):
+		e = fiber.ErrConflict
+	default:
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	h.log.Error(ctx, logger.Client, err)
+	return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+}
@@ -14,14 +14,16 @@ import (
 )
 
 type ProductController struct {
-	svc service.ProductServicer
-	log logger.Logger
+	svc       service.ProductServicer
+	log       logger.Logger
+	limitSize int64
 }
 
-func New(svc service.ProductServicer, log logger.Logger) *ProductController {
+func New(svc service.ProductServicer, log logger.Logger, limitSize int64) *ProductController {
 	return &ProductController{
-		svc: svc,
-		log: log,
+		svc:       svc,
+		log:       log,
+		limitSize: limitSize,
 	}
 }
 
@@ -35,6 +37,7 @@ func New(svc service.ProductServicer, log logger.Logger) *ProductController {
 //	@Param			payload	body		dto.CreateProduct	true	"Product details"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		409		{object}	handlers.HTTPError
 //	@Failure		502		{object}	handlers.HTTPError
 //	@Router			/products [post]
 //	@Security		Bearer
@@ -51,9 +54,7 @@ func (h *ProductController) Create(ctx *fiber.Ctx) error {
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
 	if err := h.svc.CreateProduct(ctx.Context(), payload); err != nil {
-		h.log.Error(ctx, logger.Server, err)
-		return ctx.Status(http.StatusBadGateway).
-			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+		return h.barcodeError(ctx, err)
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
@@ -230,7 +231,7 @@ func (h *ProductController) FindCategories(ctx *fiber.Ctx) error {
 // FindRecipes finds product recipes
 //
 //	@Summary		Get recipes of a product
-//	@Description	Get the recipes of a product by ID
+//	@Description	Get the recipes of a product by ID, the published recipes and the recipes of the user are listed
 //	@Tags			Products
 //	@Accept			json
 //	@Produce		json
@@ -240,7 +241,11 @@ func (h *ProductController) FindCategories(ctx *fiber.Ctx) error {
 //	@Router			/products/{product_id}/recipes [get]
 func (h *ProductController) FindRecipes(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ProductID).(int)
-	recipes, err := h.svc.FindProductRecipes(ctx.Context(), id)
+	userID := 0
+	if user, ok := ctx.Locals("user").(*params.TokenPayload); ok {
+		userID = user.UserID
+	}
+	recipes, err := h.svc.FindProductRecipes(ctx.Context(), id, userID)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
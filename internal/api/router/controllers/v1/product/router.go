@@ -5,6 +5,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	svc "github.com/romankravchuk/muerta/internal/services/product"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
@@ -12,6 +13,7 @@ import (
 )
 
 func NewRouter(
+	cfg *config.Config,
 	client postgres.Client,
 	log logger.Logger,
 	jware *jware.JWTMiddleware,
@@ -19,9 +21,13 @@ func NewRouter(
 	router := fiber.New()
 	repository := repo.New(client)
 	service := svc.New(repository)
-	handler := New(service, log)
+	handler := New(service, log, cfg.OCR.UploadLimit)
 	router.Get("/", handler.FindMany)
 	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
+	router.Route("/barcodes", func(router fiber.Router) {
+		router.Post("/scan", jware.DeserializeUser, handler.ScanBarcode)
+		router.Get("/:barcode", handler.FindByBarcode)
+	})
 	router.Route(context.ProductID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.ProductID))
 		router.Get("/", handler.FindOne)
@@ -38,8 +44,17 @@ func NewRouter(
 				)
 			})
 		})
+		router.Route("/barcodes", func(router fiber.Router) {
+			router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddBarcode)
+			router.Delete(
+				"/:barcode",
+				jware.DeserializeUser,
+				access.AdminOnly(log),
+				handler.RemoveBarcode,
+			)
+		})
 		router.Route("/recipes", func(router fiber.Router) {
-			router.Get("/", handler.FindRecipes)
+			router.Get("/", jware.OptionalUser, handler.FindRecipes)
 		})
 		router.Route("/tips", func(router fiber.Router) {
 			router.Get("/", handler.FindTips)
@@ -0,0 +1,161 @@
+package receipt
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/receipt"
+	service "github.com/romankravchuk/muerta/internal/services/receipt"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/receipt"
+)
+
+type ReceiptController struct {
+	svc       service.ReceiptServicer
+	log       logger.Logger
+	limitSize int64
+}
+
+func New(svc service.ReceiptServicer, log logger.Logger, limitSize int64) *ReceiptController {
+	return &ReceiptController{svc: svc, log: log, limitSize: limitSize}
+}
+
+// FindReceipt finds the items of a receipt by its QR code
+//
+//	@Summary		Find a receipt
+//	@Description	Find the items of the fiscal receipt by the text of its QR code and propose the products and the measures of the items
+//	@Tags			Receipts
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.ReceiptQR	true	"QR code"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Failure		503		{object}	handlers.HTTPError
+//	@Router			/receipts [post]
+//	@Security		Bearer
+func (h *ReceiptController) FindReceipt(ctx *fiber.Ctx) error {
+	payload := new(params.ReceiptQR)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindReceipt(ctx.Context(), payload)
+	if err != nil {
+		return h.receiptError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"receipt": result}})
+}
+
+// ScanReceipt decodes the QR code of a receipt from image and finds its items
+//
+//	@Summary		Scan a receipt
+//	@Description	Decode the QR code of the fiscal receipt from the JPEG, PNG or WebP image, find the items of the receipt and propose the products and the measures of the items
+//	@Tags			Receipts
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			fileToScan	formData	file	true	"image of the receipt"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		404			{object}	handlers.HTTPError
+//	@Failure		413			{object}	handlers.HTTPError
+//	@Failure		415			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Failure		503			{object}	handlers.HTTPError
+//	@Router			/receipts/scan [post]
+//	@Security		Bearer
+func (h *ReceiptController) ScanReceipt(ctx *fiber.Ctx) error {
+	data, err := utils.ReadImage(ctx, "fileToScan", h.limitSize)
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	result, err := h.svc.ScanReceipt(ctx.Context(), data)
+	if err != nil {
+		return h.receiptError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"receipt": result}})
+}
+
+// ConfirmReceipt creates the shelf lives of a receipt
+//
+//	@Summary		Confirm a receipt
+//	@Description	Create the shelf lives of the items of the fiscal receipt confirmed by the user, the purchase date is the time of the receipt. A receipt can be confirmed once.
+//	@Tags			Receipts
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.ConfirmReceipt	true	"Shelf lives of the receipt"
+//	@Success		201		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		409		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/receipts/confirm [post]
+//	@Security		Bearer
+func (h *ReceiptController) ConfirmReceipt(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.ConfirmReceipt)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.ConfirmReceipt(ctx.Context(), userID, payload)
+	if err != nil {
+		return h.receiptError(ctx, err)
+	}
+	return ctx.Status(http.StatusCreated).
+		JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf_lives": result}})
+}
+
+// receiptError responds with the status of the receipt error.
+func (h *ReceiptController) receiptError(ctx *fiber.Ctx, err error) error {
+	var e *fiber.Error
+	switch {
+	case errors.Is(err, receipt.ErrInvalidQR),
+		errors.Is(err, service.ErrInvalidEndDate),
+		errors.Is(err, imaging.ErrCorrupt):
+		e = fiber.ErrBadRequest
+	case errors.Is(err, imaging.ErrTooLarge):
+		e = fiber.ErrRequestEntityTooLarge
+	case errors.Is(err, receipt.ErrNoQR):
+		e = fiber.ErrUnprocessableEntity
+	case errors.Is(err, apperrors.ErrNotOwner):
+		e = fiber.ErrForbidden
+	case errors.Is(err, receipt.ErrNotFound):
+		e = fiber.ErrNotFound
+	case errors.Is(err, repository.ErrReceiptAdded):
+		e = fiber.ErrConflict
+	case errors.Is(err, service.ErrNoProvider):
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusServiceUnavailable).
+			JSON(controllers.HTTPError{Error: fiber.ErrServiceUnavailable.Error()})
+	default:
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	h.log.Error(ctx, logger.Client, err)
+	return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+}
@@ -0,0 +1,34 @@
+package receipt
+
+import (
+	"github.com/gofiber/fiber/v2"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/receipt"
+	service "github.com/romankravchuk/muerta/internal/services/receipt"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/receipt"
+)
+
+func NewRouter(
+	cfg *config.Config,
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	router := fiber.New()
+	var provider receipt.ReceiptProvider
+	if cfg.ReceiptsDir != "" {
+		provider = receipt.NewFileProvider(cfg.ReceiptsDir)
+	}
+	svc := service.New(repository.New(client), product.New(client), measure.New(client), provider)
+	handler := New(svc, log, cfg.OCR.UploadLimit)
+	router.Use(jware.DeserializeUser)
+	router.Post("/", handler.FindReceipt)
+	router.Post("/scan", handler.ScanReceipt)
+	router.Post("/confirm", handler.ConfirmReceipt)
+	return router
+}
@@ -1,16 +1,23 @@
 package recipe
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/api/router/utils"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/schemaorg"
 	"github.com/romankravchuk/muerta/internal/services/recipe"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
 )
 
 type RecipesController struct {
@@ -28,7 +35,7 @@ func New(svc recipe.RecipeServicer, log logger.Logger) *RecipesController {
 // Create creates a new recipe with the provided data.
 //
 //	@Summary		Create a new recipe
-//	@Description	Creates a new recipe with the provided data
+//	@Description	Creates a new recipe with the provided data. The recipe of a user is private until it is published and approved by a moderator.
 //	@Tags			Recipes
 //	@Accept			json
 //	@Produce		json
@@ -39,6 +46,7 @@ func New(svc recipe.RecipeServicer, log logger.Logger) *RecipesController {
 //	@Router			/recipes [post]
 //	@Security		Bearer
 func (h *RecipesController) Create(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*params.TokenPayload)
 	payload := new(params.CreateRecipe)
 	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
 		if err, ok := err.(validator.ValidationErrors); ok {
@@ -50,7 +58,13 @@ func (h *RecipesController) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.CreateRecipe(ctx.Context(), payload); err != nil {
+	// the recipes of the admins are published, the recipes of the users are
+	// private until they are approved by a moderator
+	admin := access.HasRole(user, "admin")
+	if payload.UserID == 0 || !admin {
+		payload.UserID = user.UserID
+	}
+	if err := h.svc.CreateRecipe(ctx.Context(), payload, admin); err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
@@ -61,18 +75,31 @@ func (h *RecipesController) Create(ctx *fiber.Ctx) error {
 // FindOne finds a recipe by its ID.
 //
 //	@Summary		Find recipe by ID
-//	@Description	Finds a recipe by its ID
+//	@Description	Finds a recipe by its ID, the ingredients are scaled to the servings
 //	@Tags			Recipes
 //	@Accept			json
 //	@Produce		json
-//	@Param			recipe_id	path		int	true	"Recipe ID"
+//	@Param			recipe_id	path		int					true	"Recipe ID"
+//	@Param			servings	query		dto.RecipeServings	false	"Servings"
 //	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
 //	@Failure		404			{object}	handlers.HTTPError
 //	@Failure		502			{object}	handlers.HTTPError
 //	@Router			/recipes/{recipe_id} [get]
 func (h *RecipesController) FindOne(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.RecipeID).(int)
-	result, err := h.svc.FindRecipeByID(ctx.Context(), id)
+	query := new(params.RecipeServings)
+	if err := utils.ParseFilterAndValidate(ctx, query); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindRecipeByID(ctx.Context(), id, query.Servings)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusNotFound).
@@ -87,13 +114,14 @@ func (h *RecipesController) FindOne(ctx *fiber.Ctx) error {
 // FindMany finds recipes based on the provided filter.
 //
 //	@Summary		Find recipes
-//	@Description	Finds recipes based on the provided filter
+//	@Description	Finds the published recipes and the recipes of the user. The recipes are filtered by name, by the user, by the favourites of the user and by the minimum rating, and sorted by ID or by rating.
 //	@Tags			Recipes
 //	@Accept			json
 //	@Produce		json
-//	@Param			filter	query		dto.RecipeFilter	false	"Filter recipes by name, category, or ingredients"
+//	@Param			filter	query		dto.RecipeFilter	false	"Filter recipes by name, owner, favourites or rating"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		401		{object}	handlers.HTTPError
 //	@Failure		502		{object}	handlers.HTTPError
 //	@Router			/recipes [get]
 func (h *RecipesController) FindMany(ctx *fiber.Ctx) error {
@@ -108,13 +136,22 @@ func (h *RecipesController) FindMany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.FindRecipes(ctx.Context(), filter)
+	userID := 0
+	if user, ok := ctx.Locals("user").(*params.TokenPayload); ok {
+		userID = user.UserID
+	}
+	if userID == 0 && (filter.Mine || filter.Favourites) {
+		h.log.Error(ctx, logger.Client, fmt.Errorf("unauthorized request"))
+		return ctx.Status(http.StatusUnauthorized).
+			JSON(controllers.HTTPError{Error: fiber.ErrUnauthorized.Error()})
+	}
+	result, err := h.svc.FindRecipes(ctx.Context(), userID, filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	count, err := h.svc.Count(ctx.Context(), *filter)
+	count, err := h.svc.Count(ctx.Context(), userID, *filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -131,7 +168,7 @@ func (h *RecipesController) FindMany(ctx *fiber.Ctx) error {
 // Update updates a recipe by its ID.
 //
 //	@Summary		Update a recipe
-//	@Description	Update a recipe by its ID.
+//	@Description	Update a recipe by its ID. The published recipe edited by a user is moved back to moderation, as with its ingredients and steps.
 //	@Tags			Recipes
 //	@Accept			json
 //	@Produce		json
@@ -155,7 +192,7 @@ func (h *RecipesController) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.UpdateRecipe(ctx.Context(), id, payload); err != nil {
+	if err := h.svc.UpdateRecipe(ctx.Context(), id, payload, review(ctx)); err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
@@ -247,7 +284,7 @@ func (h *RecipesController) AddIngredient(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.CreateIngredient(ctx.Context(), id, payload)
+	result, err := h.svc.CreateIngredient(ctx.Context(), id, payload, review(ctx))
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -283,7 +320,7 @@ func (h *RecipesController) UpdateIngredient(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.UpdateIngredient(ctx.Context(), id, payload)
+	result, err := h.svc.UpdateIngredient(ctx.Context(), id, payload, review(ctx))
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -319,7 +356,7 @@ func (h *RecipesController) RemoveIngredient(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.DeleteIngredient(ctx.Context(), id, payload); err != nil {
+	if err := h.svc.DeleteIngredient(ctx.Context(), id, payload, review(ctx)); err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
@@ -352,35 +389,58 @@ func (h *RecipesController) FindSteps(ctx *fiber.Ctx) error {
 
 // AddStep creates a new recipe step for a given recipe and step ID.
 //
-//	@Summary	Create a recipe step
-//	@Tags		Recipes
-//	@Param		recipe_id	path		int						true	"Recipe ID"
-//	@Param		step_id		path		int						true	"Step ID"
-//	@Param		payload		body		dto.CreateRecipeStep	true	"Request body"
-//	@Success	200			{object}	handlers.HTTPSuccess
-//	@Failure	400			{object}	handlers.HTTPError
-//	@Failure	502			{object}	handlers.HTTPError
-//	@Router		/recipes/{recipe_id}/steps/{step_id} [post]
-//	@Security	Bearer
+//	@Summary		Create a recipe step
+//	@Description	Inserts the step at the place, the later steps are shifted. The step is appended if the place is omitted.
+//	@Tags			Recipes
+//	@Param			recipe_id	path		int						true	"Recipe ID"
+//	@Param			step_id		path		int						true	"Step ID"
+//	@Param			payload		body		dto.CreateRecipeStep	true	"Request body"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/steps/{step_id} [post]
+//	@Security		Bearer
 func (h *RecipesController) AddStep(ctx *fiber.Ctx) error {
 	recipeId := ctx.Locals(context.RecipeID).(int)
 	stepId := ctx.Locals(context.StepID).(int)
 	payload := new(params.CreateRecipeStep)
 	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
-		if err, ok := err.(validator.ValidationErrors); ok {
-			h.log.Error(ctx, logger.Validation, err)
-			return ctx.Status(http.StatusBadRequest).
-				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
-		}
-		h.log.Error(ctx, logger.Client, err)
-		return ctx.Status(http.StatusBadRequest).
-			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		return h.badRequest(ctx, err)
 	}
-	result, err := h.svc.CreateRecipeStep(ctx.Context(), recipeId, stepId, payload.Place)
+	result, err := h.svc.CreateRecipeStep(ctx.Context(), recipeId, stepId, payload, review(ctx))
 	if err != nil {
-		h.log.Error(ctx, logger.Server, err)
-		return ctx.Status(http.StatusBadGateway).
-			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+		return h.stepError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"step": result}})
+}
+
+// UpdateStep updates the duration, the timer, the temperature and the
+// ingredients of a recipe step.
+//
+//	@Summary		Update a recipe step
+//	@Description	Updates the step at the place, the first step with the ID if the place is omitted.
+//	@Tags			Recipes
+//	@Param			recipe_id	path		int						true	"Recipe ID"
+//	@Param			step_id		path		int						true	"Step ID"
+//	@Param			payload		body		dto.UpdateRecipeStep	true	"Request body"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		404			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/steps/{step_id} [put]
+//	@Security		Bearer
+func (h *RecipesController) UpdateStep(ctx *fiber.Ctx) error {
+	recipeId := ctx.Locals(context.RecipeID).(int)
+	stepId := ctx.Locals(context.StepID).(int)
+	payload := new(params.UpdateRecipeStep)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.UpdateRecipeStep(ctx.Context(), recipeId, stepId, payload, review(ctx))
+	if err != nil {
+		return h.stepError(ctx, err)
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"step": result}})
 }
@@ -388,36 +448,444 @@ func (h *RecipesController) AddStep(ctx *fiber.Ctx) error {
 // RemoveStep removes a recipe step.
 //
 //	@Summary		Remove a recipe step.
-//	@Description	Removes the specified recipe step of a recipe.
+//	@Description	Removes the step at the place, the first step with the ID if the place is omitted. The later steps are shifted to close the gap.
 //	@Tags			Recipes
 //	@Accept			json
 //	@Produce		json
 //	@Param			recipe_id	path		int						true	"Recipe ID."
 //	@Param			step_id		path		int						true	"Step ID."
-//	@Param			playload	body		dto.DeleteRecipeStep	true	"Request body"
+//	@Param			place		query		dto.DeleteRecipeStep	false	"Place of the step"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		404			{object}	handlers.HTTPError
 //	@Failure		502			{object}	handlers.HTTPError
 //	@Router			/recipes/{recipe_id}/steps/{step_id} [delete]
 //	@Security		Bearer
 func (h *RecipesController) RemoveStep(ctx *fiber.Ctx) error {
 	recipeId := ctx.Locals(context.RecipeID).(int)
 	stepId := ctx.Locals(context.StepID).(int)
-	payload := new(params.DeleteRecipeStep)
+	query := new(params.DeleteRecipeStep)
+	if err := utils.ParseFilterAndValidate(ctx, query); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	if err := h.svc.DeleteRecipeStep(ctx.Context(), recipeId, stepId, query.Place, review(ctx)); err != nil {
+		return h.stepError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// ReorderSteps reorders the steps of a recipe.
+//
+//	@Summary		Reorder recipe steps
+//	@Description	Renumbers the steps of a recipe in one transaction. The payload lists the IDs of all the steps of the recipe in the new order, a repeated step is listed as many times as it is repeated.
+//	@Tags			Recipes
+//	@Accept			json
+//	@Produce		json
+//	@Param			recipe_id	path		int						true	"Recipe ID"
+//	@Param			payload		body		dto.ReorderRecipeSteps	true	"Request body"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/steps/order [put]
+//	@Security		Bearer
+func (h *RecipesController) ReorderSteps(ctx *fiber.Ctx) error {
+	recipeId := ctx.Locals(context.RecipeID).(int)
+	payload := new(params.ReorderRecipeSteps)
 	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
-		if err, ok := err.(validator.ValidationErrors); ok {
-			h.log.Error(ctx, logger.Validation, err)
-			return ctx.Status(http.StatusBadRequest).
-				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
-		}
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.ReorderRecipeSteps(ctx.Context(), recipeId, payload, review(ctx))
+	if err != nil {
+		return h.stepError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"steps": result}})
+}
+
+func (h *RecipesController) badRequest(ctx *fiber.Ctx, err error) error {
+	if err, ok := err.(validator.ValidationErrors); ok {
+		h.log.Error(ctx, logger.Validation, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	h.log.Error(ctx, logger.Client, err)
+	return ctx.Status(http.StatusBadRequest).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+}
+
+func (h *RecipesController) stepError(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, recipes.ErrStepNotFound):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	case errors.Is(err, recipes.ErrInvalidOrder), errors.Is(err, recipe.ErrUnknownIngredient):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	h.log.Error(ctx, logger.Server, err)
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
+
+// Import imports a recipe from a schema.org JSON-LD document.
+//
+//	@Summary		Import recipe
+//	@Description	Imports a schema.org Recipe from a JSON-LD document or an HTML page containing one. The ingredient lines are matched to the products and the measures, the unmatched lines are returned for review. The recipe is not saved on the dry run.
+//	@Tags			Recipes
+//	@Accept			json,html
+//	@Produce		json
+//	@Param			dry_run	query		dto.ImportRecipe	false	"Dry run"
+//	@Param			payload	body		string				true	"JSON-LD document or HTML page"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/recipes/import [post]
+//	@Security		Bearer
+func (h *RecipesController) Import(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	query := new(params.ImportRecipe)
+	if err := utils.ParseFilterAndValidate(ctx, query); err != nil {
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.DeleteRecipeStep(ctx.Context(), recipeId, stepId, payload.Place); err != nil {
+	result, err := h.svc.ImportRecipe(ctx.Context(), userID, ctx.Body(), query.DryRun)
+	if errors.Is(err, schemaorg.ErrNoRecipe) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"recipe": result.Recipe, "unmatched": result.Unmatched},
+	})
+}
+
+// Export exports a recipe to a schema.org JSON-LD document.
+//
+//	@Summary		Export recipe
+//	@Description	Exports a recipe as a schema.org Recipe JSON-LD document
+//	@Tags			Recipes
+//	@Produce		application/ld+json
+//	@Param			recipe_id	path		int	true	"Recipe ID"
+//	@Success		200			{string}	string
+//	@Failure		404			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/export [get]
+func (h *RecipesController) Export(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	data, err := h.svc.ExportRecipe(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, "application/ld+json; charset=utf-8")
+	return ctx.Send(data)
+}
+
+// FindNutrition computes the nutrition of a recipe.
+//
+//	@Summary		Find recipe nutrition
+//	@Description	Computes the total and the per serving nutrition of a recipe from the nutrition facts of the products of the ingredients. The ingredients are scaled to the servings.
+//	@Tags			Recipes
+//	@Produce		json
+//	@Param			recipe_id	path		int					true	"Recipe ID"
+//	@Param			servings	query		dto.RecipeServings	false	"Servings"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		404			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/nutrition [get]
+func (h *RecipesController) FindNutrition(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	query := new(params.RecipeServings)
+	if err := utils.ParseFilterAndValidate(ctx, query); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.FindRecipeNutrition(ctx.Context(), id, query.Servings)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"nutrition": result}})
+}
+
+// Visible passes the requests to the published recipes, the other recipes
+// are visible only to their owners, the moderators and the admins.
+func (h *RecipesController) Visible(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	recipe, err := h.svc.FindRecipeAccess(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if recipe.Status == models.RecipePublished {
+		return ctx.Next()
+	}
+	user, _ := ctx.Locals("user").(*params.TokenPayload)
+	if user != nil && (user.UserID == recipe.UserID || access.HasRole(user, "moderator", "admin")) {
+		return ctx.Next()
+	}
+	h.log.Error(ctx, logger.Client, fmt.Errorf("recipe %d is %s", id, recipe.Status))
+	return ctx.Status(http.StatusNotFound).
+		JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+}
+
+// OwnerOnly passes the requests of the owner of the recipe and the admins.
+func (h *RecipesController) OwnerOnly(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	user, ok := ctx.Locals("user").(*params.TokenPayload)
+	if !ok {
+		h.log.Error(ctx, logger.Client, apperrors.ErrFailedToGetTokenPayload)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+	if access.HasRole(user, "admin") {
+		return ctx.Next()
+	}
+	recipe, err := h.svc.FindRecipeAccess(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if recipe.UserID != user.UserID {
+		h.log.Error(ctx, logger.Client, apperrors.ErrNotOwner)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+	return ctx.Next()
+}
+
+// review reports whether the edit of the user moves the published recipe
+// back to moderation, the edits of the moderators and the admins are not
+// reviewed.
+func review(ctx *fiber.Ctx) bool {
+	user, ok := ctx.Locals("user").(*params.TokenPayload)
+	return !ok || !access.HasRole(user, "moderator", "admin")
+}
+
+// FindPending finds the recipes waiting for moderation.
+//
+//	@Summary		Find pending recipes
+//	@Description	Finds the recipes of all the users waiting for moderation
+//	@Tags			Recipes
+//	@Produce		json
+//	@Param			filter	query		dto.RecipeFilter	false	"Filter recipes by name"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/recipes/moderation [get]
+//	@Security		Bearer
+func (h *RecipesController) FindPending(ctx *fiber.Ctx) error {
+	filter := new(params.RecipeFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, count, err := h.svc.FindPendingRecipes(ctx.Context(), filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"recipes": result, "count": count},
+	})
+}
+
+// Publish sends a recipe to moderation.
+//
+//	@Summary		Publish a recipe
+//	@Description	Sends the private or rejected recipe of the user to moderation
+//	@Tags			Recipes
+//	@Produce		json
+//	@Param			recipe_id	path		int	true	"Recipe ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		403			{object}	handlers.HTTPError
+//	@Failure		409			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/publish [post]
+//	@Security		Bearer
+func (h *RecipesController) Publish(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	if err := h.svc.PublishRecipe(ctx.Context(), id); err != nil {
+		return h.moderationError(ctx, err)
+	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// Moderate approves or rejects a pending recipe.
+//
+//	@Summary		Moderate a recipe
+//	@Description	Publishes or rejects the recipe waiting for moderation
+//	@Tags			Recipes
+//	@Accept			json
+//	@Produce		json
+//	@Param			recipe_id	path		int					true	"Recipe ID"
+//	@Param			payload		body		dto.ModerateRecipe	true	"Decision"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
+//	@Failure		409			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/moderation [post]
+//	@Security		Bearer
+func (h *RecipesController) Moderate(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	payload := new(params.ModerateRecipe)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	if err := h.svc.ModerateRecipe(ctx.Context(), id, payload); err != nil {
+		return h.moderationError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// FindRatings finds the ratings and the reviews of a recipe.
+//
+//	@Summary		Find recipe ratings
+//	@Description	Finds the ratings and the reviews of a recipe, the latest first
+//	@Tags			Recipes
+//	@Produce		json
+//	@Param			recipe_id	path		int						true	"Recipe ID"
+//	@Param			filter		query		dto.RecipeRatingFilter	false	"Paging"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/ratings [get]
+func (h *RecipesController) FindRatings(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	filter := new(params.RecipeRatingFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.FindRatings(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"ratings": result}})
+}
+
+// Rate rates and reviews a recipe.
+//
+//	@Summary		Rate a recipe
+//	@Description	Rates a published recipe of another user, the rating replaces the previous one of the user
+//	@Tags			Recipes
+//	@Accept			json
+//	@Produce		json
+//	@Param			recipe_id	path		int				true	"Recipe ID"
+//	@Param			payload		body		dto.RateRecipe	true	"Rating"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		422			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/rating [put]
+//	@Security		Bearer
+func (h *RecipesController) Rate(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.RateRecipe)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.RateRecipe(ctx.Context(), id, userID, payload)
+	if errors.Is(err, recipe.ErrNotPublished) || errors.Is(err, recipe.ErrOwnRecipe) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"rating": result}})
+}
+
+// DeleteRating deletes the rating of a recipe by the user.
+//
+//	@Summary	Delete a recipe rating
+//	@Tags		Recipes
+//	@Produce	json
+//	@Param		recipe_id	path		int	true	"Recipe ID"
+//	@Success	200			{object}	handlers.HTTPSuccess
+//	@Failure	502			{object}	handlers.HTTPError
+//	@Router		/recipes/{recipe_id}/rating [delete]
+//	@Security	Bearer
+func (h *RecipesController) DeleteRating(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	if err := h.svc.DeleteRating(ctx.Context(), id, userID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// AddFavourite marks a recipe as a favourite of the user.
+//
+//	@Summary	Add a favourite recipe
+//	@Tags		Recipes
+//	@Produce	json
+//	@Param		recipe_id	path		int	true	"Recipe ID"
+//	@Success	200			{object}	handlers.HTTPSuccess
+//	@Failure	502			{object}	handlers.HTTPError
+//	@Router		/recipes/{recipe_id}/favourite [put]
+//	@Security	Bearer
+func (h *RecipesController) AddFavourite(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	if err := h.svc.AddFavourite(ctx.Context(), id, userID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// RemoveFavourite removes a recipe from the favourites of the user.
+//
+//	@Summary	Remove a favourite recipe
+//	@Tags		Recipes
+//	@Produce	json
+//	@Param		recipe_id	path		int	true	"Recipe ID"
+//	@Success	200			{object}	handlers.HTTPSuccess
+//	@Failure	502			{object}	handlers.HTTPError
+//	@Router		/recipes/{recipe_id}/favourite [delete]
+//	@Security	Bearer
+func (h *RecipesController) RemoveFavourite(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.RecipeID).(int)
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	if err := h.svc.RemoveFavourite(ctx.Context(), id, userID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+func (h *RecipesController) moderationError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, recipes.ErrUnexpectedStatus) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusConflict).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	h.log.Error(ctx, logger.Server, err)
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	svc "github.com/romankravchuk/muerta/internal/services/recipe"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 	repo "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
 )
 
@@ -18,33 +20,51 @@ func NewRouter(
 ) *fiber.App {
 	router := fiber.New()
 	repository := repo.New(client)
-	service := svc.New(repository)
+	service := svc.New(repository, product.New(client), measure.New(client))
 	handler := New(service, log)
-	router.Get("/", handler.FindMany)
-	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
+	router.Get("/", jware.OptionalUser, handler.FindMany)
+	router.Post("/", jware.DeserializeUser, handler.Create)
+	router.Post("/import", jware.DeserializeUser, access.AdminOnly(log), handler.Import)
+	router.Get(
+		"/moderation",
+		jware.DeserializeUser,
+		access.ModeratorOnly(log),
+		handler.FindPending,
+	)
 	router.Route(context.RecipeID.Path(), func(router fiber.Router) {
-		router.Use(context.New(log, context.RecipeID))
+		router.Use(context.New(log, context.RecipeID), jware.OptionalUser, handler.Visible)
 		router.Get("/", handler.FindOne)
-		router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.Update)
-		router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.Delete)
+		router.Put("/", jware.DeserializeUser, handler.OwnerOnly, handler.Update)
+		router.Delete("/", jware.DeserializeUser, handler.OwnerOnly, handler.Delete)
 		router.Patch("/", jware.DeserializeUser, access.AdminOnly(log), handler.Restore)
+		router.Get("/export", handler.Export)
+		router.Get("/nutrition", handler.FindNutrition)
+		router.Post("/publish", jware.DeserializeUser, handler.OwnerOnly, handler.Publish)
+		router.Post(
+			"/moderation",
+			jware.DeserializeUser,
+			access.ModeratorOnly(log),
+			handler.Moderate,
+		)
+		router.Get("/ratings", handler.FindRatings)
+		router.Put("/rating", jware.DeserializeUser, handler.Rate)
+		router.Delete("/rating", jware.DeserializeUser, handler.DeleteRating)
+		router.Put("/favourite", jware.DeserializeUser, handler.AddFavourite)
+		router.Delete("/favourite", jware.DeserializeUser, handler.RemoveFavourite)
 		router.Route("/ingredients", func(router fiber.Router) {
 			router.Get("/", handler.FindRecipeIngredients)
-			router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddIngredient)
-			router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.UpdateIngredient)
-			router.Delete(
-				"/",
-				jware.DeserializeUser,
-				access.AdminOnly(log),
-				handler.RemoveIngredient,
-			)
+			router.Post("/", jware.DeserializeUser, handler.OwnerOnly, handler.AddIngredient)
+			router.Put("/", jware.DeserializeUser, handler.OwnerOnly, handler.UpdateIngredient)
+			router.Delete("/", jware.DeserializeUser, handler.OwnerOnly, handler.RemoveIngredient)
 		})
 		router.Route("/steps", func(router fiber.Router) {
 			router.Get("/", handler.FindSteps)
+			router.Put("/order", jware.DeserializeUser, handler.OwnerOnly, handler.ReorderSteps)
 			router.Route(context.StepID.Path(), func(router fiber.Router) {
 				router.Use(context.New(log, context.StepID))
-				router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddStep)
-				router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.RemoveStep)
+				router.Post("/", jware.DeserializeUser, handler.OwnerOnly, handler.AddStep)
+				router.Put("/", jware.DeserializeUser, handler.OwnerOnly, handler.UpdateStep)
+				router.Delete("/", jware.DeserializeUser, handler.OwnerOnly, handler.RemoveStep)
 			})
 		})
 	})
@@ -1,81 +1,516 @@
 package shelflifedetector
 
 import (
-	"fmt"
-	"io"
+	"bufio"
+	stdcontext "context"
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/gofiber/contrib/fiberzerolog"
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	detectionjob "github.com/romankravchuk/muerta/internal/services/detection-job"
+	labelshelflife "github.com/romankravchuk/muerta/internal/services/label-shelf-life"
+	"github.com/romankravchuk/muerta/internal/services/receipt"
 	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
 )
 
 type ShelfLifeDetectorController struct {
-	svc       sldetector.DateDetectorServicer
-	log       logger.Logger
-	limitSize int64
+	svc        sldetector.DateDetectorServicer
+	receipts   receipt.ReceiptServicer
+	jobs       detectionjob.DetectionJobServicer
+	labels     labelshelflife.LabelShelfLifeServicer
+	detections detection.DetectionServicer
+	log        logger.Logger
+	limitSize  int64
 }
 
-func New(svc sldetector.DateDetectorServicer, log logger.Logger) *ShelfLifeDetectorController {
+func New(
+	svc sldetector.DateDetectorServicer,
+	receipts receipt.ReceiptServicer,
+	jobs detectionjob.DetectionJobServicer,
+	labels labelshelflife.LabelShelfLifeServicer,
+	detections detection.DetectionServicer,
+	log logger.Logger,
+	limitSize int64,
+) *ShelfLifeDetectorController {
 	return &ShelfLifeDetectorController{
-		svc: svc,
-		log: log,
-		// Limit - 512KB
-		limitSize: 1024 * 512,
+		svc:        svc,
+		receipts:   receipts,
+		jobs:       jobs,
+		labels:     labels,
+		detections: detections,
+		log:        log,
+		limitSize:  limitSize,
 	}
 }
 
 // DetectDates - detects shelf life dates from file
 //
 //	@Summary		Detect shelf life dates from file
-//	@Description	detect shelf life dates from file
+//	@Description	detect shelf life dates from file with their roles, confidences and boxes, and suggest the purchase date and the end date of the shelf life. The debug mode also returns the PNG overlay of the boxes. The detection is stored with the id returned for the feedback, the file is kept only with the consent
 //	@Tags			Shelf Life Detector
 //	@Accept			json
 //	@Produce		json
 //	@Param			fileToDetect	formData	file	true	"file to detect"
-//	@Success		200				{object}	handlers.HTTPError
+//	@Param			consent			formData	bool	false	"keep the file in the dataset of the detections"
+//	@Param			debug			query		bool	false	"return the overlay of the boxes"
+//	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		415				{object}	handlers.HTTPError
+//	@Failure		422				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
+//	@Failure		503				{object}	handlers.HTTPError
+//	@Failure		504				{object}	handlers.HTTPError
 //	@Router			/shelf-life-detector [post]
 //	@Security		Bearer
 func (h *ShelfLifeDetectorController) DetectDates(ctx *fiber.Ctx) error {
-	file, err := ctx.FormFile("fileToDetect")
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	data, err := utils.ReadImage(ctx, "fileToDetect", h.limitSize)
 	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	dates, err := h.svc.Detect(ctx.Context(), data, ctx.QueryBool("debug"))
+	if errors.Is(err, sldetector.ErrNoDates) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
+		return h.detectError(ctx, err)
+	}
+	if err := h.detections.Record(ctx.Context(), userID, data, consent(ctx), &dates); err != nil {
+		// the dates are detected, only their feedback is lost
+		h.log.Error(ctx, logger.Server, err)
+	}
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data":    dates,
+	})
+}
+
+// DetectText - detects shelf life dates from text
+//
+//	@Summary		Detect shelf life dates from text
+//	@Description	detect shelf life dates from the text of the label recognized by the OCR of the client, such as of a phone. The dates have no boxes. The detection is stored with the id returned for the feedback
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.DetectText	true	"Recognized text"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/text [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) DetectText(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.DetectText)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if file.Size > h.limitSize {
-		h.log.Error(ctx, logger.Client, fmt.Errorf("file size is too large: %d", file.Size))
-		return fiber.ErrRequestEntityTooLarge
+	dates, err := h.svc.DetectText(ctx.Context(), []string{payload.Text})
+	if errors.Is(err, sldetector.ErrNoDates) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
+		return h.detectError(ctx, err)
+	}
+	// there is no image to keep, the text is hashed instead
+	if err := h.detections.Record(ctx.Context(), userID, []byte(payload.Text), false, &dates); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+	}
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"data":    dates,
+	})
+}
+
+// CreateJob - queues the detection of shelf life dates from file
+//
+//	@Summary		Queue detection of shelf life dates
+//	@Description	Store the image and queue the job detecting its dates, the status and the result of the job are polled by its id
+//	@Tags			Shelf Life Detector
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			fileToDetect	formData	file	true	"file to detect"
+//	@Param			consent			formData	bool	false	"keep the file in the dataset of the detections"
+//	@Success		202				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		415				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/jobs [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) CreateJob(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	data, err := utils.ReadImage(ctx, "fileToDetect", h.limitSize)
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	job, err := h.jobs.Create(ctx.Context(), userID, data, consent(ctx))
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	ctx.Set(fiber.HeaderLocation, ctx.Path()+"/"+job.ID)
+	return ctx.Status(http.StatusAccepted).
+		JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"job": job}})
+}
+
+// FindJobByID - returns the status of a detection job
+//
+//	@Summary		Find detection job
+//	@Description	Get the status of the detection job of the user, and the detected dates when it is done
+//	@Tags			Shelf Life Detector
+//	@Produce		json
+//	@Param			id	path		string	true	"Job ID"
+//	@Success		200	{object}	handlers.HTTPSuccess
+//	@Failure		404	{object}	handlers.HTTPError
+//	@Failure		502	{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/jobs/{id} [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) FindJobByID(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	job, err := h.jobs.FindByID(ctx.Context(), userID, ctx.Params("id"))
+	if errors.Is(err, detectionjob.ErrNotFound) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"job": job}})
+}
+
+// DetectReceipt - detects the items of a paper receipt from file
+//
+//	@Summary		Detect receipt items from file
+//	@Description	Recognize the paper receipt and propose the shelf lives of its items with the matched products, the measures and the default end dates
+//	@Tags			Shelf Life Detector
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			fileToDetect	formData	file	true	"image of the receipt"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		415				{object}	handlers.HTTPError
+//	@Failure		422				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Failure		503				{object}	handlers.HTTPError
+//	@Failure		504				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/receipt [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) DetectReceipt(ctx *fiber.Ctx) error {
+	data, err := utils.ReadImage(ctx, "fileToDetect", h.limitSize)
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	found, err := h.svc.DetectReceipt(ctx.Context(), data)
+	if errors.Is(err, sldetector.ErrNoItems) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	}
+	if err != nil {
+		return h.detectError(ctx, err)
 	}
-	fileContent, err := file.Open()
+	result, err := h.receipts.ProposeReceipt(ctx.Context(), found)
 	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"receipt": result}})
+}
+
+// CommitReceipt - creates the shelf lives of a paper receipt
+//
+//	@Summary		Commit receipt items
+//	@Description	Create the shelf lives of the receipt items accepted by the user in one transaction
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.CreateReceiptShelfLives	true	"Accepted items"
+//	@Success		201		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/receipt/commit [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) CommitReceipt(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.CreateReceiptShelfLives)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	defer fileContent.Close()
-	data, err := io.ReadAll(fileContent)
+	result, err := h.receipts.CreateShelfLives(ctx.Context(), userID, payload)
+	if errors.Is(err, apperrors.ErrNotOwner) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
 	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.Status(http.StatusCreated).
+		JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf_lives": result}})
+}
+
+// CreateShelfLife - creates a shelf life from the photo of the label
+//
+//	@Summary		Create shelf life from label photo
+//	@Description	Detect the dates on the photo of the label and create the shelf life of the user with the photo attached. The dates set in the form are not detected. The ambiguous dates are returned as the candidates with 422, the user sets one of them as the end date
+//	@Tags			Shelf Life Detector
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			fileToDetect	formData	file	true	"photo of the label"
+//	@Param			id_product		formData	int		true	"Product ID"
+//	@Param			id_storage		formData	int		true	"Storage ID"
+//	@Param			id_measure		formData	int		true	"Measure ID"
+//	@Param			quantity		formData	number	true	"Quantity"
+//	@Param			purchase_date	formData	string	false	"Purchase date, 2006-01-02"
+//	@Param			end_date		formData	string	false	"End date, 2006-01-02"
+//	@Param			detection_id	formData	int		false	"Detection answered with the candidates"
+//	@Param			consent			formData	bool	false	"keep the photo in the dataset of the detections"
+//	@Success		201				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		415				{object}	handlers.HTTPError
+//	@Failure		422				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Failure		503				{object}	handlers.HTTPError
+//	@Failure		504				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/shelf-lives [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) CreateShelfLife(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.CreateDetectedShelfLife)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	dates, err := h.svc.Detect(data)
+	data, err := utils.ReadImage(ctx, "fileToDetect", h.limitSize)
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		e := utils.ImageError(err)
+		return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
+	}
+	result, err := h.labels.Create(ctx.Context(), userID, payload, data)
+	var ambiguous *labelshelflife.AmbiguousError
+	switch {
+	case err == nil:
+		return ctx.Status(http.StatusCreated).
+			JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf_life": result}})
+	case errors.As(err, &ambiguous):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"data": controllers.Data{
+				"detection_id": ambiguous.DetectionID,
+				"candidates":   ambiguous.Candidates,
+			},
+		})
+	case errors.Is(err, sldetector.ErrNoDates):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	case errors.Is(err, labelshelflife.ErrInvalidEndDate):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: err.Error()})
+	case errors.Is(err, apperrors.ErrNotOwner):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+	return h.detectError(ctx, err)
+}
+
+// FindAttachment - returns the photo of the label of a shelf life
+//
+//	@Summary		Find shelf life photo
+//	@Description	Get the photo of the label the shelf life of the user is created from
+//	@Tags			Shelf Life Detector
+//	@Produce		image/jpeg,image/png,image/webp
+//	@Param			id	path		int	true	"Shelf life ID"
+//	@Success		200	{file}		binary
+//	@Failure		404	{object}	handlers.HTTPError
+//	@Failure		502	{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/shelf-lives/{id}/attachment [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) FindAttachment(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShelfLifeID).(int)
+	file, err := h.labels.FindAttachment(ctx.Context(), userID, id)
+	if errors.Is(err, labelshelflife.ErrNotFound) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	if dates == nil {
-		h.log.Error(ctx, logger.Server, fmt.Errorf("dates is nil"))
+	ctx.Set(fiber.HeaderContentType, file.ContentType)
+	return ctx.Send(file.Data)
+}
+
+// SaveFeedback - saves the dates of a detection the user saved
+//
+//	@Summary		Save detection feedback
+//	@Description	Save the dates of the shelf life the user finally saved for the detection, the detection becomes a sample of the dataset. The shelf life must be of the user
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			detection_id	path		int						true	"Detection ID"
+//	@Param			payload			body		dto.DetectionFeedback	true	"Saved dates"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/detections/{detection_id}/feedback [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) SaveFeedback(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.DetectionID).(int)
+	payload := new(params.DetectionFeedback)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	err := h.detections.SaveFeedback(ctx.Context(), userID, id, payload)
+	if errors.Is(err, detection.ErrNotFound) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	return ctx.JSON(fiber.Map{
-		"success": true,
-		"data":    dates,
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// ExportDetections - exports the labelled detections
+//
+//	@Summary		Export detection dataset
+//	@Description	Export the page of the detections with the dates the users saved as JSON lines, the next page is after the id of the last line. The lines are streamed as the detections are read, a failed export ends early. The images are exported only if the users consented
+//	@Tags			Shelf Life Detector
+//	@Produce		application/x-ndjson
+//	@Param			filter	query		dto.DetectionExportFilter	true	"Page"
+//	@Success		200		{array}		dto.DetectionSample
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/detections/export [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) ExportDetections(ctx *fiber.Ctx) error {
+	filter := new(params.DetectionExportFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	// The page is written while the rows are read, so a failure after the
+	// status is sent only cuts the lines short and is logged.
+	requestID := ctx.GetRespHeader(fiber.HeaderXRequestID)
+	reqCtx := ctx.Context()
+	ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	reqCtx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		enc := json.NewEncoder(w)
+		err := h.detections.Export(reqCtx, filter, func(sample params.DetectionSample) error {
+			if err := enc.Encode(sample); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		if err != nil {
+			h.log.GetLogger().Error().
+				Interface(fiberzerolog.FieldRequestID, requestID).
+				Err(err).Msg("Server Error")
+		}
 	})
+	return nil
+}
+
+// consent reports whether the user consented to keep the uploaded image in
+// the dataset of the detections.
+func consent(ctx *fiber.Ctx) bool {
+	ok, _ := strconv.ParseBool(ctx.FormValue("consent"))
+	return ok
+}
+
+// detectError responds with the status of the recognition error, the busy
+// OCR clients are reported as unavailable.
+func (h *ShelfLifeDetectorController) detectError(ctx *fiber.Ctx, err error) error {
+	h.log.Error(ctx, logger.Server, err)
+	e := fiber.ErrBadGateway
+	switch {
+	case errors.Is(err, ocr.ErrQueueFull), errors.Is(err, ocr.ErrClosed):
+		ctx.Set(fiber.HeaderRetryAfter, "1")
+		e = fiber.ErrServiceUnavailable
+	case errors.Is(err, stdcontext.DeadlineExceeded):
+		e = fiber.ErrGatewayTimeout
+	case errors.Is(err, imaging.ErrCorrupt):
+		// the format is sniffed by ReadImage, but the data may be broken
+		e = fiber.ErrBadRequest
+	case errors.Is(err, imaging.ErrTooLarge):
+		e = fiber.ErrRequestEntityTooLarge
+	}
+	return ctx.Status(e.Code).JSON(controllers.HTTPError{Error: e.Message})
 }
@@ -2,16 +2,57 @@ package shelflifedetector
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	detectionjob "github.com/romankravchuk/muerta/internal/services/detection-job"
+	labelshelflife "github.com/romankravchuk/muerta/internal/services/label-shelf-life"
+	"github.com/romankravchuk/muerta/internal/services/receipt"
 	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/attachment"
+	detections "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	receipts "github.com/romankravchuk/muerta/internal/storage/postgres/receipt"
+	"github.com/romankravchuk/muerta/internal/storage/redis"
+	"github.com/romankravchuk/muerta/internal/storage/redis/job"
 )
 
-func NewRouter(cfg *config.Config, log logger.Logger, jware *jware.JWTMiddleware) *fiber.App {
+func NewRouter(
+	cfg *config.Config,
+	client postgres.Client,
+	log logger.Logger,
+	cache redis.Client,
+	backend ocr.Backend,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
 	router := fiber.New()
-	service := sldetector.New(cfg.ShutdownShelfDetectorChan)
-	handler := New(service, log)
+	service := sldetector.New(backend)
+	receiptService := receipt.New(receipts.New(client), product.New(client), measure.New(client), nil)
+	detectionService := detection.New(detections.New(client))
+	jobService := detectionjob.New(job.New(cache), service, detectionService, cfg, log)
+	labelService := labelshelflife.New(attachment.New(client), service, detectionService)
+	handler := New(service, receiptService, jobService, labelService, detectionService, log, cfg.OCR.UploadLimit)
 	router.Post("/", jware.DeserializeUser, handler.DetectDates)
+	router.Post("/text", jware.DeserializeUser, handler.DetectText)
+	router.Post("/jobs", jware.DeserializeUser, handler.CreateJob)
+	router.Get("/jobs/:id", jware.DeserializeUser, handler.FindJobByID)
+	router.Post("/receipt", jware.DeserializeUser, handler.DetectReceipt)
+	router.Post("/receipt/commit", jware.DeserializeUser, handler.CommitReceipt)
+	router.Post("/shelf-lives", jware.DeserializeUser, handler.CreateShelfLife)
+	router.Route("/shelf-lives"+context.ShelfLifeID.Path(), func(router fiber.Router) {
+		router.Use(context.New(log, context.ShelfLifeID))
+		router.Get("/attachment", jware.DeserializeUser, handler.FindAttachment)
+	})
+	router.Get("/detections/export", jware.DeserializeUser, access.AdminOnly(log), handler.ExportDetections)
+	router.Route("/detections"+context.DetectionID.Path(), func(router fiber.Router) {
+		router.Use(context.New(log, context.DetectionID))
+		router.Post("/feedback", jware.DeserializeUser, handler.SaveFeedback)
+	})
 	return router
 }
@@ -88,7 +88,7 @@ func (h *ShelfLifeController) FindOne(ctx *fiber.Ctx) error {
 //	@Tags			Shelf Lives
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		dto.ShelfLifeFilter	true	"Shelf Life Filter"
+//	@Param			filter	query		dto.ShelfLifeFilter	true	"Shelf Life Filter"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
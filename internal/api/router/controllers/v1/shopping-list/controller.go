@@ -0,0 +1,387 @@
+package shoppinglist
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	recipesvc "github.com/romankravchuk/muerta/internal/services/recipe"
+	service "github.com/romankravchuk/muerta/internal/services/shopping-list"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+)
+
+type ShoppingListController struct {
+	svc service.ShoppingListServicer
+	log logger.Logger
+}
+
+func New(svc service.ShoppingListServicer, log logger.Logger) *ShoppingListController {
+	return &ShoppingListController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// FindMany godoc
+//
+//	@Summary		Find shopping lists
+//	@Description	Find shopping lists of the user and of the user's households
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			filter	query		dto.ShoppingListFilter	true	"filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shopping-lists [get]
+//	@Security		Bearer
+func (h *ShoppingListController) FindMany(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	filter := new(params.ShoppingListFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.FindShoppingLists(ctx.Context(), userID, filter)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	count, err := h.svc.Count(ctx.Context(), userID, *filter)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-lists": result, "count": count},
+	})
+}
+
+// Create godoc
+//
+//	@Summary		Create shopping list
+//	@Description	Create shopping list, the list is shared with the users of the storage if it is set
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.CreateShoppingList	true	"shopping list"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shopping-lists [post]
+//	@Security		Bearer
+func (h *ShoppingListController) Create(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	payload := new(params.CreateShoppingList)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.CreateShoppingList(ctx.Context(), userID, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// FindOne godoc
+//
+//	@Summary		Find shopping list by id
+//	@Description	Find shopping list with items by id
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int	true	"shopping list id"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list} [get]
+//	@Security		Bearer
+func (h *ShoppingListController) FindOne(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	result, err := h.svc.FindShoppingListByID(ctx.Context(), userID, id)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// Update godoc
+//
+//	@Summary		Update shopping list
+//	@Description	Update shopping list, only the owner can update it
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int						true	"shopping list id"
+//	@Param			payload				body		dto.UpdateShoppingList	true	"shopping list"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list} [put]
+//	@Security		Bearer
+func (h *ShoppingListController) Update(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	payload := new(params.UpdateShoppingList)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.UpdateShoppingList(ctx.Context(), userID, id, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// Delete godoc
+//
+//	@Summary		Delete shopping list
+//	@Description	Delete shopping list, only the owner can delete it
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int	true	"shopping list id"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list} [delete]
+//	@Security		Bearer
+func (h *ShoppingListController) Delete(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	if err := h.svc.DeleteShoppingList(ctx.Context(), userID, id); err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// AddItem godoc
+//
+//	@Summary		Add shopping list item
+//	@Description	Add item to the shopping list, the quantity is added to the unchecked item of the same product and measure
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int							true	"shopping list id"
+//	@Param			payload				body		dto.CreateShoppingListItem	true	"item"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/items [post]
+//	@Security		Bearer
+func (h *ShoppingListController) AddItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	payload := new(params.CreateShoppingListItem)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.AddItem(ctx.Context(), userID, id, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"item": result}})
+}
+
+// UpdateItem godoc
+//
+//	@Summary		Update shopping list item
+//	@Description	Update shopping list item
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int							true	"shopping list id"
+//	@Param			id_item				path		int							true	"item id"
+//	@Param			payload				body		dto.UpdateShoppingListItem	true	"item"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/items/{id_item} [put]
+//	@Security		Bearer
+func (h *ShoppingListController) UpdateItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	payload := new(params.UpdateShoppingListItem)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.UpdateItem(ctx.Context(), userID, id, itemID, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"item": result}})
+}
+
+// DeleteItem godoc
+//
+//	@Summary		Delete shopping list item
+//	@Description	Delete shopping list item
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int	true	"shopping list id"
+//	@Param			id_item				path		int	true	"item id"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/items/{id_item} [delete]
+//	@Security		Bearer
+func (h *ShoppingListController) DeleteItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	if err := h.svc.DeleteItem(ctx.Context(), userID, id, itemID); err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// CheckItem godoc
+//
+//	@Summary		Check shopping list item
+//	@Description	Check or uncheck shopping list item, a shelf life is created in the storage if it is set
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int							true	"shopping list id"
+//	@Param			id_item				path		int							true	"item id"
+//	@Param			payload				body		dto.CheckShoppingListItem	true	"check"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/items/{id_item}/check [post]
+//	@Security		Bearer
+func (h *ShoppingListController) CheckItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	payload := new(params.CheckShoppingListItem)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.CheckItem(ctx.Context(), userID, id, itemID, payload)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"item": result}})
+}
+
+// GenerateFromRecipe godoc
+//
+//	@Summary		Generate items from recipe
+//	@Description	Add the recipe ingredients which are missing in the user's storages
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int								true	"shopping list id"
+//	@Param			payload				body		dto.GenerateShoppingListItems	true	"recipe"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/generate/recipe [post]
+//	@Security		Bearer
+func (h *ShoppingListController) GenerateFromRecipe(ctx *fiber.Ctx) error {
+	user := ctx.Locals("user").(*params.TokenPayload)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	payload := new(params.GenerateShoppingListItems)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.GenerateFromRecipe(
+		ctx.Context(), user.UserID, id, payload, access.HasRole(user, "moderator", "admin"),
+	)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// GenerateFromRestock godoc
+//
+//	@Summary		Generate items from restock
+//	@Description	Add the consumed or expired shelf lives flagged to restock
+//	@Tags			Shopping Lists
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_shopping_list	path		int	true	"shopping list id"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/shopping-lists/{id_shopping_list}/generate/restock [post]
+//	@Security		Bearer
+func (h *ShoppingListController) GenerateFromRestock(ctx *fiber.Ctx) error {
+	userID := ctx.Locals("user").(*params.TokenPayload).UserID
+	id := ctx.Locals(context.ShoppingListID).(int)
+	result, err := h.svc.GenerateFromRestock(ctx.Context(), userID, id)
+	if err != nil {
+		return h.serviceError(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+func (h *ShoppingListController) badRequest(ctx *fiber.Ctx, err error) error {
+	if err, ok := err.(validator.ValidationErrors); ok {
+		h.log.Error(ctx, logger.Validation, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	h.log.Error(ctx, logger.Client, err)
+	return ctx.Status(http.StatusBadRequest).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+}
+
+func (h *ShoppingListController) serviceError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, apperrors.ErrNotOwner) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+	if errors.Is(err, repository.ErrNotFound) ||
+		errors.Is(err, repository.ErrItemNotFound) ||
+		errors.Is(err, recipesvc.ErrNotVisible) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	h.log.Error(ctx, logger.Server, err)
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
@@ -0,0 +1,44 @@
+package shoppinglist
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/shopping-list"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+)
+
+func NewRouter(
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	router := fiber.New()
+	repo := repository.New(client)
+	svc := service.New(repo, recipes.New(client))
+	handler := New(svc, log)
+	router.Use(jware.DeserializeUser)
+	router.Get("/", handler.FindMany)
+	router.Post("/", handler.Create)
+	router.Route(context.ShoppingListID.Path(), func(router fiber.Router) {
+		router.Use(context.New(log, context.ShoppingListID))
+		router.Get("/", handler.FindOne)
+		router.Put("/", handler.Update)
+		router.Delete("/", handler.Delete)
+		router.Post("/generate/recipe", handler.GenerateFromRecipe)
+		router.Post("/generate/restock", handler.GenerateFromRestock)
+		router.Route("/items", func(router fiber.Router) {
+			router.Post("/", handler.AddItem)
+			router.Route(context.ItemID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.ItemID))
+				router.Put("/", handler.UpdateItem)
+				router.Delete("/", handler.DeleteItem)
+				router.Post("/check", handler.CheckItem)
+			})
+		})
+	})
+	return router
+}
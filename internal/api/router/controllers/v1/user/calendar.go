@@ -0,0 +1,139 @@
+package user
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/pkg/auth"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/calendar"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+// calendarFeed is the name of the feed the calendar tokens are issued for.
+const calendarFeed = "calendar"
+
+type CalendarController struct {
+	svc    service.CalendarServicer
+	secret []byte
+	log    logger.Logger
+}
+
+func NewCalendar(
+	svc service.CalendarServicer,
+	secret []byte,
+	log logger.Logger,
+) *CalendarController {
+	return &CalendarController{svc: svc, secret: secret, log: log}
+}
+
+// FindFeed godoc
+//
+//	@Summary		Find calendar feed link
+//	@Description	Find the secret link of the user's iCalendar feed of upcoming expirations
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int	true	"User ID"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		401		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/calendar [get]
+func (h *CalendarController) FindFeed(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	version, err := h.svc.FindFeedVersion(ctx.Context(), id)
+	if err != nil {
+		return h.feedError(ctx, err)
+	}
+	return h.link(ctx, id, version, strings.TrimSuffix(ctx.Path(), "/"))
+}
+
+// ResetFeed godoc
+//
+//	@Summary		Reset calendar feed link
+//	@Description	Revoke the secret link of the user's iCalendar feed and find the new one
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int	true	"User ID"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		401		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/calendar/reset [post]
+func (h *CalendarController) ResetFeed(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	version, err := h.svc.ResetFeed(ctx.Context(), id)
+	if err != nil {
+		return h.feedError(ctx, err)
+	}
+	path := strings.TrimSuffix(strings.TrimSuffix(ctx.Path(), "/"), "/reset")
+	return h.link(ctx, id, version, path)
+}
+
+// Feed godoc
+//
+//	@Summary		Calendar feed
+//	@Description	iCalendar feed of upcoming expirations of the user's shelf lives
+//	@Tags			Users
+//	@Produce		text/calendar
+//	@Param			id_user	path		int		true	"User ID"
+//	@Param			token	query		string	true	"Feed token"
+//	@Success		200		{string}	string
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/calendar.ics [get]
+func (h *CalendarController) Feed(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	version, err := h.svc.FindFeedVersion(ctx.Context(), id)
+	if err != nil && !errors.Is(err, repo.ErrNoFeed) {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(fiber.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	if err != nil || !auth.CompareFeedToken(calendarFeed, id, version, h.secret, ctx.Query("token")) {
+		return ctx.Status(fiber.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+	var buf bytes.Buffer
+	if err := h.svc.EncodeFeed(ctx.Context(), id, &buf); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(fiber.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	ctx.Set(fiber.HeaderContentDisposition, `inline; filename="muerta.ics"`)
+	return ctx.Send(buf.Bytes())
+}
+
+// link responds with the token and the link of the calendar feed of the
+// given version, the path is the path of the feed without the extension.
+func (h *CalendarController) link(ctx *fiber.Ctx, id, version int, path string) error {
+	token := auth.GenerateFeedToken(calendarFeed, id, version, h.secret)
+	url := fmt.Sprintf("%s%s.ics?token=%s", ctx.BaseURL(), path, token)
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"token": token, "url": url},
+	})
+}
+
+// feedError responds with 404 if the user of the feed does not exist and
+// with 502 on the other errors.
+func (h *CalendarController) feedError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, repo.ErrNoFeed) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(fiber.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	h.log.Error(ctx, logger.Server, err)
+	return ctx.Status(fiber.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,6 +12,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/validator"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	service "github.com/romankravchuk/muerta/internal/services/user"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
 )
 
 type UserController struct {
@@ -361,7 +363,8 @@ func (h *UserController) RemoveStorage(ctx *fiber.Ctx) error {
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id_user	query		int	true	"User ID"
+//	@Param			id_user	query		int					true	"User ID"
+//	@Param			filter	query		dto.ShelfLifeFilter	true	"Shelf Life Filter"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		404		{object}	handlers.HTTPError
@@ -369,13 +372,35 @@ func (h *UserController) RemoveStorage(ctx *fiber.Ctx) error {
 //	@Router			/users/{id_user}/shelf-lives [get]
 func (h *UserController) FindShelfLives(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.UserID).(int)
-	result, err := h.svc.FindShelfLives(ctx.Context(), id)
+	filter := new(params.ShelfLifeFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindShelfLives(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			SendString("Bad Gateway")
+	}
+	count, err := h.svc.CountShelfLives(ctx.Context(), id, *filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			SendString("Bad Gateway")
 	}
-	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf-lives": result}})
+	return ctx.JSON(
+		controllers.HTTPSuccess{
+			Success: true,
+			Data:    controllers.Data{"shelf-lives": result, "count": count},
+		},
+	)
 }
 
 // CreateShelfLife godoc
@@ -508,3 +533,131 @@ func (h *UserController) DeleteShelfLife(ctx *fiber.Ctx) error {
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// ConsumeShelfLife godoc
+//
+//	@Summary		Consume user shelf life
+//	@Description	Record the consumption of the shelf life and decrease its quantity
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user			path		int						true	"User ID"
+//	@Param			id_shelf_life	path		int						true	"Shelf Life ID"
+//	@Param			payload			body		dto.ConsumeShelfLife	true	"Consumed quantity"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shelf-lives/{id_shelf_life}/consume [post]
+//	@Security		Bearer
+func (h *UserController) ConsumeShelfLife(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	shelfLifeID := ctx.Locals(context.ShelfLifeID).(int)
+	payload := new(params.ConsumeShelfLife)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.ConsumeShelfLife(ctx.Context(), id, shelfLifeID, payload)
+	if errors.Is(err, repo.ErrNothingToConsume) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			SendString("Bad Gateway")
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"consumption": result}})
+}
+
+// FindNutrition godoc
+//
+//	@Summary		Find consumed nutrition
+//	@Description	Find the nutrition consumed by the user by day, the last week by default
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int					true	"User ID"
+//	@Param			filter	query		dto.NutritionFilter	false	"Nutrition Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/nutrition [get]
+//	@Security		Bearer
+func (h *UserController) FindNutrition(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	filter := new(params.NutritionFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindNutrition(ctx.Context(), id, filter)
+	if errors.Is(err, service.ErrInvalidRange) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			SendString("Bad Gateway")
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"nutrition": result}})
+}
+
+// FindRecipeSuggestions godoc
+//
+//	@Summary		Find recipe suggestions
+//	@Description	Find recipes ranked by the ingredients in the user's shelf lives and by how soon they expire
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int							true	"User ID"
+//	@Param			filter	query		dto.RecipeSuggestionFilter	true	"Recipe Suggestion Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/recipe-suggestions [get]
+//	@Security		Bearer
+func (h *UserController) FindRecipeSuggestions(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	filter := new(params.RecipeSuggestionFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, count, err := h.svc.FindRecipeSuggestions(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			SendString("Bad Gateway")
+	}
+	return ctx.JSON(
+		controllers.HTTPSuccess{
+			Success: true,
+			Data:    controllers.Data{"suggestions": result, "count": count},
+		},
+	)
+}
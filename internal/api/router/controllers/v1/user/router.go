@@ -5,13 +5,16 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	calendarsvc "github.com/romankravchuk/muerta/internal/services/calendar"
 	svc "github.com/romankravchuk/muerta/internal/services/user"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
 )
 
 func NewRouter(
+	cfg *config.Config,
 	client postgres.Client,
 	log logger.Logger,
 	jware *jware.JWTMiddleware,
@@ -20,6 +23,7 @@ func NewRouter(
 	repo := repo.New(client)
 	svc := svc.New(repo)
 	h := New(svc, log)
+	calendar := NewCalendar(calendarsvc.New(repo), cfg.CalendarSecret, log)
 	r.Get("/", h.FindMany)
 	r.Post("/", jware.DeserializeUser, access.AdminOnly(log), h.Create)
 	r.Route(context.UserID.Path(), func(r fiber.Router) {
@@ -36,14 +40,20 @@ func NewRouter(
 				router.Put("/", jware.DeserializeUser, access.OwnerOnly(log), h.UpdateShelfLife)
 				router.Patch("/", jware.DeserializeUser, access.OwnerOnly(log), h.RestoreShelfLife)
 				router.Delete("/", jware.DeserializeUser, access.OwnerOnly(log), h.DeleteShelfLife)
+				router.Post("/consume", jware.DeserializeUser, access.OwnerOnly(log), h.ConsumeShelfLife)
 			})
 		})
+		r.Get("/calendar", jware.DeserializeUser, access.OwnerOnly(log), calendar.FindFeed)
+		r.Post("/calendar/reset", jware.DeserializeUser, access.OwnerOnly(log), calendar.ResetFeed)
+		r.Get("/calendar.ics", calendar.Feed)
 		r.Route("/settings", func(router fiber.Router) {
 			router.Get("/", jware.DeserializeUser, access.OwnerOnly(log), h.FindSettings)
 			router.Route(context.SettingID.Path(), func(router fiber.Router) {
 				router.Put("/", jware.DeserializeUser, access.OwnerOnly(log), h.UpdateSetting)
 			})
 		})
+		r.Get("/recipe-suggestions", jware.DeserializeUser, access.OwnerOnly(log), h.FindRecipeSuggestions)
+		r.Get("/nutrition", jware.DeserializeUser, access.OwnerOnly(log), h.FindNutrition)
 		r.Get("/roles", h.FindRoles)
 		r.Route("/storages", func(router fiber.Router) {
 			router.Get("/", h.FindStorages)
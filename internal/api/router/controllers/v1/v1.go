@@ -3,14 +3,18 @@ package v1
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/auth"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/catalog"
+	mealplan "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/meal-plan"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/measure"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/product"
 	productcategory "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/product-category"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/receipt"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/recipe"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/role"
 	shelflife "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life"
 	shelflifedetector "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life-detector"
 	shelflifestatus "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life-status"
+	shoppinglist "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shopping-list"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/step"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/tip"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/user"
@@ -20,6 +24,7 @@ import (
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/redis"
 )
@@ -29,16 +34,17 @@ func New(
 	app fiber.Router,
 	db postgres.Client,
 	cache redis.Client,
+	backend ocr.Backend,
 	log logger.Logger,
 ) {
 	jware := jware.New(cfg, log)
 	app.Mount("/auth", auth.NewRouter(cfg, db, log, cache, jware))
-	app.Mount("/shelf-life-detector", shelflifedetector.NewRouter(cfg, log, jware))
+	app.Mount("/shelf-life-detector", shelflifedetector.NewRouter(cfg, db, log, cache, backend, jware))
 	app.Mount("/recipes", recipe.NewRouter(db, log, jware))
-	app.Mount("/users", user.NewRouter(db, log, jware))
+	app.Mount("/users", user.NewRouter(cfg, db, log, jware))
 	app.Mount("/settings", usersetting.NewRouter(db, log, jware))
 	app.Mount("/storages", vault.NewRouter(db, log, jware))
-	app.Mount("/products", product.NewRouter(db, log, jware))
+	app.Mount("/products", product.NewRouter(cfg, db, log, jware))
 	app.Mount("/roles", role.NewRouter(db, log, jware))
 	app.Mount("/product-categories", productcategory.NewRouter(db, log, jware))
 	app.Mount("/tips", tip.NewRouter(db, log, jware))
@@ -47,4 +53,8 @@ func New(
 	app.Mount("/shelf-lives", shelflife.NewRouter(db, log, jware))
 	app.Mount("/shelf-life-statuses", shelflifestatus.NewRouter(db, log, jware))
 	app.Mount("/storage-types", storagetype.NewRouter(db, log, jware))
+	app.Mount("/shopping-lists", shoppinglist.NewRouter(db, log, jware))
+	app.Mount("/meal-plans", mealplan.NewRouter(db, log, jware))
+	app.Mount("/catalog", catalog.NewRouter(db, log, jware))
+	app.Mount("/receipts", receipt.NewRouter(cfg, db, log, jware))
 }
@@ -287,18 +287,41 @@ func (h *VaultController) RemoveTip(ctx *fiber.Ctx) error {
 //	@Tags			Storages
 //	@Accept			json
 //	@Produce		json
-//	@Param			id_storage	path		int	true	"Storage ID"
+//	@Param			id_storage	path		int					true	"Storage ID"
+//	@Param			filter		query		dto.ShelfLifeFilter	true	"Shelf Life Filter"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage}/shelf-lives [get]
 func (h *VaultController) FindShelfLives(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	result, err := h.svc.FindShelfLives(ctx.Context(), id)
+	filter := new(params.ShelfLifeFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindShelfLives(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	count, err := h.svc.CountShelfLives(ctx.Context(), id, *filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf-lives": result}})
+	return ctx.JSON(
+		controllers.HTTPSuccess{
+			Success: true,
+			Data:    controllers.Data{"shelf-lives": result, "count": count},
+		},
+	)
 }
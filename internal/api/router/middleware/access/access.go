@@ -19,10 +19,8 @@ func AdminOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 			return ctx.Status(http.StatusForbidden).
 				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
 		}
-		for _, role := range payload.Roles {
-			if role == "admin" {
-				return ctx.Next()
-			}
+		if HasRole(payload, "admin") {
+			return ctx.Next()
 		}
 		l.Error(ctx, logger.Client, errors.ErrNotAdmin)
 		return ctx.Status(http.StatusForbidden).
@@ -30,6 +28,39 @@ func AdminOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 	}
 }
 
+// ModeratorOnly passes the moderators and the admins.
+func ModeratorOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
+	return func(ctx *fiber.Ctx) error {
+		payload, ok := ctx.Locals("user").(*params.TokenPayload)
+		if !ok {
+			l.Error(ctx, logger.Client, errors.ErrFailedToGetTokenPayload)
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if HasRole(payload, "moderator", "admin") {
+			return ctx.Next()
+		}
+		l.Error(ctx, logger.Client, errors.ErrNotModerator)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	}
+}
+
+// HasRole reports whether the user has one of the roles.
+func HasRole(payload *params.TokenPayload, roles ...string) bool {
+	if payload == nil {
+		return false
+	}
+	for _, role := range payload.Roles {
+		for _, name := range roles {
+			if role == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func OwnerOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 	return func(ctx *fiber.Ctx) error {
 		id, ok := ctx.Locals(context.UserID).(int)
@@ -44,14 +75,9 @@ func OwnerOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 			return ctx.Status(http.StatusForbidden).
 				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
 		}
-		if payload.UserID == id {
+		if payload.UserID == id || HasRole(payload, "admin") {
 			return ctx.Next()
 		}
-		for _, role := range payload.Roles {
-			if role == "admin" {
-				return ctx.Next()
-			}
-		}
 		l.Error(ctx, logger.Client, errors.ErrNotOwner)
 		return ctx.Status(http.StatusForbidden).
 			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
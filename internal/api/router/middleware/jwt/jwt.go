@@ -25,13 +25,7 @@ func New(cfg *config.Config, log logger.Logger) *JWTMiddleware {
 }
 
 func (m *JWTMiddleware) DeserializeUser(ctx *fiber.Ctx) error {
-	var token string
-	authorization := ctx.Get("Authorization")
-	if strings.HasPrefix(authorization, "Bearer ") {
-		token = strings.TrimPrefix(authorization, "Bearer ")
-	} else {
-		token = ctx.Cookies("access_token")
-	}
+	token := accessToken(ctx)
 	if token == "" {
 		m.log.Error(ctx, logger.Client, fmt.Errorf("unauthorized request"))
 		return ctx.Status(http.StatusUnauthorized).
@@ -47,3 +41,20 @@ func (m *JWTMiddleware) DeserializeUser(ctx *fiber.Ctx) error {
 	ctx.Locals("user", payload)
 	return ctx.Next()
 }
+
+// OptionalUser deserializes the user if the request has an access token, the
+// anonymous requests are passed on without the user.
+func (m *JWTMiddleware) OptionalUser(ctx *fiber.Ctx) error {
+	if accessToken(ctx) == "" {
+		return ctx.Next()
+	}
+	return m.DeserializeUser(ctx)
+}
+
+func accessToken(ctx *fiber.Ctx) string {
+	authorization := ctx.Get("Authorization")
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimPrefix(authorization, "Bearer ")
+	}
+	return ctx.Cookies("access_token")
+}
@@ -0,0 +1,46 @@
+package params
+
+import "time"
+
+// ImportProducts is the Open Food Facts dump to import from a local file. The
+// categories file maps the category tags of the dump onto the names of the
+// categories, such as {"en:dairies": "Молочные продукты"}.
+type ImportProducts struct {
+	Path       string `json:"path"       validate:"required"                     example:"/data/openfoodfacts-products.jsonl.gz"`
+	Format     string `json:"format"     validate:"omitempty,oneof=jsonl csv"    example:"jsonl"`
+	Language   string `json:"language"   validate:"omitempty,alpha,len=2"        example:"ru"`
+	Categories string `json:"categories"                                         example:"/data/categories.json"`
+	DryRun     bool   `json:"dry_run"                                            example:"false"`
+	Restart    bool   `json:"restart"                                            example:"false"`
+	ChunkSize  int    `json:"chunk_size" validate:"omitempty,gte=100,lte=100000" example:"5000"`
+}
+
+// FindImport is the progress of an import.
+type FindImport struct {
+	Source     string      `json:"source"                example:"/data/products.jsonl.gz:1024"`
+	Path       string      `json:"path"                  example:"/data/products.jsonl.gz"`
+	DryRun     bool        `json:"dry_run"               example:"false"`
+	Status     string      `json:"status"                example:"running"`
+	Position   int64       `json:"position"              example:"15000"`
+	Offset     int64       `json:"offset"                example:"512"`
+	Size       int64       `json:"size"                  example:"1024"`
+	Progress   float64     `json:"progress"              example:"50"`
+	Stats      ImportStats `json:"stats"`
+	Error      string      `json:"error,omitempty"       example:"failed to copy products"`
+	StartedAt  time.Time   `json:"started_at"            example:"2023-05-01T12:00:00Z"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty" example:"2023-05-01T13:00:00Z"`
+}
+
+type ImportStats struct {
+	Records            int64 `json:"records"             example:"15000"`
+	Invalid            int64 `json:"invalid"             example:"3"`
+	Skipped            int64 `json:"skipped"             example:"120"`
+	InvalidBarcodes    int64 `json:"invalid_barcodes"    example:"40"`
+	Created            int64 `json:"created"             example:"9000"`
+	MatchedBarcode     int64 `json:"matched_barcode"     example:"5000"`
+	MatchedName        int64 `json:"matched_name"        example:"700"`
+	Duplicates         int64 `json:"duplicates"          example:"177"`
+	BarcodesAdded      int64 `json:"barcodes_added"      example:"9500"`
+	CategoriesLinked   int64 `json:"categories_linked"   example:"12000"`
+	UnmappedCategories int64 `json:"unmapped_categories" example:"30000"`
+}
@@ -22,7 +22,15 @@ type MeasureFilter struct {
 
 type RecipeFilter struct {
 	Paging
-	Name string `query:"name" example:"салат" validate:"omitempty,gte=1,notblank"`
+	Name       string  `query:"name"       example:"салат"  validate:"omitempty,gte=1,notblank"`
+	Mine       bool    `query:"mine"       example:"false"`
+	Favourites bool    `query:"favourites" example:"false"`
+	MinRating  float64 `query:"min_rating" example:"4"      validate:"omitempty,gte=0,lte=5"`
+	Sort       string  `query:"sort"       example:"rating" validate:"omitempty,oneof=id rating"`
+}
+
+type RecipeRatingFilter struct {
+	Paging
 }
 
 type StepFilter struct {
@@ -42,6 +50,15 @@ type ShelfLifeStatusFilter struct {
 
 type ShelfLifeFilter struct {
 	Paging
+	StorageID        int      `query:"id_storage"        example:"1"                     validate:"omitempty,gt=0"`
+	ProductID        int      `query:"id_product"        example:"1"                     validate:"omitempty,gt=0"`
+	CategoryID       int      `query:"id_category"       example:"1"                     validate:"omitempty,gt=0"`
+	StatusID         int      `query:"id_status"         example:"1"                     validate:"omitempty,gt=0"`
+	EndBefore        string   `query:"end_before"        example:"2023-01-31"            validate:"omitempty,datetime=2006-01-02"`
+	EndAfter         string   `query:"end_after"         example:"2023-01-01"            validate:"omitempty,datetime=2006-01-02"`
+	PurchasedBetween []string `query:"purchased_between" example:"2023-01-01,2023-01-31" validate:"omitempty,len=2,dive,datetime=2006-01-02"`
+	ExpiresIn        int      `query:"expires_in"        example:"3"                     validate:"omitempty,gt=0,lte=365"`
+	Sort             string   `query:"sort"              example:"-end_date"             validate:"omitempty,oneof=end_date -end_date purchase_date -purchase_date product -product quantity -quantity"`
 }
 
 type StorageTypeFilter struct {
@@ -59,6 +76,11 @@ type TipFilter struct {
 	Description string `query:"description" example:"хранить при низкой температуре" validate:"omitempty,gte=1,notblank"`
 }
 
+type ShoppingListFilter struct {
+	Paging
+	Name string `query:"name" example:"на неделю" validate:"omitempty,gte=1,notblank"`
+}
+
 type UserFilter struct {
 	Paging
 	Name string `query:"name" example:"hunter" validate:"omitempty,gte=1,alpha"`
@@ -68,3 +90,14 @@ type SettingFilter struct {
 	Paging
 	Name string `query:"name" example:"получать рассылку" validate:"omitempty,gte=1,notblank"`
 }
+
+type RecipeSuggestionFilter struct {
+	Paging
+	MaxMissing *int `query:"max_missing" example:"2" validate:"omitempty,gte=0"`
+}
+
+type MealPlanFilter struct {
+	Paging
+	From string `query:"from" example:"2023-05-01" validate:"omitempty,datetime=2006-01-02"`
+	To   string `query:"to"   example:"2023-05-31" validate:"omitempty,datetime=2006-01-02"`
+}
@@ -0,0 +1,37 @@
+package params
+
+import "time"
+
+type CreateMealPlan struct {
+	RecipeID int        `json:"id_recipe" validate:"required,gt=0"         example:"1"`
+	Date     *time.Time `json:"date"      validate:"required"              example:"2023-05-20T00:00:00Z"`
+	Servings int        `json:"servings"  validate:"required,gt=0,lte=100" example:"2"`
+}
+
+type UpdateMealPlan struct {
+	Date     *time.Time `json:"date"                                        example:"2023-05-20T00:00:00Z"`
+	Servings int        `json:"servings" validate:"omitempty,gt=0,lte=100" example:"2"`
+}
+
+type FindMealPlan struct {
+	ID           int                   `json:"id"                  example:"1"`
+	Recipe       FindRecipe            `json:"recipe"`
+	Date         time.Time             `json:"date"                example:"2023-05-20T00:00:00Z"`
+	Servings     int                   `json:"servings"            example:"2"`
+	CookedAt     *time.Time            `json:"cooked_at,omitempty" example:"2023-05-20T18:00:00Z"`
+	Reservations []FindReservation     `json:"reservations,omitempty"`
+	Warnings     []FindMealPlanWarning `json:"warnings,omitempty"`
+}
+
+type FindReservation struct {
+	ShelfLife FindShelfLife `json:"shelf_life"`
+	Quantity  float32       `json:"quantity"   example:"0.5"`
+}
+
+type FindMealPlanWarning struct {
+	Kind     string      `json:"kind"               example:"expired"`
+	Product  FindProduct `json:"product"`
+	Measure  FindMeasure `json:"measure"`
+	Quantity float32     `json:"quantity"           example:"0.5"`
+	EndDate  *time.Time  `json:"end_date,omitempty" example:"2023-05-19T00:00:00Z"`
+}
@@ -1,14 +1,32 @@
 package params
 
 type CreateMeasure struct {
-	Name string `json:"name" validate:"required,gte=1,notblank" example:"кг"`
+	Name      string  `json:"name"      validate:"required,gte=1,notblank"          example:"кг"`
+	Dimension string  `json:"dimension" validate:"required,oneof=mass volume count" example:"mass"`
+	Factor    float64 `json:"factor"    validate:"required,gt=0"                    example:"1000"`
 }
 
 type UpdateMeasure struct {
-	Name string `json:"name" validate:"required,gte=1,notblank" example:"л"`
+	Name      string  `json:"name"      validate:"required,gte=1,notblank"           example:"л"`
+	Dimension string  `json:"dimension" validate:"omitempty,oneof=mass volume count" example:"volume"`
+	Factor    float64 `json:"factor"    validate:"omitempty,gt=0"                    example:"1000"`
 }
 
 type FindMeasure struct {
-	ID   int    `json:"id"   example:"1"`
-	Name string `json:"name" example:"кг"`
+	ID        int     `json:"id"                  example:"1"`
+	Name      string  `json:"name"                example:"кг"`
+	Dimension string  `json:"dimension,omitempty" example:"mass"`
+	Factor    float64 `json:"factor,omitempty"    example:"1000"`
+}
+
+type ConvertMeasure struct {
+	Quantity  float64 `query:"quantity" validate:"gte=0"           example:"1.5"`
+	FromID    int     `query:"from"     validate:"required,gte=1"  example:"1"`
+	ToID      int     `query:"to"       validate:"required,gte=1"  example:"2"`
+	ProductID int     `query:"product"  validate:"omitempty,gte=1" example:"1"`
+}
+
+type FindConversion struct {
+	Quantity float64     `json:"quantity" example:"1500"`
+	Measure  FindMeasure `json:"measure"`
 }
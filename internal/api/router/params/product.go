@@ -1,14 +1,49 @@
 package params
 
 type CreateProduct struct {
-	Name string `json:"name" validate:"required,gte=2,notblank" example:"Томат"`
+	Name        string     `json:"name"               validate:"required,gte=2,notblank" example:"Томат"`
+	Density     *float64   `json:"density"            validate:"omitempty,gt=0"          example:"1.03"`
+	PieceWeight *float64   `json:"piece_weight"       validate:"omitempty,gt=0"          example:"120"`
+	Nutrition   *Nutrition `json:"nutrition"          validate:"omitempty"`
+	Barcodes    []string   `json:"barcodes,omitempty" validate:"unique,dive,barcode"     example:"4600680001418"`
 }
 
 type UpdateProduct struct {
-	Name string `json:"name" validate:"required,gte=2,notblank" exmaple:"Морковь"`
+	Name        string     `json:"name"         validate:"required,gte=2,notblank" exmaple:"Морковь"`
+	Density     *float64   `json:"density"      validate:"omitempty,gt=0"          example:"1.03"`
+	PieceWeight *float64   `json:"piece_weight" validate:"omitempty,gt=0"          example:"75"`
+	Nutrition   *Nutrition `json:"nutrition"    validate:"omitempty"`
 }
 
 type FindProduct struct {
-	ID   int    `json:"id"   example:"1"`
-	Name string `json:"name" example:"Морковь"`
+	ID          int        `json:"id"                     example:"1"`
+	Name        string     `json:"name"                   example:"Морковь"`
+	Density     *float64   `json:"density,omitempty"      example:"1.03"`
+	PieceWeight *float64   `json:"piece_weight,omitempty" example:"75"`
+	Nutrition   *Nutrition `json:"nutrition,omitempty"`
+	Barcodes    []string   `json:"barcodes,omitempty"     example:"4600680001418"`
+}
+
+// ProductBarcode is an EAN-13 or UPC-A code of a product.
+type ProductBarcode struct {
+	Barcode string `json:"barcode" validate:"required,barcode" example:"4600680001418"`
+}
+
+// FindBarcode is the product of the barcode. The suggestion to create a
+// product is given instead when the barcode is unknown.
+type FindBarcode struct {
+	Barcode    string         `json:"barcode"              example:"4600680001418"`
+	Product    *FindProduct   `json:"product,omitempty"`
+	Suggestion *CreateProduct `json:"suggestion,omitempty"`
+}
+
+// Nutrition is the nutrition facts per 100 g of a product or the totals of
+// the products. The energy is in kilocalories, the macronutrients are in
+// grams.
+type Nutrition struct {
+	Energy         float64            `json:"energy"                   validate:"gte=0,lte=900"                           example:"41"`
+	Protein        float64            `json:"protein"                  validate:"gte=0,lte=100"                           example:"1.3"`
+	Fat            float64            `json:"fat"                      validate:"gte=0,lte=100"                           example:"0.1"`
+	Carbohydrates  float64            `json:"carbohydrates"            validate:"gte=0,lte=100"                           example:"6.9"`
+	Micronutrients map[string]float64 `json:"micronutrients,omitempty" validate:"omitempty,dive,keys,notblank,endkeys,gte=0"`
 }
@@ -0,0 +1,61 @@
+package params
+
+import "time"
+
+// ReceiptQR is the text of the QR code of a fiscal receipt.
+type ReceiptQR struct {
+	QR string `json:"qr" validate:"required" example:"t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1"`
+}
+
+// FindReceipt is a receipt with the products proposed for its items. The
+// QR code is empty for the recognized paper receipts. The sums are in rubles.
+type FindReceipt struct {
+	QR           string            `json:"qr,omitempty"  example:"t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1"`
+	PurchaseDate time.Time         `json:"purchase_date" example:"2023-09-15T18:30:00Z"`
+	Sum          float64           `json:"sum"           example:"1234.5"`
+	Items        []FindReceiptItem `json:"items"`
+}
+
+// FindReceiptItem is a line of a receipt with the product and the measure it
+// matches. The score is the share of the words of the product name found in
+// the line, the end date is the default end date of the product.
+type FindReceiptItem struct {
+	Name     string       `json:"name"               example:"МОЛОКО ПАСТ.3,2% 930МЛ"`
+	Price    float64      `json:"price"              example:"89.9"`
+	Quantity float64      `json:"quantity"           example:"2"`
+	Sum      float64      `json:"sum"                example:"179.8"`
+	Product  *FindProduct `json:"product,omitempty"`
+	Measure  *FindMeasure `json:"measure,omitempty"`
+	Score    float64      `json:"score"              example:"1"`
+	EndDate  *time.Time   `json:"end_date,omitempty" example:"2023-09-22T18:30:00Z"`
+}
+
+// ConfirmReceipt is the batch of the shelf lives of a receipt confirmed by
+// the user. The purchase date of the shelf lives is the time of the receipt.
+type ConfirmReceipt struct {
+	QR    string               `json:"qr"    validate:"required"                 example:"t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1"`
+	Items []ConfirmReceiptItem `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type ConfirmReceiptItem struct {
+	ProductID int        `json:"id_product" validate:"required,gt=0" example:"1"`
+	StorageID int        `json:"id_storage" validate:"required,gt=0" example:"1"`
+	MeasureID int        `json:"id_measure" validate:"required,gt=0" example:"1"`
+	Quantity  float32    `json:"quantity"   validate:"required,gt=0" example:"2"`
+	EndDate   *time.Time `json:"end_date"   validate:"required"      example:"2023-09-25T00:00:00Z"`
+}
+
+// CreateReceiptShelfLives is the batch of the shelf lives of a recognized
+// paper receipt accepted by the user.
+type CreateReceiptShelfLives struct {
+	Items []ReceiptShelfLife `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type ReceiptShelfLife struct {
+	ProductID    int        `json:"id_product"    validate:"required,gt=0"                 example:"1"`
+	StorageID    int        `json:"id_storage"    validate:"required,gt=0"                 example:"1"`
+	MeasureID    int        `json:"id_measure"    validate:"required,gt=0"                 example:"1"`
+	Quantity     float32    `json:"quantity"      validate:"required,gt=0"                 example:"2"`
+	PurchaseDate *time.Time `json:"purchase_date" validate:"required"                      example:"2023-09-15T18:30:00Z"`
+	EndDate      *time.Time `json:"end_date"      validate:"required,gtfield=PurchaseDate" example:"2023-09-25T00:00:00Z"`
+}
@@ -1,29 +1,118 @@
 package params
 
+import "time"
+
 type CreateRecipe struct {
-	UserID      int          `json:"id_user"               validate:"required,gt=0"                   exmaple:"1"`
+	UserID      int          `json:"id_user"               validate:"omitempty,gt=0"                  exmaple:"1"`
 	Name        string       `json:"name"                  validate:"required,gte=2,lte=100,notblank"             example:"Салат"`
 	Description string       `json:"description,omitempty" validate:"lte=200"                                     example:"Салат из миндаля"`
+	Servings    int          `json:"servings,omitempty"    validate:"omitempty,gt=0,lte=100"                      example:"2"`
 	Steps       []RecipeStep `json:"steps"                 validate:"required"`
 	Ingredients []Ingredient `json:"ingredients"           validate:"required"`
 }
 
 type FindRecipe struct {
-	ID          int        `json:"id"                    example:"1"`
-	Name        string     `json:"name"                  example:"Салат"`
-	Description string     `json:"description,omitempty" example:"Салат из миндаля"`
-	Steps       []FindStep `json:"steps,omitempty"`
+	ID             int                    `json:"id"                        example:"1"`
+	UserID         int                    `json:"id_user,omitempty"         example:"1"`
+	Name           string                 `json:"name"                      example:"Салат"`
+	Description    string                 `json:"description,omitempty"     example:"Салат из миндаля"`
+	Servings       int                    `json:"servings,omitempty"        example:"2"`
+	Status         string                 `json:"status,omitempty"          example:"published"`
+	ModerationNote string                 `json:"moderation_note,omitempty" example:"Нет шагов"`
+	Rating         float64                `json:"rating"                    example:"4.5"`
+	Ratings        int                    `json:"ratings"                   example:"12"`
+	Steps          []FindStep             `json:"steps,omitempty"`
+	Ingredients    []FindRecipeIngredient `json:"ingredients,omitempty"`
+	Nutrition      *FindRecipeNutrition   `json:"nutrition,omitempty"`
+}
+
+// FindRecipeNutrition is the nutrition of the recipe for the servings. The
+// ingredients of the missing products are not counted.
+type FindRecipeNutrition struct {
+	Servings   int           `json:"servings"    example:"4"`
+	Total      Nutrition     `json:"total"`
+	PerServing Nutrition     `json:"per_serving"`
+	Missing    []FindProduct `json:"missing"`
 }
 
 type UpdateRecipe struct {
-	Name        string `json:"name"        validate:"gte=2,lte=100" example:"Салат"`
-	Description string `json:"description" validate:"lte=200"       example:"Салат из миндаля"`
+	Name        string `json:"name"        validate:"gte=2,lte=100"          example:"Салат"`
+	Description string `json:"description" validate:"lte=200"                example:"Салат из миндаля"`
+	Servings    int    `json:"servings"    validate:"omitempty,gt=0,lte=100" example:"4"`
+}
+
+type RecipeServings struct {
+	Servings int `query:"servings" validate:"omitempty,gt=0,lte=1000" example:"4"`
 }
 
 type DeleteRecipeStep struct {
-	Place int `json:"place" validate:"required,gt=0" example:"1"`
+	Place int `query:"place" validate:"omitempty,gt=0" example:"1"`
 }
 
 type CreateRecipeStep struct {
-	Place int `json:"place" validate:"required,gt=0" example:"1"`
+	Place       int   `json:"place"       validate:"omitempty,gt=0"             example:"1"`
+	Duration    *int  `json:"duration"    validate:"omitempty,gt=0"             example:"1200"`
+	Timer       *int  `json:"timer"       validate:"omitempty,gt=0"             example:"900"`
+	Temperature *int  `json:"temperature" validate:"omitempty,gte=-50,lte=400"  example:"100"`
+	Ingredients []int `json:"ingredients" validate:"omitempty,unique,dive,gt=0" example:"1,2"`
+}
+
+type UpdateRecipeStep struct {
+	Place       int   `json:"place"       validate:"omitempty,gt=0"             example:"1"`
+	Duration    *int  `json:"duration"    validate:"omitempty,gt=0"             example:"1200"`
+	Timer       *int  `json:"timer"       validate:"omitempty,gt=0"             example:"900"`
+	Temperature *int  `json:"temperature" validate:"omitempty,gte=-50,lte=400"  example:"100"`
+	Ingredients []int `json:"ingredients" validate:"omitempty,unique,dive,gt=0" example:"1,2"`
+}
+
+type ReorderRecipeSteps struct {
+	Steps []int `json:"steps" validate:"required,min=1,dive,gt=0" example:"3,1,2"`
+}
+
+type FindRecipeSuggestion struct {
+	Recipe    FindRecipe              `json:"recipe"`
+	Matched   int                     `json:"matched"              example:"3"`
+	Total     int                     `json:"total"                example:"4"`
+	ExpiresAt *time.Time              `json:"expires_at,omitempty" example:"2023-05-22T00:00:00Z"`
+	Missing   []FindMissingIngredient `json:"missing"`
+}
+
+type FindMissingIngredient struct {
+	Product  FindProduct `json:"product"`
+	Measure  FindMeasure `json:"measure"`
+	Quantity float32     `json:"quantity" example:"0.5"`
+}
+
+type ImportRecipe struct {
+	DryRun bool `query:"dry_run" example:"false"`
+}
+
+type FindImportedRecipe struct {
+	Recipe    FindRecipe `json:"recipe"`
+	Unmatched []string   `json:"unmatched"`
+}
+
+type RateRecipe struct {
+	Rating int    `json:"rating" validate:"required,gte=1,lte=5" example:"5"`
+	Review string `json:"review" validate:"lte=1000"             example:"Очень вкусно"`
+}
+
+type FindRecipeRating struct {
+	UserID    int        `json:"id_user"              example:"1"`
+	Username  string     `json:"username"             example:"user"`
+	Rating    int        `json:"rating"               example:"5"`
+	Review    string     `json:"review,omitempty"     example:"Очень вкусно"`
+	CreatedAt time.Time  `json:"created_at"           example:"2023-05-22T00:00:00Z"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" example:"2023-05-22T00:00:00Z"`
+}
+
+type ModerateRecipe struct {
+	Decision string `json:"decision" validate:"required,oneof=approve reject" example:"approve"`
+	Note     string `json:"note"     validate:"lte=200"                        example:"Нет шагов"`
+}
+
+// RecipeAccess is the owner and the status of a recipe.
+type RecipeAccess struct {
+	UserID int
+	Status string
 }
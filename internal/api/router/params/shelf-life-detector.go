@@ -0,0 +1,158 @@
+package params
+
+import "time"
+
+// DetectedDates are the dates found on the label of a product. The purchase
+// and the end dates are the suggestions of the shelf life by the most
+// confident dates of the roles, they are empty if no such dates are found.
+// The shelf life and the storage temperature are set if they are printed.
+// The raw text is the text of every OCR pass, the overlay is the PNG image
+// with the boxes of the words and the dates drawn on it, returned only in
+// the debug mode. The detection id is of the detection stored for the
+// feedback of the user.
+type DetectedDates struct {
+	DetectionID  int                  `json:"detection_id,omitempty"  example:"1"`
+	Dates        []DetectedDate       `json:"dates"`
+	PurchaseDate *time.Time           `json:"purchase_date,omitempty" example:"2022-09-15T00:00:00Z"`
+	EndDate      *time.Time           `json:"end_date,omitempty"      example:"2022-09-24T00:00:00Z"`
+	ShelfLife    *DetectedShelfLife   `json:"shelf_life,omitempty"`
+	Temperature  *DetectedTemperature `json:"temperature,omitempty"`
+	RawText      []string             `json:"raw_text"                example:"15.09.22 4Г745"`
+	Overlay      []byte               `json:"overlay,omitempty"       swaggertype:"string" format:"base64"`
+}
+
+// DetectedDate is a date of the label with its role: manufactured, packed,
+// best_before, use_by or unknown. The confidence is from 0 to 1, the month
+// only dates are the last days of their months.
+// The computed dates are not printed but computed by the production date and
+// the shelf life. The boxes are of the words of the date, or of the shelf
+// life for the computed dates. The format is the printed format of the date.
+type DetectedDate struct {
+	Date       time.Time     `json:"date"       example:"2022-09-24T00:00:00Z"`
+	Role       string        `json:"role"       example:"best_before"`
+	Confidence float64       `json:"confidence" example:"0.9"`
+	MonthOnly  bool          `json:"month_only" example:"false"`
+	Computed   bool          `json:"computed"   example:"false"`
+	Format     string        `json:"format"     example:"day-month-year"`
+	Boxes      []DetectedBox `json:"boxes"`
+}
+
+// DetectedBox is the bounding box of a recognized word in the pixels of the
+// image rotated by its EXIF orientation.
+type DetectedBox struct {
+	X      int `json:"x"      example:"12"`
+	Y      int `json:"y"      example:"40"`
+	Width  int `json:"width"  example:"120"`
+	Height int `json:"height" example:"24"`
+}
+
+// DetectedShelfLife is the printed duration of the shelf life in hours,
+// days, weeks, months or years.
+type DetectedShelfLife struct {
+	Amount int    `json:"amount" example:"30"`
+	Unit   string `json:"unit"   example:"days"`
+	Role   string `json:"role"   example:"best_before"`
+}
+
+// DetectedTemperature is the storage temperature range in degrees Celsius,
+// a bound is empty if it is not printed. It is compared with the temperature
+// of the storage.
+type DetectedTemperature struct {
+	Min *float64 `json:"min,omitempty" example:"2"`
+	Max *float64 `json:"max,omitempty" example:"6"`
+}
+
+// DetectionJob is an asynchronous detection of the dates of an image. Its
+// status is queued, running, done or failed, the result is set when it is
+// done and the error when it is failed.
+type DetectionJob struct {
+	ID        string         `json:"id"              example:"0b8f1b5e-6a9e-4b8b-9a43-4cf5d3c3a0a1"`
+	Status    string         `json:"status"          example:"done"`
+	Attempts  int            `json:"attempts"        example:"1"`
+	Error     string         `json:"error,omitempty" example:"no dates found"`
+	Result    *DetectedDates `json:"result,omitempty"`
+	CreatedAt time.Time      `json:"created_at"      example:"2022-09-15T10:00:00Z"`
+	UpdatedAt time.Time      `json:"updated_at"      example:"2022-09-15T10:00:02Z"`
+}
+
+// CreateDetectedShelfLife is the shelf life created from the photo of the
+// label of the product, it is sent as the form with the photo. The dates
+// are in the 2006-01-02 format and override the detected dates. The
+// detection id is of the detection answered with the candidates, the dates
+// chosen by the user are saved as its feedback. The consent allows to keep
+// the photo in the dataset of the detections.
+type CreateDetectedShelfLife struct {
+	ProductID    int     `form:"id_product"    validate:"required,gt=0"                 example:"1"`
+	StorageID    int     `form:"id_storage"    validate:"required,gt=0"                 example:"1"`
+	MeasureID    int     `form:"id_measure"    validate:"required,gt=0"                 example:"1"`
+	Quantity     float32 `form:"quantity"      validate:"required,gt=0"                 example:"1"`
+	PurchaseDate string  `form:"purchase_date" validate:"omitempty,datetime=2006-01-02" example:"2022-09-15"`
+	EndDate      string  `form:"end_date"      validate:"omitempty,datetime=2006-01-02" example:"2022-09-24"`
+	DetectionID  int     `form:"detection_id"  validate:"omitempty,gt=0"                example:"1"`
+	Consent      bool    `form:"consent"                                                example:"false"`
+}
+
+// ShelfLifeAttachment is the photo of the label the shelf life is created
+// from.
+type ShelfLifeAttachment struct {
+	ContentType string
+	Data        []byte
+}
+
+// DetectText is the text of a label recognized by the OCR of the client,
+// such as of a phone. The form feeds separate the texts of several passes.
+type DetectText struct {
+	Text string `json:"text" validate:"required,max=10000" example:"изг 15.09.22 годен до 24.09.22"`
+}
+
+// DetectionFeedback is the dates of the shelf life the user finally saved
+// for a detection.
+type DetectionFeedback struct {
+	PurchaseDate *time.Time `json:"purchase_date" validate:"required"                      example:"2022-09-15T00:00:00Z"`
+	EndDate      *time.Time `json:"end_date"      validate:"required,gtfield=PurchaseDate" example:"2022-09-24T00:00:00Z"`
+	ShelfLifeID  int        `json:"id_shelf_life" validate:"omitempty,gt=0"                example:"1"`
+}
+
+// DetectionExportFilter pages the labelled detections by their ids, the
+// next page is after the id of the last detection.
+type DetectionExportFilter struct {
+	After int `query:"after" example:"0"    validate:"omitempty,gte=0"`
+	Limit int `query:"limit" example:"1000" validate:"omitempty,gte=1,lte=10000"`
+}
+
+// DetectionSample is a detection of the labelled dataset. The image is set
+// only if the user consented to keep it, the proposed dates are the
+// detected dates without the raw text and the overlay, the purchase and the
+// end dates are the dates the user saved.
+type DetectionSample struct {
+	ID           int           `json:"id"                     example:"1"`
+	ImageHash    string        `json:"image_hash"             example:"9f86d081884c7d65"`
+	Image        []byte        `json:"image,omitempty"        swaggertype:"string" format:"base64"`
+	ContentType  string        `json:"content_type,omitempty" example:"image/jpeg"`
+	RawText      []string      `json:"raw_text"               example:"15.09.22 4Г745"`
+	Proposed     DetectedDates `json:"proposed"`
+	PurchaseDate *time.Time    `json:"purchase_date"          example:"2022-09-15T00:00:00Z"`
+	EndDate      *time.Time    `json:"end_date"               example:"2022-09-24T00:00:00Z"`
+	CreatedAt    time.Time     `json:"created_at"             example:"2022-09-15T10:00:00Z"`
+}
+
+// DetectionReport is the accuracy of the end dates detected on a dataset
+// by the printed formats of the dates. The dates the parser did not find are
+// of the "none" format.
+type DetectionReport struct {
+	Samples int                    `json:"samples" example:"120"`
+	Skipped int                    `json:"skipped" example:"2"`
+	Total   DetectionFormatScore   `json:"total"`
+	Formats []DetectionFormatScore `json:"formats"`
+}
+
+// DetectionFormatScore is the precision and the recall of the end dates of
+// a format.
+type DetectionFormatScore struct {
+	Format         string  `json:"format"          example:"day-month-year"`
+	TruePositives  int     `json:"true_positives"  example:"90"`
+	FalsePositives int     `json:"false_positives" example:"4"`
+	FalseNegatives int     `json:"false_negatives" example:"6"`
+	Precision      float64 `json:"precision"       example:"0.96"`
+	Recall         float64 `json:"recall"          example:"0.94"`
+}
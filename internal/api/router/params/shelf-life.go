@@ -20,6 +20,7 @@ type FindShelfLife struct {
 	Quantity     float32     `json:"quantity"      example:"1"`
 	PurchaseDate *time.Time  `json:"purchase_date" example:"2020-01-01T00:00:00Z"`
 	EndDate      *time.Time  `json:"end_date"      example:"2020-01-02T00:00:00Z"`
+	Restock      bool        `json:"restock"       example:"false"`
 }
 
 type UpdateShelfLife struct {
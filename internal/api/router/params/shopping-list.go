@@ -0,0 +1,55 @@
+package params
+
+import "time"
+
+type FindShoppingList struct {
+	ID        int                    `json:"id"                example:"1"`
+	Name      string                 `json:"name"              example:"На неделю"`
+	Storage   *FindStorage           `json:"storage,omitempty"`
+	Items     []FindShoppingListItem `json:"items,omitempty"`
+	CreatedAt *time.Time             `json:"created_at"        example:"2023-05-20T00:00:00Z"`
+}
+
+type CreateShoppingList struct {
+	Name      string `json:"name"       validate:"required,gte=1,lte=100,notblank" example:"На неделю"`
+	StorageID int    `json:"id_storage" validate:"omitempty,gt=0"                  example:"1"`
+}
+
+type UpdateShoppingList struct {
+	Name      string `json:"name"       validate:"omitempty,gte=1,lte=100,notblank" example:"На неделю"`
+	StorageID *int   `json:"id_storage" validate:"omitempty,gte=0"                  example:"1"`
+}
+
+type FindShoppingListItem struct {
+	ID       int         `json:"id"       example:"1"`
+	Product  FindProduct `json:"product"`
+	Measure  FindMeasure `json:"measure"`
+	Quantity float32     `json:"quantity" example:"1.5"`
+	Checked  bool        `json:"checked"  example:"false"`
+	Note     string      `json:"note"     example:"Без лактозы"`
+}
+
+type CreateShoppingListItem struct {
+	ProductID int     `json:"id_product" validate:"required,gt=0"     example:"1"`
+	MeasureID int     `json:"id_measure" validate:"required,gt=0"     example:"1"`
+	Quantity  float32 `json:"quantity"   validate:"required,gt=0"     example:"1.5"`
+	Note      string  `json:"note"       validate:"omitempty,lte=200" example:"Без лактозы"`
+}
+
+type UpdateShoppingListItem struct {
+	MeasureID int     `json:"id_measure" validate:"omitempty,gt=0"    example:"1"`
+	Quantity  float32 `json:"quantity"   validate:"omitempty,gt=0"    example:"1.5"`
+	Note      *string `json:"note"       validate:"omitempty,lte=200" example:"Без лактозы"`
+}
+
+// CheckShoppingListItem checks off the item. If the storage is set, a new
+// shelf life of the bought product is created in it.
+type CheckShoppingListItem struct {
+	Checked   bool       `json:"checked"                                           example:"true"`
+	StorageID int        `json:"id_storage" validate:"gte=0,required_with=EndDate" example:"1"`
+	EndDate   *time.Time `json:"end_date"   validate:"required_with=StorageID"     example:"2023-05-27T00:00:00Z"`
+}
+
+type GenerateShoppingListItems struct {
+	RecipeID int `json:"id_recipe" validate:"required,gt=0" example:"1"`
+}
@@ -9,9 +9,13 @@ type UpdateStep struct {
 }
 
 type FindStep struct {
-	ID    int    `json:"id"              example:"1"`
-	Name  string `json:"name"            example:"Сварить картошку"`
-	Place int    `json:"place,omitempty" example:"1"`
+	ID          int    `json:"id"                    example:"1"`
+	Name        string `json:"name"                  example:"Сварить картошку"`
+	Place       int    `json:"place,omitempty"       example:"1"`
+	Duration    *int   `json:"duration,omitempty"    example:"1200"`
+	Timer       *int   `json:"timer,omitempty"       example:"900"`
+	Temperature *int   `json:"temperature,omitempty" example:"100"`
+	Ingredients []int  `json:"ingredients,omitempty" example:"1,2"`
 }
 
 type RecipeStep struct {
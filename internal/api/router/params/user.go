@@ -45,4 +45,34 @@ type UserShelfLife struct {
 	Quantity     float32    `json:"quantity"      validate:"omitempty,gt=0"                                  example:"1"`
 	PurchaseDate *time.Time `json:"purchase_date" validate:"required_with=EndDate,ltfield=EndDate"           example:"2020-01-01T00:00:00Z"`
 	EndDate      *time.Time `json:"end_date"      validate:"required_with=PurchaseDate,gtfield=PurchaseDate" example:"2020-01-02T00:00:00Z"`
+	Restock      *bool      `json:"restock"                                                                  example:"true"`
+}
+
+// ConsumeShelfLife is the quantity of the shelf life consumed by the user, in
+// the measure of the shelf life.
+type ConsumeShelfLife struct {
+	Quantity float64 `json:"quantity" validate:"required,gt=0" example:"0.5"`
+}
+
+type FindConsumption struct {
+	ID          int       `json:"id"            example:"1"`
+	ShelfLifeID int       `json:"id_shelf_life" example:"1"`
+	Quantity    float64   `json:"quantity"      example:"0.5"`
+	Remaining   float32   `json:"remaining"     example:"0.5"`
+	ConsumedAt  time.Time `json:"consumed_at"   example:"2023-05-01T12:00:00Z"`
+}
+
+// NutritionFilter is the range of the days of the nutrition summary, the last
+// week by default.
+type NutritionFilter struct {
+	From string `query:"from" validate:"omitempty,datetime=2006-01-02" example:"2023-05-01"`
+	To   string `query:"to"   validate:"omitempty,datetime=2006-01-02" example:"2023-05-07"`
+}
+
+// FindDailyNutrition is the nutrition consumed by the user in a day. The
+// consumptions of the missing products are not counted.
+type FindDailyNutrition struct {
+	Date      string        `json:"date"      example:"2023-05-01"`
+	Nutrition Nutrition     `json:"nutrition"`
+	Missing   []FindProduct `json:"missing"`
 }
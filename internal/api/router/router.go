@@ -16,6 +16,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/notfound"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/redis"
 )
@@ -28,6 +29,7 @@ func NewV1(
 	cfg *config.Config,
 	client postgres.Client,
 	cache redis.Client,
+	backend ocr.Backend,
 	logger logger.Logger,
 ) *Router {
 	r := &Router{
@@ -35,17 +37,29 @@ func NewV1(
 			AppName:     "Muerta API v1.0",
 			JSONEncoder: sonic.Marshal,
 			JSONDecoder: sonic.Unmarshal,
+			BodyLimit:   bodyLimit(cfg),
 		}),
 	}
 	r.mountAPIMiddlewares(cfg, logger)
 	r.Get("/docs/*", swagger.HandlerDefault)
 	api := r.Group("/api")
 	routesV1 := api.Group("/v1")
-	v1.New(cfg, routesV1, client, cache, logger)
+	v1.New(cfg, routesV1, client, cache, backend, logger)
 	r.Use(notfound.New())
 	return r
 }
 
+// bodyLimit returns the largest size of a request body, the default limit
+// is raised to fit the form of the largest uploaded image.
+func bodyLimit(cfg *config.Config) int {
+	// room for the other fields and the boundaries of the form
+	const formOverhead = 64 * 1024
+	if limit := int(cfg.OCR.UploadLimit) + formOverhead; limit > fiber.DefaultBodyLimit {
+		return limit
+	}
+	return fiber.DefaultBodyLimit
+}
+
 func (r *Router) mountAPIMiddlewares(cfg *config.Config, logger logger.Logger) {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.AllowOrigins,
@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
 )
 
 func ParseIDFromPath(ctx *fiber.Ctx, key string) (int, error) {
@@ -42,3 +45,43 @@ func ParseBodyAndValidate(ctx *fiber.Ctx, body interface{}) error {
 	}
 	return nil
 }
+
+// ErrFileTooLarge is returned when the uploaded file exceeds the limit.
+var ErrFileTooLarge = errors.New("file is too large")
+
+// ReadImage reads the image uploaded as the form file. The size of the file
+// and the dimensions of the image are limited, only the formats supported by
+// imaging are accepted.
+func ReadImage(ctx *fiber.Ctx, key string, limit int64) ([]byte, error) {
+	file, err := ctx.FormFile(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get form file: %w", err)
+	}
+	if file.Size > limit {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFileTooLarge, file.Size)
+	}
+	content, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open form file: %w", err)
+	}
+	defer content.Close()
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read form file: %w", err)
+	}
+	if err := imaging.Check(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImageError returns the response error of the ReadImage error.
+func ImageError(err error) *fiber.Error {
+	switch {
+	case errors.Is(err, ErrFileTooLarge), errors.Is(err, imaging.ErrTooLarge):
+		return fiber.ErrRequestEntityTooLarge
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		return fiber.ErrUnsupportedMediaType
+	}
+	return fiber.ErrBadRequest
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/romankravchuk/muerta/internal/pkg/barcode"
 )
 
 var validate *validator.Validate
@@ -21,9 +22,15 @@ func notBlank(fl validator.FieldLevel) bool {
 	return true
 }
 
+// validBarcode validates an EAN-13 or UPC-A code.
+func validBarcode(fl validator.FieldLevel) bool {
+	return barcode.Valid(fl.Field().String())
+}
+
 func init() {
 	validate = validator.New()
 	validate.RegisterValidation("notblank", notBlank)
+	validate.RegisterValidation("barcode", validBarcode)
 }
 
 type ValidationError struct {
@@ -2,8 +2,11 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 )
 
 // GenerateHashFromPassword returns a SHA256 hash string for the given password and salt.
@@ -18,3 +21,20 @@ func GenerateHashFromPassword(password, salt string) string {
 func CompareHashAndPassword(password, salt, hashedPassword string) bool {
 	return GenerateHashFromPassword(password, salt) == hashedPassword
 }
+
+// GenerateFeedToken returns a secret token granting read access to the feeds
+// of the user with the given id. The token is an HMAC-SHA256 of the feed name,
+// the user id and the version of the user feeds signed with the given secret,
+// the tokens of the previous versions are revoked.
+func GenerateFeedToken(feed string, userID, version int, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(feed + ":" + strconv.Itoa(userID) + ":" + strconv.Itoa(version)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CompareFeedToken compares the given token against the token of the user feed
+// in constant time. It returns true if they match, false otherwise.
+func CompareFeedToken(feed string, userID, version int, secret []byte, token string) bool {
+	expected := GenerateFeedToken(feed, userID, version, secret)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
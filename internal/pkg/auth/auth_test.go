@@ -44,3 +44,50 @@ func Test_CompareHashAndPassword(t *testing.T) {
 		assert.Equal(t, testCase.expected, isCompare)
 	})
 }
+
+func Test_CompareFeedToken(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userID   int
+		token    func(secret []byte) string
+		expected bool
+	}{
+		{
+			name:     "valid token",
+			userID:   1,
+			token:    func(secret []byte) string { return GenerateFeedToken("calendar", 1, 1, secret) },
+			expected: true,
+		},
+		{
+			name:     "token of another user",
+			userID:   1,
+			token:    func(secret []byte) string { return GenerateFeedToken("calendar", 2, 1, secret) },
+			expected: false,
+		},
+		{
+			name:     "token of another feed",
+			userID:   1,
+			token:    func(secret []byte) string { return GenerateFeedToken("shopping", 1, 1, secret) },
+			expected: false,
+		},
+		{
+			name:     "token of a reset feed",
+			userID:   1,
+			token:    func(secret []byte) string { return GenerateFeedToken("calendar", 1, 0, secret) },
+			expected: false,
+		},
+		{
+			name:     "empty token",
+			userID:   1,
+			token:    func(secret []byte) string { return "" },
+			expected: false,
+		},
+	}
+	secret := []byte("30612ede-2254-4708-9f4e-90afedbc33fb")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			isCompare := CompareFeedToken("calendar", tc.userID, 1, secret, tc.token(secret))
+			assert.Equal(t, tc.expected, isCompare)
+		})
+	}
+}
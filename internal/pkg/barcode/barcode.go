@@ -0,0 +1,56 @@
+// Package barcode validates and decodes EAN-13 and UPC-A barcodes. UPC-A
+// codes are handled as EAN-13 codes with the leading zero.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalid is returned when a code is not a valid EAN-13 or UPC-A code.
+	ErrInvalid = errors.New("invalid barcode")
+	// ErrNotFound is returned when no barcode is found in the image.
+	ErrNotFound = errors.New("barcode not found")
+)
+
+// Normalize returns the EAN-13 form of the EAN-13 or UPC-A code. The spaces
+// and the dashes are ignored, the check digit is verified.
+func Normalize(code string) (string, error) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(code)
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	if len(code) != 13 {
+		return "", fmt.Errorf("%w: %q must have 12 or 13 digits", ErrInvalid, code)
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", fmt.Errorf("%w: %q must have only digits", ErrInvalid, code)
+		}
+	}
+	if CheckDigit(code[:12]) != int(code[12]-'0') {
+		return "", fmt.Errorf("%w: %q has wrong check digit", ErrInvalid, code)
+	}
+	return code, nil
+}
+
+// Valid reports whether the code is a valid EAN-13 or UPC-A code.
+func Valid(code string) bool {
+	_, err := Normalize(code)
+	return err == nil
+}
+
+// CheckDigit returns the check digit of the first 12 digits of an EAN-13 code.
+func CheckDigit(digits string) int {
+	sum := 0
+	for i, r := range digits {
+		d := int(r - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,116 @@
+package barcode
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Normalize(t *testing.T) {
+	testCases := []struct {
+		name string
+		code string
+		want string
+		err  bool
+	}{
+		{name: "ean-13", code: "4006381333931", want: "4006381333931"},
+		{name: "upc-a", code: "036000291452", want: "0036000291452"},
+		{name: "spaces", code: "4 600680 001418", want: "4600680001418"},
+		{name: "wrong check digit", code: "4006381333932", err: true},
+		{name: "too short", code: "12345", err: true},
+		{name: "letters", code: "40063813339a1", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Normalize(tc.code)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func Test_Decode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		code   string
+		module float64
+		rotate bool
+		want   string
+	}{
+		{name: "ean-13", code: "4600680001418", module: 3, want: "4600680001418"},
+		{name: "upc-a", code: "0036000291452", module: 2, want: "0036000291452"},
+		{name: "fractional module", code: "4006381333931", module: 2.6, want: "4006381333931"},
+		{name: "upside down", code: "5901234123457", module: 3, rotate: true, want: "5901234123457"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img := render(encode(tc.code), tc.module, tc.rotate)
+			got, err := Decode(img)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	t.Run("blank", func(t *testing.T) {
+		img := image.NewGray(image.Rect(0, 0, 100, 20))
+		_, err := Decode(img)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// encode returns the modules of the EAN-13 code, true for a bar.
+func encode(code string) []bool {
+	var result []bool
+	put := func(widths [4]float64, bar bool) {
+		for _, w := range widths {
+			for i := 0; i < int(w); i++ {
+				result = append(result, bar)
+			}
+			bar = !bar
+		}
+	}
+	result = append(result, true, false, true)
+	parity := parities[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		widths := patterns[code[i]-'0']
+		if parity[i-1] == 'G' {
+			widths = [4]float64{widths[3], widths[2], widths[1], widths[0]}
+		}
+		put(widths, false)
+	}
+	result = append(result, false, true, false, true, false)
+	for i := 7; i <= 12; i++ {
+		put(patterns[code[i]-'0'], true)
+	}
+	return append(result, true, false, true)
+}
+
+// render draws the modules with a quiet zone, mirrored when rotated.
+func render(bars []bool, module float64, rotate bool) image.Image {
+	var (
+		quiet  = 10 * module
+		width  = int(float64(len(bars))*module + 2*quiet)
+		height = 30
+		img    = image.NewGray(image.Rect(0, 0, width, height))
+	)
+	for x := 0; x < width; x++ {
+		i := int((float64(x) - quiet) / module)
+		c := color.Gray{Y: 230}
+		if float64(x) >= quiet && i < len(bars) && bars[i] {
+			c = color.Gray{Y: 20}
+		}
+		for y := 0; y < height; y++ {
+			if rotate {
+				img.SetGray(width-1-x, y, c)
+				continue
+			}
+			img.SetGray(x, y, c)
+		}
+	}
+	return img
+}
@@ -0,0 +1,218 @@
+package barcode
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// modules is the number of modules of an EAN-13 barcode, runs is the number
+// of alternating bars and spaces from the start guard to the end guard.
+const (
+	modules = 95
+	runs    = 59
+	// maxDigitError is the largest distance, in modules, between the widths of
+	// a digit and the closest pattern
+	maxDigitError = 1.6
+	// minContrast is the smallest difference between the darkest and the
+	// lightest pixels of a scanned line
+	minContrast = 48
+)
+
+// patterns are the widths of the L-code digits in modules, the R-code widths
+// are the same and the G-code widths are reversed.
+var patterns = [10][4]float64{
+	{3, 2, 1, 1},
+	{2, 2, 2, 1},
+	{2, 1, 2, 2},
+	{1, 4, 1, 1},
+	{1, 1, 3, 2},
+	{1, 2, 3, 1},
+	{1, 1, 1, 4},
+	{1, 3, 1, 2},
+	{1, 2, 1, 3},
+	{3, 1, 1, 2},
+}
+
+// parities are the L/G parities of the left digits by the first digit.
+var parities = [10]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// Decode finds an EAN-13 or UPC-A barcode in the image and returns its EAN-13
+// form. The rows and the columns of the image are scanned in both directions,
+// the code read from the most lines wins.
+func Decode(img image.Image) (string, error) {
+	var (
+		bounds = img.Bounds()
+		votes  = make(map[string]int)
+		best   string
+	)
+	scan := func(line []uint8) {
+		for _, widths := range lineRuns(line) {
+			code, ok := decodeRuns(widths)
+			if !ok {
+				continue
+			}
+			votes[code]++
+			if votes[code] > votes[best] {
+				best = code
+			}
+		}
+	}
+	line := make([]uint8, bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			line[x-bounds.Min.X] = gray(img.At(x, y))
+		}
+		scan(line)
+	}
+	if best == "" {
+		line = make([]uint8, bounds.Dy())
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				line[y-bounds.Min.Y] = gray(img.At(x, y))
+			}
+			scan(line)
+		}
+	}
+	if best == "" {
+		return "", ErrNotFound
+	}
+	return best, nil
+}
+
+func gray(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// lineRuns binarizes the line by the middle of its contrast and returns the
+// widths of the runs starting with a bar, forward and backward. Nil is
+// returned for a line without contrast.
+func lineRuns(line []uint8) [][]float64 {
+	var lo, hi uint8 = 255, 0
+	for _, v := range line {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if int(hi)-int(lo) < minContrast {
+		return nil
+	}
+	threshold := (int(lo) + int(hi)) / 2
+	var (
+		widths []float64
+		dark   = false
+	)
+	for _, v := range line {
+		isDark := int(v) < threshold
+		if len(widths) == 0 {
+			if isDark {
+				widths, dark = append(widths, 1), true
+			}
+			continue
+		}
+		if isDark == dark {
+			widths[len(widths)-1]++
+			continue
+		}
+		widths, dark = append(widths, 1), isDark
+	}
+	if len(widths) > 0 && !dark {
+		widths = widths[:len(widths)-1]
+	}
+	reversed := make([]float64, len(widths))
+	for i, w := range widths {
+		reversed[len(widths)-1-i] = w
+	}
+	return [][]float64{widths, reversed}
+}
+
+// decodeRuns tries every bar of the runs as the start of the barcode.
+func decodeRuns(widths []float64) (string, bool) {
+	for i := 0; i+runs <= len(widths); i += 2 {
+		if code, ok := decodeAt(widths[i : i+runs]); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// decodeAt decodes the barcode which guards are the first and the last runs.
+func decodeAt(widths []float64) (string, bool) {
+	total := 0.0
+	for _, w := range widths {
+		total += w
+	}
+	module := total / modules
+	guards := append(append(widths[0:3:3], widths[27:32]...), widths[56:59]...)
+	for _, w := range guards {
+		if w < module*0.4 || w > module*1.8 {
+			return "", false
+		}
+	}
+	var (
+		digits [13]byte
+		parity = make([]byte, 6)
+	)
+	for i := 0; i < 6; i++ {
+		digit, even, ok := decodeDigit(widths[3+i*4:7+i*4], true)
+		if !ok {
+			return "", false
+		}
+		digits[i+1] = byte('0' + digit)
+		parity[i] = 'L'
+		if even {
+			parity[i] = 'G'
+		}
+	}
+	for i := 0; i < 6; i++ {
+		digit, _, ok := decodeDigit(widths[32+i*4:36+i*4], false)
+		if !ok {
+			return "", false
+		}
+		digits[i+7] = byte('0' + digit)
+	}
+	first := -1
+	for d, p := range parities {
+		if p == string(parity) {
+			first = d
+		}
+	}
+	if first < 0 {
+		return "", false
+	}
+	digits[0] = byte('0' + first)
+	code, err := Normalize(string(digits[:]))
+	return code, err == nil
+}
+
+// decodeDigit matches the widths of a digit against the patterns scaled to
+// seven modules. The left digits are matched against the G-codes too.
+func decodeDigit(widths []float64, left bool) (int, bool, bool) {
+	sum := widths[0] + widths[1] + widths[2] + widths[3]
+	var (
+		best      = -1
+		bestEven  bool
+		bestError = math.Inf(1)
+	)
+	for digit, pattern := range patterns {
+		odd, even := 0.0, 0.0
+		for i := range pattern {
+			w := widths[i] * 7 / sum
+			odd += math.Abs(w - pattern[i])
+			even += math.Abs(w - pattern[3-i])
+		}
+		if odd < bestError {
+			best, bestEven, bestError = digit, false, odd
+		}
+		if left && even < bestError {
+			best, bestEven, bestError = digit, true, even
+		}
+	}
+	return best, bestEven, bestError <= maxDigitError
+}
@@ -12,8 +12,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -67,8 +70,41 @@ type Config struct {
 	RefreshTokenExpiresIn time.Duration
 	//
 	AllowOrigins string
-	//
-	ShutdownShelfDetectorChan chan struct{}
+	// Secret key for signing the tokens of the user feeds, it is required
+	CalendarSecret []byte
+	// Directory of the fiscal receipts in the JSON format of the tax service,
+	// the receipts are not available if it is empty
+	ReceiptsDir string
+	// Settings of the OCR backend
+	OCR struct {
+		// Backend recognizing the images: tesseract or http
+		Backend string
+		// Language packs of the Tesseract clients, such as "eng+rus"
+		Languages []string
+		// URL of the external OCR service of the http backend
+		URL string
+		// Largest size of an image uploaded to the detector in bytes
+		UploadLimit int64
+		// Number of the OCR clients recognizing the images concurrently
+		Workers int
+		// Number of the requests waiting for a free OCR client
+		Queue int
+		// Maximum duration of a recognition including the waiting
+		Timeout time.Duration
+		// Preprocessing stages and page segmentation modes of the
+		// recognition passes, such as "orient,grayscale,stretch:3;binarize:11",
+		// the default passes are used if it is empty
+		Passes string
+	}
+	// Settings of the asynchronous detection jobs
+	Jobs struct {
+		// Number of the workers running the jobs concurrently
+		Workers int
+		// Number of the retries of a job failed by the OCR
+		Retries int
+		// Duration the results of the finished jobs are kept
+		TTL time.Duration
+	}
 }
 
 // New initializes a Config object with values from environment variables and
@@ -99,6 +135,10 @@ func New() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	calendarSecret := []byte(os.Getenv("CALENDAR_SECRET"))
+	if len(calendarSecret) == 0 {
+		return nil, errors.New("CALENDAR_SECRET is not set")
+	}
 	cfg := &Config{
 		API: struct {
 			Name string
@@ -143,7 +183,68 @@ func New() (*Config, error) {
 			strings.Split(os.Getenv("ALLOWED_ORIGINS"), ","),
 			", ",
 		),
-		ShutdownShelfDetectorChan: make(chan struct{}, 1),
+		CalendarSecret: calendarSecret,
+		ReceiptsDir:    os.Getenv("RECEIPTS_DIR"),
+	}
+	if cfg.OCR.Workers, err = envInt("OCR_WORKERS", runtime.NumCPU()); err != nil {
+		return nil, err
+	}
+	if cfg.OCR.Queue, err = envInt("OCR_QUEUE", 4*cfg.OCR.Workers); err != nil {
+		return nil, err
+	}
+	if cfg.OCR.Timeout, err = envDuration("OCR_TIMEOUT", 30*time.Second); err != nil {
+		return nil, err
+	}
+	cfg.OCR.Passes = os.Getenv("OCR_PASSES")
+	cfg.OCR.Backend = os.Getenv("OCR_BACKEND")
+	if languages := os.Getenv("OCR_LANGUAGES"); languages != "" {
+		cfg.OCR.Languages = strings.Split(languages, "+")
+	}
+	cfg.OCR.URL = os.Getenv("OCR_URL")
+	uploadLimit, err := envInt("OCR_UPLOAD_LIMIT", 512*1024)
+	if err != nil {
+		return nil, err
+	}
+	if uploadLimit <= 0 {
+		return nil, fmt.Errorf("invalid OCR_UPLOAD_LIMIT: %d is not positive", uploadLimit)
+	}
+	cfg.OCR.UploadLimit = int64(uploadLimit)
+	if cfg.Jobs.Workers, err = envInt("JOBS_WORKERS", cfg.OCR.Workers); err != nil {
+		return nil, err
+	}
+	if cfg.Jobs.Retries, err = envInt("JOBS_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.Jobs.TTL, err = envDuration("JOBS_TTL", 24*time.Hour); err != nil {
+		return nil, err
 	}
 	return cfg, nil
 }
+
+// envInt returns the integer value of the environment variable or def if it
+// is not set.
+func envInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// envDuration returns the duration value of the environment variable, such as
+// "30s", or def if it is not set.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
@@ -1,8 +1,9 @@
 package errors
 
 var (
-	ErrNotAdmin = New("user is not admin")
-	ErrNotOwner = New("user is not owner")
+	ErrNotAdmin     = New("user is not admin")
+	ErrNotOwner     = New("user is not owner")
+	ErrNotModerator = New("user is not moderator")
 )
 
 var (
@@ -0,0 +1,176 @@
+// Package ical provides an encoder for iCalendar (RFC 5545) feeds made of
+// all-day events with reminders.
+//
+// Example usage:
+//
+//	cal := ical.Calendar{ProdID: "-//Muerta//Shelf Lives//RU", Stamp: time.Now()}
+//	cal.Events = append(cal.Events, ical.Event{UID: "1@muerta", Summary: "Молоко", Date: end})
+//	if err := cal.Encode(w); err != nil {
+//	    return err
+//	}
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets is the maximum length of a content line excluding the
+	// line break, see RFC 5545 section 3.1.
+	maxLineOctets = 75
+	crlf          = "\r\n"
+	dateFormat    = "20060102"
+	stampFormat   = "20060102T150405Z"
+)
+
+// Calendar is a VCALENDAR object.
+type Calendar struct {
+	// ProdID identifies the product that created the calendar
+	ProdID string
+	// Name is a display name of the calendar for the clients
+	Name string
+	// Stamp is the DTSTAMP of every event of the calendar
+	Stamp  time.Time
+	Events []Event
+}
+
+// Event is an all-day VEVENT.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	// Date is the day of the event, only the date part is used
+	Date   time.Time
+	Alarms []Alarm
+}
+
+// Alarm is a VALARM that displays the description before the event starts.
+type Alarm struct {
+	Before      time.Duration
+	Description string
+}
+
+// Encode writes the calendar to the writer.
+func (c *Calendar) Encode(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", Escape(c.ProdID))
+	e.line("CALSCALE", "GREGORIAN")
+	e.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		e.line("X-WR-CALNAME", Escape(c.Name))
+	}
+	stamp := c.Stamp.UTC().Format(stampFormat)
+	for _, event := range c.Events {
+		e.line("BEGIN", "VEVENT")
+		e.line("UID", Escape(event.UID))
+		e.line("DTSTAMP", stamp)
+		e.line("DTSTART;VALUE=DATE", event.Date.Format(dateFormat))
+		e.line("DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format(dateFormat))
+		e.line("SUMMARY", Escape(event.Summary))
+		if event.Description != "" {
+			e.line("DESCRIPTION", Escape(event.Description))
+		}
+		e.line("TRANSP", "TRANSPARENT")
+		for _, alarm := range event.Alarms {
+			e.line("BEGIN", "VALARM")
+			e.line("ACTION", "DISPLAY")
+			e.line("TRIGGER", "-"+Duration(alarm.Before))
+			e.line("DESCRIPTION", Escape(alarm.Description))
+			e.line("END", "VALARM")
+		}
+		e.line("END", "VEVENT")
+	}
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return fmt.Errorf("failed to encode calendar: %w", e.err)
+	}
+	if err := e.w.Flush(); err != nil {
+		return fmt.Errorf("failed to encode calendar: %w", err)
+	}
+	return nil
+}
+
+// Escape escapes the TEXT value, see RFC 5545 section 3.3.11.
+func Escape(value string) string {
+	return textEscaper.Replace(value)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Duration formats the non-negative duration as a DURATION value with at
+// most second precision, e.g. P1D or PT12H30M.
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	d = d.Truncate(time.Second)
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if d > 0 || days == 0 {
+		b.WriteString("T")
+		hours, minutes, seconds := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 || d == 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
+
+// Fold splits the content line into lines of at most 75 octets without
+// breaking multi-byte characters, see RFC 5545 section 3.1. The returned
+// string is terminated with CRLF.
+func Fold(line string) string {
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString(crlf + " ")
+		line = line[cut:]
+		// the leading space of a continuation line counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString(crlf)
+	return b.String()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(Fold(name + ":" + value))
+}
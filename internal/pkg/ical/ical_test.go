@@ -0,0 +1,122 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_Encode(t *testing.T) {
+	stamp := time.Date(2023, time.May, 20, 10, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		calendar Calendar
+		golden   string
+	}{
+		{
+			name: "empty calendar",
+			calendar: Calendar{
+				ProdID: "-//Muerta//Shelf Lives//RU",
+				Stamp:  stamp,
+			},
+			golden: "empty.golden.ics",
+		},
+		{
+			name: "events with alarms",
+			calendar: Calendar{
+				ProdID: "-//Muerta//Shelf Lives//RU",
+				Name:   "Сроки годности",
+				Stamp:  stamp,
+				Events: []Event{
+					{
+						UID:         "shelf-life-1@muerta",
+						Summary:     "Молоко ультрапастеризованное 3,2%; бутылка",
+						Description: "Хранилище: Холодильник\nКоличество: 1 л",
+						Date:        time.Date(2023, time.May, 24, 0, 0, 0, 0, time.UTC),
+						Alarms: []Alarm{
+							{Before: 24 * time.Hour, Description: "Завтра истекает срок годности: Молоко"},
+							{Before: 3 * 24 * time.Hour, Description: "Через 3 дня истекает срок годности: Молоко"},
+						},
+					},
+					{
+						UID:     "shelf-life-2@muerta",
+						Summary: `Cheese \ "Gouda"`,
+						Date:    time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+			golden: "events.golden.ics",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, tc.calendar.Encode(&buf))
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				assert.Nil(t, os.WriteFile(path, buf.Bytes(), 0o644))
+			}
+			expected, err := os.ReadFile(path)
+			assert.Nil(t, err)
+			assert.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func Test_Fold(t *testing.T) {
+	testCases := []struct {
+		name string
+		line string
+	}{
+		{name: "short ascii", line: "SUMMARY:Milk"},
+		{name: "long ascii", line: "DESCRIPTION:" + strings.Repeat("a", 200)},
+		{name: "long cyrillic", line: "DESCRIPTION:" + strings.Repeat("ж", 100)},
+		{name: "mixed", line: "SUMMARY:" + strings.Repeat("яa€", 40)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			folded := Fold(tc.line)
+			assert.True(t, strings.HasSuffix(folded, "\r\n"))
+			lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+			for i, line := range lines {
+				assert.LessOrEqual(t, len(line), maxLineOctets)
+				assert.True(t, utf8.ValidString(line))
+				if i > 0 {
+					assert.True(t, strings.HasPrefix(line, " "))
+					lines[i] = line[1:]
+				}
+			}
+			assert.Equal(t, tc.line, strings.Join(lines, ""))
+		})
+	}
+}
+
+func Test_Escape(t *testing.T) {
+	assert.Equal(t, `a\\b\;c\,d\ne`, Escape("a\\b;c,d\ne"))
+}
+
+func Test_Duration(t *testing.T) {
+	testCases := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{duration: 0, expected: "PT0S"},
+		{duration: 24 * time.Hour, expected: "P1D"},
+		{duration: 3*24*time.Hour + 90*time.Minute, expected: "P3DT1H30M"},
+		{duration: 45 * time.Second, expected: "PT45S"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Duration(tc.duration))
+		})
+	}
+}
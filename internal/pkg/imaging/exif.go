@@ -0,0 +1,120 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// orientationTag is the EXIF tag of the orientation of the camera.
+const orientationTag = 0x0112
+
+// Orientation returns the EXIF orientation of the JPEG, PNG or WebP data
+// from 1 to 8, it is 1 if the data has no orientation.
+func Orientation(data []byte) int {
+	if o := exifOrientation(exif(data)); o >= 1 && o <= 8 {
+		return o
+	}
+	return 1
+}
+
+// exif returns the TIFF structure of the EXIF metadata of the data.
+func exif(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return jpegExif(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return chunk(data[8:], "eXIf", binary.BigEndian, 4)
+	case len(data) > 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return chunk(data[12:], "EXIF", binary.LittleEndian, 0)
+	}
+	return nil
+}
+
+// jpegExif returns the EXIF of the APP1 segment of the JPEG data.
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || marker >= 0xD0 && marker <= 0xD7 || marker == 0x01 {
+			i += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// the image data starts, the metadata are before it
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+// chunk returns the data of the chunk of the PNG or the RIFF container. The
+// PNG chunks are followed by the checksum.
+func chunk(data []byte, name string, order binary.ByteOrder, crc int) []byte {
+	for i := 0; i+8 <= len(data); {
+		var size int
+		var id string
+		if order == binary.BigEndian {
+			size, id = int(order.Uint32(data[i:])), string(data[i+4:i+8])
+		} else {
+			id, size = string(data[i:i+4]), int(order.Uint32(data[i+4:]))
+		}
+		start := i + 8
+		if size < 0 || start+size > len(data) {
+			return nil
+		}
+		if id == name {
+			result := data[start : start+size]
+			// some writers keep the JPEG header of the EXIF
+			return bytes.TrimPrefix(result, []byte("Exif\x00\x00"))
+		}
+		i = start + size + crc
+		if crc == 0 && size%2 == 1 {
+			// the RIFF chunks are padded to the even size
+			i++
+		}
+	}
+	return nil
+}
+
+// exifOrientation returns the orientation of the first IFD of the TIFF
+// structure, or 0 if there is none.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
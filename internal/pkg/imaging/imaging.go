@@ -0,0 +1,79 @@
+// Package imaging decodes the uploaded images. The JPEG, PNG and WebP formats
+// are supported.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for the data of any other format than
+	// JPEG, PNG or WebP.
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrCorrupt is returned when the image of a supported format can not be
+	// decoded.
+	ErrCorrupt = errors.New("corrupt image")
+	// ErrTooLarge is returned for the image of more pixels than MaxPixels.
+	ErrTooLarge = errors.New("image is too large")
+)
+
+// MaxPixels is the largest number of pixels of a decoded image, 25 MP. The
+// size of the compressed data does not bound the size of its bitmap, a
+// small PNG of a plain color decodes to hundreds of megabytes.
+const MaxPixels = 25_000_000
+
+// contentTypes are the supported formats by the sniffed content type.
+var contentTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// Format returns the format of the data sniffed by its signature.
+func Format(data []byte) (string, error) {
+	format, ok := contentTypes[http.DetectContentType(data)]
+	if !ok {
+		return "", ErrUnsupportedFormat
+	}
+	return format, nil
+}
+
+// Check checks the format of the data and the dimensions of the image in its
+// header, the image is not decoded.
+func Check(data []byte) error {
+	if _, err := Format(data); err != nil {
+		return err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return fmt.Errorf("%w: %dx%d", ErrCorrupt, cfg.Width, cfg.Height)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return fmt.Errorf("%w: %dx%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// Decode decodes the image of a supported format. The image of more pixels
+// than MaxPixels is rejected before it is decoded.
+func Decode(data []byte) (image.Image, error) {
+	if err := Check(data); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	return img, nil
+}
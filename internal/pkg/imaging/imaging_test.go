@@ -0,0 +1,84 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Decode(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3))))
+	webp, err := os.ReadFile("../../services/shelf-life-detector/test_data.webp")
+	assert.NoError(t, err)
+	testCases := []struct {
+		name   string
+		data   []byte
+		format string
+		err    error
+	}{
+		{name: "png", data: buf.Bytes(), format: "png"},
+		{name: "webp", data: webp, format: "webp"},
+		{name: "text", data: []byte("not an image"), err: ErrUnsupportedFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := Format(tc.data)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.format, format)
+			img, err := Decode(tc.data)
+			assert.NoError(t, err)
+			assert.NotNil(t, img)
+		})
+	}
+}
+
+// pngHeader returns the signature and the header chunk of a PNG of the
+// dimensions without the pixels, as a decompression bomb starts.
+func pngHeader(width, height uint32) []byte {
+	chunk := []byte("IHDR")
+	chunk = binary.BigEndian.AppendUint32(chunk, width)
+	chunk = binary.BigEndian.AppendUint32(chunk, height)
+	// 8 bit grayscale, no interlace
+	chunk = append(chunk, 8, 0, 0, 0, 0)
+	data := []byte("\x89PNG\r\n\x1a\n")
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+}
+
+func Test_Check(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3))))
+	testCases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "small", data: buf.Bytes()},
+		{name: "largest", data: pngHeader(5000, 5000)},
+		{name: "too large", data: pngHeader(16384, 16384), err: ErrTooLarge},
+		{name: "too wide", data: pngHeader(1<<31-1, 1), err: ErrTooLarge},
+		{name: "no header", data: []byte("\x89PNG\r\n\x1a\n"), err: ErrCorrupt},
+		{name: "text", data: []byte("not an image"), err: ErrUnsupportedFormat},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.ErrorIs(t, Check(tc.data), tc.err)
+			if tc.err != nil {
+				_, err := Decode(tc.data)
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
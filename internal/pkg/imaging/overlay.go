@@ -0,0 +1,56 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// Box is a rectangle outlined on the overlay.
+type Box struct {
+	Rect  image.Rectangle
+	Color color.Color
+}
+
+// Overlay draws the outlines of the boxes on the image rotated by its EXIF
+// orientation and encodes it as PNG. The boxes are in the coordinates of
+// the rotated image, as the Transform returns them.
+func Overlay(data []byte, boxes []Box) ([]byte, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	img = orient(img, Orientation(data))
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	// the outlines stay visible on the downscaled previews
+	width := (b.Dx() + b.Dy()) / 800
+	if width < 2 {
+		width = 2
+	}
+	for _, box := range boxes {
+		outline(dst, box.Rect, width, image.NewUniform(box.Color))
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("failed to encode overlay: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// outline draws the border of the width around the rectangle.
+func outline(dst draw.Image, r image.Rectangle, width int, src image.Image) {
+	r = r.Inset(-width)
+	for _, side := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+width),
+		image.Rect(r.Min.X, r.Max.Y-width, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+width, r.Max.Y),
+		image.Rect(r.Max.X-width, r.Min.Y, r.Max.X, r.Max.Y),
+	} {
+		draw.Draw(dst, side.Intersect(dst.Bounds()), src, image.Point{}, draw.Over)
+	}
+}
@@ -0,0 +1,349 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Stages are the stages of the preprocessing of an image before the OCR, in
+// the order they are applied.
+type Stages struct {
+	// Orient rotates the image by its EXIF orientation
+	Orient bool
+	// Grayscale converts the image to the shades of gray, the other stages
+	// convert it too
+	Grayscale bool
+	// Upscale enlarges the images shorter than MinHeight
+	Upscale bool
+	// Stretch stretches the contrast of the image onto the full range
+	Stretch bool
+	// Deskew rotates the lines of the text to the horizontal
+	Deskew bool
+	// Binarize converts the image to black and white by the local
+	// brightness, which copes with the shadows and the glare
+	Binarize bool
+}
+
+// stageNames are the stages by their names.
+var stageNames = map[string]func(*Stages){
+	"orient":    func(s *Stages) { s.Orient = true },
+	"grayscale": func(s *Stages) { s.Grayscale = true },
+	"upscale":   func(s *Stages) { s.Upscale = true },
+	"stretch":   func(s *Stages) { s.Stretch = true },
+	"deskew":    func(s *Stages) { s.Deskew = true },
+	"binarize":  func(s *Stages) { s.Binarize = true },
+}
+
+// ParseStages parses the comma separated names of the stages, such as
+// "orient,grayscale,stretch,binarize".
+func ParseStages(text string) (Stages, error) {
+	var result Stages
+	for _, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		set, ok := stageNames[name]
+		if !ok {
+			return Stages{}, fmt.Errorf("unknown preprocessing stage %q", name)
+		}
+		set(&result)
+	}
+	return result, nil
+}
+
+// The parameters of the stages.
+const (
+	// MinHeight is the height the shorter images are upscaled to, the text
+	// is recognized better when its letters are about 30 pixels high
+	MinHeight = 1200
+	// maxUpscale is the maximum factor of the upscale
+	maxUpscale = 4
+	// stretchClip is the share of the darkest and the lightest pixels which
+	// are clipped by the contrast stretch
+	stretchClip = 0.01
+	// binarizeWindow is the size of the neighbourhood of a pixel as the share
+	// of the width of the image
+	binarizeWindow = 8
+	// binarizeThreshold is how much darker than its neighbourhood a pixel
+	// must be to become black
+	binarizeThreshold = 0.15
+	// maxSkew and skewStep are the range and the step of the angles of the
+	// deskew in degrees
+	maxSkew  = 10.0
+	skewStep = 0.5
+	// skewSamples is the maximum number of the dark pixels measured by the
+	// deskew
+	skewSamples = 50000
+)
+
+// Preprocess decodes the JPEG, PNG or WebP data, applies the stages and
+// encodes the result as PNG. The transform maps the coordinates of the
+// result back to the original image.
+func Preprocess(data []byte, stages Stages) ([]byte, Transform, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, Transform{}, err
+	}
+	b := img.Bounds()
+	t := Transform{orientation: Orientation(data), width: b.Dx(), height: b.Dy()}
+	if stages.Orient {
+		img, t.orientation = orient(img, t.orientation), 1
+	}
+	if stages.Grayscale || stages.Stretch || stages.Deskew || stages.Binarize {
+		img = grayscale(img)
+	}
+	if stages.Upscale {
+		before := img.Bounds().Dy()
+		img = upscale(img, MinHeight)
+		t.scale = float64(img.Bounds().Dy()) / float64(before)
+	}
+	if g, ok := img.(*image.Gray); ok {
+		if stages.Stretch {
+			stretch(g)
+		}
+		if stages.Deskew {
+			t.angle = skewAngle(g)
+			t.center = [2]float64{float64(g.Rect.Dx()) / 2, float64(g.Rect.Dy()) / 2}
+			if t.angle != 0 {
+				g = rotate(g, t.angle)
+			}
+		}
+		if stages.Binarize {
+			binarize(g)
+		}
+		img = g
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, Transform{}, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), t, nil
+}
+
+// orient returns the image rotated and flipped by the EXIF orientation.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// the orientations from 5 to 8 swap the sides
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+func grayscale(img image.Image) *image.Gray {
+	if g, ok := img.(*image.Gray); ok {
+		return g
+	}
+	b := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// upscale enlarges the image shorter than the height.
+func upscale(img image.Image, height int) image.Image {
+	b := img.Bounds()
+	if b.Dy() == 0 || b.Dy() >= height {
+		return img
+	}
+	scale := math.Min(float64(height)/float64(b.Dy()), maxUpscale)
+	rect := image.Rect(0, 0, int(float64(b.Dx())*scale), int(float64(b.Dy())*scale))
+	var dst draw.Image
+	if _, ok := img.(*image.Gray); ok {
+		dst = image.NewGray(rect)
+	} else {
+		dst = image.NewNRGBA(rect)
+	}
+	draw.CatmullRom.Scale(dst, rect, img, b, draw.Src, nil)
+	return dst
+}
+
+// stretch maps the brightness of the pixels onto the full range, the
+// darkest and the lightest pixels are clipped.
+func stretch(g *image.Gray) {
+	var histogram [256]int
+	for _, v := range g.Pix {
+		histogram[v]++
+	}
+	clip := int(float64(len(g.Pix)) * stretchClip)
+	low, high := 0, 255
+	for n := 0; low < 255 && n+histogram[low] <= clip; low++ {
+		n += histogram[low]
+	}
+	for n := 0; high > 0 && n+histogram[high] <= clip; high-- {
+		n += histogram[high]
+	}
+	if high <= low {
+		return
+	}
+	var table [256]uint8
+	for v := range table {
+		switch {
+		case v <= low:
+			table[v] = 0
+		case v >= high:
+			table[v] = 255
+		default:
+			table[v] = uint8((v - low) * 255 / (high - low))
+		}
+	}
+	for i, v := range g.Pix {
+		g.Pix[i] = table[v]
+	}
+}
+
+// binarize makes the pixels darker than their neighbourhood black and the
+// others white, by the integral image of the brightness.
+func binarize(g *image.Gray) {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	if w == 0 || h == 0 {
+		return
+	}
+	integral := make([]int64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var row int64
+		for x := 0; x < w; x++ {
+			row += int64(g.Pix[y*g.Stride+x])
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + row
+		}
+	}
+	half := w / binarizeWindow / 2
+	if half < 1 {
+		half = 1
+	}
+	for y := 0; y < h; y++ {
+		y0, y1 := clamp(y-half, 0, h-1), clamp(y+half, 0, h-1)
+		for x := 0; x < w; x++ {
+			x0, x1 := clamp(x-half, 0, w-1), clamp(x+half, 0, w-1)
+			area := int64((x1 - x0 + 1) * (y1 - y0 + 1))
+			sum := integral[(y1+1)*(w+1)+x1+1] - integral[y0*(w+1)+x1+1] -
+				integral[(y1+1)*(w+1)+x0] + integral[y0*(w+1)+x0]
+			i := y*g.Stride + x
+			if float64(int64(g.Pix[i])*area) < float64(sum)*(1-binarizeThreshold) {
+				g.Pix[i] = 0
+			} else {
+				g.Pix[i] = 255
+			}
+		}
+	}
+}
+
+// skewAngle returns the angle in degrees with the largest variance of the
+// numbers of the dark pixels in the rows, the deskew rotates the image by it
+// to align the lines of the text.
+func skewAngle(g *image.Gray) float64 {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	var mean int64
+	for _, v := range g.Pix {
+		mean += int64(v)
+	}
+	if len(g.Pix) == 0 {
+		return 0
+	}
+	threshold := uint8(mean / int64(len(g.Pix)) * 2 / 3)
+	var points []image.Point
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if g.Pix[y*g.Stride+x] < threshold {
+				points = append(points, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(points) == 0 {
+		return 0
+	}
+	step := len(points)/skewSamples + 1
+	best, bestScore := 0.0, -1.0
+	rows := make([]int, 2*(w+h))
+	for angle := -maxSkew; angle <= maxSkew; angle += skewStep {
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		for i := range rows {
+			rows[i] = 0
+		}
+		for i := 0; i < len(points); i += step {
+			p := points[i]
+			row := int(float64(p.Y)*cos-float64(p.X)*sin) + w
+			if row >= 0 && row < len(rows) {
+				rows[row]++
+			}
+		}
+		var score float64
+		for _, n := range rows {
+			score += float64(n * n)
+		}
+		// the current angle is kept when the others are not better
+		if score > bestScore*1.001 || angle == 0 && score >= bestScore {
+			best, bestScore = angle, score
+		}
+	}
+	return best
+}
+
+// rotate rotates the image around its center by the angle in degrees, the
+// uncovered pixels are white.
+func rotate(g *image.Gray, angle float64) *image.Gray {
+	w, h := g.Rect.Dx(), g.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(w)/2, float64(h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// the source of the pixel rotated back
+			dx, dy := float64(x)-cx, float64(y)-cy
+			sx := int(math.Round(dx*cos - dy*sin + cx))
+			sy := int(math.Round(dx*sin + dy*cos + cy))
+			if sx < 0 || sy < 0 || sx >= w || sy >= h {
+				dst.SetGray(x, y, color.Gray{Y: 255})
+				continue
+			}
+			dst.Pix[y*dst.Stride+x] = g.Pix[sy*g.Stride+sx]
+		}
+	}
+	return dst
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
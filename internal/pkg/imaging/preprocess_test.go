@@ -0,0 +1,181 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withOrientation returns the JPEG of a w×h image with the EXIF orientation.
+func withOrientation(t *testing.T, w, h int, orientation uint16) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil))
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, orientationTag)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	data := append([]byte{0xFF, 0xD8}, app1...)
+	data = append(data, segment...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func decodePNG(t *testing.T, data []byte) image.Image {
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	return img
+}
+
+func Test_Orientation(t *testing.T) {
+	for _, o := range []uint16{1, 3, 6, 8} {
+		assert.Equal(t, int(o), Orientation(withOrientation(t, 4, 2, o)))
+	}
+	assert.Equal(t, 1, Orientation([]byte("not an image")))
+
+	data, _, err := Preprocess(withOrientation(t, 40, 20, 6), Stages{Orient: true})
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 40), decodePNG(t, data).Bounds())
+}
+
+func Test_TransformRect(t *testing.T) {
+	// the image is 40×20 before and 20×40 after the orientation
+	data := withOrientation(t, 40, 20, 6)
+	testCases := []struct {
+		name     string
+		stages   Stages
+		rect     image.Rectangle
+		expected image.Rectangle
+	}{
+		{
+			name:     "oriented and upscaled",
+			stages:   Stages{Orient: true, Upscale: true},
+			rect:     image.Rect(0, 0, 80, 160),
+			expected: image.Rect(0, 0, 20, 40),
+		},
+		{
+			name:     "upscaled only",
+			stages:   Stages{Upscale: true},
+			rect:     image.Rect(0, 0, 160, 80),
+			expected: image.Rect(0, 0, 20, 40),
+		},
+		{
+			name:     "corner of upscaled only",
+			stages:   Stages{Upscale: true},
+			rect:     image.Rect(0, 0, 40, 20),
+			expected: image.Rect(15, 0, 20, 10),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, transform, err := Preprocess(data, tc.stages)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, transform.Rect(tc.rect))
+		})
+	}
+	assert.Equal(t, image.Rect(1, 2, 3, 4), Transform{}.Rect(image.Rect(1, 2, 3, 4)))
+}
+
+func Test_ParseStages(t *testing.T) {
+	stages, err := ParseStages("orient, grayscale,binarize")
+	assert.NoError(t, err)
+	assert.Equal(t, Stages{Orient: true, Grayscale: true, Binarize: true}, stages)
+	_, err = ParseStages("orient,sharpen")
+	assert.Error(t, err)
+}
+
+func Test_Preprocess(t *testing.T) {
+	webp, err := os.ReadFile("../../services/shelf-life-detector/test_data.webp")
+	assert.NoError(t, err)
+	testCases := []struct {
+		name   string
+		stages Stages
+		bounds image.Rectangle
+		binary bool
+	}{
+		{name: "none", bounds: image.Rect(0, 0, 900, 618)},
+		{name: "grayscale", stages: Stages{Grayscale: true, Stretch: true}, bounds: image.Rect(0, 0, 900, 618)},
+		{
+			name:   "upscale",
+			stages: Stages{Grayscale: true, Upscale: true},
+			bounds: image.Rect(0, 0, 900*MinHeight/618, MinHeight),
+		},
+		{
+			name:   "binarize",
+			stages: Stages{Grayscale: true, Deskew: true, Binarize: true},
+			bounds: image.Rect(0, 0, 900, 618),
+			binary: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, _, err := Preprocess(webp, tc.stages)
+			assert.NoError(t, err)
+			img := decodePNG(t, data)
+			assert.InDelta(t, tc.bounds.Dx(), img.Bounds().Dx(), 1)
+			assert.Equal(t, tc.bounds.Dy(), img.Bounds().Dy())
+			if tc.binary {
+				g, ok := img.(*image.Gray)
+				assert.True(t, ok)
+				for _, v := range g.Pix {
+					if v != 0 && v != 255 {
+						t.Fatalf("pixel %d is not binary", v)
+					}
+				}
+			}
+		})
+	}
+	_, _, err = Preprocess([]byte("not an image"), Stages{})
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func Test_Stretch(t *testing.T) {
+	g := image.NewGray(image.Rect(0, 0, 100, 1))
+	for x := 0; x < 100; x++ {
+		g.SetGray(x, 0, color.Gray{Y: uint8(100 + x/2)})
+	}
+	stretch(g)
+	assert.Equal(t, uint8(0), g.Pix[1])
+	assert.Equal(t, uint8(255), g.Pix[98])
+}
+
+func Test_SkewAngle(t *testing.T) {
+	testCases := []struct {
+		name  string
+		angle float64
+	}{
+		{name: "straight", angle: 0},
+		{name: "clockwise", angle: 4},
+		{name: "counterclockwise", angle: -3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// the lines of the text are the dark stripes
+			g := image.NewGray(image.Rect(0, 0, 400, 300))
+			for i := range g.Pix {
+				g.Pix[i] = 255
+			}
+			for y := 40; y < 260; y += 30 {
+				for x := 50; x < 350; x++ {
+					for dy := 0; dy < 8; dy++ {
+						g.SetGray(x, y+dy, color.Gray{})
+					}
+				}
+			}
+			skewed := rotate(g, -tc.angle)
+			assert.LessOrEqual(t, math.Abs(skewAngle(skewed)-tc.angle), skewStep)
+		})
+	}
+}
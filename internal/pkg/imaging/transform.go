@@ -0,0 +1,80 @@
+package imaging
+
+import (
+	"image"
+	"math"
+)
+
+// Transform maps the coordinates of the preprocessed image back to the
+// original image rotated by its EXIF orientation, as the image is shown to
+// the user. The zero Transform keeps the coordinates.
+type Transform struct {
+	// orientation is applied to the coordinates when the image is not
+	// oriented by the preprocessing
+	orientation int
+	// width and height are the size of the image before the orientation
+	width, height int
+	// scale is the factor of the upscale
+	scale float64
+	// angle is the angle of the deskew around the center
+	angle  float64
+	center [2]float64
+}
+
+// Rect returns the rectangle of the original image which bounds the
+// rectangle of the preprocessed image.
+func (t Transform) Rect(r image.Rectangle) image.Rectangle {
+	corners := [4][2]float64{
+		{float64(r.Min.X), float64(r.Min.Y)},
+		{float64(r.Max.X), float64(r.Min.Y)},
+		{float64(r.Min.X), float64(r.Max.Y)},
+		{float64(r.Max.X), float64(r.Max.Y)},
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range corners {
+		x, y := t.point(c[0], c[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// point maps the point in the reverse order of the stages.
+func (t Transform) point(x, y float64) (float64, float64) {
+	if t.angle != 0 {
+		// the same rotation as the one of the pixels by the deskew
+		sin, cos := math.Sincos(t.angle * math.Pi / 180)
+		dx, dy := x-t.center[0], y-t.center[1]
+		x, y = dx*cos-dy*sin+t.center[0], dx*sin+dy*cos+t.center[1]
+	}
+	if t.scale > 0 {
+		x, y = x/t.scale, y/t.scale
+	}
+	if t.orientation > 1 {
+		x, y = orientPoint(t.orientation, float64(t.width), float64(t.height), x, y)
+	}
+	return x, y
+}
+
+// orientPoint returns the point of the image of the size w×h in the image
+// rotated and flipped by the EXIF orientation.
+func orientPoint(orientation int, w, h, x, y float64) (float64, float64) {
+	switch orientation {
+	case 2:
+		return w - x, y
+	case 3:
+		return w - x, h - y
+	case 4:
+		return x, h - y
+	case 5:
+		return y, x
+	case 6:
+		return h - y, x
+	case 7:
+		return h - y, w - x
+	case 8:
+		return y, w - x
+	}
+	return x, y
+}
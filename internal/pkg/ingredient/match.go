@@ -0,0 +1,67 @@
+package ingredient
+
+import (
+	"strings"
+	"unicode"
+)
+
+// endings are the inflectional endings of the Russian and English nouns and
+// adjectives, the longest first.
+var endings = []string{
+	"иями", "ями", "ами", "ого", "его", "ому", "ему", "ими", "ыми",
+	"ов", "ев", "ей", "ой", "ий", "ый", "ая", "яя", "ое", "ее", "ые", "ие", "ую", "юю",
+	"ам", "ям", "ах", "ях", "ом", "ем",
+	"а", "я", "ы", "и", "у", "ю", "е", "о", "ь", "й",
+	"es", "s", "e",
+}
+
+// Stems returns the stems of the words of the text. The stem is the
+// lowercase word without the inflectional ending, so "муки" and "Мука" have
+// the same stem.
+func Stems(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	stems := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ReplaceAll(word, "ё", "е")
+		for _, ending := range endings {
+			stem := strings.TrimSuffix(word, ending)
+			if stem != word && len([]rune(stem)) >= 3 {
+				word = stem
+				break
+			}
+		}
+		stems = append(stems, word)
+	}
+	return stems
+}
+
+// Match returns the index of the candidate name which matches the name of
+// an ingredient, or -1. A candidate matches if all of its stems are in the
+// name, the candidate with the most stems wins, so "оливковое масло" is
+// preferred to "масло".
+func Match(name string, candidates []string) int {
+	words := make(map[string]bool)
+	for _, stem := range Stems(name) {
+		words[stem] = true
+	}
+	best, score := -1, 0
+	for i, candidate := range candidates {
+		stems := Stems(candidate)
+		if len(stems) <= score {
+			continue
+		}
+		matched := true
+		for _, stem := range stems {
+			if !words[stem] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			best, score = i, len(stems)
+		}
+	}
+	return best
+}
@@ -0,0 +1,178 @@
+// Package ingredient parses the free text ingredient lines of recipes in
+// Russian and English, such as "200 г муки", "Молоко — 0,5 л" or
+// "1 1/2 cups sugar".
+package ingredient
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Line is a parsed ingredient line.
+type Line struct {
+	// Text is the line as written
+	Text string
+	// Quantity is zero if the line has no quantity, the upper bound is
+	// taken for the ranges such as "2-3"
+	Quantity float64
+	// Unit is the canonical name of the unit, empty if the line has no unit
+	Unit string
+	// Name is the ingredient without the quantity, the unit and the notes
+	Name string
+}
+
+// units maps the spellings of the units to the canonical names, the names
+// of the measures in the database.
+var units = map[string][]string{
+	"г": {
+		"г", "гр", "грамм", "грамма", "граммов", "g", "gr", "gram", "grams", "gramme", "grammes",
+	},
+	"кг": {"кг", "килограмм", "килограмма", "килограммов", "kg", "kilogram", "kilograms"},
+	"мг": {"мг", "mg", "milligram", "milligrams"},
+	"мл": {"мл", "миллилитр", "миллилитра", "миллилитров", "ml", "milliliter", "milliliters", "millilitre", "millilitres"},
+	"л":  {"л", "литр", "литра", "литров", "l", "liter", "liters", "litre", "litres"},
+	"шт": {"шт", "штука", "штуки", "штук", "pc", "pcs", "piece", "pieces"},
+	"ст. л.": {
+		"ст. л.", "ст.л.", "ст л", "ст. ложка", "ст. ложки", "столовая ложка", "столовые ложки",
+		"столовых ложки", "столовых ложек", "tbsp", "tbs", "tablespoon", "tablespoons",
+	},
+	"ч. л.": {
+		"ч. л.", "ч.л.", "ч л", "ч. ложка", "ч. ложки", "чайная ложка", "чайные ложки",
+		"чайных ложки", "чайных ложек", "tsp", "teaspoon", "teaspoons",
+	},
+	"стакан":  {"стакан", "стакана", "стаканов", "cup", "cups"},
+	"щепотка": {"щепотка", "щепотки", "щепоток", "pinch", "pinches"},
+	"зубчик":  {"зубчик", "зубчика", "зубчиков", "clove", "cloves"},
+	"пучок":   {"пучок", "пучка", "пучков", "bunch", "bunches"},
+	"унция":   {"oz", "ounce", "ounces", "унция", "унции", "унций"},
+	"фунт":    {"lb", "lbs", "pound", "pounds", "фунт", "фунта", "фунтов"},
+}
+
+// aliases are the spellings of the units, the longest first, so "ст. л."
+// is matched before "ст".
+var aliases = func() []alias {
+	result := make([]alias, 0)
+	for unit, spellings := range units {
+		for _, spelling := range spellings {
+			result = append(result, alias{spelling: spelling, unit: unit})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := []rune(result[i].spelling), []rune(result[j].spelling)
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return result[i].spelling < result[j].spelling
+	})
+	return result
+}()
+
+type alias struct {
+	spelling string
+	unit     string
+}
+
+var (
+	fractions = strings.NewReplacer(
+		"½", " 1/2", "⅓", " 1/3", "⅔", " 2/3", "¼", " 1/4", "¾", " 3/4", "⅛", " 1/8",
+		"–", "-", "—", "-", "−", "-",
+	)
+	// quantityRx matches "2", "1,5", "1 1/2", "1/2" and the ranges "2-3"
+	quantityRx = regexp.MustCompile(
+		`^(?:(\d+(?:[.,]\d+)?)\s*-\s*)?(\d+(?:[.,]\d+)?)(?:\s+(\d+)/(\d+))?(?:/(\d+))?`,
+	)
+	parensRx = regexp.MustCompile(`\([^)]*\)`)
+	notesRx  = regexp.MustCompile(`,.*$`)
+	bulletRx = regexp.MustCompile(`^[\s\-*•·]+`)
+	spaceRx  = regexp.MustCompile(`\s+`)
+)
+
+// Parse parses the ingredient line. The quantity and the unit are looked for
+// at the start and at the end of the line, such as "Мука — 200 г",
+// "Сахар: 1 ст. л." or "Соль 1 щепотка". The notes in parentheses or after a
+// comma are dropped from the name.
+func Parse(text string) Line {
+	line := Line{Text: text}
+	s := strings.TrimSpace(fractions.Replace(text))
+	s = bulletRx.ReplaceAllString(s, "")
+	s = strings.TrimSpace(spaceRx.ReplaceAllString(parensRx.ReplaceAllString(s, ""), " "))
+	line.Name = s
+	if quantity, rest, ok := parseQuantity(s); ok {
+		line.Quantity = quantity
+		line.Unit, line.Name = parseUnit(rest)
+	} else if i, quantity, unit, ok := trailing(s); ok {
+		line.Quantity, line.Unit = quantity, unit
+		line.Name = strings.TrimRight(s[:i], " -:")
+	}
+	line.Name = strings.TrimSpace(notesRx.ReplaceAllString(line.Name, ""))
+	line.Name = strings.TrimSpace(strings.TrimPrefix(line.Name, "of "))
+	return line
+}
+
+// trailing finds the quantity and the unit which end the line. It returns
+// the index of the quantity.
+func trailing(s string) (int, float64, string, bool) {
+	for i, r := range s {
+		if i == 0 || !unicode.IsDigit(r) || !strings.ContainsAny(s[i-1:i], " -:") {
+			continue
+		}
+		quantity, rest, ok := parseQuantity(s[i:])
+		if !ok {
+			continue
+		}
+		unit, rest := parseUnit(rest)
+		if strings.Trim(rest, " .") == "" {
+			return i, quantity, unit, true
+		}
+	}
+	return 0, 0, "", false
+}
+
+func parseQuantity(s string) (float64, string, bool) {
+	match := quantityRx.FindStringSubmatch(s)
+	if match == nil {
+		return 0, s, false
+	}
+	quantity := number(match[2])
+	switch {
+	case match[3] != "" && match[4] != "":
+		if d := number(match[4]); d != 0 {
+			quantity += number(match[3]) / d
+		}
+	case match[5] != "":
+		if d := number(match[5]); d != 0 {
+			quantity /= d
+		}
+	}
+	return quantity, strings.TrimSpace(s[len(match[0]):]), quantity > 0
+}
+
+func number(s string) float64 {
+	n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseUnit returns the canonical unit at the start of the text and the rest
+// of the text.
+func parseUnit(s string) (string, string) {
+	lower := strings.ToLower(s)
+	for _, alias := range aliases {
+		if !strings.HasPrefix(lower, alias.spelling) {
+			continue
+		}
+		rest := s[len(alias.spelling):]
+		rest = strings.TrimPrefix(rest, ".")
+		if r := []rune(rest); len(r) > 0 && (unicode.IsLetter(r[0]) || unicode.IsDigit(r[0])) {
+			// a part of a word, such as "г" of "горох"
+			continue
+		}
+		return alias.unit, strings.TrimSpace(rest)
+	}
+	return "", s
+}
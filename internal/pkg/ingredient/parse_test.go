@@ -0,0 +1,64 @@
+package ingredient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	testCases := []struct {
+		text     string
+		quantity float64
+		unit     string
+		name     string
+	}{
+		{text: "200 г муки", quantity: 200, unit: "г", name: "муки"},
+		{text: "200г муки высшего сорта", quantity: 200, unit: "г", name: "муки высшего сорта"},
+		{text: "1,5 л молока", quantity: 1.5, unit: "л", name: "молока"},
+		{text: "2 яйца", quantity: 2, name: "яйца"},
+		{text: "1 лук", quantity: 1, name: "лук"},
+		{text: "2 ст. л. сахара", quantity: 2, unit: "ст. л.", name: "сахара"},
+		{text: "½ ч.л. соли", quantity: 0.5, unit: "ч. л.", name: "соли"},
+		{text: "2-3 зубчика чеснока", quantity: 3, unit: "зубчик", name: "чеснока"},
+		{text: "Молоко — 0,5 л", quantity: 0.5, unit: "л", name: "Молоко"},
+		{text: "Яйца: 2 шт.", quantity: 2, unit: "шт", name: "Яйца"},
+		{text: "Сливочное масло 50 г (комнатной температуры)", quantity: 50, unit: "г", name: "Сливочное масло"},
+		{text: "- Соль по вкусу", name: "Соль по вкусу"},
+		{text: "1 1/2 cups of sugar", quantity: 1.5, unit: "стакан", name: "sugar"},
+		{text: "1/2 tsp salt", quantity: 0.5, unit: "ч. л.", name: "salt"},
+		{text: "2 large eggs, beaten", quantity: 2, name: "large eggs"},
+		{text: "500 ml milk", quantity: 500, unit: "мл", name: "milk"},
+		{text: "Garlic - 2 cloves", quantity: 2, unit: "зубчик", name: "Garlic"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			actual := Parse(tc.text)
+			assert.Equal(t, tc.text, actual.Text)
+			assert.InDelta(t, tc.quantity, actual.Quantity, 1e-9)
+			assert.Equal(t, tc.unit, actual.Unit)
+			assert.Equal(t, tc.name, actual.Name)
+		})
+	}
+}
+
+func Test_Match(t *testing.T) {
+	candidates := []string{"Мука", "Масло", "Оливковое масло", "Яйцо", "Egg", "Tomato"}
+	testCases := []struct {
+		name     string
+		expected int
+	}{
+		{name: "муки высшего сорта", expected: 0},
+		{name: "оливкового масла", expected: 2},
+		{name: "сливочное масло", expected: 1},
+		{name: "яйца", expected: 3},
+		{name: "large eggs", expected: 4},
+		{name: "tomatoes", expected: 5},
+		{name: "сахара", expected: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Match(tc.name, candidates))
+		})
+	}
+}
@@ -0,0 +1,441 @@
+// Package label recognizes the dates printed on the labels of the products
+// in the text read by the OCR. The text is split into the tokens, the
+// letters and the digits confused by the recognition are normalized, and the
+// dates are matched by the grammar of the known formats.
+package label
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Date is a date found in the text.
+type Date struct {
+	Time time.Time
+	// MonthOnly reports whether the date has no day, such as "09/24", the
+	// time is the last day of the month then
+	MonthOnly bool
+	// Start and End are the byte offsets of the date in the text
+	Start, End int
+	// Source is the index of the label passed to Merge the date is kept
+	// from, the offsets are of the text of that label
+	Source int
+	// Role is the meaning of the date found by the words near it
+	Role Role
+	// Confidence is the score of the date and its role from 0 to 1
+	Confidence float64
+	// Computed reports whether the date is not printed but computed by the
+	// production date and the shelf life
+	Computed bool
+	// Format is the printed format of the date
+	Format Format
+	// first and last are the indexes of the tokens of the date
+	first, last int
+}
+
+// Format is the printed format of a date, the formats are named by the
+// rules of the grammar.
+type Format string
+
+const (
+	FormatYearMonthDay Format = "year-month-day"
+	FormatDayMonthYear Format = "day-month-year"
+	FormatDayNameYear  Format = "day-name-year"
+	FormatNameDayYear  Format = "name-day-year"
+	FormatNameYear     Format = "name-year"
+	FormatMonthYear    Format = "month-year"
+	// FormatComputed is the format of the dates computed by the shelf life
+	FormatComputed Format = "computed"
+)
+
+// months are the English and the Russian names of the months, the Russian
+// names are in the nominative and the genitive cases.
+var months = map[time.Month][]string{
+	time.January:   {"january", "январь", "января"},
+	time.February:  {"february", "февраль", "февраля"},
+	time.March:     {"march", "март", "марта"},
+	time.April:     {"april", "апрель", "апреля"},
+	time.May:       {"may", "май", "мая"},
+	time.June:      {"june", "июнь", "июня"},
+	time.July:      {"july", "июль", "июля"},
+	time.August:    {"august", "август", "августа"},
+	time.September: {"september", "sept", "сентябрь", "сентября"},
+	time.October:   {"october", "октябрь", "октября"},
+	time.November:  {"november", "ноябрь", "ноября"},
+	time.December:  {"december", "декабрь", "декабря"},
+}
+
+// toLatin and toCyrillic replace the letters of the same shape.
+var (
+	toLatin = strings.NewReplacer(
+		"а", "a", "в", "b", "е", "e", "к", "k", "м", "m", "н", "h", "о", "o",
+		"р", "p", "с", "c", "т", "t", "у", "y", "х", "x",
+	)
+	toCyrillic = strings.NewReplacer(
+		"a", "а", "b", "в", "e", "е", "k", "к", "m", "м", "h", "н", "o", "о",
+		"p", "р", "c", "с", "t", "т", "y", "у", "x", "х",
+	)
+)
+
+// month returns the month of the word, which is a name or an abbreviation
+// of at least three letters.
+func month(word string) (time.Month, bool) {
+	word = strings.ToLower(word)
+	if utf8.RuneCountInString(word) < 3 {
+		return 0, false
+	}
+	for _, w := range []string{word, toLatin.Replace(word), toCyrillic.Replace(word)} {
+		for m, names := range months {
+			for _, name := range names {
+				if strings.HasPrefix(name, w) {
+					return m, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func isMonth(word string) bool {
+	_, ok := month(word)
+	return ok
+}
+
+// Find returns the dates found in the text in the order of the text with
+// their roles. The impossible dates, such as "31.02.22", are skipped.
+func Find(text string) []Date {
+	p := &parser{tokens: tokenize(text)}
+	return p.dates()
+}
+
+// dates returns the dates of the tokens with their roles.
+func (p *parser) dates() []Date {
+	var result []Date
+	for i := 0; i < len(p.tokens); {
+		date, next, ok := p.date(i)
+		if !ok {
+			i++
+			continue
+		}
+		result = append(result, date)
+		i = next
+	}
+	p.roles(result)
+	for i := range result {
+		result[i].Confidence = confidence(result[i].Confidence)
+	}
+	return result
+}
+
+// confidence returns the score limited from 0 to 1 and rounded to
+// hundredths.
+func confidence(score float64) float64 {
+	return math.Round(math.Max(0, math.Min(1, score))*100) / 100
+}
+
+// parser matches the grammar of the dates on the tokens.
+type parser struct {
+	tokens []token
+}
+
+// date matches the formats at the token i and returns the index of the token
+// after the date.
+func (p *parser) date(i int) (Date, int, bool) {
+	rules := []struct {
+		format Format
+		match  func(int) (match, bool)
+	}{
+		{FormatYearMonthDay, p.yearMonthDay},
+		{FormatDayMonthYear, p.dayMonthYear},
+		{FormatDayNameYear, p.dayNameYear},
+		{FormatNameDayYear, p.nameDayYear},
+		{FormatNameYear, p.nameYear},
+		{FormatMonthYear, p.monthYear},
+	}
+	for _, rule := range rules {
+		m, ok := rule.match(i)
+		if !ok {
+			continue
+		}
+		date := Date{
+			Start:      p.tokens[i].start,
+			End:        p.tokens[m.next-1].end,
+			Confidence: m.score,
+			Format:     rule.format,
+			first:      i,
+			last:       m.next - 1,
+		}
+		for _, t := range p.tokens[i:m.next] {
+			if t.fixed {
+				date.Confidence -= fixedPenalty
+				break
+			}
+		}
+		if m.day == 0 {
+			date.MonthOnly = true
+			date.Time = time.Date(m.year, time.Month(m.month)+1, 0, 0, 0, 0, 0, time.UTC)
+			return date, m.next, true
+		}
+		t := time.Date(m.year, time.Month(m.month), m.day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != m.day || t.Month() != time.Month(m.month) {
+			continue
+		}
+		date.Time = t
+		return date, m.next, true
+	}
+	return Date{}, i, false
+}
+
+// match is a date matched by a rule. The score is the confidence of the
+// format, the day is zero for the dates without a day.
+type match struct {
+	year, month, day int
+	next             int
+	score            float64
+}
+
+// The confidences of the formats. The dates of the unusual formats are more
+// often the numbers of the other kinds, such as the prices.
+const (
+	fullScore      = 0.6
+	shortYearScore = 0.55
+	spacedScore    = 0.4
+	swappedScore   = 0.45
+	monthOnlyScore = 0.35
+	fixedPenalty   = 0.15
+)
+
+// yearMonthDay matches "2022-09-24".
+func (p *parser) yearMonthDay(i int) (match, bool) {
+	year, ok := p.number(i, 4, 4)
+	if !ok || !validYear(year) {
+		return match{}, false
+	}
+	j, sep, ok := p.gap(i+1, "-./")
+	if !ok || sep == " " {
+		return match{}, false
+	}
+	month, ok := p.number(j, 1, 2)
+	if !ok {
+		return match{}, false
+	}
+	k, sep2, ok := p.gap(j+1, "-./")
+	if !ok || sep2 != sep {
+		return match{}, false
+	}
+	day, ok := p.number(k, 1, 2)
+	if !ok || !validMonth(month) || p.chained(k+1) {
+		return match{}, false
+	}
+	return match{year: year, month: month, day: day, next: k + 1, score: fullScore}, true
+}
+
+// dayMonthYear matches "24.09.22", "24/09/2022", "24 09 2022" and the US
+// order "09/24/2022" when the day can not be the month.
+func (p *parser) dayMonthYear(i int) (match, bool) {
+	day, ok := p.number(i, 1, 2)
+	if !ok || p.chainedBefore(i) {
+		return match{}, false
+	}
+	j, sep, ok := p.gap(i+1, "-./,")
+	if !ok {
+		return match{}, false
+	}
+	month, ok := p.number(j, 1, 2)
+	if !ok {
+		return match{}, false
+	}
+	k, sep2, ok := p.gap(j+1, "-./,")
+	if !ok || (sep == " ") != (sep2 == " ") {
+		return match{}, false
+	}
+	year, next, ok := p.year(k)
+	if !ok || p.chained(next) {
+		return match{}, false
+	}
+	score := p.yearScore(k, next)
+	if sep == " " {
+		score = spacedScore
+	}
+	if month > 12 && day <= 12 {
+		day, month = month, day
+		score = swappedScore
+	}
+	if !validMonth(month) {
+		return match{}, false
+	}
+	return match{year: year, month: month, day: day, next: next, score: score}, true
+}
+
+// dayNameYear matches "15 сент 2022", "24 сентября 2022 г." and "15SEP22".
+func (p *parser) dayNameYear(i int) (match, bool) {
+	day, ok := p.number(i, 1, 2)
+	if !ok {
+		return match{}, false
+	}
+	j := p.skip(i+1, "-./")
+	month, ok := p.month(j)
+	if !ok {
+		return match{}, false
+	}
+	k := p.skip(j+1, "-./,")
+	year, next, ok := p.year(k)
+	if !ok {
+		return match{}, false
+	}
+	return match{year: year, month: int(month), day: day, next: next, score: p.yearScore(k, next)}, true
+}
+
+// nameDayYear matches "SEP 24, 2022" and "September 24 2022".
+func (p *parser) nameDayYear(i int) (match, bool) {
+	month, ok := p.month(i)
+	if !ok {
+		return match{}, false
+	}
+	j := p.skip(i+1, ".")
+	day, ok := p.number(j, 1, 2)
+	if !ok {
+		return match{}, false
+	}
+	k := p.skip(j+1, ",")
+	year, ok := p.number(k, 4, 4)
+	if !ok || !validYear(year) {
+		return match{}, false
+	}
+	return match{year: year, month: int(month), day: day, next: k + 1, score: fullScore}, true
+}
+
+// nameYear matches "SEP 24", "сентябрь 2022" and "OCT/23".
+func (p *parser) nameYear(i int) (match, bool) {
+	month, ok := p.month(i)
+	if !ok {
+		return match{}, false
+	}
+	year, next, ok := p.year(p.skip(i+1, "-./,"))
+	if !ok || p.chained(next) {
+		return match{}, false
+	}
+	return match{year: year, month: int(month), next: next, score: monthOnlyScore}, true
+}
+
+// monthYear matches "09/24", "09.2024" and "9-24".
+func (p *parser) monthYear(i int) (match, bool) {
+	month, ok := p.number(i, 1, 2)
+	if !ok || !validMonth(month) || p.chainedBefore(i) {
+		return match{}, false
+	}
+	j, sep, ok := p.gap(i+1, "-./")
+	if !ok || sep == " " {
+		return match{}, false
+	}
+	year, next, ok := p.year(j)
+	if !ok || p.chained(next) {
+		return match{}, false
+	}
+	return match{year: year, month: month, next: next, score: monthOnlyScore}, true
+}
+
+// number returns the value of the number token i of lo to hi digits.
+func (p *parser) number(i, lo, hi int) (int, bool) {
+	if i >= len(p.tokens) || p.tokens[i].kind != number {
+		return 0, false
+	}
+	text := p.tokens[i].text
+	if len(text) < lo || len(text) > hi {
+		return 0, false
+	}
+	n, err := strconv.Atoi(text)
+	return n, err == nil
+}
+
+// month returns the month of the word token i.
+func (p *parser) month(i int) (time.Month, bool) {
+	if i >= len(p.tokens) || p.tokens[i].kind != word {
+		return 0, false
+	}
+	return month(p.tokens[i].text)
+}
+
+// year returns the year of two or four digits at the token i, a four digits
+// year may be split by a space, such as "20 22".
+func (p *parser) year(i int) (int, int, bool) {
+	if year, ok := p.number(i, 4, 4); ok {
+		return year, i + 1, validYear(year)
+	}
+	year, ok := p.number(i, 2, 2)
+	if !ok {
+		return 0, i, false
+	}
+	if rest, ok := p.number(i+1, 2, 2); ok && (year == 19 || year == 20) && p.tokens[i+1].space &&
+		!p.chained(i+2) && !p.is(i+2, ":") {
+		return year*100 + rest, i + 2, true
+	}
+	return 2000 + year, i + 1, true
+}
+
+// yearScore returns the confidence of the date by its year at the tokens i
+// to next, the two digits years are less reliable.
+func (p *parser) yearScore(i, next int) float64 {
+	if next == i+1 && len(p.tokens[i].text) == 2 {
+		return shortYearScore
+	}
+	return fullScore
+}
+
+// gap returns the token after the separator at the token i. The numbers
+// separated by a space have the " " separator.
+func (p *parser) gap(i int, seps string) (int, string, bool) {
+	if i >= len(p.tokens) {
+		return i, "", false
+	}
+	t := p.tokens[i]
+	switch {
+	case t.kind == separator && strings.Contains(seps, t.text):
+		return i + 1, t.text, true
+	case t.kind == number && t.space:
+		return i, " ", true
+	}
+	return i, "", false
+}
+
+// skip returns the token after the optional separator at the token i.
+func (p *parser) skip(i int, seps string) int {
+	if i < len(p.tokens) && p.tokens[i].kind == separator && strings.Contains(seps, p.tokens[i].text) {
+		return i + 1
+	}
+	return i
+}
+
+// is reports whether the token i is the separator.
+func (p *parser) is(i int, sep string) bool {
+	return i < len(p.tokens) && p.tokens[i].kind == separator && p.tokens[i].text == sep
+}
+
+// chained reports whether the token i continues the numbers, such as ".4" in
+// "1.2.3.4", so the date is a part of a longer number.
+func (p *parser) chained(i int) bool {
+	if i+1 >= len(p.tokens) || p.tokens[i].kind != separator || p.tokens[i+1].kind != number {
+		return false
+	}
+	return strings.Contains("-./,", p.tokens[i].text) && !p.tokens[i].space && !p.tokens[i+1].space
+}
+
+// chainedBefore reports whether the number token i continues the numbers
+// before it.
+func (p *parser) chainedBefore(i int) bool {
+	if i < 2 || p.tokens[i-2].kind != number || p.tokens[i-1].kind != separator {
+		return false
+	}
+	return strings.Contains("-./,", p.tokens[i-1].text) && !p.tokens[i-1].space && !p.tokens[i].space
+}
+
+func validMonth(month int) bool {
+	return month >= 1 && month <= 12
+}
+
+func validYear(year int) bool {
+	return year >= 2000 && year < 2100
+}
@@ -0,0 +1,196 @@
+package label
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_Find(t *testing.T) {
+	testCases := []struct {
+		name      string
+		text      string
+		expected  []time.Time
+		monthOnly bool
+	}{
+		{
+			name:     "day month year",
+			text:     "Изготовлено: 15.09.22\nГоден до: 24.09.22",
+			expected: []time.Time{date(2022, 9, 15), date(2022, 9, 24)},
+		},
+		{
+			name:     "slashes and full year",
+			text:     "ГОДЕН ДО 24/09/2022 ХРАНИТЬ ПРИ t +2...+6",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "dashes and time",
+			text:     "ДАТА ИЗГ. 15-09-2022 08:30 СМ.2",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "spaces around separators",
+			text:     "годен до 24. 09. 2022",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "year split by space",
+			text:     "EXP 24.09.20 22",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "different separators",
+			text:     "изг 15.09,22",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "iso",
+			text:     "EXP 2022-09-24 LOT A12",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "us order",
+			text:     "BEST BY 09/24/2022",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:      "month and year",
+			text:      "BEST BEFORE END 09/24",
+			expected:  []time.Time{date(2024, 9, 30)},
+			monthOnly: true,
+		},
+		{
+			name:      "february of leap year",
+			text:      "годен до 02.2024",
+			expected:  []time.Time{date(2024, 2, 29)},
+			monthOnly: true,
+		},
+		{
+			name:     "russian abbreviation",
+			text:     "изготовлено 15 сент 2022",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "russian genitive",
+			text:     "Годен до 24 сентября 2022 г.",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "english abbreviation",
+			text:     "EXP 15 SEP 2022",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "glued",
+			text:     "BB 15SEP22",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "month first",
+			text:     "Best before SEP 24, 2022",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:      "month name and short year",
+			text:      "EXP SEP 24",
+			expected:  []time.Time{date(2024, 9, 30)},
+			monthOnly: true,
+		},
+		{
+			name:     "letters instead of zeros",
+			text:     "ГОДЕН ДО 24.O9.2O22",
+			expected: []time.Time{date(2022, 9, 24)},
+		},
+		{
+			name:     "letters instead of ones",
+			text:     "изг. l5.09.22",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:     "letters instead of digits in iso",
+			text:     "2O22-O9-l5",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name:      "zero instead of letter",
+			text:      "EXP 0CT 2023",
+			expected:  []time.Time{date(2023, 10, 31)},
+			monthOnly: true,
+		},
+		{
+			name:     "cyrillic letters of latin month",
+			text:     "BB 15 ОСТ 2022",
+			expected: []time.Time{date(2022, 10, 15)},
+		},
+		{
+			name:     "pipe instead of one",
+			text:     "годен до |5.09.22",
+			expected: []time.Time{date(2022, 9, 15)},
+		},
+		{
+			name: "impossible day",
+			text: "годен до 31.02.22",
+		},
+		{
+			name:     "leap day",
+			text:     "29.02.2024 29.02.2023",
+			expected: []time.Time{date(2024, 2, 29)},
+		},
+		{
+			name: "prices and weights",
+			text: "Цена 89.90 руб Масса 0.5 кг 3,2% 930 мл",
+		},
+		{
+			name: "batch number",
+			text: "Партия 123.45.678 ТУ 10.51.52-001",
+		},
+		{
+			name: "words of digit-like letters",
+			text: "is oil SOS",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found := Find(tc.text)
+			var dates []time.Time
+			for _, d := range found {
+				dates = append(dates, d.Time)
+				assert.Equal(t, tc.monthOnly, d.MonthOnly)
+			}
+			assert.Equal(t, tc.expected, dates)
+		})
+	}
+}
+
+func Test_FindOffsets(t *testing.T) {
+	text := "Годен до: 24.09.22 г."
+	found := Find(text)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "24.09.22", text[found[0].Start:found[0].End])
+}
+
+func Test_FindFormats(t *testing.T) {
+	testCases := []struct {
+		text     string
+		expected Format
+	}{
+		{text: "EXP 2022-09-24", expected: FormatYearMonthDay},
+		{text: "годен до 24.09.22", expected: FormatDayMonthYear},
+		{text: "24 сентября 2022 г.", expected: FormatDayNameYear},
+		{text: "BEST BY SEP 24, 2022", expected: FormatNameDayYear},
+		{text: "EXP OCT/23", expected: FormatNameYear},
+		{text: "EXP 09/24", expected: FormatMonthYear},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			found := Find(tc.text)
+			assert.Len(t, found, 1)
+			assert.Equal(t, tc.expected, found[0].Format)
+		})
+	}
+}
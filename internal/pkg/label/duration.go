@@ -0,0 +1,172 @@
+package label
+
+import "time"
+
+// Unit is the unit of a shelf life duration.
+type Unit string
+
+const (
+	Hours  Unit = "hours"
+	Days   Unit = "days"
+	Weeks  Unit = "weeks"
+	Months Unit = "months"
+	Years  Unit = "years"
+)
+
+// Duration is the shelf life printed as the amount of the units, such as
+// "годен 30 суток" or "best within 6 months".
+type Duration struct {
+	Amount int
+	Unit   Unit
+	// Role is the role of the end of the shelf life, best before or use by
+	Role Role
+	// Start and End are the byte offsets of the duration in the text
+	Start, End int
+	// first and last are the indexes of the tokens of the duration
+	first, last int
+}
+
+// After returns the end of the shelf life started at t. The hours are
+// rounded down to the days, since the production time is not known.
+func (d Duration) After(t time.Time) time.Time {
+	switch d.Unit {
+	case Hours:
+		return t.Add(time.Duration(d.Amount) * time.Hour).Truncate(24 * time.Hour)
+	case Weeks:
+		return t.AddDate(0, 0, 7*d.Amount)
+	case Months:
+		return t.AddDate(0, d.Amount, 0)
+	case Years:
+		return t.AddDate(d.Amount, 0, 0)
+	}
+	return t.AddDate(0, 0, d.Amount)
+}
+
+// units are the patterns of keyword of the words of the units.
+var units = map[Unit][]string{
+	Hours:  {"ч", "час*", "hour*", "hr", "hrs", "h"},
+	Days:   {"сут*", "дн*", "день", "day*"},
+	Weeks:  {"нед*", "week*", "wk*"},
+	Months: {"мес*", "month*", "mo", "mos", "mth*"},
+	Years:  {"год", "года", "лет", "year*", "yr*"},
+}
+
+// shelfLifeKeywords are the words before a duration of the shelf life.
+var shelfLifeKeywords = []keyword{
+	{[]string{"годен*"}, RoleBestBefore},
+	{[]string{"годн*"}, RoleBestBefore},
+	{[]string{"срок"}, RoleBestBefore},
+	{[]string{"хран*"}, RoleBestBefore},
+	{[]string{"best"}, RoleBestBefore},
+	{[]string{"shelf"}, RoleBestBefore},
+	{[]string{"exp*"}, RoleBestBefore},
+	{[]string{"употреб*"}, RoleUseBy},
+	{[]string{"использ*"}, RoleUseBy},
+	{[]string{"реализ*"}, RoleUseBy},
+	{[]string{"use"}, RoleUseBy},
+}
+
+// openedKeywords are the words after a duration of the storage of an opened
+// package, which is not the shelf life.
+var openedKeywords = [][]string{
+	{"после", "вскрыт*"},
+	{"после", "открыт*"},
+	{"после", "нарушения"},
+	{"after", "open*"},
+	{"once", "open*"},
+}
+
+// maxDurationAmount is the maximum number of the units of a shelf life.
+const maxDurationAmount = 999
+
+// shelfLife returns the first duration of the shelf life after the keyword.
+func (p *parser) shelfLife() *Duration {
+	for i := range p.tokens {
+		amount, ok := p.number(i, 1, 3)
+		if !ok || amount == 0 || amount > maxDurationAmount {
+			continue
+		}
+		j := p.skip(i+1, "-")
+		unit, ok := p.unit(j)
+		if !ok {
+			continue
+		}
+		last := j
+		if p.is(j+1, ".") {
+			last = j + 1
+		}
+		if p.opened(i, last+1) {
+			continue
+		}
+		role, _ := p.keyword(i, 0, shelfLifeKeywords)
+		if role == RoleUnknown {
+			continue
+		}
+		return &Duration{
+			Amount: amount,
+			Unit:   unit,
+			Role:   role,
+			Start:  p.tokens[i].start,
+			End:    p.tokens[last].end,
+			first:  i,
+			last:   last,
+		}
+	}
+	return nil
+}
+
+// unit returns the unit of the word token i.
+func (p *parser) unit(i int) (Unit, bool) {
+	if i >= len(p.tokens) || p.tokens[i].kind != word {
+		return "", false
+	}
+	for unit, patterns := range units {
+		for _, pattern := range patterns {
+			if matchWord(p.tokens[i].text, pattern) {
+				return unit, true
+			}
+		}
+	}
+	return "", false
+}
+
+// opened reports whether the words around the duration at the tokens i to
+// next are about an opened package. The words are searched up to the other
+// numbers.
+func (p *parser) opened(i, next int) bool {
+	for j := next; j < len(p.tokens) && j < next+maxKeywordDistance; j++ {
+		if p.tokens[j].kind == lineBreak || p.tokens[j].kind == number {
+			break
+		}
+		for _, words := range openedKeywords {
+			if p.matchesFrom(j, words) {
+				return true
+			}
+		}
+	}
+	for j := i - 1; j >= 0 && j >= i-maxKeywordDistance; j-- {
+		if p.tokens[j].kind == lineBreak || p.tokens[j].kind == number {
+			break
+		}
+		for _, words := range openedKeywords {
+			if p.matchesFrom(j, words) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesFrom reports whether the words of the keyword start at the token j.
+func (p *parser) matchesFrom(j int, words []string) bool {
+	for _, w := range words {
+		for j < len(p.tokens) && p.tokens[j].kind == separator {
+			j++
+		}
+		if j >= len(p.tokens) || p.tokens[j].kind != word || !matchWord(p.tokens[j].text, w) {
+			return false
+		}
+		j++
+	}
+	return true
+}
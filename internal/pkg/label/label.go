@@ -0,0 +1,130 @@
+package label
+
+// Label is the information recognized on the label of a product.
+type Label struct {
+	// Dates are the printed dates and the end of the shelf life computed by
+	// the production date and the duration
+	Dates []Date
+	// ShelfLife is the printed duration of the shelf life, nil if there is
+	// none
+	ShelfLife *Duration
+	// Temperature is the printed storage temperature, nil if there is none
+	Temperature *Temperature
+}
+
+// Parse recognizes the dates, the shelf life and the storage temperature of
+// the label. When the label has the production date and the duration of the
+// shelf life but no end date, the end date is computed.
+func Parse(text string) Label {
+	p := &parser{tokens: tokenize(text)}
+	result := Label{
+		Dates:       p.dates(),
+		ShelfLife:   p.shelfLife(),
+		Temperature: p.temperature(),
+	}
+	if result.ShelfLife != nil {
+		result.Dates = computeEnd(result.Dates, *result.ShelfLife)
+	}
+	return result
+}
+
+// confirmScore is added to the confidence of a date for every other text
+// of the same image it is found in.
+const confirmScore = 0.05
+
+// Merge merges the labels recognized in the texts of the same image, such as
+// the texts of the passes with the different preprocessing. The dates found
+// in several texts are merged into the most confident one with a known role,
+// and their confidence is raised. The offsets of a date are of the text it is
+// kept from. The shelf life and the temperature are of the first label which
+// has them.
+func Merge(labels ...Label) Label {
+	var (
+		result Label
+		found  []int
+	)
+	for n, l := range labels {
+		seen := make(map[int]bool)
+		for _, d := range l.Dates {
+			d.Source = n
+			i := 0
+			for i < len(result.Dates) && !sameDate(result.Dates[i], d) {
+				i++
+			}
+			if i == len(result.Dates) {
+				result.Dates = append(result.Dates, d)
+				found = append(found, 0)
+			} else if preferred(d, result.Dates[i]) {
+				result.Dates[i] = d
+			}
+			if !seen[i] {
+				seen[i] = true
+				found[i]++
+			}
+		}
+		if result.ShelfLife == nil {
+			result.ShelfLife = l.ShelfLife
+		}
+		if result.Temperature == nil {
+			result.Temperature = l.Temperature
+		}
+	}
+	for i := range result.Dates {
+		result.Dates[i].Confidence = confidence(result.Dates[i].Confidence + confirmScore*float64(found[i]-1))
+	}
+	return result
+}
+
+func sameDate(a, b Date) bool {
+	return a.Time.Equal(b.Time) && a.MonthOnly == b.MonthOnly && a.Computed == b.Computed
+}
+
+// preferred reports whether the date a is preferred to b: the dates with a
+// known role come first, then the more confident ones.
+func preferred(a, b Date) bool {
+	if (a.Role == RoleUnknown) != (b.Role == RoleUnknown) {
+		return b.Role == RoleUnknown
+	}
+	return a.Confidence > b.Confidence
+}
+
+// computeEnd adds the end of the shelf life started by the most confident
+// production date. A single date with no role is the production date then.
+// The dates are not changed if any of them ends the shelf life.
+func computeEnd(dates []Date, shelfLife Duration) []Date {
+	start := -1
+	for i, d := range dates {
+		if d.Role.End() {
+			return dates
+		}
+		if d.Role.Start() && (start < 0 || d.Confidence > dates[start].Confidence) {
+			start = i
+		}
+	}
+	if start < 0 && len(dates) == 1 && dates[0].Role == RoleUnknown {
+		start = 0
+		dates[0].Role = RoleManufactured
+		dates[0].Confidence = confidence(dates[0].Confidence + orderScore)
+	}
+	if start < 0 {
+		return dates
+	}
+	from := dates[start]
+	end := Date{
+		Time:       shelfLife.After(from.Time),
+		Start:      shelfLife.Start,
+		End:        shelfLife.End,
+		Role:       shelfLife.Role,
+		Confidence: confidence(from.Confidence - orderScore),
+		Computed:   true,
+		Format:     FormatComputed,
+		first:      shelfLife.first,
+		last:       shelfLife.last,
+	}
+	if from.MonthOnly {
+		// the earliest end of the shelf life started in the month
+		end.Time = shelfLife.After(from.Time.AddDate(0, 0, 1-from.Time.Day()))
+		end.MonthOnly = true
+	}
+	return append(dates, end)
+}
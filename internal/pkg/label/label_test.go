@@ -0,0 +1,147 @@
+package label
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	testCases := []struct {
+		name        string
+		text        string
+		shelfLife   *Duration
+		end         time.Time
+		temperature *Temperature
+	}{
+		{
+			name:        "days and temperature range",
+			text:        "Изготовлено: 15.09.22\nГоден 30 суток при t +2...+6°C",
+			shelfLife:   &Duration{Amount: 30, Unit: Days, Role: RoleBestBefore},
+			end:         date(2022, 10, 15),
+			temperature: &Temperature{Min: ptr(2), Max: ptr(6)},
+		},
+		{
+			name:        "abbreviated days",
+			text:        "Дата изготовления 15.09.22 Срок годности 10 сут. при температуре от +2 до +6 °С",
+			shelfLife:   &Duration{Amount: 10, Unit: Days, Role: RoleBestBefore},
+			end:         date(2022, 9, 25),
+			temperature: &Temperature{Min: ptr(2), Max: ptr(6)},
+		},
+		{
+			name:        "abbreviated months",
+			text:        "изг. 15.09.2022 Срок годности: 6 мес. Хранить при температуре не выше +25°С",
+			shelfLife:   &Duration{Amount: 6, Unit: Months, Role: RoleBestBefore},
+			end:         date(2023, 3, 15),
+			temperature: &Temperature{Max: ptr(25)},
+		},
+		{
+			name:      "english months",
+			text:      "MFG 15 SEP 2022 Best within 6 months",
+			shelfLife: &Duration{Amount: 6, Unit: Months, Role: RoleBestBefore},
+			end:       date(2023, 3, 15),
+		},
+		{
+			name:        "hours rounded down",
+			text:        "Упаковано 15.09.22\nУпотребить в течение 36 часов при 2-6°C",
+			shelfLife:   &Duration{Amount: 36, Unit: Hours, Role: RoleUseBy},
+			end:         date(2022, 9, 16),
+			temperature: &Temperature{Min: ptr(2), Max: ptr(6)},
+		},
+		{
+			name:        "years and freezer",
+			text:        "15.09.2022 срок хранения 1 год при -18°С",
+			shelfLife:   &Duration{Amount: 1, Unit: Years, Role: RoleBestBefore},
+			end:         date(2023, 9, 15),
+			temperature: &Temperature{Min: ptr(-18), Max: ptr(-18)},
+		},
+		{
+			name:        "end date printed",
+			text:        "изг 15.09.22 годен до 24.09.22 срок годности 9 суток",
+			shelfLife:   &Duration{Amount: 9, Unit: Days, Role: RoleBestBefore},
+			end:         date(2022, 9, 24),
+			temperature: nil,
+		},
+		{
+			name: "after opening",
+			text: "изг 15.09.22 после вскрытия хранить 3 суток",
+		},
+		{
+			name:        "storage after opening",
+			text:        "изг 15.09.22 годен 6 мес. хранить не более 48 ч после вскрытия при t 0-4°C",
+			shelfLife:   &Duration{Amount: 6, Unit: Months, Role: RoleBestBefore},
+			end:         date(2023, 3, 15),
+			temperature: &Temperature{Min: ptr(0), Max: ptr(4)},
+		},
+		{
+			name: "numbers without context",
+			text: "15-09-2022 масса 2,5 кг 3 шт 20 минут",
+		},
+		{
+			name:        "degrees without sign",
+			text:        "хранить при 4 °C",
+			temperature: &Temperature{Min: ptr(4), Max: ptr(4)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := Parse(tc.text)
+			if tc.shelfLife == nil {
+				assert.Nil(t, result.ShelfLife)
+			} else if assert.NotNil(t, result.ShelfLife) {
+				assert.Equal(t, tc.shelfLife.Amount, result.ShelfLife.Amount)
+				assert.Equal(t, tc.shelfLife.Unit, result.ShelfLife.Unit)
+				assert.Equal(t, tc.shelfLife.Role, result.ShelfLife.Role)
+			}
+			if !tc.end.IsZero() {
+				var end *Date
+				for i, d := range result.Dates {
+					if d.Role.End() {
+						end = &result.Dates[i]
+					}
+				}
+				if assert.NotNil(t, end) {
+					assert.Equal(t, tc.end, end.Time)
+				}
+			}
+			if tc.temperature == nil {
+				assert.Nil(t, result.Temperature)
+			} else if assert.NotNil(t, result.Temperature) {
+				assert.Equal(t, tc.temperature.Min, result.Temperature.Min)
+				assert.Equal(t, tc.temperature.Max, result.Temperature.Max)
+			}
+		})
+	}
+}
+
+func Test_TemperatureContains(t *testing.T) {
+	r := Parse("при t +2...+6°C").Temperature
+	assert.True(t, r.Contains(4))
+	assert.False(t, r.Contains(-18))
+	upper := Parse("не выше +25°С").Temperature
+	assert.True(t, upper.Contains(-18))
+	assert.False(t, upper.Contains(30))
+}
+
+func Test_Merge(t *testing.T) {
+	merged := Merge(
+		Parse("15.09.22 4Г745\n24.09.22 (03)Л"),
+		Parse("Изготовлено 15.09.22\nГоден до 24.09.22 при t +2...+6°C"),
+		Parse("l5.O9.22 Годен до 24.09.22"),
+	)
+	var dates []string
+	for _, d := range merged.Dates {
+		dates = append(dates, d.Time.Format("02.01.06"))
+	}
+	assert.Equal(t, []string{"15.09.22", "24.09.22"}, dates)
+	assert.Equal(t, RoleManufactured, merged.Dates[0].Role)
+	assert.Equal(t, RoleBestBefore, merged.Dates[1].Role)
+	assert.Equal(t, 1, merged.Dates[0].Source)
+	second := Parse("Годен до 24.09.22")
+	assert.Greater(t, merged.Dates[1].Confidence, second.Dates[0].Confidence)
+	assert.NotNil(t, merged.Temperature)
+	assert.Nil(t, merged.ShelfLife)
+	assert.Empty(t, Merge().Dates)
+}
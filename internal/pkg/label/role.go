@@ -0,0 +1,229 @@
+package label
+
+import (
+	"strings"
+)
+
+// Role is the meaning of a date on the label.
+type Role string
+
+const (
+	RoleUnknown      Role = "unknown"
+	RoleManufactured Role = "manufactured"
+	RolePacked       Role = "packed"
+	RoleBestBefore   Role = "best_before"
+	RoleUseBy        Role = "use_by"
+)
+
+// Start reports whether the date starts the shelf life.
+func (r Role) Start() bool {
+	return r == RoleManufactured || r == RolePacked
+}
+
+// End reports whether the date ends the shelf life.
+func (r Role) End() bool {
+	return r == RoleBestBefore || r == RoleUseBy
+}
+
+// keyword is the words before a date which give its role. A word ending
+// with "*" is a prefix of the word of the text.
+type keyword struct {
+	words []string
+	role  Role
+}
+
+var keywords = []keyword{
+	{[]string{"изг*"}, RoleManufactured},
+	{[]string{"произв*"}, RoleManufactured},
+	{[]string{"выраб*"}, RoleManufactured},
+	{[]string{"дата", "пр"}, RoleManufactured},
+	{[]string{"mfg"}, RoleManufactured},
+	{[]string{"mfd"}, RoleManufactured},
+	{[]string{"manuf*"}, RoleManufactured},
+	{[]string{"produced"}, RoleManufactured},
+	{[]string{"production"}, RoleManufactured},
+	{[]string{"prod"}, RoleManufactured},
+	{[]string{"упак*"}, RolePacked},
+	{[]string{"фасов*"}, RolePacked},
+	{[]string{"pkd"}, RolePacked},
+	{[]string{"packed"}, RolePacked},
+	{[]string{"pack", "date"}, RolePacked},
+	{[]string{"годен*"}, RoleBestBefore},
+	{[]string{"годн*"}, RoleBestBefore},
+	{[]string{"хорош*"}, RoleBestBefore},
+	{[]string{"best"}, RoleBestBefore},
+	{[]string{"bb"}, RoleBestBefore},
+	{[]string{"bbe"}, RoleBestBefore},
+	{[]string{"exp*"}, RoleBestBefore},
+	{[]string{"употреб*"}, RoleUseBy},
+	{[]string{"использ*"}, RoleUseBy},
+	{[]string{"реализ*"}, RoleUseBy},
+	{[]string{"use", "by"}, RoleUseBy},
+	{[]string{"use", "before"}, RoleUseBy},
+}
+
+// The confidences added for the role of a date.
+const (
+	// keywordScore is added when the role is found by a keyword right
+	// before the date
+	keywordScore = 0.35
+	// distantKeywordScore is added when there are the other words between
+	// the keyword and the date
+	distantKeywordScore = 0.25
+	// orderScore is added when the role is guessed by the order of the
+	// dates
+	orderScore = 0.05
+)
+
+// maxKeywordDistance is the number of the tokens between a keyword and its
+// date.
+const maxKeywordDistance = 6
+
+// roles sets the roles of the dates by the keywords before them. The dates
+// with no keywords are the start and the end of the shelf life by their
+// order, if there is no other date of the role.
+func (p *parser) roles(dates []Date) {
+	for i := range dates {
+		limit := 0
+		if i > 0 {
+			limit = dates[i-1].last + 1
+		}
+		role, distance := p.keyword(dates[i].first, limit, keywords)
+		if role == RoleUnknown {
+			dates[i].Role = RoleUnknown
+			continue
+		}
+		dates[i].Role = role
+		if distance == 0 {
+			dates[i].Confidence += keywordScore
+		} else {
+			dates[i].Confidence += distantKeywordScore
+		}
+	}
+	if len(dates) < 2 {
+		return
+	}
+	if i := unknown(dates, true); i >= 0 && !hasRoles(dates, RoleManufactured, RolePacked) &&
+		before(dates, i, RoleBestBefore, RoleUseBy) {
+		dates[i].Role = RoleManufactured
+		dates[i].Confidence += orderScore
+	}
+	if i := unknown(dates, false); i >= 0 && !hasRoles(dates, RoleBestBefore, RoleUseBy) &&
+		after(dates, i, RoleManufactured, RolePacked) {
+		dates[i].Role = RoleBestBefore
+		dates[i].Confidence += orderScore
+	}
+}
+
+// unknown returns the earliest or the latest date with no role, or -1.
+func unknown(dates []Date, earliest bool) int {
+	result := -1
+	for i, d := range dates {
+		if d.Role != RoleUnknown {
+			continue
+		}
+		if result < 0 || earliest && d.Time.Before(dates[result].Time) ||
+			!earliest && d.Time.After(dates[result].Time) {
+			result = i
+		}
+	}
+	return result
+}
+
+func hasRoles(dates []Date, roles ...Role) bool {
+	for _, d := range dates {
+		if hasRole(d.Role, roles) {
+			return true
+		}
+	}
+	return false
+}
+
+// before reports whether the date i is before all the dates of the roles.
+func before(dates []Date, i int, roles ...Role) bool {
+	for _, d := range dates {
+		if hasRole(d.Role, roles) && !dates[i].Time.Before(d.Time) {
+			return false
+		}
+	}
+	return true
+}
+
+// after reports whether the date i is after all the dates of the roles.
+func after(dates []Date, i int, roles ...Role) bool {
+	for _, d := range dates {
+		if hasRole(d.Role, roles) && !dates[i].Time.After(d.Time) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasRole(role Role, roles []Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// fillers are the words between a keyword and its date which do not make
+// the keyword distant.
+var fillers = map[string]bool{
+	"до": true, "г": true, "дата": true, "срок": true, "by": true, "before": true,
+	"date": true, "of": true, "on": true, "end": true, "the": true,
+	"в": true, "течение": true, "within": true,
+}
+
+// keyword returns the role of the nearest keyword of the list before the
+// token i and the number of the words between them. The tokens before limit
+// are not searched, the previous line is searched only when the token i
+// starts its line.
+func (p *parser) keyword(i, limit int, list []keyword) (Role, int) {
+	distance, words, previous := 0, 0, false
+	for j := i - 1; j >= limit && distance <= maxKeywordDistance; j-- {
+		t := p.tokens[j]
+		if t.kind == lineBreak {
+			if words > 0 || previous {
+				break
+			}
+			previous = true
+			continue
+		}
+		if t.kind != word {
+			continue
+		}
+		for _, k := range list {
+			if p.matches(j, limit, k.words) {
+				return k.role, distance
+			}
+		}
+		words++
+		if !fillers[t.text] {
+			distance++
+		}
+	}
+	return RoleUnknown, 0
+}
+
+// matches reports whether the words of the keyword end at the word token j.
+func (p *parser) matches(j, limit int, words []string) bool {
+	for k := len(words) - 1; k >= 0; k-- {
+		for j >= limit && p.tokens[j].kind == separator {
+			j--
+		}
+		if j < limit || p.tokens[j].kind != word || !matchWord(p.tokens[j].text, words[k]) {
+			return false
+		}
+		j--
+	}
+	return true
+}
+
+func matchWord(text, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(text, prefix)
+	}
+	return text == pattern
+}
@@ -0,0 +1,115 @@
+package label
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Roles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		text        string
+		roles       []Role
+		confidences []float64
+	}{
+		{
+			name:        "keywords on lines",
+			text:        "Изготовлено: 15.09.22\nГоден до: 24.09.22",
+			roles:       []Role{RoleManufactured, RoleBestBefore},
+			confidences: []float64{0.9, 0.9},
+		},
+		{
+			name:        "keywords on one line",
+			text:        "изг. 15.09.22 годен до 24.09.22",
+			roles:       []Role{RoleManufactured, RoleBestBefore},
+			confidences: []float64{0.9, 0.9},
+		},
+		{
+			name:        "use by",
+			text:        "Употребить до 24.09.2022",
+			roles:       []Role{RoleUseBy},
+			confidences: []float64{0.95},
+		},
+		{
+			name:        "english abbreviations",
+			text:        "MFG 15SEP22 EXP 15SEP23",
+			roles:       []Role{RoleManufactured, RoleBestBefore},
+			confidences: []float64{0.9, 0.9},
+		},
+		{
+			name:        "iso expiry",
+			text:        "EXP 2022-09-24 LOT A12",
+			roles:       []Role{RoleBestBefore},
+			confidences: []float64{0.95},
+		},
+		{
+			name:        "keyword on previous line",
+			text:        "Годен до:\n24.09.22",
+			roles:       []Role{RoleBestBefore},
+			confidences: []float64{0.9},
+		},
+		{
+			name:        "keyword of other line",
+			text:        "Годен до: см. на крышке\nпартия 24.09.22",
+			roles:       []Role{RoleUnknown},
+			confidences: []float64{0.55},
+		},
+		{
+			name:        "distant keyword",
+			text:        "годен при хранении в холодильнике 24.09.22",
+			roles:       []Role{RoleBestBefore},
+			confidences: []float64{0.8},
+		},
+		{
+			name:        "packed",
+			text:        "Дата упаковки 15.09.22",
+			roles:       []Role{RolePacked},
+			confidences: []float64{0.9},
+		},
+		{
+			name:        "order of dates",
+			text:        "15.09.22 24.09.22",
+			roles:       []Role{RoleManufactured, RoleBestBefore},
+			confidences: []float64{0.6, 0.6},
+		},
+		{
+			name:        "production date by expiry",
+			text:        "ГОДЕН ДО 24.09.22 15.09.22",
+			roles:       []Role{RoleBestBefore, RoleManufactured},
+			confidences: []float64{0.9, 0.6},
+		},
+		{
+			name:        "single date",
+			text:        "партия 15.09.22",
+			roles:       []Role{RoleUnknown},
+			confidences: []float64{0.55},
+		},
+		{
+			name:        "month only",
+			text:        "Best before end 09/24",
+			roles:       []Role{RoleBestBefore},
+			confidences: []float64{0.7},
+		},
+		{
+			name:        "recognition errors",
+			text:        "ГОДЕН ДО 24.O9.22",
+			roles:       []Role{RoleBestBefore},
+			confidences: []float64{0.75},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				roles       []Role
+				confidences []float64
+			)
+			for _, d := range Find(tc.text) {
+				roles = append(roles, d.Role)
+				confidences = append(confidences, d.Confidence)
+			}
+			assert.Equal(t, tc.roles, roles)
+			assert.Equal(t, tc.confidences, confidences)
+		})
+	}
+}
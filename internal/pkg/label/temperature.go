@@ -0,0 +1,199 @@
+package label
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Temperature is the range of the storage temperature in degrees Celsius,
+// such as "при t +2...+6°C". A bound is nil if it is not printed, as in
+// "не выше +25°C".
+type Temperature struct {
+	Min, Max *float64
+	// Start and End are the byte offsets of the temperature in the text
+	Start, End int
+}
+
+// Contains reports whether the temperature t is in the range.
+func (r Temperature) Contains(t float64) bool {
+	return (r.Min == nil || t >= *r.Min) && (r.Max == nil || t <= *r.Max)
+}
+
+// temperatureContext are the words before a temperature which are not
+// followed by the degrees.
+var temperatureContext = []string{"t", "т", "температур*", "temp*"}
+
+// upperBounds and lowerBounds are the words before the bound of the range.
+var (
+	upperBounds = [][]string{{"не", "выше"}, {"не", "более"}, {"below"}, {"under"}, {"max*"}, {"до"}, {"up", "to"}}
+	lowerBounds = [][]string{{"не", "ниже"}, {"не", "менее"}, {"above"}, {"min*"}, {"от"}, {"from"}}
+)
+
+// minTemperature and maxTemperature bound the storage temperatures.
+const (
+	minTemperature = -60
+	maxTemperature = 60
+)
+
+// temperature returns the first storage temperature range.
+func (p *parser) temperature() *Temperature {
+	for i := range p.tokens {
+		if r, ok := p.temperatureAt(i); ok {
+			return &r
+		}
+	}
+	return nil
+}
+
+// temperatureAt matches a range, such as "+2...+6°C", "от +2 до +6 °С" or
+// "2-6°C", or a bound, such as "не выше +25°С", at the token i.
+func (p *parser) temperatureAt(i int) (Temperature, bool) {
+	from, j, signed, ok := p.signed(i)
+	if !ok {
+		return Temperature{}, false
+	}
+	j, degrees := p.degrees(j)
+	context := p.temperatureContext(i)
+	result := Temperature{Start: p.tokens[i].start, End: p.tokens[j-1].end}
+	if k, sep, ok := p.rangeSeparator(j); ok {
+		to, l, signed2, ok := p.signed(k)
+		if ok {
+			l, degrees2 := p.degrees(l)
+			// the dashed numbers are the temperatures only by the context
+			strong := degrees || degrees2 || context || signed && signed2
+			if sep == "-" && !signed2 && !degrees2 && !context {
+				strong = false
+			}
+			if strong && from <= to {
+				result.Min, result.Max, result.End = &from, &to, p.tokens[l-1].end
+				return result, true
+			}
+		}
+	}
+	if !degrees {
+		return Temperature{}, false
+	}
+	switch {
+	case p.bound(i, upperBounds):
+		result.Max = &from
+	case p.bound(i, lowerBounds):
+		result.Min = &from
+	default:
+		result.Min, result.Max = &from, &from
+	}
+	return result, true
+}
+
+// signed returns the value of the number with the optional sign at the
+// token i, the index of the token after it and whether the sign is printed.
+func (p *parser) signed(i int) (float64, int, bool, bool) {
+	sign, signed := 1.0, false
+	if i+1 < len(p.tokens) && p.tokens[i].kind == separator && !p.tokens[i+1].space &&
+		(p.tokens[i].text == "+" || p.tokens[i].text == "-") {
+		if p.tokens[i].text == "-" {
+			sign = -1
+		}
+		signed = true
+		i++
+	}
+	if i >= len(p.tokens) || p.tokens[i].kind != number || len(p.tokens[i].text) > 2 {
+		return 0, i, false, false
+	}
+	text := p.tokens[i].text
+	next := i + 1
+	// the decimal fraction, such as "2,5"
+	if p.is(next, ",") || p.is(next, ".") {
+		if frac, ok := p.number(next+1, 1, 1); ok && !p.tokens[next].space && !p.tokens[next+1].space {
+			text += "." + strconv.Itoa(frac)
+			next += 2
+		}
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || sign*value < minTemperature || sign*value > maxTemperature {
+		return 0, i, false, false
+	}
+	return sign * value, next, signed, true
+}
+
+// degrees returns the token after the degrees sign at the token i, such as
+// "°C", "°", "С" or "градусов".
+func (p *parser) degrees(i int) (int, bool) {
+	if p.is(i, "°") {
+		if i+1 < len(p.tokens) && p.tokens[i+1].kind == word && !p.tokens[i+1].space && celsius(p.tokens[i+1].text) {
+			return i + 2, true
+		}
+		return i + 1, true
+	}
+	if i < len(p.tokens) && p.tokens[i].kind == word {
+		text := p.tokens[i].text
+		if celsius(text) && !p.tokens[i].space || strings.HasPrefix(text, "градус") {
+			return i + 1, true
+		}
+	}
+	return i, false
+}
+
+func celsius(text string) bool {
+	return text == "c" || text == "с"
+}
+
+// rangeSeparator returns the token after the separator of the range at the
+// token i: "...", "..", "-", "÷", "до" or "to".
+func (p *parser) rangeSeparator(i int) (int, string, bool) {
+	if i >= len(p.tokens) {
+		return i, "", false
+	}
+	t := p.tokens[i]
+	switch {
+	case t.kind == separator && (t.text == "-" || t.text == "÷" || t.text == "..."):
+		return i + 1, t.text, true
+	case t.kind == separator && t.text == ".":
+		j := i
+		for p.is(j, ".") {
+			j++
+		}
+		if j-i >= 2 {
+			return j, "...", true
+		}
+	case t.kind == word && (t.text == "до" || t.text == "to"):
+		return i + 1, t.text, true
+	}
+	return i, "", false
+}
+
+// temperatureContext reports whether the words before the token i are about
+// the temperature.
+func (p *parser) temperatureContext(i int) bool {
+	for j := i - 1; j >= 0 && j >= i-4; j-- {
+		t := p.tokens[j]
+		if t.kind == lineBreak {
+			return false
+		}
+		if t.kind != word {
+			continue
+		}
+		for _, pattern := range temperatureContext {
+			if matchWord(t.text, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bound reports whether the words of a bound are right before the token i.
+func (p *parser) bound(i int, bounds [][]string) bool {
+	j := i - 1
+	for j >= 0 && p.tokens[j].kind == word && (p.tokens[j].text == "t" || p.tokens[j].text == "т") {
+		j--
+	}
+	if j < 0 || p.tokens[j].kind != word {
+		return false
+	}
+	for _, words := range bounds {
+		if p.matches(j, 0, words) {
+			return true
+		}
+	}
+	return false
+}
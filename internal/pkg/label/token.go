@@ -0,0 +1,195 @@
+package label
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type kind int
+
+const (
+	number kind = iota
+	word
+	separator
+	lineBreak
+)
+
+// token is a number, a word or a punctuation mark of the text.
+type token struct {
+	kind kind
+	// text is the normalized text, the words are in lower case
+	text string
+	// start and end are the byte offsets of the token in the text
+	start, end int
+	// space reports whether the token follows a whitespace
+	space bool
+	// fixed reports whether the characters of the token were replaced
+	fixed bool
+}
+
+// digitLike are the letters recognized instead of the digits.
+var digitLike = map[rune]rune{
+	'O': '0', 'o': '0', 'О': '0', 'о': '0', 'D': '0', 'Q': '0',
+	'l': '1', 'I': '1', 'i': '1', '|': '1', 'і': '1', 'І': '1', '!': '1',
+	'Z': '2', 'z': '2',
+	'З': '3', 'з': '3',
+	'S': '5', 's': '5',
+	'б': '6', 'G': '6',
+	'B': '8', 'В': '8',
+}
+
+// letterLike are the digits recognized instead of the letters.
+var letterLike = map[rune]rune{'0': 'o', '1': 'l', '5': 's', '8': 'b'}
+
+// separators are the punctuation marks by their normalized text.
+var separators = map[rune]string{
+	'.': ".", ',': ",", '/': "/", '\\': "/", '-': "-", '–': "-", '—': "-", '−': "-",
+	':': ":", ';': ";", '%': "%", '…': "...",
+}
+
+// part is a run of the digits or the letters of a word.
+type part struct {
+	digits     bool
+	text       string
+	start, end int
+	fixed      bool
+}
+
+// tokenize splits the text into the tokens. The letters between the digits
+// and the digits between the letters of a month are replaced by the
+// characters they are confused with.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		run    []part
+		space  bool
+	)
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		for i, p := range normalize(run) {
+			t := token{kind: word, text: strings.ToLower(p.text), start: p.start, end: p.end, fixed: p.fixed}
+			if p.digits {
+				t.kind = number
+			}
+			t.space = i == 0 && space
+			tokens = append(tokens, t)
+		}
+		run, space = nil, false
+	}
+	for i, r := range text {
+		size := utf8.RuneLen(r)
+		switch {
+		case unicode.IsDigit(r) || unicode.IsLetter(r) || r == '|' || r == '!' && len(run) > 0:
+			digits := unicode.IsDigit(r)
+			if n := len(run); n > 0 && run[n-1].digits == digits && run[n-1].end == i {
+				run[n-1].text += string(r)
+				run[n-1].end = i + size
+			} else {
+				run = append(run, part{digits: digits, text: string(r), start: i, end: i + size})
+			}
+		case r == '\n':
+			flush()
+			tokens = append(tokens, token{kind: lineBreak, start: i, end: i + size})
+		case unicode.IsSpace(r):
+			flush()
+			space = true
+		default:
+			flush()
+			if sep, ok := separators[r]; ok {
+				tokens = append(tokens, token{kind: separator, text: sep, start: i, end: i + size, space: space})
+			} else {
+				tokens = append(tokens, token{kind: separator, text: string(r), start: i, end: i + size, space: space})
+			}
+			space = false
+		}
+	}
+	flush()
+	return digitTokens(tokens)
+}
+
+// normalize joins the parts of a word confused by the recognition: the short
+// runs of the digit-like letters next to the digits become the digits, and
+// the digits glued to the letters of a month become the letters.
+func normalize(parts []part) []part {
+	for i := range parts {
+		p := &parts[i]
+		if p.digits || utf8.RuneCountInString(p.text) > 2 || !replaceable(p.text, digitLike) {
+			continue
+		}
+		if i > 0 && parts[i-1].digits || i+1 < len(parts) && parts[i+1].digits {
+			p.text, p.digits, p.fixed = replace(p.text, digitLike), true, true
+		}
+	}
+	parts = merge(parts)
+	for i := 0; i < len(parts); i++ {
+		if !parts[i].digits || len(parts[i].text) > 2 || !replaceable(parts[i].text, letterLike) {
+			continue
+		}
+		letters := replace(parts[i].text, letterLike)
+		switch {
+		case i+1 < len(parts) && isMonth(letters+parts[i+1].text),
+			i > 0 && !parts[i-1].digits && isMonth(parts[i-1].text+letters):
+			parts[i].text, parts[i].digits, parts[i].fixed = letters, false, true
+		}
+	}
+	return merge(parts)
+}
+
+// merge joins the adjacent parts of the same kind.
+func merge(parts []part) []part {
+	result := parts[:1]
+	for _, p := range parts[1:] {
+		last := &result[len(result)-1]
+		if last.digits == p.digits {
+			last.text += p.text
+			last.end = p.end
+			last.fixed = last.fixed || p.fixed
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// digitTokens replaces the words of the digit-like letters which are glued
+// to the date separators, such as "O9" in "15.O9.22".
+func digitTokens(tokens []token) []token {
+	glued := func(i int) bool {
+		return i >= 0 && i < len(tokens) && tokens[i].kind == separator && strings.Contains("./-", tokens[i].text)
+	}
+	for i := range tokens {
+		t := &tokens[i]
+		if t.kind != word || utf8.RuneCountInString(t.text) > 4 || !replaceable(t.text, digitLike) {
+			continue
+		}
+		before := glued(i-1) && !tokens[i].space
+		after := glued(i+1) && !tokens[i+1].space
+		if before || after {
+			t.text, t.kind, t.fixed = replace(t.text, digitLike), number, true
+		}
+	}
+	return tokens
+}
+
+// replaceable reports whether every rune of the text is in the table or is
+// already a digit.
+func replaceable(text string, table map[rune]rune) bool {
+	for _, r := range text {
+		if _, ok := table[r]; !ok && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func replace(text string, table map[rune]rune) string {
+	return strings.Map(func(r rune) rune {
+		if c, ok := table[r]; ok {
+			return c
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,37 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+)
+
+// The names of the backends selected by the configuration.
+const (
+	BackendTesseract = "tesseract"
+	BackendHTTP      = "http"
+)
+
+// Backend is an OCR engine recognizing the text of the images with the
+// boxes of the words. The Pool of the Tesseract clients is the default one.
+type Backend interface {
+	// Recognize recognizes the image once for each pass of the backend and
+	// returns the results of the passes.
+	Recognize(ctx context.Context, image []byte) ([]Result, error)
+	Close() error
+}
+
+// New creates the backend selected by the configuration.
+func New(cfg *config.Config) (Backend, error) {
+	switch cfg.OCR.Backend {
+	case "", BackendTesseract:
+		return newTesseractPool(cfg)
+	case BackendHTTP:
+		if cfg.OCR.URL == "" {
+			return nil, fmt.Errorf("OCR_URL is required by the %s backend", BackendHTTP)
+		}
+		return NewHTTP(cfg.OCR.URL, cfg.OCR.Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown OCR backend %q", cfg.OCR.Backend)
+}
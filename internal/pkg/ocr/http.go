@@ -0,0 +1,104 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"time"
+)
+
+// responseLimit is the largest response of the OCR service, 8 MB.
+const responseLimit = 8 << 20
+
+// HTTP is the backend of an external OCR service. The image is posted to the
+// URL as it is stored, the service answers with the words of its passes in
+// the reading order:
+//
+//	{"passes": [{"words": [{"text": "24.09.22", "box": [12, 40, 120, 24], "confidence": 91.5, "line": 0}]}]}
+//
+// The boxes are the x, y, width and height in the pixels of the image
+// rotated by its EXIF orientation. The busy service answers with 429 or 503.
+type HTTP struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewHTTP creates the backend of the service at the URL. A recognition fails
+// with the context error if the service does not answer within the timeout,
+// a zero timeout waits until the context is done.
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	return &HTTP{url: url, timeout: timeout, client: new(http.Client)}
+}
+
+// httpResponse is the answer of the OCR service.
+type httpResponse struct {
+	Passes []struct {
+		Words []struct {
+			Text       string  `json:"text"`
+			Box        [4]int  `json:"box"`
+			Confidence float64 `json:"confidence"`
+			Line       int     `json:"line"`
+		} `json:"words"`
+	} `json:"passes"`
+}
+
+// Recognize implements Backend
+func (b *HTTP) Recognize(ctx context.Context, data []byte) ([]Result, error) {
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ocr request: %w", err)
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(data))
+	resp, err := b.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to request ocr service: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, responseLimit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ocr response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return nil, ErrQueueFull
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("ocr service answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	var answer httpResponse
+	if err := json.Unmarshal(body, &answer); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ocr response: %w", err)
+	}
+	results := make([]Result, 0, len(answer.Passes))
+	for _, pass := range answer.Passes {
+		words := make([]Word, 0, len(pass.Words))
+		for _, w := range pass.Words {
+			words = append(words, Word{
+				Text:       w.Text,
+				Box:        image.Rect(w.Box[0], w.Box[1], w.Box[0]+w.Box[2], w.Box[1]+w.Box[3]),
+				Confidence: w.Confidence,
+				Line:       w.Line,
+			})
+		}
+		results = append(results, newResult(words))
+	}
+	return results, nil
+}
+
+// Close implements Backend
+func (b *HTTP) Close() error {
+	b.client.CloseIdleConnections()
+	return nil
+}
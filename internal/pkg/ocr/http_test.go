@@ -0,0 +1,109 @@
+package ocr
+
+import (
+	"context"
+	"image"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTTP(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	testCases := []struct {
+		name    string
+		status  int
+		body    string
+		delay   time.Duration
+		results []Result
+		err     error
+		message string
+	}{
+		{
+			name:   "recognized",
+			status: http.StatusOK,
+			body: `{"passes": [
+				{"words": [
+					{"text": "годен", "box": [10, 40, 60, 20], "confidence": 90, "line": 0},
+					{"text": "24.09.22", "box": [80, 40, 90, 20], "confidence": 85.5, "line": 0},
+					{"text": "1кг", "box": [10, 70, 30, 20], "confidence": 70, "line": 1}
+				]},
+				{"words": []}
+			]}`,
+			results: []Result{
+				{
+					Text: "годен 24.09.22\n1кг",
+					Words: []Word{
+						{Text: "годен", Box: image.Rect(10, 40, 70, 60), Confidence: 90},
+						{Text: "24.09.22", Box: image.Rect(80, 40, 170, 60), Confidence: 85.5},
+						{Text: "1кг", Box: image.Rect(10, 70, 40, 90), Confidence: 70, Line: 1},
+					},
+				},
+				{Words: []Word{}},
+			},
+		},
+		{
+			name:   "busy",
+			status: http.StatusServiceUnavailable,
+			err:    ErrQueueFull,
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			err:    ErrQueueFull,
+		},
+		{
+			name:    "failed",
+			status:  http.StatusInternalServerError,
+			body:    "model is not loaded\n",
+			message: "ocr service answered 500: model is not loaded",
+		},
+		{
+			name:    "invalid response",
+			status:  http.StatusOK,
+			body:    "<html>",
+			message: "failed to unmarshal ocr response",
+		},
+		{
+			name:   "timeout",
+			status: http.StatusOK,
+			delay:  time.Second,
+			err:    context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "image/png", r.Header.Get("Content-Type"))
+				assert.Equal(t, png, body)
+				select {
+				case <-time.After(tc.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(tc.status)
+				_, _ = io.WriteString(w, tc.body)
+			}))
+			defer server.Close()
+			backend := NewHTTP(server.URL, 100*time.Millisecond)
+			defer backend.Close()
+
+			results, err := backend.Recognize(context.Background(), png)
+			switch {
+			case tc.err != nil:
+				assert.ErrorIs(t, err, tc.err)
+			case tc.message != "":
+				assert.ErrorContains(t, err, tc.message)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.results, withoutOffsets(results))
+		})
+	}
+}
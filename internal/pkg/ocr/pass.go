@@ -0,0 +1,76 @@
+package ocr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+)
+
+// PageSegMode is the Tesseract page segmentation mode, the way the image is
+// split into the blocks and the lines of the text.
+type PageSegMode int
+
+const (
+	// PageSegAuto finds the blocks of the text automatically
+	PageSegAuto PageSegMode = 3
+	// PageSegSingleBlock treats the image as a single block of the text
+	PageSegSingleBlock PageSegMode = 6
+	// PageSegSparseText finds as much text as possible in no order, which
+	// suits the labels with the scattered text
+	PageSegSparseText PageSegMode = 11
+)
+
+// Pass is a recognition of the image preprocessed by the stages with the
+// page segmentation mode. The image of a pass with no stages is not decoded.
+type Pass struct {
+	Stages imaging.Stages
+	Mode   PageSegMode
+}
+
+// DefaultPasses recognize the contrasted image as it is and the binarized
+// image as the sparse text, which finds the dates printed apart from the
+// other text.
+var DefaultPasses = []Pass{
+	{
+		Stages: imaging.Stages{Orient: true, Grayscale: true, Upscale: true, Stretch: true},
+		Mode:   PageSegAuto,
+	},
+	{
+		Stages: imaging.Stages{Orient: true, Grayscale: true, Upscale: true, Stretch: true, Binarize: true},
+		Mode:   PageSegSparseText,
+	},
+}
+
+// ParsePasses parses the passes separated by the semicolons. A pass is the
+// comma separated stages and the page segmentation mode after the colon,
+// such as "orient,grayscale,stretch:3;orient,binarize:11". The mode is
+// PageSegAuto if it is omitted.
+func ParsePasses(text string) ([]Pass, error) {
+	var passes []Pass
+	for _, item := range strings.Split(text, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pass := Pass{Mode: PageSegAuto}
+		stages, mode, found := strings.Cut(item, ":")
+		if found {
+			n, err := strconv.Atoi(strings.TrimSpace(mode))
+			if err != nil || n < 0 || n > 13 {
+				return nil, fmt.Errorf("invalid page segmentation mode %q", mode)
+			}
+			pass.Mode = PageSegMode(n)
+		}
+		var err error
+		if pass.Stages, err = imaging.ParseStages(stages); err != nil {
+			return nil, err
+		}
+		passes = append(passes, pass)
+	}
+	if len(passes) == 0 {
+		return nil, fmt.Errorf("no passes in %q", text)
+	}
+	return passes, nil
+}
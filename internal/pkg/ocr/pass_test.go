@@ -0,0 +1,45 @@
+package ocr
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParsePasses(t *testing.T) {
+	testCases := []struct {
+		name   string
+		text   string
+		passes []Pass
+		err    bool
+	}{
+		{
+			name: "modes",
+			text: "orient,grayscale,stretch:3; binarize:11",
+			passes: []Pass{
+				{Stages: imaging.Stages{Orient: true, Grayscale: true, Stretch: true}, Mode: PageSegAuto},
+				{Stages: imaging.Stages{Binarize: true}, Mode: PageSegSparseText},
+			},
+		},
+		{
+			name:   "default mode",
+			text:   "upscale,deskew",
+			passes: []Pass{{Stages: imaging.Stages{Upscale: true, Deskew: true}, Mode: PageSegAuto}},
+		},
+		{name: "unknown stage", text: "sharpen:3", err: true},
+		{name: "invalid mode", text: "grayscale:auto", err: true},
+		{name: "empty", text: " ; ", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			passes, err := ParsePasses(tc.text)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.passes, passes)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Package ocr shares the OCR clients between the requests. A client is not
+// safe for concurrent use, so the Pool lends each client to one request at a
+// time, queues the requests waiting for a client and rejects the requests
+// when the queue is full.
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+)
+
+var (
+	// ErrQueueFull is returned when all the clients are busy and the queue
+	// of the waiting requests is full.
+	ErrQueueFull = errors.New("ocr queue is full")
+	// ErrClosed is returned when the pool is closed.
+	ErrClosed = errors.New("ocr pool is closed")
+)
+
+// Client recognizes the text of an image.
+type Client interface {
+	SetImageFromBytes(data []byte) error
+	SetPageSegMode(mode PageSegMode) error
+	Text() (string, error)
+	// Words recognizes the words of the image in the reading order
+	Words() ([]Word, error)
+	Close() error
+}
+
+// Pool is a bounded pool of the clients.
+type Pool struct {
+	clients chan Client
+	// slots bounds the number of the requests using or waiting for a client
+	slots   chan struct{}
+	timeout time.Duration
+	passes  []Pass
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+	all     []Client
+}
+
+// NewPool creates the pool of size clients by newClient. Up to queue
+// requests wait for a client, a request fails with the context error if no
+// client is released or its work is not done within the timeout. A zero
+// timeout waits until the context is done. The pool recognizes the images
+// by the DefaultPasses, SetPasses changes them.
+func NewPool(
+	size, queue int,
+	timeout time.Duration,
+	newClient func() (Client, error),
+) (*Pool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid pool size %d", size)
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	p := &Pool{
+		clients: make(chan Client, size),
+		slots:   make(chan struct{}, size+queue),
+		timeout: timeout,
+		passes:  DefaultPasses,
+		done:    make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		client, err := newClient()
+		if err != nil {
+			_ = p.Close()
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
+		p.all = append(p.all, client)
+		p.clients <- client
+	}
+	return p, nil
+}
+
+// Do calls fn with a client of the pool which is not used by any other
+// request and returns its result. The client is returned to the pool when fn
+// returns, even if ctx is done before that, since the recognition can not be
+// interrupted. The result of fn is dropped then, so fn must not write to the
+// variables of the caller.
+func Do[T any](ctx context.Context, p *Pool, fn func(Client) (T, error)) (T, error) {
+	var zero T
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return zero, ErrClosed
+	}
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		p.mu.RUnlock()
+		return zero, ErrQueueFull
+	}
+	p.wg.Add(1)
+	p.mu.RUnlock()
+	release := func() {
+		<-p.slots
+		p.wg.Done()
+	}
+
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	var client Client
+	select {
+	case client = <-p.clients:
+	case <-ctx.Done():
+		release()
+		return zero, ctx.Err()
+	case <-p.done:
+		release()
+		return zero, ErrClosed
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		defer func() {
+			p.clients <- client
+			release()
+		}()
+		value, err := fn(client)
+		results <- result{value: value, err: err}
+	}()
+	select {
+	case r := <-results:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Text recognizes the text of the image by a client of the pool.
+func (p *Pool) Text(ctx context.Context, image []byte) (string, error) {
+	return Do(ctx, p, func(client Client) (string, error) {
+		if err := client.SetImageFromBytes(image); err != nil {
+			return "", fmt.Errorf("failed to set image: %w", err)
+		}
+		return client.Text()
+	})
+}
+
+// SetPasses sets the passes of Recognize. It must be called before the pool
+// is used.
+func (p *Pool) SetPasses(passes []Pass) {
+	p.passes = passes
+}
+
+// Recognize recognizes the words of the image once for each pass of the
+// pool, the image is preprocessed by the stages of the pass. It returns the
+// results of the passes which succeeded, and fails only if all of them fail
+// or the image can not be decoded. The boxes of a pass with no stages are in
+// the coordinates of the image as it is stored.
+func (p *Pool) Recognize(ctx context.Context, image []byte) ([]Result, error) {
+	return Do(ctx, p, func(client Client) ([]Result, error) {
+		var (
+			results []Result
+			errs    []error
+		)
+		for _, pass := range p.passes {
+			data, transform := image, imaging.Transform{}
+			if pass.Stages != (imaging.Stages{}) {
+				var err error
+				if data, transform, err = imaging.Preprocess(image, pass.Stages); err != nil {
+					return nil, fmt.Errorf("failed to preprocess image: %w", err)
+				}
+			}
+			if err := client.SetPageSegMode(pass.Mode); err != nil {
+				errs = append(errs, fmt.Errorf("failed to set page segmentation mode: %w", err))
+				continue
+			}
+			if err := client.SetImageFromBytes(data); err != nil {
+				errs = append(errs, fmt.Errorf("failed to set image: %w", err))
+				continue
+			}
+			words, err := client.Words()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for i := range words {
+				words[i].Box = transform.Rect(words[i].Box)
+			}
+			results = append(results, newResult(words))
+		}
+		if len(results) == 0 {
+			return nil, errors.Join(errs...)
+		}
+		return results, nil
+	})
+}
+
+// Close rejects the new requests, waits for the requests using the clients
+// and closes the clients.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+	p.wg.Wait()
+	var errs []error
+	for _, client := range p.all {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,187 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient returns the text of the image after the delay and counts the
+// concurrent uses.
+type fakeClient struct {
+	image  []byte
+	delay  time.Duration
+	busy   int32
+	shared *int32
+	closed bool
+	modes  []PageSegMode
+}
+
+func (c *fakeClient) SetImageFromBytes(data []byte) error {
+	if !atomic.CompareAndSwapInt32(&c.busy, 0, 1) {
+		atomic.AddInt32(c.shared, 1)
+	}
+	c.image = data
+	return nil
+}
+
+func (c *fakeClient) SetPageSegMode(mode PageSegMode) error {
+	c.modes = append(c.modes, mode)
+	return nil
+}
+
+func (c *fakeClient) Text() (string, error) {
+	defer atomic.StoreInt32(&c.busy, 0)
+	time.Sleep(c.delay)
+	return string(c.image), nil
+}
+
+func (c *fakeClient) Words() ([]Word, error) {
+	text, err := c.Text()
+	if err != nil {
+		return nil, err
+	}
+	return []Word{{Text: text, Box: image.Rect(0, 0, 10, 10)}}, nil
+}
+
+func (c *fakeClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func newPool(t *testing.T, size, queue int, timeout, delay time.Duration) (*Pool, []*fakeClient, *int32) {
+	var (
+		clients []*fakeClient
+		shared  int32
+	)
+	pool, err := NewPool(size, queue, timeout, func() (Client, error) {
+		client := &fakeClient{delay: delay, shared: &shared}
+		clients = append(clients, client)
+		return client, nil
+	})
+	assert.NoError(t, err)
+	return pool, clients, &shared
+}
+
+func Test_PoolConcurrent(t *testing.T) {
+	pool, _, shared := newPool(t, 3, 100, 0, time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			text, err := pool.Text(context.Background(), []byte(image))
+			assert.NoError(t, err)
+			assert.Equal(t, image, text)
+		}(time.Duration(i).String())
+	}
+	wg.Wait()
+	assert.Zero(t, atomic.LoadInt32(shared))
+	assert.NoError(t, pool.Close())
+}
+
+func Test_PoolRecognize(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 30))))
+	pool, clients, _ := newPool(t, 1, 0, 0, 0)
+	results, err := pool.Recognize(context.Background(), buf.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, results, len(DefaultPasses))
+	// the image is upscaled 4 times, the box is mapped back
+	assert.Equal(t, image.Rect(0, 0, 3, 3), results[0].Words[0].Box)
+	assert.Equal(t, []PageSegMode{PageSegAuto, PageSegSparseText}, clients[0].modes)
+
+	_, err = pool.Recognize(context.Background(), []byte("not an image"))
+	assert.ErrorIs(t, err, imaging.ErrUnsupportedFormat)
+	assert.NoError(t, pool.Close())
+}
+
+func Test_PoolQueueFull(t *testing.T) {
+	pool, _, _ := newPool(t, 1, 1, 0, 0)
+	started, unblock := make(chan struct{}), make(chan struct{})
+	go func() {
+		_, _ = Do(context.Background(), pool, func(Client) (struct{}, error) {
+			close(started)
+			<-unblock
+			return struct{}{}, nil
+		})
+	}()
+	<-started
+	queued := make(chan error)
+	go func() {
+		_, err := pool.Text(context.Background(), []byte("queued"))
+		queued <- err
+	}()
+	assert.Eventually(t, func() bool { return len(pool.slots) == 2 }, time.Second, time.Millisecond)
+
+	_, err := pool.Text(context.Background(), []byte("rejected"))
+	assert.ErrorIs(t, err, ErrQueueFull)
+	close(unblock)
+	assert.NoError(t, <-queued)
+	assert.NoError(t, pool.Close())
+}
+
+func Test_PoolTimeout(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+		err     error
+	}{
+		{
+			name:    "pool timeout",
+			timeout: 10 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.Background(), func() {}
+			},
+			err: context.DeadlineExceeded,
+		},
+		{
+			name: "canceled request",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			err: context.Canceled,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pool, _, _ := newPool(t, 1, 1, tc.timeout, 50*time.Millisecond)
+			ctx, cancel := tc.ctx()
+			defer cancel()
+			_, err := pool.Text(ctx, []byte("slow"))
+			assert.ErrorIs(t, err, tc.err)
+			assert.NoError(t, pool.Close())
+		})
+	}
+}
+
+func Test_PoolClose(t *testing.T) {
+	pool, clients, _ := newPool(t, 2, 0, 0, 20*time.Millisecond)
+	done := make(chan error)
+	go func() {
+		_, err := pool.Text(context.Background(), []byte("in flight"))
+		done <- err
+	}()
+	assert.Eventually(t, func() bool { return len(pool.slots) == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Close())
+	for _, client := range clients {
+		assert.True(t, client.closed)
+	}
+	assert.NoError(t, <-done)
+	_, err := pool.Text(context.Background(), []byte("late"))
+	assert.ErrorIs(t, err, ErrClosed)
+
+	_, err = NewPool(0, 0, 0, nil)
+	assert.Error(t, err)
+}
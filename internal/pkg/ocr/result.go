@@ -0,0 +1,58 @@
+package ocr
+
+import (
+	"image"
+	"strings"
+)
+
+// Word is a word recognized by a client with its bounding box.
+type Word struct {
+	Text string
+	// Box is the bounding box of the word in the image
+	Box image.Rectangle
+	// Confidence is the confidence of the recognition from 0 to 100
+	Confidence float64
+	// Line is the number of the line of the word, the words of the same
+	// line have the same number
+	Line int
+	// Start and End are the byte offsets of the word in the text of the
+	// Result
+	Start, End int
+}
+
+// Result is the text recognized by a pass. The boxes of the words are in
+// the coordinates of the original image rotated by its EXIF orientation.
+type Result struct {
+	Text  string
+	Words []Word
+}
+
+// newResult joins the words into the lines of the text.
+func newResult(words []Word) Result {
+	var text strings.Builder
+	for i := range words {
+		if i > 0 {
+			if words[i].Line != words[i-1].Line {
+				text.WriteByte('\n')
+			} else {
+				text.WriteByte(' ')
+			}
+		}
+		words[i].Start = text.Len()
+		text.WriteString(words[i].Text)
+		words[i].End = text.Len()
+	}
+	return Result{Text: text.String(), Words: words}
+}
+
+// Boxes returns the boxes of the words overlapping the byte offsets of the
+// text, the words without the boxes are skipped.
+func (r Result) Boxes(start, end int) []image.Rectangle {
+	var boxes []image.Rectangle
+	for _, w := range r.Words {
+		if w.Start < end && start < w.End && !w.Box.Empty() {
+			boxes = append(boxes, w.Box)
+		}
+	}
+	return boxes
+}
@@ -0,0 +1,82 @@
+package ocr
+
+import (
+	"fmt"
+
+	"github.com/otiai10/gosseract/v2"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+)
+
+// DefaultLanguages are the language packs of Tesseract if none are
+// configured.
+var DefaultLanguages = []string{"eng", "rus"}
+
+// newTesseractPool creates the pool of the Tesseract clients by the
+// configuration.
+func newTesseractPool(cfg *config.Config) (*Pool, error) {
+	passes := DefaultPasses
+	if cfg.OCR.Passes != "" {
+		var err error
+		if passes, err = ParsePasses(cfg.OCR.Passes); err != nil {
+			return nil, fmt.Errorf("invalid OCR_PASSES: %w", err)
+		}
+	}
+	newClient := NewTesseract(cfg.OCR.Languages...)
+	pool, err := NewPool(cfg.OCR.Workers, cfg.OCR.Queue, cfg.OCR.Timeout, newClient)
+	if err != nil {
+		return nil, err
+	}
+	pool.SetPasses(passes)
+	return pool, nil
+}
+
+// tesseract adapts the gosseract client to the Client.
+type tesseract struct {
+	*gosseract.Client
+}
+
+func (c tesseract) SetPageSegMode(mode PageSegMode) error {
+	return c.Client.SetPageSegMode(gosseract.PageSegMode(mode))
+}
+
+// Words returns the words of the image. The text is recognized once for the
+// words and their boxes, the lines are numbered by the blocks and the
+// paragraphs of the page.
+func (c tesseract) Words() ([]Word, error) {
+	boxes, err := c.Client.GetBoundingBoxesVerbose()
+	if err != nil {
+		return nil, err
+	}
+	words := make([]Word, 0, len(boxes))
+	line := 0
+	for i, b := range boxes {
+		if i > 0 {
+			prev := boxes[i-1]
+			if b.BlockNum != prev.BlockNum || b.ParNum != prev.ParNum || b.LineNum != prev.LineNum {
+				line++
+			}
+		}
+		if b.Word == "" {
+			continue
+		}
+		words = append(words, Word{Text: b.Word, Box: b.Box, Confidence: b.Confidence, Line: line})
+	}
+	return words, nil
+}
+
+// NewTesseract returns the function creating the Tesseract clients which
+// recognize the texts of the language packs, the DefaultLanguages if none
+// are given.
+func NewTesseract(languages ...string) func() (Client, error) {
+	if len(languages) == 0 {
+		languages = DefaultLanguages
+	}
+	return func() (Client, error) {
+		client := gosseract.NewClient()
+		if err := client.SetLanguage(languages...); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+		return tesseract{Client: client}, nil
+	}
+}
@@ -0,0 +1,40 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotText is returned by the Text backend when the data is not a UTF-8
+// text.
+var ErrNotText = errors.New("data is not a text")
+
+// Text is the backend of the texts already recognized by the clients, such
+// as by the OCR of a phone. The data is a UTF-8 text, its pages separated by
+// the form feeds are the results of the passes. The words have no boxes.
+type Text struct{}
+
+// Recognize implements Backend
+func (Text) Recognize(_ context.Context, data []byte) ([]Result, error) {
+	if !utf8.Valid(data) {
+		return nil, ErrNotText
+	}
+	var results []Result
+	for _, page := range strings.Split(string(data), "\f") {
+		var words []Word
+		for line, text := range strings.Split(page, "\n") {
+			for _, word := range strings.Fields(text) {
+				words = append(words, Word{Text: word, Line: line})
+			}
+		}
+		results = append(results, newResult(words))
+	}
+	return results, nil
+}
+
+// Close implements Backend
+func (Text) Close() error {
+	return nil
+}
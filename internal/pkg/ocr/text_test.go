@@ -0,0 +1,63 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Text(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    string
+		results []Result
+		err     error
+	}{
+		{
+			name: "lines",
+			data: "изг 15.09.22\n\n  годен до\t24.09.22 ",
+			results: []Result{{
+				Text: "изг 15.09.22\nгоден до 24.09.22",
+				Words: []Word{
+					{Text: "изг", Line: 0},
+					{Text: "15.09.22", Line: 0},
+					{Text: "годен", Line: 2},
+					{Text: "до", Line: 2},
+					{Text: "24.09.22", Line: 2},
+				},
+			}},
+		},
+		{
+			name: "passes",
+			data: "24.09.22\f24.O9.22",
+			results: []Result{
+				{Text: "24.09.22", Words: []Word{{Text: "24.09.22"}}},
+				{Text: "24.O9.22", Words: []Word{{Text: "24.O9.22"}}},
+			},
+		},
+		{
+			name: "not text",
+			data: "\x89PNG\r\n\x1a\n\xff",
+			err:  ErrNotText,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := Text{}.Recognize(context.Background(), []byte(tc.data))
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.results, withoutOffsets(results))
+		})
+	}
+}
+
+// withoutOffsets clears the offsets of the words in the text, they are set
+// by newResult and not by the backends.
+func withoutOffsets(results []Result) []Result {
+	for _, r := range results {
+		for i := range r.Words {
+			r.Words[i].Start, r.Words[i].End = 0, 0
+		}
+	}
+	return results
+}
@@ -0,0 +1,350 @@
+// Package openfoodfacts streams the products of the Open Food Facts dumps. The
+// JSONL and the tab separated CSV exports are supported, plain or gzipped.
+// Only one record is held in memory at a time.
+//
+// Example usage:
+//
+//	dump, err := openfoodfacts.Open("products.jsonl.gz", "ru")
+//	if err != nil {
+//	    return err
+//	}
+//	defer dump.Close()
+//	for {
+//	    product, err := dump.Next()
+//	    if errors.Is(err, io.EOF) {
+//	        break
+//	    }
+//	    ...
+//	}
+package openfoodfacts
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The formats of the dumps.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// MaxRecordSize is the largest JSONL line read, the longer lines are skipped
+// as invalid records.
+const MaxRecordSize = 16 << 20
+
+var (
+	// ErrInvalidRecord is returned for a record which can not be parsed, the
+	// reading can be continued.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrUnknownFormat is returned when the format of the dump is not given
+	// and can not be guessed by the file name.
+	ErrUnknownFormat = errors.New("unknown dump format")
+)
+
+// Product is a product of the dump.
+type Product struct {
+	// Code is the barcode as it is in the dump
+	Code string
+	// Name is the name in the requested language or the generic one
+	Name string
+	// Categories are the category tags, such as "en:dairies"
+	Categories []string
+	// Nutrition is nil when the dump has no energy nor macronutrients
+	Nutrition *Nutrition
+}
+
+// Nutrition is the nutrition facts per 100 g.
+type Nutrition struct {
+	Energy        float64
+	Protein       float64
+	Fat           float64
+	Carbohydrates float64
+}
+
+// Reader reads the products of a dump one by one.
+type Reader interface {
+	// Next returns the next product, io.EOF at the end of the dump. An error
+	// wrapping ErrInvalidRecord is returned for a malformed record, the
+	// reading can be continued.
+	Next() (Product, error)
+	// Position is the number of the records read, the invalid ones included.
+	Position() int64
+}
+
+// Dump is an opened dump file.
+type Dump struct {
+	Reader
+	file    *os.File
+	counter *counter
+	gzip    *gzip.Reader
+	// Size is the size of the file in bytes
+	Size int64
+}
+
+// Open opens the dump, the format is guessed by the file name. The names are
+// taken in the language when present.
+func Open(path, lang string) (*Dump, error) {
+	format, err := Format(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenFormat(path, format, lang)
+}
+
+// OpenFormat opens the dump of the format. The gzipped files are detected by
+// the content.
+func OpenFormat(path, format, lang string) (*Dump, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dump: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat dump: %w", err)
+	}
+	dump := &Dump{file: file, counter: &counter{r: file}, Size: info.Size()}
+	buffered := bufio.NewReaderSize(dump.counter, 1<<20)
+	var r io.Reader = buffered
+	if magic, _ := buffered.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		dump.gzip, err = gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to open gzipped dump: %w", err)
+		}
+		r = dump.gzip
+	}
+	dump.Reader, err = NewReader(r, format, lang)
+	if err != nil {
+		dump.Close()
+		return nil, err
+	}
+	return dump, nil
+}
+
+// Offset returns the number of the bytes of the file read.
+func (d *Dump) Offset() int64 {
+	return d.counter.n
+}
+
+// Close closes the file.
+func (d *Dump) Close() error {
+	if d.gzip != nil {
+		d.gzip.Close()
+	}
+	return d.file.Close()
+}
+
+// Format guesses the format of the dump by the file name.
+func Format(path string) (string, error) {
+	name := strings.TrimSuffix(strings.ToLower(path), ".gz")
+	switch {
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".json"):
+		return FormatJSONL, nil
+	case strings.HasSuffix(name, ".csv"), strings.HasSuffix(name, ".tsv"):
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+// NewReader returns the reader of the dump of the format.
+func NewReader(r io.Reader, format, lang string) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{r: bufio.NewReaderSize(r, 1<<16), lang: lang}, nil
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.Comma = '\t'
+		reader.LazyQuotes = true
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+		return &csvReader{r: reader, lang: lang}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+type jsonlReader struct {
+	r        *bufio.Reader
+	lang     string
+	position int64
+	line     []byte
+}
+
+// errTooLong is returned by readLine for a line longer than MaxRecordSize.
+var errTooLong = fmt.Errorf("longer than %d bytes", MaxRecordSize)
+
+// Next implements Reader
+func (r *jsonlReader) Next() (Product, error) {
+	for {
+		line, err := r.readLine()
+		if errors.Is(err, errTooLong) {
+			r.position++
+			return Product{}, fmt.Errorf("%w %d: %v", ErrInvalidRecord, r.position, err)
+		}
+		if err != nil {
+			return Product{}, err
+		}
+		r.position++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return Product{}, fmt.Errorf("%w %d: %v", ErrInvalidRecord, r.position, err)
+		}
+		var (
+			product    Product
+			nutriments map[string]json.RawMessage
+			localized  string
+		)
+		// the fields of unexpected types are left empty
+		_ = json.Unmarshal(fields["code"], &product.Code)
+		_ = json.Unmarshal(fields["product_name"], &product.Name)
+		_ = json.Unmarshal(fields["categories_tags"], &product.Categories)
+		_ = json.Unmarshal(fields["nutriments"], &nutriments)
+		if r.lang != "" {
+			_ = json.Unmarshal(fields["product_name_"+r.lang], &localized)
+		}
+		if localized = strings.TrimSpace(localized); localized != "" {
+			product.Name = localized
+		}
+		product.Name = strings.TrimSpace(product.Name)
+		product.Nutrition = nutrition(func(key string) string {
+			return strings.Trim(string(nutriments[key]), `"`)
+		})
+		return product, nil
+	}
+}
+
+// readLine reads the next line. The lines longer than MaxRecordSize are read
+// through and errTooLong is returned.
+func (r *jsonlReader) readLine() ([]byte, error) {
+	r.line = r.line[:0]
+	tooLong := false
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if !tooLong && len(r.line)+len(chunk) > MaxRecordSize {
+			tooLong, r.line = true, r.line[:0]
+		}
+		if !tooLong {
+			r.line = append(r.line, chunk...)
+		}
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(r.line) == 0 && !tooLong:
+			return nil, io.EOF
+		case err != nil && !errors.Is(err, io.EOF):
+			return nil, err
+		}
+		if tooLong {
+			return nil, errTooLong
+		}
+		return r.line, nil
+	}
+}
+
+// Position implements Reader
+func (r *jsonlReader) Position() int64 {
+	return r.position
+}
+
+type csvReader struct {
+	r        *csv.Reader
+	lang     string
+	position int64
+	columns  map[string]int
+}
+
+// Next implements Reader
+func (r *csvReader) Next() (Product, error) {
+	if r.columns == nil {
+		header, err := r.r.Read()
+		if err != nil {
+			return Product{}, err
+		}
+		r.columns = make(map[string]int, len(header))
+		for i, name := range header {
+			r.columns[strings.TrimSpace(name)] = i
+		}
+	}
+	row, err := r.r.Read()
+	if errors.Is(err, io.EOF) {
+		return Product{}, err
+	}
+	r.position++
+	if err != nil {
+		return Product{}, fmt.Errorf("%w %d: %v", ErrInvalidRecord, r.position, err)
+	}
+	field := func(name string) string {
+		i, ok := r.columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	product := Product{
+		Code:      field("code"),
+		Name:      field("product_name"),
+		Nutrition: nutrition(field),
+	}
+	if name := field("product_name_" + r.lang); r.lang != "" && name != "" {
+		product.Name = name
+	}
+	for _, tag := range strings.Split(field("categories_tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			product.Categories = append(product.Categories, tag)
+		}
+	}
+	return product, nil
+}
+
+// Position implements Reader
+func (r *csvReader) Position() int64 {
+	return r.position
+}
+
+// nutrition reads the nutriments per 100 g by the value of the field, nil is
+// returned when none is present.
+func nutrition(field func(key string) string) *Nutrition {
+	var (
+		result Nutrition
+		found  bool
+	)
+	for key, value := range map[string]*float64{
+		"energy-kcal_100g":   &result.Energy,
+		"proteins_100g":      &result.Protein,
+		"fat_100g":           &result.Fat,
+		"carbohydrates_100g": &result.Carbohydrates,
+	} {
+		if v, err := strconv.ParseFloat(field(key), 64); err == nil {
+			*value, found = v, true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &result
+}
+
+// counter counts the bytes read.
+type counter struct {
+	r io.Reader
+	n int64
+}
+
+func (c *counter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
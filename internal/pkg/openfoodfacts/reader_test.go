@@ -0,0 +1,102 @@
+package openfoodfacts
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readAll reads the products and the positions of the invalid records.
+func readAll(t *testing.T, r Reader) ([]Product, []int64) {
+	var (
+		products []Product
+		invalid  []int64
+	)
+	for {
+		product, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return products, invalid
+		}
+		if errors.Is(err, ErrInvalidRecord) {
+			invalid = append(invalid, r.Position())
+			continue
+		}
+		assert.NoError(t, err)
+		products = append(products, product)
+	}
+}
+
+func Test_Reader(t *testing.T) {
+	milk := Product{
+		Code:       "4600680001418",
+		Name:       "Молоко",
+		Categories: []string{"en:dairies", "en:milks"},
+		Nutrition:  &Nutrition{Energy: 60, Protein: 3, Fat: 3.2, Carbohydrates: 4.7},
+	}
+	testCases := []struct {
+		name     string
+		format   string
+		data     string
+		products []Product
+		invalid  []int64
+	}{
+		{
+			name:   "jsonl",
+			format: FormatJSONL,
+			data: `{"code":"4600680001418","product_name":"Milk","product_name_ru":"Молоко",` +
+				`"categories_tags":["en:dairies","en:milks"],"nutriments":{"energy-kcal_100g":60,` +
+				`"proteins_100g":"3","fat_100g":3.2,"carbohydrates_100g":4.7,"salt_100g":0.1}}
+{"code":"1", broken
+
+{"code":"2","product_name":" Salt ","categories_tags":null,"nutriments":{"energy-kcal_100g":"n/a"}}`,
+			products: []Product{milk, {Code: "2", Name: "Salt"}},
+			invalid:  []int64{2},
+		},
+		{
+			name:   "csv",
+			format: FormatCSV,
+			data: "code\tproduct_name\tproduct_name_ru\tcategories_tags\tenergy-kcal_100g\tproteins_100g\tfat_100g\tcarbohydrates_100g\n" +
+				"4600680001418\tMilk\tМолоко\ten:dairies,en:milks\t60\t3\t3.2\t4.7\n" +
+				"2\tSalt\t\t\t\t\t\t\n",
+			products: []Product{milk, {Code: "2", Name: "Salt"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(tc.data), tc.format, "ru")
+			assert.NoError(t, err)
+			products, invalid := readAll(t, r)
+			assert.Equal(t, tc.products, products)
+			assert.Equal(t, tc.invalid, invalid)
+		})
+	}
+}
+
+func Test_Open(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "products.jsonl.gz")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	w := gzip.NewWriter(file)
+	_, err = w.Write([]byte(`{"code":"1","product_name":"Соль"}` + "\n" + `{"code":"2","product_name":"Сахар"}` + "\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, file.Close())
+
+	dump, err := Open(path, "")
+	assert.NoError(t, err)
+	defer dump.Close()
+	products, invalid := readAll(t, dump)
+	assert.Empty(t, invalid)
+	assert.Equal(t, []Product{{Code: "1", Name: "Соль"}, {Code: "2", Name: "Сахар"}}, products)
+	assert.Equal(t, int64(2), dump.Position())
+	assert.Equal(t, dump.Size, dump.Offset())
+
+	_, err = Open("products.xml", "")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
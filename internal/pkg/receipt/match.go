@@ -0,0 +1,107 @@
+package receipt
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/pkg/ingredient"
+)
+
+const (
+	// minScore is the smallest share of the stems of a product name found in
+	// a line for the product to match
+	minScore = 0.5
+	// minPrefix is the length of the shortest abbreviation
+	minPrefix = 3
+	// minTypo is the length of the shortest word which may have a typo
+	minTypo = 5
+)
+
+// Words returns the stems of the line which are long enough to look up the
+// products by, the longest first.
+func Words(line string) []string {
+	var words []string
+	for _, stem := range ingredient.Stems(line) {
+		if len([]rune(stem)) >= minPrefix {
+			words = append(words, stem)
+		}
+	}
+	sort.SliceStable(words, func(i, j int) bool {
+		return len([]rune(words[i])) > len([]rune(words[j]))
+	})
+	return words
+}
+
+// Match returns the index of the candidate product name which matches the
+// line of a receipt best and its score, the share of the stems of the name
+// found in the line, or -1. The lines are abbreviated, so a stem matches a
+// word which starts with it or which it starts with, and a long stem matches
+// a word with one typo. The candidate with the most matched stems wins, so
+// "молоко пастеризованное" is preferred to "молоко".
+func Match(line string, candidates []string) (int, float64) {
+	words := Words(line)
+	best, matched, score := -1, 0, 0.0
+	for i, candidate := range candidates {
+		stems := ingredient.Stems(candidate)
+		if len(stems) == 0 {
+			continue
+		}
+		n := 0
+		for _, stem := range stems {
+			for _, word := range words {
+				if similar(stem, word) {
+					n++
+					break
+				}
+			}
+		}
+		s := float64(n) / float64(len(stems))
+		if s < minScore || n < matched || n == matched && s <= score {
+			continue
+		}
+		best, matched, score = i, n, s
+	}
+	return best, score
+}
+
+// similar reports whether the stem matches the word of a line.
+func similar(stem, word string) bool {
+	if stem == word {
+		return true
+	}
+	a, b := []rune(stem), []rune(word)
+	if len(a) < minPrefix || len(b) < minPrefix {
+		return false
+	}
+	if strings.HasPrefix(stem, word) || strings.HasPrefix(word, stem) {
+		return true
+	}
+	return len(a) >= minTypo && len(b) >= minTypo && distance(a, b) <= 1
+}
+
+// distance returns the Levenshtein distance of the words.
+func distance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
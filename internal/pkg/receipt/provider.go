@@ -0,0 +1,46 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ReceiptProvider finds the receipts by their fiscal parameters, such as the
+// receipt check service of the tax service.
+type ReceiptProvider interface {
+	Receipt(ctx context.Context, fiscal Fiscal) (Receipt, error)
+}
+
+// FileProvider finds the receipts in a directory. A receipt is stored in the
+// JSON format of the tax service in the file named "<fn>_<i>_<fp>.json".
+type FileProvider struct {
+	dir string
+}
+
+func NewFileProvider(dir string) *FileProvider {
+	return &FileProvider{dir: dir}
+}
+
+// Receipt implements ReceiptProvider
+func (p *FileProvider) Receipt(ctx context.Context, fiscal Fiscal) (Receipt, error) {
+	name := fmt.Sprintf("%s_%s_%s.json", fiscal.FN, fiscal.FD, fiscal.FP)
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Receipt{}, fmt.Errorf("%w: %s", ErrNotFound, fiscal.Key())
+	}
+	if err != nil {
+		return Receipt{}, fmt.Errorf("read receipt: %w", err)
+	}
+	result, err := Parse(data)
+	if err != nil {
+		return Receipt{}, err
+	}
+	// the QR code is the source of the fiscal parameters, the document may
+	// have not all of them
+	result.Fiscal = fiscal
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// DecodeQR finds the QR code of a receipt in the image and parses it.
+func DecodeQR(img image.Image) (Fiscal, error) {
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return Fiscal{}, fmt.Errorf("%w: %v", ErrNoQR, err)
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, hints)
+	var notFound gozxing.NotFoundException
+	if errors.As(err, &notFound) {
+		return Fiscal{}, ErrNoQR
+	}
+	if err != nil {
+		return Fiscal{}, fmt.Errorf("%w: %v", ErrInvalidQR, err)
+	}
+	return ParseQR(result.GetText())
+}
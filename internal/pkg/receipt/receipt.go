@@ -0,0 +1,232 @@
+// Package receipt parses the QR codes and the documents of the Russian fiscal
+// receipts. The QR code holds the fiscal parameters of the receipt, the items
+// are requested from a ReceiptProvider by these parameters.
+package receipt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidQR is returned when the text of the QR code has no valid
+	// fiscal parameters.
+	ErrInvalidQR = errors.New("invalid receipt QR code")
+	// ErrInvalidReceipt is returned when the receipt document can not be
+	// parsed.
+	ErrInvalidReceipt = errors.New("invalid receipt")
+	// ErrNoQR is returned when no QR code is found in the image.
+	ErrNoQR = errors.New("receipt QR code not found")
+	// ErrNotFound is returned when the provider has no receipt with the
+	// fiscal parameters.
+	ErrNotFound = errors.New("receipt not found")
+)
+
+// qrTimeLayouts are the layouts of the time of the QR code, with and without
+// the seconds.
+var qrTimeLayouts = []string{"20060102T150405", "20060102T1504"}
+
+// Fiscal are the fiscal parameters of a receipt printed in its QR code.
+type Fiscal struct {
+	// Time is the local time of the receipt
+	Time time.Time
+	// Sum is the total sum in kopecks
+	Sum int64
+	// FN is the number of the fiscal drive
+	FN string
+	// FD is the number of the fiscal document
+	FD string
+	// FP is the fiscal sign of the document
+	FP string
+	// Operation is the type of the operation, 1 is a purchase
+	Operation int
+}
+
+// Key returns the identifier of the receipt, the fiscal document number is
+// unique for the fiscal drive.
+func (f Fiscal) Key() string {
+	return f.FN + "-" + f.FD + "-" + f.FP
+}
+
+// String returns the text of the QR code of the receipt.
+func (f Fiscal) String() string {
+	return fmt.Sprintf("t=%s&s=%d.%02d&fn=%s&i=%s&fp=%s&n=%d",
+		f.Time.Format("20060102T1504"), f.Sum/100, f.Sum%100, f.FN, f.FD, f.FP, f.Operation)
+}
+
+// ParseQR parses the text of the QR code of a receipt, such as
+// "t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1".
+func ParseQR(text string) (Fiscal, error) {
+	values, err := url.ParseQuery(strings.TrimSpace(text))
+	if err != nil {
+		return Fiscal{}, fmt.Errorf("%w: %v", ErrInvalidQR, err)
+	}
+	var f Fiscal
+	for _, layout := range qrTimeLayouts {
+		if f.Time, err = time.Parse(layout, values.Get("t")); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Fiscal{}, fmt.Errorf("%w: time %q", ErrInvalidQR, values.Get("t"))
+	}
+	sum, err := strconv.ParseFloat(values.Get("s"), 64)
+	if err != nil || sum < 0 {
+		return Fiscal{}, fmt.Errorf("%w: sum %q", ErrInvalidQR, values.Get("s"))
+	}
+	f.Sum = int64(math.Round(sum * 100))
+	f.FN, f.FD, f.FP = values.Get("fn"), values.Get("i"), values.Get("fp")
+	for name, value := range map[string]string{"fn": f.FN, "i": f.FD, "fp": f.FP} {
+		if !digits(value) {
+			return Fiscal{}, fmt.Errorf("%w: %s %q", ErrInvalidQR, name, value)
+		}
+	}
+	f.Operation = 1
+	if n := values.Get("n"); n != "" {
+		if f.Operation, err = strconv.Atoi(n); err != nil {
+			return Fiscal{}, fmt.Errorf("%w: operation %q", ErrInvalidQR, n)
+		}
+	}
+	return f, nil
+}
+
+func digits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Receipt is a fiscal receipt with its items.
+type Receipt struct {
+	Fiscal Fiscal
+	Items  []Item
+}
+
+// Item is a line of a receipt.
+type Item struct {
+	Name string
+	// Price is the price of the unit in kopecks
+	Price int64
+	// Quantity is the number of the units
+	Quantity float64
+	// Sum is the cost of the line in kopecks
+	Sum int64
+	// Unit is the measure of the quantity, empty if it is not known
+	Unit string
+}
+
+// units are the codes of the measures of the items by the fiscal data
+// format 1.2.
+var units = map[int]string{
+	0:  "шт",
+	10: "г",
+	11: "кг",
+	40: "мл",
+	41: "л",
+}
+
+// document is the receipt in the JSON format of the tax service.
+type document struct {
+	DateTime json.RawMessage `json:"dateTime"`
+	TotalSum int64           `json:"totalSum"`
+	FN       string          `json:"fiscalDriveNumber"`
+	FD       json.Number     `json:"fiscalDocumentNumber"`
+	FP       json.Number     `json:"fiscalSign"`
+	Items    []struct {
+		Name            string  `json:"name"`
+		Price           int64   `json:"price"`
+		Quantity        float64 `json:"quantity"`
+		Sum             int64   `json:"sum"`
+		MeasurementUnit string  `json:"measurementUnit"`
+		QuantityMeasure *int    `json:"itemsQuantityMeasure"`
+	} `json:"items"`
+	Document *struct {
+		Receipt *document `json:"receipt"`
+	} `json:"document"`
+	Ticket *struct {
+		Document *struct {
+			Receipt *document `json:"receipt"`
+		} `json:"document"`
+	} `json:"ticket"`
+}
+
+// receipt returns the receipt of the document, which may be wrapped as by
+// the tax service application.
+func (d *document) receipt() *document {
+	switch {
+	case d.Document != nil && d.Document.Receipt != nil:
+		return d.Document.Receipt
+	case d.Ticket != nil && d.Ticket.Document != nil && d.Ticket.Document.Receipt != nil:
+		return d.Ticket.Document.Receipt
+	}
+	return d
+}
+
+// Parse parses the receipt in the JSON format of the tax service, the receipt
+// may be wrapped in the "document" or the "ticket" objects. The time is
+// either the local time or the Unix time.
+func Parse(data []byte) (Receipt, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc document
+	if err := dec.Decode(&doc); err != nil {
+		return Receipt{}, fmt.Errorf("%w: %v", ErrInvalidReceipt, err)
+	}
+	d := doc.receipt()
+	result := Receipt{
+		Fiscal: Fiscal{
+			Sum:       d.TotalSum,
+			FN:        strings.TrimSpace(d.FN),
+			FD:        d.FD.String(),
+			FP:        d.FP.String(),
+			Operation: 1,
+		},
+		Items: make([]Item, 0, len(d.Items)),
+	}
+	var (
+		local string
+		unix  int64
+	)
+	if err := json.Unmarshal(d.DateTime, &local); err == nil {
+		t, err := time.Parse("2006-01-02T15:04:05", local)
+		if err != nil {
+			return Receipt{}, fmt.Errorf("%w: time %q", ErrInvalidReceipt, local)
+		}
+		result.Fiscal.Time = t
+	} else if err := json.Unmarshal(d.DateTime, &unix); err == nil {
+		result.Fiscal.Time = time.Unix(unix, 0).UTC()
+	} else {
+		return Receipt{}, fmt.Errorf("%w: time %s", ErrInvalidReceipt, d.DateTime)
+	}
+	for _, item := range d.Items {
+		name := strings.Join(strings.Fields(item.Name), " ")
+		if name == "" {
+			continue
+		}
+		unit := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(item.MeasurementUnit)), ".")
+		if item.QuantityMeasure != nil {
+			unit = units[*item.QuantityMeasure]
+		}
+		result.Items = append(result.Items, Item{
+			Name:     name,
+			Price:    item.Price,
+			Quantity: item.Quantity,
+			Sum:      item.Sum,
+			Unit:     unit,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,230 @@
+package receipt
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+var fiscal = Fiscal{
+	Time:      time.Date(2023, 9, 15, 18, 30, 0, 0, time.UTC),
+	Sum:       123450,
+	FN:        "9960440300000000",
+	FD:        "12345",
+	FP:        "1234567890",
+	Operation: 1,
+}
+
+func Test_ParseQR(t *testing.T) {
+	testCases := []struct {
+		name   string
+		text   string
+		fiscal Fiscal
+		err    error
+	}{
+		{
+			name:   "valid",
+			text:   "t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1",
+			fiscal: fiscal,
+		},
+		{
+			name:   "seconds and no operation",
+			text:   " t=20230915T183000&s=1234.5&fn=9960440300000000&i=12345&fp=1234567890\n",
+			fiscal: fiscal,
+		},
+		{
+			name: "invalid time",
+			text: "t=2023-09-15&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1",
+			err:  ErrInvalidQR,
+		},
+		{
+			name: "no fiscal sign",
+			text: "t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&n=1",
+			err:  ErrInvalidQR,
+		},
+		{
+			name: "not a receipt",
+			text: "https://example.com",
+			err:  ErrInvalidQR,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ParseQR(tc.text)
+			assert.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.fiscal, result)
+		})
+	}
+	assert.Equal(t,
+		"t=20230915T1830&s=1234.50&fn=9960440300000000&i=12345&fp=1234567890&n=1",
+		fiscal.String(),
+	)
+}
+
+const receiptJSON = `{
+	"dateTime": "2023-09-15T18:30:00",
+	"totalSum": 123450,
+	"fiscalDriveNumber": "9960440300000000",
+	"fiscalDocumentNumber": 12345,
+	"fiscalSign": 1234567890,
+	"items": [
+		{"name": "МОЛОКО ПРОСТОКВАШИНО  ПАСТ.3,2% 930МЛ", "price": 8990, "quantity": 2, "sum": 17980},
+		{"name": "БАНАНЫ ВЕС", "price": 12990, "quantity": 1.25, "sum": 16238, "itemsQuantityMeasure": 11},
+		{"name": " ", "price": 100, "quantity": 1, "sum": 100}
+	]
+}`
+
+func Test_Parse(t *testing.T) {
+	items := []Item{
+		{Name: "МОЛОКО ПРОСТОКВАШИНО ПАСТ.3,2% 930МЛ", Price: 8990, Quantity: 2, Sum: 17980},
+		{Name: "БАНАНЫ ВЕС", Price: 12990, Quantity: 1.25, Sum: 16238, Unit: "кг"},
+	}
+	for name, data := range map[string]string{
+		"receipt": receiptJSON,
+		"ticket":  `{"ticket":{"document":{"receipt":` + receiptJSON + `}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			result, err := Parse([]byte(data))
+			assert.NoError(t, err)
+			assert.Equal(t, fiscal, result.Fiscal)
+			assert.Equal(t, items, result.Items)
+		})
+	}
+	_, err := Parse([]byte(`{"dateTime": true}`))
+	assert.ErrorIs(t, err, ErrInvalidReceipt)
+}
+
+func Test_FileProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "9960440300000000_12345_1234567890.json")
+	assert.NoError(t, os.WriteFile(path, []byte(receiptJSON), 0o600))
+	provider := NewFileProvider(dir)
+
+	result, err := provider.Receipt(context.Background(), fiscal)
+	assert.NoError(t, err)
+	assert.Equal(t, fiscal, result.Fiscal)
+	assert.Len(t, result.Items, 2)
+
+	other := fiscal
+	other.FD = "1"
+	_, err = provider.Receipt(context.Background(), other)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_Match(t *testing.T) {
+	candidates := []string{"Молоко", "Молоко пастеризованное", "Бананы", "Хлеб бородинский", "Паста"}
+	testCases := []struct {
+		line  string
+		index int
+	}{
+		{line: "МОЛОКО ПРОСТОКВАШИНО ПАСТ.3,2% 930МЛ", index: 1},
+		{line: "МОЛ. ПРОСТОКВАШИНО 930МЛ", index: 0},
+		{line: "БАНАНЫ ВЕС", index: 2},
+		{line: "ХЛЕБ БОРОДИНСКЙ 400Г", index: 3},
+		{line: "ПАКЕТ МАЙКА", index: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			index, score := Match(tc.line, candidates)
+			assert.Equal(t, tc.index, index)
+			if index >= 0 {
+				assert.GreaterOrEqual(t, score, minScore)
+			}
+		})
+	}
+}
+
+// render draws the QR code with a quiet zone on a white image.
+func render(t *testing.T, text string) image.Image {
+	matrix, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 300, 300, nil)
+	assert.NoError(t, err)
+	img := image.NewGray(image.Rect(0, 0, matrix.GetWidth(), matrix.GetHeight()))
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if matrix.Get(x, y) {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img
+}
+
+func Test_DecodeQR(t *testing.T) {
+	result, err := DecodeQR(render(t, fiscal.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, fiscal, result)
+
+	_, err = DecodeQR(render(t, "https://example.com"))
+	assert.ErrorIs(t, err, ErrInvalidQR)
+
+	blank := image.NewGray(image.Rect(0, 0, 100, 100))
+	_, err = DecodeQR(blank)
+	assert.ErrorIs(t, err, ErrNoQR)
+}
+
+func Test_ParseText(t *testing.T) {
+	testCases := []struct {
+		name  string
+		text  string
+		time  time.Time
+		items []Item
+	}{
+		{
+			name: "one line items",
+			text: `ООО "РОМАШКА"
+КАССОВЫЙ ЧЕК ПРИХОД
+1. МОЛОКО ПАСТ. 3,2% 930МЛ    89,90
+2. ХЛЕБ БОРОДИНСКИЙ   =45.5O
+ЧЕСНОК 1 ШТ 19.90
+НДС 10%        13.04
+ИТОГ          =155.30
+НАЛИЧНЫМИ 200.00
+15.09.23 18:30`,
+			time: time.Date(2023, 9, 15, 18, 30, 0, 0, time.UTC),
+			items: []Item{
+				{Name: "МОЛОКО ПАСТ. 3,2% 930МЛ", Price: 8990, Quantity: 1, Sum: 8990},
+				{Name: "ХЛЕБ БОРОДИНСКИЙ", Price: 4550, Quantity: 1, Sum: 4550},
+				{Name: "ЧЕСНОК 1 ШТ", Price: 1990, Quantity: 1, Sum: 1990},
+			},
+		},
+		{
+			name: "wrapped names with quantities",
+			text: `Кассир Иванова И.
+15.09.2023 9:05 Смена 12
+МОЛОКО ПРОСТОКВАШИНО
+ПАСТЕРИЗОВАННОЕ 3,2%
+2 x 89,90 = 179,80
+БАНАНЫ
+1,250 кг х 129,90
+162,38
+ЯЙЦО С0 10 шт * 99.90
+Итого: 441.18`,
+			time: time.Date(2023, 9, 15, 9, 5, 0, 0, time.UTC),
+			items: []Item{
+				{Name: "МОЛОКО ПРОСТОКВАШИНО ПАСТЕРИЗОВАННОЕ 3,2%", Price: 8990, Quantity: 2, Sum: 17980},
+				{Name: "БАНАНЫ", Price: 12990, Quantity: 1.25, Sum: 16238, Unit: "кг"},
+				{Name: "ЯЙЦО С0", Price: 9990, Quantity: 10, Sum: 99900, Unit: "шт"},
+			},
+		},
+		{
+			name: "no items",
+			text: "Добро пожаловать!\n\nСпасибо за покупку",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseText(tc.text)
+			assert.Equal(t, tc.time, result.Fiscal.Time)
+			assert.Equal(t, tc.items, result.Items)
+		})
+	}
+}
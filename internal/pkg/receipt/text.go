@@ -0,0 +1,204 @@
+package receipt
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxNameLines is the number of the lines a name of an item may be wrapped
+// onto.
+const maxNameLines = 2
+
+var (
+	// reQuantity matches the quantity, the price and the optional sum of an
+	// item, such as "2 x 89,90 = 179,80" or "1,250 кг * 129.90".
+	reQuantity = regexp.MustCompile(
+		`(?i)(\d+(?:[.,]\d+)?)\s*(шт|кг|г|л|мл)?\.?\s*[xх*×]\s*(\d+[.,]\d{2})(?:\s*=?\s*(\d+[.,]\d{2}))?\s*$`,
+	)
+	// reAmount matches the amount at the end of a line.
+	reAmount = regexp.MustCompile(`(?:^|\s)=?\s*(\d+[.,]\d{2})\s*$`)
+	// reIndex matches the number of an item at the start of a line followed
+	// by its name.
+	reIndex = regexp.MustCompile(`^\d{1,3}[.)]\s*(\pL)`)
+	// reDateTime matches the time of a receipt, such as "15.09.23 18:30".
+	reDateTime = regexp.MustCompile(
+		`\b(0[1-9]|[12]\d|3[01])[.\-/](0[1-9]|1[0-2])[.\-/](\d{4}|\d{2})\s+([01]?\d|2[0-3]):([0-5]\d)\b`,
+	)
+	// totals are the prefixes of the lines after the items.
+	totals = []string{"итог", "всего", "к оплате", "сумма по чеку"}
+	// services are the words of the lines which are not items.
+	services = map[string]bool{
+		"кассир": true, "смена": true, "инн": true, "ндс": true, "кассовый": true,
+		"чек": true, "приход": true, "возврат": true, "фн": true, "фд": true,
+		"фп": true, "ккт": true, "сно": true, "фнс": true, "скидка": true,
+		"сайт": true, "расчетов": true, "пожаловать": true,
+	}
+)
+
+// ParseText parses the text recognized on a paper receipt. The items are
+// either one line with the name and the sum, or the name wrapped onto the
+// lines followed by the quantity, the price and the sum. The lines after the
+// total have no items. The time of the receipt is set if it is found.
+func ParseText(text string) Receipt {
+	var (
+		result Receipt
+		name   []string
+		last   *Item
+		total  bool
+	)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		lower := strings.ToLower(line)
+		if result.Fiscal.Time.IsZero() {
+			if t, ok := parseDateTime(line); ok {
+				result.Fiscal.Time = t
+			}
+		}
+		if total = total || hasPrefix(lower, totals); total {
+			continue
+		}
+		if line == "" || service(lower) {
+			name, last = nil, nil
+			continue
+		}
+		line = reIndex.ReplaceAllString(digitLike(line), "$1")
+		if m := reQuantity.FindStringSubmatchIndex(line); m != nil {
+			item := Item{
+				Name:     join(name, line[:m[0]]),
+				Quantity: number(line[m[2]:m[3]]),
+				Price:    kopecks(line[m[6]:m[7]]),
+			}
+			if m[4] >= 0 {
+				item.Unit = strings.ToLower(line[m[4]:m[5]])
+			}
+			if m[8] >= 0 {
+				item.Sum = kopecks(line[m[8]:m[9]])
+			} else {
+				item.Sum = int64(math.Round(item.Quantity * float64(item.Price)))
+			}
+			name, last = nil, nil
+			if item.Name != "" && item.Quantity > 0 {
+				result.Items = append(result.Items, item)
+				last = &result.Items[len(result.Items)-1]
+			}
+			continue
+		}
+		if m := reAmount.FindStringSubmatchIndex(line); m != nil {
+			amount := kopecks(line[m[2]:m[3]])
+			switch prefix := line[:m[0]]; {
+			case letters(prefix) || len(name) > 0:
+				result.Items = append(result.Items, Item{
+					Name:     join(name, prefix),
+					Quantity: 1,
+					Price:    amount,
+					Sum:      amount,
+				})
+			case last != nil:
+				// the sum of the item printed under its quantity
+				last.Sum = amount
+			}
+			name, last = nil, nil
+			continue
+		}
+		if letters(line) {
+			name = append(name, line)
+			if len(name) > maxNameLines {
+				name = name[1:]
+			}
+		}
+	}
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Unit == "" && item.Quantity != math.Trunc(item.Quantity) {
+			item.Unit = "кг"
+		}
+	}
+	return result
+}
+
+// parseDateTime returns the time found in the line.
+func parseDateTime(line string) (time.Time, bool) {
+	m := reDateTime.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}, false
+	}
+	year := m[3]
+	if len(year) == 2 {
+		year = "20" + year
+	}
+	hour := m[4]
+	if len(hour) == 1 {
+		hour = "0" + hour
+	}
+	t, err := time.Parse("02.01.2006 15:04", m[1]+"."+m[2]+"."+year+" "+hour+":"+m[5])
+	return t, err == nil
+}
+
+// digitLike replaces the letters O recognized instead of zeros in the
+// numbers of the line.
+func digitLike(line string) string {
+	words := strings.Split(line, " ")
+	for i, word := range words {
+		if strings.IndexFunc(word, unicode.IsDigit) < 0 {
+			continue
+		}
+		if strings.Trim(word, "0123456789.,=OoОо") == "" {
+			words[i] = strings.NewReplacer("O", "0", "o", "0", "О", "0", "о", "0").Replace(word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// join returns the name of the item wrapped onto the lines.
+func join(lines []string, rest string) string {
+	rest = strings.Trim(rest, " =*-")
+	if rest != "" && letters(rest) {
+		lines = append(lines, rest)
+	}
+	return strings.Join(lines, " ")
+}
+
+// letters reports whether the text has at least two letters.
+func letters(text string) bool {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n >= 2
+}
+
+func number(s string) float64 {
+	n, _ := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	return n
+}
+
+func kopecks(s string) int64 {
+	return int64(math.Round(number(s) * 100))
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// service reports whether the line has a word of the service lines.
+func service(line string) bool {
+	for _, word := range strings.FieldsFunc(line, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if services[strings.ReplaceAll(word, "ё", "е")] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+// Package schemaorg reads and writes schema.org Recipe documents in JSON-LD.
+//
+// Example usage:
+//
+//	recipe, err := schemaorg.Extract(body) // JSON-LD or an HTML page
+//	if err != nil {
+//	    return err
+//	}
+//	data, err := schemaorg.Marshal(recipe)
+package schemaorg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoRecipe is returned when the document does not contain a Recipe.
+var ErrNoRecipe = errors.New("no schema.org recipe found")
+
+var (
+	scriptRx = regexp.MustCompile(
+		`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`,
+	)
+	tagRx    = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRx  = regexp.MustCompile(`\s+`)
+	numberRx = regexp.MustCompile(`\d+`)
+)
+
+// Recipe is the subset of the schema.org Recipe used by the service.
+type Recipe struct {
+	Name        string
+	Description string
+	// Yield is the number of servings, zero if unknown
+	Yield int
+	// Ingredients are the recipeIngredient lines as written
+	Ingredients []string
+	// Instructions are the texts of the steps in order
+	Instructions []string
+}
+
+// Extract finds the first Recipe in a JSON-LD document or in the
+// application/ld+json scripts of an HTML page. The Recipe may be nested in
+// an array or a @graph.
+func Extract(data []byte) (Recipe, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return extractJSON(trimmed)
+	}
+	for _, match := range scriptRx.FindAllSubmatch(data, -1) {
+		recipe, err := extractJSON(bytes.TrimSpace(match[1]))
+		if err == nil {
+			return recipe, nil
+		}
+	}
+	return Recipe{}, ErrNoRecipe
+}
+
+func extractJSON(data []byte) (Recipe, error) {
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return Recipe{}, fmt.Errorf("%w: failed to decode json-ld: %s", ErrNoRecipe, err)
+	}
+	node, ok := findRecipe(document)
+	if !ok {
+		return Recipe{}, ErrNoRecipe
+	}
+	recipe := Recipe{
+		Name:         text(node["name"]),
+		Description:  text(node["description"]),
+		Yield:        yield(node["recipeYield"]),
+		Ingredients:  texts(node["recipeIngredient"]),
+		Instructions: instructions(node["recipeInstructions"]),
+	}
+	if len(recipe.Ingredients) == 0 {
+		// the property of the older versions of the vocabulary
+		recipe.Ingredients = texts(node["ingredients"])
+	}
+	return recipe, nil
+}
+
+func findRecipe(node any) (map[string]any, bool) {
+	switch node := node.(type) {
+	case []any:
+		for _, item := range node {
+			if recipe, ok := findRecipe(item); ok {
+				return recipe, true
+			}
+		}
+	case map[string]any:
+		if isType(node, "Recipe") {
+			return node, true
+		}
+		if graph, ok := node["@graph"]; ok {
+			return findRecipe(graph)
+		}
+	}
+	return nil, false
+}
+
+func isType(node map[string]any, name string) bool {
+	switch t := node["@type"].(type) {
+	case string:
+		return t == name
+	case []any:
+		for _, item := range t {
+			if item == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// text returns the value as plain text without the markup and the
+// redundant spaces.
+func text(value any) string {
+	switch value := value.(type) {
+	case string:
+		s := html.UnescapeString(tagRx.ReplaceAllString(value, " "))
+		return strings.TrimSpace(spaceRx.ReplaceAllString(s, " "))
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []any:
+		if len(value) > 0 {
+			return text(value[0])
+		}
+	}
+	return ""
+}
+
+func texts(value any) []string {
+	var values []any
+	switch value := value.(type) {
+	case []any:
+		values = value
+	case string:
+		values = []any{value}
+	}
+	result := make([]string, 0, len(values))
+	for _, item := range values {
+		if s := text(item); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func yield(value any) int {
+	if n, ok := value.(float64); ok {
+		return int(n)
+	}
+	if n, err := strconv.Atoi(numberRx.FindString(text(value))); err == nil {
+		return n
+	}
+	return 0
+}
+
+// instructions flattens the text, the list of texts, the HowToStep and the
+// HowToSection forms of recipeInstructions.
+func instructions(value any) []string {
+	result := make([]string, 0)
+	switch value := value.(type) {
+	case string:
+		for _, line := range strings.Split(html.UnescapeString(value), "\n") {
+			if s := text(line); s != "" {
+				result = append(result, s)
+			}
+		}
+	case []any:
+		for _, item := range value {
+			result = append(result, instructions(item)...)
+		}
+	case map[string]any:
+		if isType(value, "HowToSection") {
+			return instructions(value["itemListElement"])
+		}
+		s := text(value["text"])
+		if s == "" {
+			s = text(value["name"])
+		}
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+type document struct {
+	Context            string      `json:"@context"`
+	Type               string      `json:"@type"`
+	Name               string      `json:"name"`
+	Description        string      `json:"description,omitempty"`
+	RecipeYield        string      `json:"recipeYield,omitempty"`
+	RecipeIngredient   []string    `json:"recipeIngredient"`
+	RecipeInstructions []howToStep `json:"recipeInstructions"`
+}
+
+type howToStep struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+}
+
+// Marshal encodes the recipe as a schema.org Recipe JSON-LD document.
+func Marshal(recipe Recipe) ([]byte, error) {
+	doc := document{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               recipe.Name,
+		Description:        recipe.Description,
+		RecipeIngredient:   recipe.Ingredients,
+		RecipeInstructions: make([]howToStep, len(recipe.Instructions)),
+	}
+	if doc.RecipeIngredient == nil {
+		doc.RecipeIngredient = []string{}
+	}
+	if recipe.Yield > 0 {
+		doc.RecipeYield = strconv.Itoa(recipe.Yield)
+	}
+	for i, instruction := range recipe.Instructions {
+		doc.RecipeInstructions[i] = howToStep{Type: "HowToStep", Position: i + 1, Text: instruction}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json-ld: %w", err)
+	}
+	return data, nil
+}
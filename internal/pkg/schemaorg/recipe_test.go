@@ -0,0 +1,101 @@
+package schemaorg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Extract(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected Recipe
+		err      error
+	}{
+		{
+			name: "json-ld",
+			data: `{
+				"@context": "https://schema.org",
+				"@type": "Recipe",
+				"name": "Блины",
+				"description": "Тонкие блины на молоке",
+				"recipeYield": "4 порции",
+				"recipeIngredient": ["500 мл молока", "2 яйца", "200 г муки"],
+				"recipeInstructions": [
+					{"@type": "HowToStep", "text": "Смешать яйца с молоком."},
+					{"@type": "HowToStep", "text": "Добавить муку."}
+				]
+			}`,
+			expected: Recipe{
+				Name:         "Блины",
+				Description:  "Тонкие блины на молоке",
+				Yield:        4,
+				Ingredients:  []string{"500 мл молока", "2 яйца", "200 г муки"},
+				Instructions: []string{"Смешать яйца с молоком.", "Добавить муку."},
+			},
+		},
+		{
+			name: "html with graph and sections",
+			data: `<html><head>
+				<script type="application/ld+json">{"@type": "WebSite", "name": "Kitchen"}</script>
+				<script type="application/ld+json">
+				{"@context": "https://schema.org", "@graph": [
+					{"@type": "WebPage", "name": "Pancakes"},
+					{"@type": ["Recipe"], "name": "Pancakes &amp; syrup", "recipeYield": [2, "2 servings"],
+					 "recipeIngredient": ["1 cup <b>flour</b>", ""],
+					 "recipeInstructions": [{"@type": "HowToSection", "name": "Batter",
+						"itemListElement": [{"@type": "HowToStep", "name": "Whisk"}]}]}
+				]}
+				</script>
+			</head></html>`,
+			expected: Recipe{
+				Name:         "Pancakes & syrup",
+				Yield:        2,
+				Ingredients:  []string{"1 cup flour"},
+				Instructions: []string{"Whisk"},
+			},
+		},
+		{
+			name: "instructions text",
+			data: `[{"@type": "Recipe", "name": "Чай", "recipeYield": 1,
+				"recipeInstructions": "Вскипятить воду.\nЗаварить чай."}]`,
+			expected: Recipe{
+				Name:         "Чай",
+				Yield:        1,
+				Ingredients:  []string{},
+				Instructions: []string{"Вскипятить воду.", "Заварить чай."},
+			},
+		},
+		{
+			name: "no recipe",
+			data: `<html><body>Рецептов нет</body></html>`,
+			err:  ErrNoRecipe,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Extract([]byte(tc.data))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_Marshal(t *testing.T) {
+	recipe := Recipe{
+		Name:         "Блины",
+		Yield:        4,
+		Ingredients:  []string{"500 мл Молоко"},
+		Instructions: []string{"Смешать.", "Пожарить."},
+	}
+	data, err := Marshal(recipe)
+	assert.NoError(t, err)
+	actual, err := Extract(data)
+	assert.NoError(t, err)
+	assert.Equal(t, recipe, actual)
+}
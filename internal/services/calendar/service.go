@@ -0,0 +1,180 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/ical"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+const (
+	prodID = "-//Muerta//Shelf Lives//RU"
+	name   = "Muerta: сроки годности"
+	// maxEvents limits the number of shelf lives exported to the feed
+	maxEvents = 500
+	// notificationsCategory is the name of the category of the settings
+	// whose values are the reminders in days before the end date
+	notificationsCategory = "Уведомления"
+)
+
+// defaultAlarms is used when the user has not configured any reminders.
+var defaultAlarms = []time.Duration{24 * time.Hour}
+
+type CalendarServicer interface {
+	EncodeFeed(ctx context.Context, userID int, w io.Writer) error
+	// FindFeedVersion returns the version of the feed tokens of the user.
+	FindFeedVersion(ctx context.Context, userID int) (int, error)
+	// ResetFeed increases the version of the feed tokens of the user, the
+	// issued tokens are revoked.
+	ResetFeed(ctx context.Context, userID int) (int, error)
+}
+
+type calendarService struct {
+	repo repo.UserStorage
+	now  func() time.Time
+}
+
+func New(repo repo.UserStorage) CalendarServicer {
+	return &calendarService{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// EncodeFeed implements CalendarServicer
+func (s *calendarService) EncodeFeed(ctx context.Context, userID int, w io.Writer) error {
+	now := s.now()
+	yesterday := now.AddDate(0, 0, -1)
+	shelfLives, err := s.repo.FindShelfLives(ctx, userID, models.ShelfLifeFilter{
+		PageFilter: models.PageFilter{Limit: maxEvents},
+		EndAfter:   &yesterday,
+		Sort:       "end_date",
+	})
+	if err != nil {
+		return fmt.Errorf("error finding shelf lives: %w", err)
+	}
+	settings, err := s.repo.FindSettings(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("error finding settings: %w", err)
+	}
+	cal := NewCalendar(userID, now, shelfLives, Alarms(settings))
+	if err := cal.Encode(w); err != nil {
+		return fmt.Errorf("error encoding calendar: %w", err)
+	}
+	return nil
+}
+
+// FindFeedVersion implements CalendarServicer
+func (s *calendarService) FindFeedVersion(ctx context.Context, userID int) (int, error) {
+	version, err := s.repo.FindFeedVersion(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error finding feed version: %w", err)
+	}
+	return version, nil
+}
+
+// ResetFeed implements CalendarServicer
+func (s *calendarService) ResetFeed(ctx context.Context, userID int) (int, error) {
+	version, err := s.repo.ResetFeedVersion(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error resetting feed version: %w", err)
+	}
+	return version, nil
+}
+
+// NewCalendar groups the shelf lives by the end date and returns a calendar
+// with an all-day event per date.
+func NewCalendar(
+	userID int,
+	stamp time.Time,
+	shelfLives []models.ShelfLife,
+	alarms []time.Duration,
+) *ical.Calendar {
+	cal := &ical.Calendar{ProdID: prodID, Name: name, Stamp: stamp}
+	groups := make(map[string][]models.ShelfLife)
+	dates := make([]string, 0)
+	for _, shelfLife := range shelfLives {
+		if shelfLife.EndDate == nil {
+			continue
+		}
+		date := shelfLife.EndDate.Format(time.DateOnly)
+		if _, ok := groups[date]; !ok {
+			dates = append(dates, date)
+		}
+		groups[date] = append(groups[date], shelfLife)
+	}
+	sort.Strings(dates)
+	for _, date := range dates {
+		group := groups[date]
+		products := make([]string, 0, len(group))
+		lines := make([]string, 0, len(group))
+		for _, shelfLife := range group {
+			products = append(products, shelfLife.Product.Name)
+			lines = append(lines, fmt.Sprintf(
+				"%s — хранилище: %s, количество: %s %s",
+				shelfLife.Product.Name,
+				shelfLife.Storage.Name,
+				strconv.FormatFloat(float64(shelfLife.Quantity), 'f', -1, 32),
+				shelfLife.Measure.Name,
+			))
+		}
+		summary := "Истекает срок годности: " + strings.Join(products, ", ")
+		event := ical.Event{
+			UID: fmt.Sprintf(
+				"expiration-%s-user-%d@muerta",
+				strings.ReplaceAll(date, "-", ""),
+				userID,
+			),
+			Summary:     summary,
+			Description: strings.Join(lines, "\n"),
+			Date:        *group[0].EndDate,
+		}
+		for _, before := range alarms {
+			event.Alarms = append(event.Alarms, ical.Alarm{Before: before, Description: summary})
+		}
+		cal.Events = append(cal.Events, event)
+	}
+	return cal
+}
+
+// Alarms returns the reminders configured in the notification settings of
+// the user. The value of such a setting is a comma separated list of days
+// before the end date, e.g. "1,3". The value "нет" disables reminders.
+func Alarms(settings []models.Setting) []time.Duration {
+	days := make(map[int]struct{})
+	configured := false
+	for _, setting := range settings {
+		if setting.Category.Name != notificationsCategory {
+			continue
+		}
+		value := strings.ToLower(strings.TrimSpace(setting.Value))
+		switch value {
+		case "нет", "no", "false", "off":
+			return nil
+		}
+		for _, part := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n < 0 {
+				continue
+			}
+			days[n] = struct{}{}
+			configured = true
+		}
+	}
+	if !configured {
+		return defaultAlarms
+	}
+	alarms := make([]time.Duration, 0, len(days))
+	for n := range days {
+		alarms = append(alarms, time.Duration(n)*24*time.Hour)
+	}
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i] < alarms[j] })
+	return alarms
+}
@@ -0,0 +1,95 @@
+package calendar
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func Test_NewCalendar(t *testing.T) {
+	stamp := time.Date(2023, time.May, 20, 10, 30, 0, 0, time.UTC)
+	milkEnd := time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC)
+	breadEnd := time.Date(2023, time.May, 21, 0, 0, 0, 0, time.UTC)
+	shelfLives := []models.ShelfLife{
+		{
+			ID:       1,
+			Product:  models.Product{Name: "Молоко"},
+			Storage:  models.Vault{Name: "Холодильник"},
+			Measure:  models.Measure{Name: "л"},
+			Quantity: 1.5,
+			EndDate:  &milkEnd,
+		},
+		{
+			ID:       2,
+			Product:  models.Product{Name: "Хлеб"},
+			Storage:  models.Vault{Name: "Шкаф, кухня"},
+			Measure:  models.Measure{Name: "шт"},
+			Quantity: 1,
+			EndDate:  &breadEnd,
+		},
+		{
+			ID:       3,
+			Product:  models.Product{Name: "Сыр; твёрдый"},
+			Storage:  models.Vault{Name: "Холодильник"},
+			Measure:  models.Measure{Name: "г"},
+			Quantity: 200,
+			EndDate:  &milkEnd,
+		},
+		{
+			ID:      4,
+			Product: models.Product{Name: "Без срока"},
+		},
+	}
+	cal := NewCalendar(1, stamp, shelfLives, []time.Duration{24 * time.Hour, 72 * time.Hour})
+	var buf bytes.Buffer
+	assert.NoError(t, cal.Encode(&buf))
+	golden := filepath.Join("testdata", "feed.golden.ics")
+	if *update {
+		assert.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+	}
+	expected, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+}
+
+func Test_Alarms(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings []models.Setting
+		expected []time.Duration
+	}{
+		{
+			name:     "no settings",
+			expected: defaultAlarms,
+		},
+		{
+			name: "days before end date",
+			settings: []models.Setting{
+				{Value: "3, 1", Category: models.SettingCategory{Name: "Уведомления"}},
+				{Value: "1", Category: models.SettingCategory{Name: "Уведомления"}},
+				{Value: "7", Category: models.SettingCategory{Name: "Внешний вид"}},
+			},
+			expected: []time.Duration{24 * time.Hour, 72 * time.Hour},
+		},
+		{
+			name: "disabled",
+			settings: []models.Setting{
+				{Value: "Нет", Category: models.SettingCategory{Name: "Уведомления"}},
+			},
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Alarms(tc.settings))
+		})
+	}
+}
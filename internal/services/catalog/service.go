@@ -0,0 +1,319 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/barcode"
+	"github.com/romankravchuk/muerta/internal/pkg/openfoodfacts"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/catalog"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// The statuses of an import.
+const (
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// defaultChunkSize is the number of the records merged in one transaction.
+const defaultChunkSize = 5000
+
+var (
+	// ErrInvalidSource is returned when the dump or the categories file can
+	// not be opened.
+	ErrInvalidSource = errors.New("invalid import source")
+	// ErrImportRunning is returned when the source is being imported.
+	ErrImportRunning = errors.New("import is running")
+)
+
+// tagPrefix is the language prefix of the category tags.
+var tagPrefix = regexp.MustCompile(`^[a-z]{2}:`)
+
+type CatalogServicer interface {
+	// ImportProducts imports the dump, the progress is reported after each
+	// chunk.
+	ImportProducts(
+		ctx context.Context,
+		payload *params.ImportProducts,
+		progress func(params.FindImport),
+	) (params.FindImport, error)
+	// StartImport imports the dump in background.
+	StartImport(payload *params.ImportProducts) (params.FindImport, error)
+	// FindImports returns the imports started in background.
+	FindImports() []params.FindImport
+}
+
+type catalogService struct {
+	repo    repo.CatalogRepositorer
+	mu      sync.Mutex
+	imports map[string]*params.FindImport
+}
+
+func New(repo repo.CatalogRepositorer) CatalogServicer {
+	return &catalogService{
+		repo:    repo,
+		imports: make(map[string]*params.FindImport),
+	}
+}
+
+// importJob is an opened dump to import.
+type importJob struct {
+	payload    *params.ImportProducts
+	dump       *openfoodfacts.Dump
+	categories map[string]string
+	state      params.FindImport
+}
+
+// ImportProducts implements CatalogServicer
+func (s *catalogService) ImportProducts(
+	ctx context.Context,
+	payload *params.ImportProducts,
+	progress func(params.FindImport),
+) (params.FindImport, error) {
+	job, err := open(payload)
+	if err != nil {
+		return params.FindImport{}, err
+	}
+	defer job.dump.Close()
+	return s.run(ctx, job, progress)
+}
+
+// StartImport implements CatalogServicer
+func (s *catalogService) StartImport(payload *params.ImportProducts) (params.FindImport, error) {
+	job, err := open(payload)
+	if err != nil {
+		return params.FindImport{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.imports[job.state.Source]; ok && state.Status == ImportRunning {
+		job.dump.Close()
+		return params.FindImport{}, fmt.Errorf("%w: %s", ErrImportRunning, job.state.Source)
+	}
+	state := job.state
+	s.imports[state.Source] = &state
+	go func() {
+		defer job.dump.Close()
+		result, _ := s.run(context.Background(), job, func(state params.FindImport) {
+			s.mu.Lock()
+			*s.imports[state.Source] = state
+			s.mu.Unlock()
+		})
+		s.mu.Lock()
+		*s.imports[result.Source] = result
+		s.mu.Unlock()
+	}()
+	return state, nil
+}
+
+// FindImports implements CatalogServicer
+func (s *catalogService) FindImports() []params.FindImport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]params.FindImport, 0, len(s.imports))
+	for _, state := range s.imports {
+		result = append(result, *state)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	return result
+}
+
+// open opens the dump and reads the categories file. The source is the
+// absolute path and the size of the dump, so a changed dump is imported from
+// the start.
+func open(payload *params.ImportProducts) (*importJob, error) {
+	path, err := filepath.Abs(payload.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
+	format := payload.Format
+	if format == "" {
+		if format, err = openfoodfacts.Format(path); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+		}
+	}
+	categories := make(map[string]string)
+	if payload.Categories != "" {
+		data, err := os.ReadFile(payload.Categories)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+		}
+		if err := json.Unmarshal(data, &categories); err != nil {
+			return nil, fmt.Errorf("%w: categories: %v", ErrInvalidSource, err)
+		}
+	}
+	dump, err := openfoodfacts.OpenFormat(path, format, payload.Language)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSource, err)
+	}
+	return &importJob{
+		payload:    payload,
+		dump:       dump,
+		categories: categories,
+		state: params.FindImport{
+			Source:    fmt.Sprintf("%s:%d", path, dump.Size),
+			Path:      path,
+			DryRun:    payload.DryRun,
+			Status:    ImportRunning,
+			Size:      dump.Size,
+			StartedAt: time.Now(),
+		},
+	}, nil
+}
+
+// run imports the job. The dry run is rolled back and always starts from the
+// beginning, otherwise the import continues from the checkpoint unless it is
+// restarted.
+func (s *catalogService) run(
+	ctx context.Context,
+	job *importJob,
+	progress func(params.FindImport),
+) (params.FindImport, error) {
+	checkpoint := models.ProductImport{Source: job.state.Source}
+	var err error
+	if !job.payload.DryRun && !job.payload.Restart {
+		checkpoint, err = s.repo.FindImport(ctx, job.state.Source)
+		if errors.Is(err, repo.ErrImportNotFound) {
+			checkpoint, err = models.ProductImport{Source: job.state.Source}, nil
+		}
+	}
+	if err == nil {
+		if job.payload.DryRun {
+			err = s.repo.DryRun(ctx, func(repo repo.CatalogRepositorer) error {
+				return job.importChunks(ctx, repo, checkpoint, progress)
+			})
+		} else {
+			err = job.importChunks(ctx, s.repo, checkpoint, progress)
+		}
+	}
+	finished := time.Now()
+	job.state.FinishedAt = &finished
+	job.state.Status = ImportDone
+	if err != nil {
+		job.state.Status = ImportFailed
+		job.state.Error = err.Error()
+		return job.state, fmt.Errorf("import products: %w", err)
+	}
+	return job.state, nil
+}
+
+// importChunks skips the records before the checkpoint and merges the rest by
+// chunks, the checkpoint is saved with every chunk.
+func (job *importJob) importChunks(
+	ctx context.Context,
+	repo repo.CatalogRepositorer,
+	checkpoint models.ProductImport,
+	progress func(params.FindImport),
+) error {
+	size := job.payload.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	for job.dump.Position() < checkpoint.Position {
+		if _, err := job.dump.Next(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil && !errors.Is(err, openfoodfacts.ErrInvalidRecord) {
+			return err
+		}
+	}
+	chunk := make([]models.CatalogProduct, 0, size)
+	flush := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		checkpoint.Position = job.dump.Position()
+		updated, err := repo.ImportChunk(ctx, checkpoint, chunk)
+		if err != nil {
+			return err
+		}
+		checkpoint, chunk = updated, chunk[:0]
+		job.state.Position = checkpoint.Position
+		job.state.Offset = job.dump.Offset()
+		if job.state.Size > 0 {
+			job.state.Progress = float64(job.state.Offset) * 100 / float64(job.state.Size)
+		}
+		job.state.Stats = utils.ImportStatsModelToFind(&checkpoint.Stats)
+		if progress != nil {
+			progress(job.state)
+		}
+		return nil
+	}
+	for {
+		product, err := job.dump.Next()
+		if errors.Is(err, io.EOF) {
+			return flush()
+		}
+		if errors.Is(err, openfoodfacts.ErrInvalidRecord) {
+			checkpoint.Stats.Invalid++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		checkpoint.Stats.Records++
+		if product.Name == "" {
+			checkpoint.Stats.Skipped++
+			continue
+		}
+		chunk = append(chunk, job.catalogProduct(product, job.dump.Position(), &checkpoint.Stats))
+		if len(chunk) == size {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// catalogProduct converts the product of the dump. The codes which are not
+// valid EAN-13 or UPC-A are dropped, the category tags are mapped onto the
+// names of the categories, the unmapped ones are matched by their labels.
+func (job *importJob) catalogProduct(
+	product openfoodfacts.Product,
+	position int64,
+	stats *models.ImportStats,
+) models.CatalogProduct {
+	result := models.CatalogProduct{Position: position, Name: product.Name}
+	if product.Code != "" {
+		if code, err := barcode.Normalize(product.Code); err == nil {
+			result.Barcode = &code
+		} else {
+			stats.InvalidBarcodes++
+		}
+	}
+	if n := product.Nutrition; n != nil {
+		result.Nutrition = &models.Nutrition{
+			Energy:        n.Energy,
+			Protein:       n.Protein,
+			Fat:           n.Fat,
+			Carbohydrates: n.Carbohydrates,
+		}
+	}
+	seen := make(map[string]bool, len(product.Categories))
+	for _, tag := range product.Categories {
+		name, ok := job.categories[tag]
+		if !ok {
+			name = strings.ReplaceAll(tagPrefix.ReplaceAllString(tag, ""), "-", " ")
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			result.Categories = append(result.Categories, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package catalog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/catalog"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	checkpoints map[string]models.ProductImport
+	chunks      [][]models.CatalogProduct
+	rolledBack  bool
+}
+
+func (r *fakeRepository) FindImport(
+	ctx context.Context,
+	source string,
+) (models.ProductImport, error) {
+	checkpoint, ok := r.checkpoints[source]
+	if !ok {
+		return models.ProductImport{}, repository.ErrImportNotFound
+	}
+	return checkpoint, nil
+}
+
+func (r *fakeRepository) ImportChunk(
+	ctx context.Context,
+	checkpoint models.ProductImport,
+	products []models.CatalogProduct,
+) (models.ProductImport, error) {
+	r.chunks = append(r.chunks, append([]models.CatalogProduct(nil), products...))
+	checkpoint.Stats.Created += int64(len(products))
+	r.checkpoints[checkpoint.Source] = checkpoint
+	return checkpoint, nil
+}
+
+func (r *fakeRepository) DryRun(
+	ctx context.Context,
+	fn func(repo repository.CatalogRepositorer) error,
+) error {
+	tx := &fakeRepository{checkpoints: make(map[string]models.ProductImport)}
+	err := fn(tx)
+	r.chunks, r.rolledBack = tx.chunks, true
+	return err
+}
+
+const dump = `{"code":"4600680001418","product_name":"Молоко","categories_tags":["en:dairies","en:milks"]}
+{"code":"123","product_name":"Соль"}
+{"code":"3", broken
+{"code":"4","product_name":""}
+{"code":"5","product_name":"Сахар","categories_tags":["en:sugars"]}
+`
+
+func Test_ImportProducts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "products.jsonl")
+	assert.NoError(t, os.WriteFile(path, []byte(dump), 0o600))
+	categories := filepath.Join(dir, "categories.json")
+	assert.NoError(t, os.WriteFile(categories, []byte(`{"en:dairies":"Молочные продукты"}`), 0o600))
+
+	repo := &fakeRepository{checkpoints: make(map[string]models.ProductImport)}
+	svc := New(repo)
+	payload := &params.ImportProducts{Path: path, Categories: categories, ChunkSize: 2}
+
+	var progress []int64
+	result, err := svc.ImportProducts(context.Background(), payload, func(state params.FindImport) {
+		progress = append(progress, state.Position)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ImportDone, result.Status)
+	assert.Equal(t, []int64{2, 5}, progress)
+	assert.Equal(t, float64(100), result.Progress)
+	assert.Equal(t, params.ImportStats{
+		Records:         4,
+		Invalid:         1,
+		Skipped:         1,
+		InvalidBarcodes: 2,
+		Created:         3,
+	}, result.Stats)
+	assert.Len(t, repo.chunks, 2)
+	milk := repo.chunks[0][0]
+	assert.Equal(t, "4600680001418", *milk.Barcode)
+	assert.Equal(t, []string{"Молочные продукты", "milks"}, milk.Categories)
+	assert.Nil(t, repo.chunks[0][1].Barcode)
+
+	// the import continues from the checkpoint
+	repo.chunks = nil
+	result, err = svc.ImportProducts(context.Background(), payload, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, repo.chunks[0])
+	assert.Equal(t, int64(3), result.Stats.Created)
+
+	// the dry run starts from the beginning and is rolled back
+	payload.DryRun = true
+	result, err = svc.ImportProducts(context.Background(), payload, nil)
+	assert.NoError(t, err)
+	assert.True(t, repo.rolledBack)
+	assert.Len(t, repo.chunks, 2)
+	assert.Equal(t, int64(4), result.Stats.Records)
+
+	_, err = svc.ImportProducts(context.Background(), &params.ImportProducts{Path: "products.xml"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidSource)
+}
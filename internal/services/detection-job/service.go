@@ -0,0 +1,285 @@
+// Package detectionjob runs the detections of the dates asynchronously. The
+// jobs are stored in Redis with their images, so they survive a restart of
+// the API, and the in-process workers run them with the retries.
+package detectionjob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/storage/redis/job"
+	"github.com/rs/zerolog"
+)
+
+// ErrNotFound is returned when the job does not exist, is expired or is of
+// another user.
+var ErrNotFound = errors.New("detection job not found")
+
+const (
+	// pollTimeout is how long a worker waits for a job before checking
+	// whether it is stopped
+	pollTimeout = 5 * time.Second
+	// minLease is the minimum lease of a job, the job is queued again as the
+	// job of a stopped worker when its lease is not renewed for it
+	minLease = time.Minute
+	// errorDelay is the delay of a worker after an error of Redis
+	errorDelay = time.Second
+)
+
+type DetectionJobServicer interface {
+	// Create stores the image and queues the job detecting its dates. The
+	// consent allows to keep the image in the dataset of the detections.
+	Create(ctx context.Context, userID int, image []byte, consent bool) (params.DetectionJob, error)
+	// FindByID returns the status of the job of the user and its result.
+	FindByID(ctx context.Context, userID int, id string) (params.DetectionJob, error)
+}
+
+type DetectionJobService struct {
+	repo       job.JobRepositorer
+	detector   sldetector.DateDetectorServicer
+	detections detection.DetectionServicer
+	workers    int
+	retries    int
+	ttl        time.Duration
+	lease      time.Duration
+	log        *zerolog.Logger
+}
+
+func New(
+	repo job.JobRepositorer,
+	detector sldetector.DateDetectorServicer,
+	detections detection.DetectionServicer,
+	cfg *config.Config,
+	log logger.Logger,
+) *DetectionJobService {
+	// the lease is renewed several times while the detection runs, a job is
+	// queued again only when it is surely not running
+	lease := 2 * cfg.OCR.Timeout
+	if lease < minLease {
+		lease = minLease
+	}
+	return &DetectionJobService{
+		repo:       repo,
+		detector:   detector,
+		detections: detections,
+		workers:    cfg.Jobs.Workers,
+		retries:    cfg.Jobs.Retries,
+		ttl:        cfg.Jobs.TTL,
+		lease:      lease,
+		log:        log.GetLogger(),
+	}
+}
+
+func (s *DetectionJobService) Create(
+	ctx context.Context,
+	userID int,
+	image []byte,
+	consent bool,
+) (params.DetectionJob, error) {
+	now := time.Now().UTC()
+	model := &job.Job{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Status:    job.StatusQueued,
+		Consent:   consent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.Create(ctx, model, image); err != nil {
+		return params.DetectionJob{}, fmt.Errorf("failed to create detection job: %w", err)
+	}
+	return detectionJob(model)
+}
+
+func (s *DetectionJobService) FindByID(ctx context.Context, userID int, id string) (params.DetectionJob, error) {
+	model, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, job.ErrNotFound) {
+		return params.DetectionJob{}, ErrNotFound
+	}
+	if err != nil {
+		return params.DetectionJob{}, fmt.Errorf("failed to find detection job: %w", err)
+	}
+	if model.UserID != userID {
+		return params.DetectionJob{}, ErrNotFound
+	}
+	return detectionJob(model)
+}
+
+func detectionJob(model *job.Job) (params.DetectionJob, error) {
+	result := params.DetectionJob{
+		ID:        model.ID,
+		Status:    string(model.Status),
+		Attempts:  model.Attempts,
+		Error:     model.Error,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+	if len(model.Result) > 0 {
+		result.Result = new(params.DetectedDates)
+		if err := json.Unmarshal(model.Result, result.Result); err != nil {
+			return params.DetectionJob{}, fmt.Errorf("failed to unmarshal result: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// Run runs the workers until the context is done. The jobs of the stopped
+// workers are queued again first and then every lease, once their leases are
+// expired. The running jobs are queued again when the context is done.
+func (s *DetectionJobService) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.recover(ctx)
+	}()
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *DetectionJobService) recover(ctx context.Context) {
+	ticker := time.NewTicker(s.lease)
+	defer ticker.Stop()
+	for {
+		n, err := s.repo.Recover(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.log.Error().Err(err).Msg("failed to recover detection jobs")
+		}
+		if n > 0 {
+			s.log.Info().Int("jobs", n).Msg("detection jobs are queued again")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DetectionJobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		id, err := s.repo.Next(ctx, pollTimeout, s.lease)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Error().Err(err).Msg("failed to get detection job")
+			select {
+			case <-ctx.Done():
+			case <-time.After(errorDelay):
+			}
+			continue
+		}
+		if id == "" {
+			continue
+		}
+		if err := s.run(ctx, id); err != nil {
+			s.log.Error().Err(err).Str("job", id).Msg("failed to run detection job")
+		}
+	}
+}
+
+// run detects the dates of the job. A job failed by the OCR is retried, a
+// job failed by the image is not.
+func (s *DetectionJobService) run(ctx context.Context, id string) error {
+	stop := s.renew(ctx, id)
+	defer stop()
+	model, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		// the expired jobs are removed from the processing list by Recover
+		return err
+	}
+	if model.Status == job.StatusDone || model.Status == job.StatusFailed {
+		// the job is queued again and finished by the worker which lost its
+		// lease, its result is kept
+		return s.repo.Finish(ctx, model, s.ttl)
+	}
+	image, err := s.repo.FindImage(ctx, id)
+	if errors.Is(err, job.ErrNotFound) {
+		model.Status, model.Error = job.StatusFailed, "image is not found"
+		return s.repo.Finish(ctx, model, s.ttl)
+	}
+	if err != nil {
+		return err
+	}
+	model.Status, model.UpdatedAt = job.StatusRunning, time.Now().UTC()
+	model.Attempts++
+	if err := s.repo.Update(ctx, model); err != nil {
+		return err
+	}
+
+	dates, err := s.detector.Detect(ctx, image, false)
+	model.UpdatedAt = time.Now().UTC()
+	switch {
+	case ctx.Err() != nil:
+		// the workers are stopped, the job is run again after the restart
+		model.Status, model.Attempts = job.StatusQueued, model.Attempts-1
+		return s.repo.Retry(context.Background(), model)
+	case err == nil:
+		if err := s.detections.Record(ctx, model.UserID, image, model.Consent, &dates); err != nil {
+			// the dates are detected, only their feedback is lost
+			s.log.Error().Err(err).Str("job", id).Msg("failed to record detection")
+		}
+		if model.Result, err = json.Marshal(dates); err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		model.Status, model.Error = job.StatusDone, ""
+		return s.repo.Finish(ctx, model, s.ttl)
+	case permanent(err) || model.Attempts > s.retries:
+		model.Status, model.Error = job.StatusFailed, err.Error()
+		return s.repo.Finish(ctx, model, s.ttl)
+	default:
+		model.Status, model.Error = job.StatusQueued, err.Error()
+		return s.repo.Retry(ctx, model)
+	}
+}
+
+// renew renews the lease of the job until stop is called.
+func (s *DetectionJobService) renew(ctx context.Context, id string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.lease / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := s.repo.Renew(ctx, id, s.lease); err != nil && ctx.Err() == nil {
+				s.log.Error().Err(err).Str("job", id).Msg("failed to renew detection job")
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// permanent reports whether the job fails the same way when it is retried.
+func permanent(err error) bool {
+	return errors.Is(err, sldetector.ErrNoDates) ||
+		errors.Is(err, imaging.ErrCorrupt) ||
+		errors.Is(err, imaging.ErrUnsupportedFormat) ||
+		errors.Is(err, imaging.ErrTooLarge)
+}
@@ -0,0 +1,288 @@
+package detectionjob
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/storage/redis/job"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the jobs in memory.
+type fakeRepository struct {
+	job.JobRepositorer
+	mu         sync.Mutex
+	jobs       map[string]job.Job
+	images     map[string][]byte
+	queue      []string
+	processing []string
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{jobs: map[string]job.Job{}, images: map[string][]byte{}}
+}
+
+func (r *fakeRepository) Create(_ context.Context, model *job.Job, image []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[model.ID], r.images[model.ID] = *model, image
+	r.queue = append(r.queue, model.ID)
+	return nil
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, id string) (*job.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	model, ok := r.jobs[id]
+	if !ok {
+		return nil, job.ErrNotFound
+	}
+	return &model, nil
+}
+
+func (r *fakeRepository) FindImage(_ context.Context, id string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	image, ok := r.images[id]
+	if !ok {
+		return nil, job.ErrNotFound
+	}
+	return image, nil
+}
+
+func (r *fakeRepository) Next(ctx context.Context, timeout, _ time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		r.mu.Lock()
+		if len(r.queue) > 0 {
+			id := r.queue[0]
+			r.queue, r.processing = r.queue[1:], append(r.processing, id)
+			r.mu.Unlock()
+			return id, nil
+		}
+		r.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	return "", ctx.Err()
+}
+
+func (r *fakeRepository) Renew(context.Context, string, time.Duration) error {
+	return nil
+}
+
+func (r *fakeRepository) Update(_ context.Context, model *job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[model.ID] = *model
+	return nil
+}
+
+func (r *fakeRepository) Retry(_ context.Context, model *job.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[model.ID] = *model
+	r.processing = remove(r.processing, model.ID)
+	r.queue = append(r.queue, model.ID)
+	return nil
+}
+
+func (r *fakeRepository) Finish(_ context.Context, model *job.Job, _ time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[model.ID] = *model
+	delete(r.images, model.ID)
+	r.processing = remove(r.processing, model.ID)
+	return nil
+}
+
+func (r *fakeRepository) Recover(context.Context) (int, error) {
+	return 0, nil
+}
+
+func remove(ids []string, id string) []string {
+	result := ids[:0]
+	for _, v := range ids {
+		if v != id {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// fakeDetector returns the errors in order and then the dates.
+type fakeDetector struct {
+	sldetector.DateDetectorServicer
+	mu     sync.Mutex
+	errs   []error
+	blocks chan struct{}
+}
+
+func (d *fakeDetector) Detect(ctx context.Context, _ []byte, _ bool) (params.DetectedDates, error) {
+	if d.blocks != nil {
+		close(d.blocks)
+		<-ctx.Done()
+		return params.DetectedDates{}, ctx.Err()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.errs) > 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return params.DetectedDates{}, err
+	}
+	return params.DetectedDates{Dates: []params.DetectedDate{{Role: "best_before"}}}, nil
+}
+
+// fakeDetections records the consents of the detections.
+type fakeDetections struct {
+	detection.DetectionServicer
+	mu       sync.Mutex
+	consents []bool
+}
+
+func (d *fakeDetections) Record(_ context.Context, _ int, _ []byte, consent bool, dates *params.DetectedDates) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.consents = append(d.consents, consent)
+	dates.DetectionID = len(d.consents)
+	return nil
+}
+
+func newService(repo job.JobRepositorer, detector sldetector.DateDetectorServicer) *DetectionJobService {
+	cfg := new(config.Config)
+	cfg.Jobs.Workers, cfg.Jobs.Retries, cfg.Jobs.TTL = 2, 2, time.Hour
+	return New(repo, detector, new(fakeDetections), cfg, logger.New())
+}
+
+func Test_Run(t *testing.T) {
+	transient := errors.New("failed to detect date: " + ocr.ErrQueueFull.Error())
+	testCases := []struct {
+		name     string
+		errs     []error
+		status   job.Status
+		attempts int
+		err      string
+	}{
+		{name: "done", status: job.StatusDone, attempts: 1},
+		{name: "retried", errs: []error{transient}, status: job.StatusDone, attempts: 2},
+		{
+			name:     "retries exhausted",
+			errs:     []error{transient, transient, transient},
+			status:   job.StatusFailed,
+			attempts: 3,
+			err:      transient.Error(),
+		},
+		{
+			name:     "no dates",
+			errs:     []error{sldetector.ErrNoDates},
+			status:   job.StatusFailed,
+			attempts: 1,
+			err:      sldetector.ErrNoDates.Error(),
+		},
+		{
+			name:     "corrupt image",
+			errs:     []error{imaging.ErrCorrupt},
+			status:   job.StatusFailed,
+			attempts: 1,
+			err:      imaging.ErrCorrupt.Error(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			svc := newService(repo, &fakeDetector{errs: tc.errs})
+			detections := svc.detections.(*fakeDetections)
+			created, err := svc.Create(context.Background(), 1, []byte("image"), true)
+			assert.NoError(t, err)
+			assert.Equal(t, "queued", created.Status)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				svc.Run(ctx)
+				close(done)
+			}()
+			var result params.DetectionJob
+			assert.Eventually(t, func() bool {
+				result, err = svc.FindByID(context.Background(), 1, created.ID)
+				return err == nil && (result.Status == "done" || result.Status == "failed")
+			}, time.Second, time.Millisecond)
+			cancel()
+			<-done
+
+			assert.Equal(t, string(tc.status), result.Status)
+			assert.Equal(t, tc.attempts, result.Attempts)
+			assert.Equal(t, tc.err, result.Error)
+			assert.Equal(t, tc.status == job.StatusDone, result.Result != nil)
+			if tc.status == job.StatusDone {
+				assert.Equal(t, []bool{true}, detections.consents)
+				assert.Equal(t, 1, result.Result.DetectionID)
+			} else {
+				assert.Empty(t, detections.consents)
+			}
+			assert.Empty(t, repo.images)
+			assert.Empty(t, repo.processing)
+		})
+	}
+}
+
+func Test_RunStopped(t *testing.T) {
+	repo := newFakeRepository()
+	detector := &fakeDetector{blocks: make(chan struct{})}
+	svc := newService(repo, detector)
+	created, err := svc.Create(context.Background(), 1, []byte("image"), false)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		svc.Run(ctx)
+		close(done)
+	}()
+	<-detector.blocks
+	cancel()
+	<-done
+
+	result, err := svc.FindByID(context.Background(), 1, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", result.Status)
+	assert.Zero(t, result.Attempts)
+	assert.Equal(t, []string{created.ID}, repo.queue)
+	assert.Contains(t, repo.images, created.ID)
+}
+
+func Test_FindByID(t *testing.T) {
+	svc := newService(newFakeRepository(), &fakeDetector{})
+	created, err := svc.Create(context.Background(), 1, []byte("image"), false)
+	assert.NoError(t, err)
+	testCases := []struct {
+		name   string
+		userID int
+		id     string
+		err    error
+	}{
+		{name: "owner", userID: 1, id: created.ID},
+		{name: "other user", userID: 2, id: created.ID, err: ErrNotFound},
+		{name: "unknown", userID: 1, id: "unknown", err: ErrNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := svc.FindByID(context.Background(), tc.userID, tc.id)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, created, result)
+		})
+	}
+}
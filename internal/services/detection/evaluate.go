@@ -0,0 +1,118 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+)
+
+// noFormat is the format of the saved dates the detector did not find.
+const noFormat = "none"
+
+// Evaluate replays the samples through the detector and scores the detected
+// end dates against the saved ones by the printed formats. A sample is
+// detected on its image if it has one, and on its raw text if it has not or
+// the text is forced, so the changes of the parser are measured without the
+// OCR. The samples without the saved end date or failed by the OCR are
+// skipped.
+func Evaluate(
+	ctx context.Context,
+	detector sldetector.DateDetectorServicer,
+	samples []params.DetectionSample,
+	text bool,
+) (params.DetectionReport, error) {
+	report := params.DetectionReport{Samples: len(samples)}
+	scores := make(map[string]*params.DetectionFormatScore)
+	score := func(format string) *params.DetectionFormatScore {
+		if scores[format] == nil {
+			scores[format] = &params.DetectionFormatScore{Format: format}
+		}
+		return scores[format]
+	}
+	for _, sample := range samples {
+		if err := ctx.Err(); err != nil {
+			return params.DetectionReport{}, err
+		}
+		if sample.EndDate == nil || (len(sample.Image) == 0 && len(sample.RawText) == 0) {
+			report.Skipped++
+			continue
+		}
+		var (
+			detected params.DetectedDates
+			err      error
+		)
+		if text || len(sample.Image) == 0 {
+			detected, err = detector.DetectText(ctx, sample.RawText)
+		} else {
+			detected, err = detector.Detect(ctx, sample.Image, false)
+		}
+		if err != nil && !errors.Is(err, sldetector.ErrNoDates) {
+			if ctx.Err() != nil {
+				return params.DetectionReport{}, ctx.Err()
+			}
+			report.Skipped++
+			continue
+		}
+		// the format of the saved date is known if either detection found it
+		expected := format(*sample.EndDate, detected.Dates, sample.Proposed.Dates)
+		switch {
+		case detected.EndDate == nil:
+			score(expected).FalseNegatives++
+		case sameDay(*detected.EndDate, *sample.EndDate):
+			score(format(*detected.EndDate, detected.Dates)).TruePositives++
+		default:
+			score(format(*detected.EndDate, detected.Dates)).FalsePositives++
+			score(expected).FalseNegatives++
+		}
+	}
+	report.Total.Format = "total"
+	for _, s := range scores {
+		report.Total.TruePositives += s.TruePositives
+		report.Total.FalsePositives += s.FalsePositives
+		report.Total.FalseNegatives += s.FalseNegatives
+		s.Precision, s.Recall = ratios(*s)
+		report.Formats = append(report.Formats, *s)
+	}
+	report.Total.Precision, report.Total.Recall = ratios(report.Total)
+	sort.Slice(report.Formats, func(i, j int) bool {
+		return report.Formats[i].Format < report.Formats[j].Format
+	})
+	return report, nil
+}
+
+// format returns the format of the first date of the day in the lists.
+func format(date time.Time, lists ...[]params.DetectedDate) string {
+	for _, dates := range lists {
+		for _, d := range dates {
+			if sameDay(d.Date, date) && d.Format != "" {
+				return d.Format
+			}
+		}
+	}
+	return noFormat
+}
+
+// sameDay reports whether the dates are of the same day in their own
+// locations, the saved dates are of the time zones of the users.
+func sameDay(a, b time.Time) bool {
+	y1, m1, d1 := a.Date()
+	y2, m2, d2 := b.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// ratios returns the precision and the recall of the score, they are zero
+// if there are no dates.
+func ratios(s params.DetectionFormatScore) (float64, float64) {
+	var precision, recall float64
+	if n := s.TruePositives + s.FalsePositives; n > 0 {
+		precision = float64(s.TruePositives) / float64(n)
+	}
+	if n := s.TruePositives + s.FalseNegatives; n > 0 {
+		recall = float64(s.TruePositives) / float64(n)
+	}
+	return precision, recall
+}
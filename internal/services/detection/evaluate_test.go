@@ -0,0 +1,52 @@
+package detection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Evaluate(t *testing.T) {
+	day := func(month time.Month, day int) *time.Time {
+		t := time.Date(2022, month, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	moscow := time.FixedZone("MSK", 3*60*60)
+	savedInMoscow := time.Date(2022, 9, 24, 0, 0, 0, 0, moscow)
+	samples := []params.DetectionSample{
+		// found
+		{RawText: []string{"изг 15.09.22 годен до 24.09.22"}, EndDate: day(9, 24)},
+		// found in the time zone of the user
+		{RawText: []string{"годен до 2022-09-24"}, EndDate: &savedInMoscow},
+		// wrong date of the format
+		{RawText: []string{"изг 15.09.22 годен до 24.09.22"}, EndDate: day(9, 25)},
+		// the saved date is printed in another format but not chosen
+		{RawText: []string{"годен до 24.09.22 партия SEP 30, 2022"}, EndDate: day(9, 30)},
+		// no date found
+		{RawText: []string{"годен до конца"}, EndDate: day(10, 1)},
+		// not labelled
+		{RawText: []string{"годен до 24.09.22"}},
+	}
+	report, err := Evaluate(context.Background(), sldetector.New(nil), samples, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.Samples)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, []params.DetectionFormatScore{
+		{Format: "day-month-year", TruePositives: 1, FalsePositives: 2, Precision: 1.0 / 3, Recall: 1},
+		{Format: "name-day-year", FalseNegatives: 1},
+		{Format: "none", FalseNegatives: 2},
+		{Format: "year-month-day", TruePositives: 1, Precision: 1, Recall: 1},
+	}, report.Formats)
+	assert.Equal(t, params.DetectionFormatScore{
+		Format:         "total",
+		TruePositives:  2,
+		FalsePositives: 2,
+		FalseNegatives: 3,
+		Precision:      0.5,
+		Recall:         0.4,
+	}, report.Total)
+}
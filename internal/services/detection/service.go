@@ -0,0 +1,147 @@
+// Package detection keeps the detections of the dates with the dates the
+// users finally saved. The labelled detections are exported as the dataset
+// the changes of the detector are evaluated on before they are shipped.
+package detection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// exportLimit is the number of the exported detections if the limit is not
+// set.
+const exportLimit = 1000
+
+// ErrNotFound is returned when the detection does not exist or is of
+// another user, or the shelf life saved for it is not of the user.
+var ErrNotFound = errors.New("detection not found")
+
+type DetectionServicer interface {
+	// Record stores the detection of the dates of the user and sets its id.
+	// The image is stored only with the consent of the user, its hash is
+	// stored always.
+	Record(
+		ctx context.Context,
+		userID int,
+		image []byte,
+		consent bool,
+		dates *params.DetectedDates,
+	) error
+	// SaveFeedback stores the dates the user saved for the detection.
+	SaveFeedback(ctx context.Context, userID, id int, payload *params.DetectionFeedback) error
+	// Export calls fn for the samples of the page of the labelled
+	// detections as they are read.
+	Export(
+		ctx context.Context,
+		filter *params.DetectionExportFilter,
+		fn func(params.DetectionSample) error,
+	) error
+}
+
+type detectionService struct {
+	repo repository.DetectionRepositorer
+}
+
+func New(repo repository.DetectionRepositorer) DetectionServicer {
+	return &detectionService{repo: repo}
+}
+
+// Record implements DetectionServicer
+func (s *detectionService) Record(
+	ctx context.Context,
+	userID int,
+	image []byte,
+	consent bool,
+	dates *params.DetectedDates,
+) error {
+	// the proposed dates are stored without the text and the image, they
+	// are stored on their own
+	proposed := *dates
+	proposed.DetectionID, proposed.RawText, proposed.Overlay = 0, nil, nil
+	data, err := json.Marshal(proposed)
+	if err != nil {
+		return fmt.Errorf("marshal proposed dates: %w", err)
+	}
+	hash := sha256.Sum256(image)
+	model := models.Detection{
+		User:      models.User{ID: userID},
+		ImageHash: hex.EncodeToString(hash[:]),
+		RawText:   dates.RawText,
+		Proposed:  data,
+	}
+	if model.RawText == nil {
+		model.RawText = []string{}
+	}
+	if consent {
+		model.Image, model.ContentType = image, http.DetectContentType(image)
+	}
+	if err := s.repo.Create(ctx, &model); err != nil {
+		return fmt.Errorf("create detection: %w", err)
+	}
+	dates.DetectionID = model.ID
+	return nil
+}
+
+// SaveFeedback implements DetectionServicer
+func (s *detectionService) SaveFeedback(
+	ctx context.Context,
+	userID, id int,
+	payload *params.DetectionFeedback,
+) error {
+	model := models.Detection{
+		ID:           id,
+		User:         models.User{ID: userID},
+		PurchaseDate: payload.PurchaseDate,
+		EndDate:      payload.EndDate,
+		ShelfLifeID:  payload.ShelfLifeID,
+	}
+	err := s.repo.SaveDates(ctx, &model)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("save detection feedback: %w", err)
+	}
+	return nil
+}
+
+// Export implements DetectionServicer
+func (s *detectionService) Export(
+	ctx context.Context,
+	filter *params.DetectionExportFilter,
+	fn func(params.DetectionSample) error,
+) error {
+	limit := filter.Limit
+	if limit == 0 {
+		limit = exportLimit
+	}
+	err := s.repo.EachLabelled(ctx, filter.After, limit, func(model models.Detection) error {
+		sample := params.DetectionSample{
+			ID:           model.ID,
+			ImageHash:    model.ImageHash,
+			Image:        model.Image,
+			ContentType:  model.ContentType,
+			RawText:      model.RawText,
+			PurchaseDate: model.PurchaseDate,
+			EndDate:      model.EndDate,
+			CreatedAt:    model.CreatedAt,
+		}
+		if err := json.Unmarshal(model.Proposed, &sample.Proposed); err != nil {
+			return fmt.Errorf("unmarshal proposed dates of detection %d: %w", model.ID, err)
+		}
+		return fn(sample)
+	})
+	if err != nil {
+		return fmt.Errorf("export labelled detections: %w", err)
+	}
+	return nil
+}
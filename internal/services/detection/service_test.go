@@ -0,0 +1,140 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the detections in memory.
+type fakeRepository struct {
+	repository.DetectionRepositorer
+	detections []models.Detection
+}
+
+func (r *fakeRepository) Create(_ context.Context, detection *models.Detection) error {
+	detection.ID = len(r.detections) + 1
+	r.detections = append(r.detections, *detection)
+	return nil
+}
+
+func (r *fakeRepository) SaveDates(_ context.Context, detection *models.Detection) error {
+	for i, d := range r.detections {
+		if d.ID == detection.ID && d.User.ID == detection.User.ID {
+			now := time.Now()
+			r.detections[i].PurchaseDate, r.detections[i].EndDate = detection.PurchaseDate, detection.EndDate
+			r.detections[i].ShelfLifeID, r.detections[i].SavedAt = detection.ShelfLifeID, &now
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakeRepository) EachLabelled(
+	_ context.Context,
+	after, limit int,
+	fn func(models.Detection) error,
+) error {
+	for _, d := range r.detections {
+		if d.SavedAt == nil || d.ID <= after {
+			continue
+		}
+		if limit == 0 {
+			break
+		}
+		limit--
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect returns the function appending the exported samples.
+func collect(samples *[]params.DetectionSample) func(params.DetectionSample) error {
+	return func(sample params.DetectionSample) error {
+		*samples = append(*samples, sample)
+		return nil
+	}
+}
+
+func Test_Record(t *testing.T) {
+	image := []byte("\xff\xd8\xff\xe0 jpeg")
+	testCases := []struct {
+		name        string
+		consent     bool
+		image       []byte
+		contentType string
+	}{
+		{name: "without consent"},
+		{name: "with consent", consent: true, image: image, contentType: "image/jpeg"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(fakeRepository)
+			svc := New(repo)
+			end := time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC)
+			dates := params.DetectedDates{
+				Dates:   []params.DetectedDate{{Date: end, Role: "best_before", Format: "day-month-year"}},
+				EndDate: &end,
+				RawText: []string{"годен до 24.09.22"},
+				Overlay: []byte("png"),
+			}
+			assert.NoError(t, svc.Record(context.Background(), 1, image, tc.consent, &dates))
+			assert.Equal(t, 1, dates.DetectionID)
+			assert.Len(t, repo.detections, 1)
+			stored := repo.detections[0]
+			assert.Equal(t, 1, stored.User.ID)
+			assert.Len(t, stored.ImageHash, 64)
+			assert.Equal(t, tc.image, stored.Image)
+			assert.Equal(t, tc.contentType, stored.ContentType)
+			assert.Equal(t, []string{"годен до 24.09.22"}, stored.RawText)
+			assert.Contains(t, string(stored.Proposed), `"raw_text":null`)
+			assert.NotContains(t, string(stored.Proposed), "overlay")
+			assert.Contains(t, string(stored.Proposed), `"format":"day-month-year"`)
+		})
+	}
+}
+
+func Test_SaveFeedbackAndExport(t *testing.T) {
+	repo := new(fakeRepository)
+	svc := New(repo)
+	end := time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		dates := params.DetectedDates{Dates: []params.DetectedDate{{Date: end}}, RawText: []string{"24.09.22"}}
+		assert.NoError(t, svc.Record(context.Background(), 1, []byte{byte(i)}, false, &dates))
+	}
+	purchase := end.AddDate(0, 0, -9)
+	feedback := &params.DetectionFeedback{PurchaseDate: &purchase, EndDate: &end, ShelfLifeID: 5}
+	assert.NoError(t, svc.SaveFeedback(context.Background(), 1, 1, feedback))
+	assert.NoError(t, svc.SaveFeedback(context.Background(), 1, 3, feedback))
+	assert.ErrorIs(t, svc.SaveFeedback(context.Background(), 2, 2, feedback), ErrNotFound)
+	assert.ErrorIs(t, svc.SaveFeedback(context.Background(), 1, 4, feedback), ErrNotFound)
+
+	var samples []params.DetectionSample
+	err := svc.Export(context.Background(), &params.DetectionExportFilter{}, collect(&samples))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 2)
+	assert.Equal(t, []int{1, 3}, []int{samples[0].ID, samples[1].ID})
+	assert.Equal(t, &end, samples[0].EndDate)
+	assert.Equal(t, end, samples[0].Proposed.Dates[0].Date)
+	assert.Equal(t, []string{"24.09.22"}, samples[0].RawText)
+
+	samples = nil
+	filter := &params.DetectionExportFilter{After: 1, Limit: 1}
+	assert.NoError(t, svc.Export(context.Background(), filter, collect(&samples)))
+	assert.Len(t, samples, 1)
+	assert.Equal(t, 3, samples[0].ID)
+
+	errWrite := errors.New("broken pipe")
+	err = svc.Export(context.Background(), &params.DetectionExportFilter{}, func(params.DetectionSample) error {
+		return errWrite
+	})
+	assert.ErrorIs(t, err, errWrite)
+}
@@ -0,0 +1,210 @@
+// Package labelshelflife creates the shelf lives from the photos of the
+// labels of the products. The end date is detected on the photo unless the
+// user sets it, and the photo is kept with the shelf life. The detections
+// are recorded with the dates the user saved as their feedback.
+package labelshelflife
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/attachment"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+const (
+	// dateLayout is the layout of the dates set by the user
+	dateLayout = "2006-01-02"
+	// minConfidence is the confidence of the end date below which the user
+	// chooses it
+	minConfidence = 0.5
+	// confidenceMargin is the difference of the confidences of two end dates
+	// of the same role below which neither of them is chosen
+	confidenceMargin = 0.1
+)
+
+var (
+	// ErrNotFound is returned when the shelf life of the user has no photo.
+	ErrNotFound = errors.New("shelf life attachment not found")
+	// ErrInvalidEndDate is returned when the end date set by the user is not
+	// after the purchase date.
+	ErrInvalidEndDate = errors.New("end date must be after purchase date")
+)
+
+// AmbiguousError is returned when the end date can not be chosen among the
+// detected dates. The user sets one of the candidates as the end date and
+// sends the id of the detection with it.
+type AmbiguousError struct {
+	Reason      string
+	DetectionID int
+	Candidates  []params.DetectedDate
+}
+
+func (e *AmbiguousError) Error() string {
+	return "detected dates are ambiguous: " + e.Reason
+}
+
+type LabelShelfLifeServicer interface {
+	// Create detects the dates on the photo and creates the shelf life of the
+	// user with the photo attached. The dates set in the payload are not
+	// detected, the photo is not recognized if the end date is set. The
+	// saved dates are the feedback of the detection, an unknown detection
+	// of the payload is ignored.
+	Create(
+		ctx context.Context,
+		userID int,
+		payload *params.CreateDetectedShelfLife,
+		image []byte,
+	) (params.FindShelfLife, error)
+	// FindAttachment returns the photo of the shelf life of the user.
+	FindAttachment(ctx context.Context, userID, shelfLifeID int) (params.ShelfLifeAttachment, error)
+}
+
+type labelShelfLifeService struct {
+	repo       attachment.AttachmentRepositorer
+	detector   sldetector.DateDetectorServicer
+	detections detection.DetectionServicer
+}
+
+func New(
+	repo attachment.AttachmentRepositorer,
+	detector sldetector.DateDetectorServicer,
+	detections detection.DetectionServicer,
+) LabelShelfLifeServicer {
+	return &labelShelfLifeService{repo: repo, detector: detector, detections: detections}
+}
+
+// Create implements LabelShelfLifeServicer
+func (s *labelShelfLifeService) Create(
+	ctx context.Context,
+	userID int,
+	payload *params.CreateDetectedShelfLife,
+	image []byte,
+) (params.FindShelfLife, error) {
+	purchase, err := parseDate(payload.PurchaseDate)
+	if err != nil {
+		return params.FindShelfLife{}, err
+	}
+	end, err := parseDate(payload.EndDate)
+	if err != nil {
+		return params.FindShelfLife{}, err
+	}
+	ok, err := s.repo.HasStorage(ctx, userID, payload.StorageID)
+	if err != nil {
+		return params.FindShelfLife{}, fmt.Errorf("check storage: %w", err)
+	}
+	if !ok {
+		return params.FindShelfLife{}, apperrors.ErrNotOwner
+	}
+	detectionID := payload.DetectionID
+	if end == nil {
+		dates, err := s.detector.Detect(ctx, image, false)
+		if err != nil {
+			return params.FindShelfLife{}, err
+		}
+		if err := s.detections.Record(ctx, userID, image, payload.Consent, &dates); err != nil {
+			return params.FindShelfLife{}, fmt.Errorf("record detection: %w", err)
+		}
+		detectionID = dates.DetectionID
+		if purchase == nil {
+			purchase = dates.PurchaseDate
+		}
+		if end, err = endDate(dates, purchase); err != nil {
+			return params.FindShelfLife{}, err
+		}
+	}
+	if purchase == nil {
+		now := time.Now().UTC().Truncate(24 * time.Hour)
+		purchase = &now
+	}
+	if !end.After(*purchase) {
+		return params.FindShelfLife{}, ErrInvalidEndDate
+	}
+	model := models.ShelfLife{
+		Product:      models.Product{ID: payload.ProductID},
+		Storage:      models.Vault{ID: payload.StorageID},
+		Measure:      models.Measure{ID: payload.MeasureID},
+		Quantity:     payload.Quantity,
+		PurchaseDate: purchase,
+		EndDate:      end,
+	}
+	file := models.Attachment{ContentType: http.DetectContentType(image), Data: image}
+	if err := s.repo.CreateShelfLife(ctx, userID, &model, &file); err != nil {
+		return params.FindShelfLife{}, fmt.Errorf("create shelf life: %w", err)
+	}
+	if detectionID != 0 {
+		feedback := &params.DetectionFeedback{PurchaseDate: purchase, EndDate: end, ShelfLifeID: model.ID}
+		err := s.detections.SaveFeedback(ctx, userID, detectionID, feedback)
+		if err != nil && !errors.Is(err, detection.ErrNotFound) {
+			return params.FindShelfLife{}, fmt.Errorf("save detection feedback: %w", err)
+		}
+	}
+	return utils.ShelfLifeModelToFind(&model), nil
+}
+
+// FindAttachment implements LabelShelfLifeServicer
+func (s *labelShelfLifeService) FindAttachment(
+	ctx context.Context,
+	userID, shelfLifeID int,
+) (params.ShelfLifeAttachment, error) {
+	file, err := s.repo.FindByShelfLife(ctx, userID, shelfLifeID)
+	if errors.Is(err, attachment.ErrNotFound) {
+		return params.ShelfLifeAttachment{}, ErrNotFound
+	}
+	if err != nil {
+		return params.ShelfLifeAttachment{}, fmt.Errorf("find attachment: %w", err)
+	}
+	return params.ShelfLifeAttachment{ContentType: file.ContentType, Data: file.Data}, nil
+}
+
+// endDate returns the suggested end date of the detected dates if it is
+// confident, after the purchase date and no other date of its role is
+// nearly as confident.
+func endDate(dates params.DetectedDates, purchase *time.Time) (*time.Time, error) {
+	ambiguous := func(reason string) error {
+		return &AmbiguousError{Reason: reason, DetectionID: dates.DetectionID, Candidates: dates.Dates}
+	}
+	if dates.EndDate == nil {
+		return nil, ambiguous("no end date is detected")
+	}
+	var chosen params.DetectedDate
+	for _, date := range dates.Dates {
+		if date.Date.Equal(*dates.EndDate) {
+			chosen = date
+			break
+		}
+	}
+	if chosen.Confidence < minConfidence {
+		return nil, ambiguous("end date is not confident")
+	}
+	for _, date := range dates.Dates {
+		if date.Role == chosen.Role && !date.Date.Equal(chosen.Date) &&
+			chosen.Confidence-date.Confidence < confidenceMargin {
+			return nil, ambiguous("several end dates are detected")
+		}
+	}
+	if purchase != nil && !dates.EndDate.After(*purchase) {
+		return nil, ambiguous("end date is not after purchase date")
+	}
+	return dates.EndDate, nil
+}
+
+// parseDate parses the date set by the user, it is nil if it is not set.
+func parseDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, fmt.Errorf("parse date: %w", err)
+	}
+	return &date, nil
+}
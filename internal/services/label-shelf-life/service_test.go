@@ -0,0 +1,261 @@
+package labelshelflife
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/detection"
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/attachment"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository owns the storage 1 and keeps the created shelf life.
+type fakeRepository struct {
+	attachment.AttachmentRepositorer
+	shelfLife  *models.ShelfLife
+	attachment *models.Attachment
+}
+
+func (r *fakeRepository) HasStorage(_ context.Context, _, storageID int) (bool, error) {
+	return storageID == 1, nil
+}
+
+func (r *fakeRepository) CreateShelfLife(
+	_ context.Context,
+	userID int,
+	shelfLife *models.ShelfLife,
+	file *models.Attachment,
+) error {
+	shelfLife.ID, shelfLife.User = 1, models.User{ID: userID}
+	file.ID, file.ShelfLifeID = 1, shelfLife.ID
+	r.shelfLife, r.attachment = shelfLife, file
+	return nil
+}
+
+func (r *fakeRepository) FindByShelfLife(_ context.Context, _, shelfLifeID int) (models.Attachment, error) {
+	if r.attachment == nil || r.attachment.ShelfLifeID != shelfLifeID {
+		return models.Attachment{}, attachment.ErrNotFound
+	}
+	return *r.attachment, nil
+}
+
+// fakeDetector returns the dates or the error and counts the detections.
+type fakeDetector struct {
+	sldetector.DateDetectorServicer
+	dates params.DetectedDates
+	err   error
+	calls int
+}
+
+func (d *fakeDetector) Detect(context.Context, []byte, bool) (params.DetectedDates, error) {
+	d.calls++
+	return d.dates, d.err
+}
+
+// fakeDetections numbers the recorded detections from 1, the detection 7
+// is recorded before.
+type fakeDetections struct {
+	detection.DetectionServicer
+	recorded int
+	consent  bool
+	feedback map[int]params.DetectionFeedback
+}
+
+func (d *fakeDetections) Record(_ context.Context, _ int, _ []byte, consent bool, dates *params.DetectedDates) error {
+	d.recorded++
+	d.consent, dates.DetectionID = consent, d.recorded
+	return nil
+}
+
+func (d *fakeDetections) SaveFeedback(_ context.Context, _, id int, payload *params.DetectionFeedback) error {
+	if id > d.recorded && id != 7 {
+		return detection.ErrNotFound
+	}
+	d.feedback[id] = *payload
+	return nil
+}
+
+func date(day int) *time.Time {
+	t := time.Date(2022, time.September, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func Test_Create(t *testing.T) {
+	image := []byte("\x89PNG\r\n\x1a\n")
+	detected := func(end *time.Time, dates ...params.DetectedDate) params.DetectedDates {
+		return params.DetectedDates{Dates: dates, PurchaseDate: date(15), EndDate: end}
+	}
+	produced := params.DetectedDate{Date: *date(15), Role: "manufactured", Confidence: 0.9}
+	testCases := []struct {
+		name      string
+		payload   params.CreateDetectedShelfLife
+		dates     params.DetectedDates
+		detectErr error
+		purchase  *time.Time
+		end       *time.Time
+		calls     int
+		feedback  int
+		ambiguous bool
+		err       error
+	}{
+		{
+			name: "detected",
+			dates: detected(date(24), produced,
+				params.DetectedDate{Date: *date(24), Role: "best_before", Confidence: 0.9}),
+			purchase: date(15),
+			end:      date(24),
+			calls:    1,
+			feedback: 1,
+		},
+		{
+			name:     "set by user",
+			payload:  params.CreateDetectedShelfLife{PurchaseDate: "2022-09-16", EndDate: "2022-09-30"},
+			purchase: date(16),
+			end:      date(30),
+		},
+		{
+			name: "chosen from candidates",
+			payload: params.CreateDetectedShelfLife{
+				PurchaseDate: "2022-09-16",
+				EndDate:      "2022-09-30",
+				DetectionID:  7,
+			},
+			purchase: date(16),
+			end:      date(30),
+			feedback: 7,
+		},
+		{
+			name: "unknown detection",
+			payload: params.CreateDetectedShelfLife{
+				PurchaseDate: "2022-09-16",
+				EndDate:      "2022-09-30",
+				DetectionID:  8,
+			},
+			purchase: date(16),
+			end:      date(30),
+		},
+		{
+			name:    "purchase date set by user",
+			payload: params.CreateDetectedShelfLife{PurchaseDate: "2022-09-17"},
+			dates: detected(date(24), produced,
+				params.DetectedDate{Date: *date(24), Role: "best_before", Confidence: 0.9}),
+			purchase: date(17),
+			end:      date(24),
+			calls:    1,
+			feedback: 1,
+		},
+		{
+			name:      "no end date",
+			dates:     detected(nil, produced),
+			calls:     1,
+			ambiguous: true,
+		},
+		{
+			name: "not confident",
+			dates: detected(date(24), produced,
+				params.DetectedDate{Date: *date(24), Role: "unknown", Confidence: 0.3}),
+			calls:     1,
+			ambiguous: true,
+		},
+		{
+			name: "several end dates",
+			dates: detected(date(24), produced,
+				params.DetectedDate{Date: *date(24), Role: "best_before", Confidence: 0.8},
+				params.DetectedDate{Date: *date(26), Role: "best_before", Confidence: 0.75}),
+			calls:     1,
+			ambiguous: true,
+		},
+		{
+			name:    "before purchase date",
+			payload: params.CreateDetectedShelfLife{PurchaseDate: "2022-09-25"},
+			dates: detected(date(24), produced,
+				params.DetectedDate{Date: *date(24), Role: "use_by", Confidence: 0.9}),
+			calls:     1,
+			ambiguous: true,
+		},
+		{
+			name:    "invalid end date",
+			payload: params.CreateDetectedShelfLife{PurchaseDate: "2022-09-25", EndDate: "2022-09-24"},
+			err:     ErrInvalidEndDate,
+		},
+		{
+			name:      "no dates",
+			detectErr: sldetector.ErrNoDates,
+			calls:     1,
+			err:       sldetector.ErrNoDates,
+		},
+		{
+			name:    "other storage",
+			payload: params.CreateDetectedShelfLife{StorageID: 2},
+			err:     apperrors.ErrNotOwner,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(fakeRepository)
+			detector := &fakeDetector{dates: tc.dates, err: tc.detectErr}
+			detections := &fakeDetections{feedback: map[int]params.DetectionFeedback{}}
+			svc := New(repo, detector, detections)
+			payload := tc.payload
+			payload.ProductID, payload.MeasureID, payload.Quantity, payload.Consent = 1, 1, 2, true
+			if payload.StorageID == 0 {
+				payload.StorageID = 1
+			}
+			result, err := svc.Create(context.Background(), 1, &payload, image)
+			assert.Equal(t, tc.calls, detector.calls)
+			if tc.ambiguous {
+				var ambiguous *AmbiguousError
+				assert.True(t, errors.As(err, &ambiguous))
+				assert.Equal(t, tc.dates.Dates, ambiguous.Candidates)
+				assert.Equal(t, 1, ambiguous.DetectionID)
+				assert.Nil(t, repo.shelfLife)
+				assert.Empty(t, detections.feedback)
+				return
+			}
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Nil(t, repo.shelfLife)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, result.ID)
+			assert.Equal(t, tc.purchase, result.PurchaseDate)
+			assert.Equal(t, tc.end, result.EndDate)
+			assert.Equal(t, "image/png", repo.attachment.ContentType)
+			assert.Equal(t, image, repo.attachment.Data)
+			assert.Equal(t, tc.calls, detections.recorded)
+			assert.Equal(t, tc.calls == 1, detections.consent)
+			if tc.feedback == 0 {
+				assert.Empty(t, detections.feedback)
+				return
+			}
+			assert.Equal(t, params.DetectionFeedback{
+				PurchaseDate: tc.purchase,
+				EndDate:      tc.end,
+				ShelfLifeID:  1,
+			}, detections.feedback[tc.feedback])
+		})
+	}
+}
+
+func Test_FindAttachment(t *testing.T) {
+	repo := &fakeRepository{attachment: &models.Attachment{
+		ShelfLifeID: 1,
+		ContentType: "image/jpeg",
+		Data:        []byte("image"),
+	}}
+	svc := New(repo, new(fakeDetector), new(fakeDetections))
+
+	result, err := svc.FindAttachment(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, params.ShelfLifeAttachment{ContentType: "image/jpeg", Data: []byte("image")}, result)
+
+	_, err = svc.FindAttachment(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
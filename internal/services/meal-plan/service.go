@@ -0,0 +1,292 @@
+package mealplan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	recipesvc "github.com/romankravchuk/muerta/internal/services/recipe"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/meal-plan"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+)
+
+type MealPlanServicer interface {
+	FindMealPlans(
+		ctx context.Context,
+		userID int,
+		filter *params.MealPlanFilter,
+	) ([]params.FindMealPlan, error)
+	Count(ctx context.Context, userID int, filter params.MealPlanFilter) (int, error)
+	FindMealPlanByID(ctx context.Context, userID, id int) (params.FindMealPlan, error)
+	// CreateMealPlan schedules the recipe visible to the user, the moderator
+	// sees all the recipes.
+	CreateMealPlan(
+		ctx context.Context,
+		userID int,
+		payload *params.CreateMealPlan,
+		moderator bool,
+	) (params.FindMealPlan, error)
+	UpdateMealPlan(
+		ctx context.Context,
+		userID, id int,
+		payload *params.UpdateMealPlan,
+	) (params.FindMealPlan, error)
+	DeleteMealPlan(ctx context.Context, userID, id int) error
+	CookMealPlan(ctx context.Context, userID, id int) (params.FindMealPlan, error)
+}
+
+type mealPlanService struct {
+	repo    repository.MealPlanRepositorer
+	recipes recipes.RecipesRepositorer
+}
+
+func New(
+	repo repository.MealPlanRepositorer,
+	recipes recipes.RecipesRepositorer,
+) MealPlanServicer {
+	return &mealPlanService{
+		repo:    repo,
+		recipes: recipes,
+	}
+}
+
+// FindMealPlans implements MealPlanServicer
+func (s *mealPlanService) FindMealPlans(
+	ctx context.Context,
+	userID int,
+	filter *params.MealPlanFilter,
+) ([]params.FindMealPlan, error) {
+	model := utils.MealPlanFilterToModel(filter)
+	model.UserID = userID
+	result, err := s.repo.FindMany(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("error finding meal plans: %w", err)
+	}
+	dtos := make([]params.FindMealPlan, len(result))
+	for i, plan := range result {
+		dtos[i] = utils.MealPlanModelToFind(&plan, nil)
+	}
+	return dtos, nil
+}
+
+// Count implements MealPlanServicer
+func (s *mealPlanService) Count(
+	ctx context.Context,
+	userID int,
+	filter params.MealPlanFilter,
+) (int, error) {
+	model := utils.MealPlanFilterToModel(&filter)
+	model.UserID = userID
+	count, err := s.repo.Count(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("error counting meal plans: %w", err)
+	}
+	return count, nil
+}
+
+// FindMealPlanByID implements MealPlanServicer
+func (s *mealPlanService) FindMealPlanByID(
+	ctx context.Context,
+	userID, id int,
+) (params.FindMealPlan, error) {
+	plan, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		return params.FindMealPlan{}, fmt.Errorf("error finding meal plan: %w", err)
+	}
+	if plan.CookedAt != nil {
+		return utils.MealPlanModelToFind(&plan, nil), nil
+	}
+	needs, err := s.needs(ctx, plan.Recipe.ID, plan.Servings)
+	if err != nil {
+		return params.FindMealPlan{}, err
+	}
+	return utils.MealPlanModelToFind(&plan, Warnings(needs, plan.Reservations, plan.Date)), nil
+}
+
+// CreateMealPlan implements MealPlanServicer
+func (s *mealPlanService) CreateMealPlan(
+	ctx context.Context,
+	userID int,
+	payload *params.CreateMealPlan,
+	moderator bool,
+) (params.FindMealPlan, error) {
+	if err := recipesvc.CheckVisible(ctx, s.recipes, payload.RecipeID, userID, moderator); err != nil {
+		return params.FindMealPlan{}, err
+	}
+	needs, err := s.needs(ctx, payload.RecipeID, payload.Servings)
+	if err != nil {
+		return params.FindMealPlan{}, err
+	}
+	plan := models.MealPlan{
+		User:     models.User{ID: userID},
+		Recipe:   models.Recipe{ID: payload.RecipeID},
+		Date:     truncateDay(*payload.Date),
+		Servings: payload.Servings,
+	}
+	if err := s.repo.Create(ctx, &plan, needs); err != nil {
+		return params.FindMealPlan{}, fmt.Errorf("error creating meal plan: %w", err)
+	}
+	return s.FindMealPlanByID(ctx, userID, plan.ID)
+}
+
+// UpdateMealPlan implements MealPlanServicer
+func (s *mealPlanService) UpdateMealPlan(
+	ctx context.Context,
+	userID, id int,
+	payload *params.UpdateMealPlan,
+) (params.FindMealPlan, error) {
+	plan, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		return params.FindMealPlan{}, fmt.Errorf("error finding meal plan: %w", err)
+	}
+	if payload.Date != nil {
+		plan.Date = truncateDay(*payload.Date)
+	}
+	if payload.Servings != 0 {
+		plan.Servings = payload.Servings
+	}
+	needs, err := s.needs(ctx, plan.Recipe.ID, plan.Servings)
+	if err != nil {
+		return params.FindMealPlan{}, err
+	}
+	if err := s.repo.Update(ctx, &plan, needs); err != nil {
+		return params.FindMealPlan{}, fmt.Errorf("error updating meal plan: %w", err)
+	}
+	return s.FindMealPlanByID(ctx, userID, id)
+}
+
+// DeleteMealPlan implements MealPlanServicer
+func (s *mealPlanService) DeleteMealPlan(ctx context.Context, userID, id int) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return fmt.Errorf("error deleting meal plan: %w", err)
+	}
+	return nil
+}
+
+// CookMealPlan implements MealPlanServicer
+func (s *mealPlanService) CookMealPlan(
+	ctx context.Context,
+	userID, id int,
+) (params.FindMealPlan, error) {
+	if err := s.repo.Cook(ctx, userID, id); err != nil {
+		return params.FindMealPlan{}, fmt.Errorf("error cooking meal plan: %w", err)
+	}
+	return s.FindMealPlanByID(ctx, userID, id)
+}
+
+// needs returns the quantities of the recipe ingredients for the servings.
+func (s *mealPlanService) needs(
+	ctx context.Context,
+	recipeID, servings int,
+) ([]models.MealPlanNeed, error) {
+	recipe, err := s.recipes.FindByID(ctx, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding recipe: %w", err)
+	}
+	return Needs(recipe, servings), nil
+}
+
+// Needs scales the recipe ingredients to the servings and merges the
+// ingredients of the same product and measure.
+func Needs(recipe models.Recipe, servings int) []models.MealPlanNeed {
+	ingredients := recipesvc.Scale(recipe.Ingredients, recipe.Servings, servings)
+	needs := make([]models.MealPlanNeed, 0, len(ingredients))
+	index := make(map[[2]int]int)
+	for _, ingredient := range ingredients {
+		quantity := float32(ingredient.Quantity)
+		key := [2]int{ingredient.Product.ID, ingredient.Measure.ID}
+		if i, ok := index[key]; ok {
+			needs[i].Quantity += quantity
+			continue
+		}
+		index[key] = len(needs)
+		needs = append(needs, models.MealPlanNeed{
+			Product:  ingredient.Product,
+			Measure:  ingredient.Measure,
+			Quantity: quantity,
+		})
+	}
+	return needs
+}
+
+// shortageEpsilon is the shortage in the measure of the need which is
+// treated as the rounding error of the conversions.
+const shortageEpsilon = 1e-3
+
+// Warnings returns a warning for every reservation of a shelf life which
+// ends before the date of the meal and for every need which is not fully
+// reserved. The reservations are matched to the needs in order as Allocate
+// does, the quantity of a reservation counted for a need is not counted for
+// the other needs. The reserved quantities are converted to the measure of
+// the need.
+func Warnings(
+	needs []models.MealPlanNeed,
+	reservations []models.Reservation,
+	date time.Time,
+) []models.MealPlanWarning {
+	warnings := make([]models.MealPlanWarning, 0)
+	for _, reservation := range reservations {
+		shelfLife := reservation.ShelfLife
+		if shelfLife.EndDate != nil && shelfLife.EndDate.Before(date) {
+			warnings = append(warnings, models.MealPlanWarning{
+				Kind:     models.MealPlanWarningExpired,
+				Product:  shelfLife.Product,
+				Measure:  shelfLife.Measure,
+				Quantity: reservation.Quantity,
+				EndDate:  shelfLife.EndDate,
+			})
+		}
+	}
+	// the reserved quantities not counted yet, in the measures of the shelf
+	// lives
+	reserved := make([]float32, len(reservations))
+	for i, reservation := range reservations {
+		reserved[i] = reservation.Quantity
+	}
+	for _, need := range needs {
+		left := need.Quantity
+		for i, reservation := range reservations {
+			if left <= shortageEpsilon {
+				break
+			}
+			shelfLife := reservation.ShelfLife
+			if shelfLife.Product.ID != need.Product.ID || reserved[i] <= 0 {
+				continue
+			}
+			available, err := measure.Convert(
+				float64(reserved[i]), shelfLife.Measure, need.Measure, need.Product,
+			)
+			if err != nil {
+				continue
+			}
+			if float32(available) <= left {
+				left -= float32(available)
+				reserved[i] = 0
+				continue
+			}
+			counted, err := measure.Convert(float64(left), need.Measure, shelfLife.Measure, need.Product)
+			if err != nil {
+				continue
+			}
+			reserved[i] -= float32(counted)
+			left = 0
+		}
+		if left > shortageEpsilon {
+			warnings = append(warnings, models.MealPlanWarning{
+				Kind:     models.MealPlanWarningShortage,
+				Product:  need.Product,
+				Measure:  need.Measure,
+				Quantity: left,
+			})
+		}
+	}
+	return warnings
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
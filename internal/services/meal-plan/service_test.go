@@ -0,0 +1,114 @@
+package mealplan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Warnings(t *testing.T) {
+	date := time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC)
+	before := date.AddDate(0, 0, -1)
+	after := date.AddDate(0, 0, 1)
+	milk := models.Product{ID: 1, Name: "Молоко"}
+	litre := models.Measure{ID: 1, Name: "л"}
+	needs := Needs(models.Recipe{
+		Servings: 1,
+		Ingredients: []models.RecipeIngredient{
+			{Product: milk, Measure: litre, Quantity: 1},
+			{Product: milk, Measure: litre, Quantity: 1},
+		},
+	}, 2)
+	testCases := []struct {
+		name         string
+		reservations []models.Reservation
+		expected     []models.MealPlanWarning
+	}{
+		{
+			name: "fully reserved",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: milk, Measure: litre, EndDate: &after}, Quantity: 4},
+			},
+			expected: []models.MealPlanWarning{},
+		},
+		{
+			name: "ends before the meal",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: milk, Measure: litre, EndDate: &after}, Quantity: 3},
+				{ShelfLife: models.ShelfLife{Product: milk, Measure: litre, EndDate: &before}, Quantity: 1},
+			},
+			expected: []models.MealPlanWarning{
+				{
+					Kind:     models.MealPlanWarningExpired,
+					Product:  milk,
+					Measure:  litre,
+					Quantity: 1,
+					EndDate:  &before,
+				},
+			},
+		},
+		{
+			name: "shortage",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: milk, Measure: litre, EndDate: &after}, Quantity: 2.5},
+			},
+			expected: []models.MealPlanWarning{
+				{Kind: models.MealPlanWarningShortage, Product: milk, Measure: litre, Quantity: 1.5},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Warnings(needs, tc.reservations, date))
+		})
+	}
+}
+
+func Test_WarningsOfOneProduct(t *testing.T) {
+	date := time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC)
+	after := date.AddDate(0, 0, 1)
+	flour := models.Product{ID: 1, Name: "Мука"}
+	gram := models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+	kilogram := models.Measure{ID: 2, Name: "кг", Dimension: models.DimensionMass, Factor: 1000}
+	needs := []models.MealPlanNeed{
+		{Product: flour, Measure: gram, Quantity: 500},
+		{Product: flour, Measure: kilogram, Quantity: 1},
+	}
+	testCases := []struct {
+		name         string
+		reservations []models.Reservation
+		expected     []models.MealPlanWarning
+	}{
+		{
+			name: "reservation counted once",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: flour, Measure: kilogram, EndDate: &after}, Quantity: 1},
+			},
+			expected: []models.MealPlanWarning{
+				{Kind: models.MealPlanWarningShortage, Product: flour, Measure: kilogram, Quantity: 0.5},
+			},
+		},
+		{
+			name: "fully reserved",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: flour, Measure: gram, EndDate: &after}, Quantity: 700},
+				{ShelfLife: models.ShelfLife{Product: flour, Measure: kilogram, EndDate: &after}, Quantity: 0.8},
+			},
+			expected: []models.MealPlanWarning{},
+		},
+		{
+			name: "rounding error of the conversion",
+			reservations: []models.Reservation{
+				{ShelfLife: models.ShelfLife{Product: flour, Measure: gram, EndDate: &after}, Quantity: 1499.9999},
+			},
+			expected: []models.MealPlanWarning{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Warnings(needs, tc.reservations, date))
+		})
+	}
+}
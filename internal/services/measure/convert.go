@@ -0,0 +1,102 @@
+package measure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+var (
+	// ErrUnknownDimension is returned when a measure has no dimension or
+	// conversion factor.
+	ErrUnknownDimension = errors.New("measure has unknown dimension")
+	// ErrIncompatibleMeasures is returned when the quantity can not be
+	// converted between the measures of the product.
+	ErrIncompatibleMeasures = errors.New("incompatible measures")
+)
+
+// Convert converts the quantity of the product from one measure to another.
+// The measures of the same dimension are converted through the base unit of
+// the dimension. The measures of different dimensions are converted through
+// grams, the volume by the density of the product and the count by the
+// weight of a piece of the product.
+func Convert(
+	quantity float64,
+	from, to models.Measure,
+	product models.Product,
+) (float64, error) {
+	if from.ID != 0 && from.ID == to.ID {
+		return quantity, nil
+	}
+	for _, measure := range []models.Measure{from, to} {
+		if !known(measure) {
+			return 0, fmt.Errorf("%w: %q", ErrUnknownDimension, measure.Name)
+		}
+	}
+	base := quantity * from.Factor
+	if from.Dimension != to.Dimension {
+		grams, ok := toGrams(base, from.Dimension, product)
+		if !ok {
+			return 0, incompatible(from, to, product)
+		}
+		if base, ok = fromGrams(grams, to.Dimension, product); !ok {
+			return 0, incompatible(from, to, product)
+		}
+	}
+	return base / to.Factor, nil
+}
+
+// Compatible reports whether the quantity of the product can be converted
+// from one measure to another.
+func Compatible(from, to models.Measure, product models.Product) bool {
+	_, err := Convert(1, from, to, product)
+	return err == nil
+}
+
+func known(measure models.Measure) bool {
+	switch measure.Dimension {
+	case models.DimensionMass, models.DimensionVolume, models.DimensionCount:
+		return measure.Factor > 0
+	}
+	return false
+}
+
+func toGrams(base float64, dimension string, product models.Product) (float64, bool) {
+	switch dimension {
+	case models.DimensionMass:
+		return base, true
+	case models.DimensionVolume:
+		if product.Density != nil && *product.Density > 0 {
+			return base * *product.Density, true
+		}
+	case models.DimensionCount:
+		if product.PieceWeight != nil && *product.PieceWeight > 0 {
+			return base * *product.PieceWeight, true
+		}
+	}
+	return 0, false
+}
+
+func fromGrams(grams float64, dimension string, product models.Product) (float64, bool) {
+	switch dimension {
+	case models.DimensionMass:
+		return grams, true
+	case models.DimensionVolume:
+		if product.Density != nil && *product.Density > 0 {
+			return grams / *product.Density, true
+		}
+	case models.DimensionCount:
+		if product.PieceWeight != nil && *product.PieceWeight > 0 {
+			return grams / *product.PieceWeight, true
+		}
+	}
+	return 0, false
+}
+
+func incompatible(from, to models.Measure, product models.Product) error {
+	return fmt.Errorf(
+		"%w: can not convert %q from %s to %s",
+		ErrIncompatibleMeasures, product.Name, from.Dimension, to.Dimension,
+	)
+}
@@ -0,0 +1,56 @@
+package measure
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Convert(t *testing.T) {
+	var (
+		density     = 1.03
+		pieceWeight = 60.0
+		milk        = models.Product{Name: "Молоко", Density: &density}
+		eggs        = models.Product{Name: "Яйца", PieceWeight: &pieceWeight}
+		salt        = models.Product{Name: "Соль"}
+		gram        = models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+		kilogram    = models.Measure{ID: 2, Name: "кг", Dimension: models.DimensionMass, Factor: 1000}
+		milliliter  = models.Measure{ID: 3, Name: "мл", Dimension: models.DimensionVolume, Factor: 1}
+		liter       = models.Measure{ID: 4, Name: "л", Dimension: models.DimensionVolume, Factor: 1000}
+		piece       = models.Measure{ID: 5, Name: "шт", Dimension: models.DimensionCount, Factor: 1}
+		dozen       = models.Measure{ID: 6, Name: "дюжина", Dimension: models.DimensionCount, Factor: 12}
+		unknown     = models.Measure{ID: 7, Name: "щепотка"}
+	)
+	testCases := []struct {
+		name     string
+		quantity float64
+		from, to models.Measure
+		product  models.Product
+		expected float64
+		err      error
+	}{
+		{name: "same measure", quantity: 2, from: unknown, to: unknown, product: salt, expected: 2},
+		{name: "mass", quantity: 1.5, from: kilogram, to: gram, product: salt, expected: 1500},
+		{name: "volume", quantity: 250, from: milliliter, to: liter, product: milk, expected: 0.25},
+		{name: "count", quantity: 2, from: dozen, to: piece, product: eggs, expected: 24},
+		{name: "volume to mass", quantity: 1, from: liter, to: gram, product: milk, expected: 1030},
+		{name: "mass to volume", quantity: 2.06, from: kilogram, to: liter, product: milk, expected: 2},
+		{name: "count to mass", quantity: 6, from: piece, to: gram, product: eggs, expected: 360},
+		{name: "mass to count", quantity: 0.72, from: kilogram, to: dozen, product: eggs, expected: 1},
+		{name: "volume to count", quantity: 1, from: liter, to: piece, product: milk, err: ErrIncompatibleMeasures},
+		{name: "no density", quantity: 1, from: gram, to: milliliter, product: salt, err: ErrIncompatibleMeasures},
+		{name: "unknown dimension", quantity: 1, from: unknown, to: gram, product: salt, err: ErrUnknownDimension},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Convert(tc.quantity, tc.from, tc.to, tc.product)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tc.expected, actual, 1e-9)
+		})
+	}
+}
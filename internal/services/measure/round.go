@@ -0,0 +1,39 @@
+package measure
+
+import (
+	"math"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// Round rounds the quantity to a precision sensible for the measure. The
+// count is rounded to whole pieces. The mass and the volume are rounded to
+// a step which grows with the quantity in the base unit: 0.1 below 10, 1
+// below 100, 5 below 1000 and 10 above. A positive quantity is never rounded
+// to zero. The quantity of a measure of unknown dimension is rounded to two
+// decimal places.
+func Round(quantity float64, measure models.Measure) float64 {
+	if !known(measure) {
+		return math.Round(quantity*100) / 100
+	}
+	base := quantity * measure.Factor
+	var step float64
+	switch {
+	case measure.Dimension == models.DimensionCount:
+		step = 1
+	case base < 10:
+		step = 0.1
+	case base < 100:
+		step = 1
+	case base < 1000:
+		step = 5
+	default:
+		step = 10
+	}
+	rounded := math.Round(base/step) * step
+	if rounded == 0 && quantity > 0 {
+		rounded = step
+	}
+	// drop the floating point noise of the division
+	return math.Round(rounded/measure.Factor*1e6) / 1e6
+}
@@ -0,0 +1,40 @@
+package measure
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Round(t *testing.T) {
+	var (
+		gram     = models.Measure{Name: "г", Dimension: models.DimensionMass, Factor: 1}
+		kilogram = models.Measure{Name: "кг", Dimension: models.DimensionMass, Factor: 1000}
+		liter    = models.Measure{Name: "л", Dimension: models.DimensionVolume, Factor: 1000}
+		piece    = models.Measure{Name: "шт", Dimension: models.DimensionCount, Factor: 1}
+		dozen    = models.Measure{Name: "дюжина", Dimension: models.DimensionCount, Factor: 12}
+		unknown  = models.Measure{Name: "по вкусу"}
+	)
+	testCases := []struct {
+		name     string
+		quantity float64
+		measure  models.Measure
+		expected float64
+	}{
+		{name: "whole pieces", quantity: 2.6666, measure: piece, expected: 3},
+		{name: "at least one piece", quantity: 0.25, measure: piece, expected: 1},
+		{name: "pieces of a dozen", quantity: 0.54, measure: dozen, expected: 0.5},
+		{name: "small mass", quantity: 3.333, measure: gram, expected: 3.3},
+		{name: "medium mass", quantity: 33.33, measure: gram, expected: 33},
+		{name: "large mass", quantity: 333.3, measure: gram, expected: 335},
+		{name: "kilograms", quantity: 1.3333, measure: kilogram, expected: 1.33},
+		{name: "liters", quantity: 0.6666, measure: liter, expected: 0.665},
+		{name: "unknown dimension", quantity: 1.3333, measure: unknown, expected: 1.33},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Round(tc.quantity, tc.measure))
+		})
+	}
+}
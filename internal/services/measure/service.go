@@ -8,6 +8,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/services/utils"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 )
 
 type MeasureServicer interface {
@@ -17,10 +18,12 @@ type MeasureServicer interface {
 	UpdateMeasure(ctx context.Context, id int, payload *params.UpdateMeasure) error
 	DeleteMeasure(ctx context.Context, id int) error
 	Count(ctx context.Context, filter params.MeasureFilter) (int, error)
+	ConvertMeasure(ctx context.Context, payload *params.ConvertMeasure) (params.FindConversion, error)
 }
 
 type measureService struct {
-	repo repository.MeasureRepositorer
+	repo     repository.MeasureRepositorer
+	products product.ProductRepositorer
 }
 
 func (s *measureService) Count(ctx context.Context, filter params.MeasureFilter) (int, error) {
@@ -93,14 +96,53 @@ func (svc *measureService) UpdateMeasure(
 	if payload.Name != "" {
 		model.Name = payload.Name
 	}
+	if payload.Dimension != "" {
+		model.Dimension = payload.Dimension
+	}
+	if payload.Factor != 0 {
+		model.Factor = payload.Factor
+	}
 	if err := svc.repo.Update(ctx, model); err != nil {
 		return err
 	}
 	return nil
 }
 
-func New(repo repository.MeasureRepositorer) MeasureServicer {
+// ConvertMeasure implements MeasureServicer
+func (svc *measureService) ConvertMeasure(
+	ctx context.Context,
+	payload *params.ConvertMeasure,
+) (params.FindConversion, error) {
+	from, err := svc.repo.FindByID(ctx, payload.FromID)
+	if err != nil {
+		return params.FindConversion{}, fmt.Errorf("error finding measure: %w", err)
+	}
+	to, err := svc.repo.FindByID(ctx, payload.ToID)
+	if err != nil {
+		return params.FindConversion{}, fmt.Errorf("error finding measure: %w", err)
+	}
+	var model models.Product
+	if payload.ProductID != 0 {
+		if model, err = svc.products.FindByID(ctx, payload.ProductID); err != nil {
+			return params.FindConversion{}, fmt.Errorf("error finding product: %w", err)
+		}
+	}
+	quantity, err := Convert(payload.Quantity, from, to, model)
+	if err != nil {
+		return params.FindConversion{}, err
+	}
+	return params.FindConversion{
+		Quantity: quantity,
+		Measure:  utils.MeasureModelToFind(&to),
+	}, nil
+}
+
+func New(
+	repo repository.MeasureRepositorer,
+	products product.ProductRepositorer,
+) MeasureServicer {
 	return &measureService{
-		repo: repo,
+		repo:     repo,
+		products: products,
 	}
 }
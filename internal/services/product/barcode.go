@@ -0,0 +1,95 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/barcode"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/product"
+)
+
+// FindProductByBarcode implements ProductServicer. The suggestion to create a
+// product with the barcode is returned for an unknown barcode.
+func (svc *productService) FindProductByBarcode(
+	ctx context.Context,
+	code string,
+) (params.FindBarcode, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return params.FindBarcode{}, err
+	}
+	model, err := svc.repo.FindByBarcode(ctx, code)
+	if errors.Is(err, repo.ErrUnknownBarcode) {
+		return params.FindBarcode{
+			Barcode:    code,
+			Suggestion: &params.CreateProduct{Barcodes: []string{code}},
+		}, nil
+	}
+	if err != nil {
+		return params.FindBarcode{}, fmt.Errorf("find product by barcode: %w", err)
+	}
+	result := utils.ProductModelToFind(&model)
+	return params.FindBarcode{Barcode: code, Product: &result}, nil
+}
+
+// ScanBarcode implements ProductServicer. It decodes the barcode from the
+// image and finds its product.
+func (svc *productService) ScanBarcode(
+	ctx context.Context,
+	image []byte,
+) (params.FindBarcode, error) {
+	img, err := imaging.Decode(image)
+	if err != nil {
+		return params.FindBarcode{}, err
+	}
+	code, err := barcode.Decode(img)
+	if err != nil {
+		return params.FindBarcode{}, err
+	}
+	return svc.FindProductByBarcode(ctx, code)
+}
+
+// AddBarcode implements ProductServicer
+func (svc *productService) AddBarcode(
+	ctx context.Context,
+	id int,
+	payload *params.ProductBarcode,
+) error {
+	code, err := barcode.Normalize(payload.Barcode)
+	if err != nil {
+		return err
+	}
+	if err := svc.repo.AddBarcode(ctx, id, code); err != nil {
+		return fmt.Errorf("add barcode: %w", err)
+	}
+	return nil
+}
+
+// RemoveBarcode implements ProductServicer
+func (svc *productService) RemoveBarcode(ctx context.Context, id int, code string) error {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return err
+	}
+	if err := svc.repo.RemoveBarcode(ctx, id, code); err != nil {
+		return fmt.Errorf("remove barcode: %w", err)
+	}
+	return nil
+}
+
+// normalizeBarcodes returns the EAN-13 forms of the validated codes.
+func normalizeBarcodes(codes []string) ([]string, error) {
+	result := make([]string, len(codes))
+	for i, code := range codes {
+		normalized, err := barcode.Normalize(code)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = normalized
+	}
+	return result, nil
+}
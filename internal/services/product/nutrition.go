@@ -0,0 +1,101 @@
+package product
+
+import (
+	"math"
+
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// grams is the measure the nutrition facts are given in, per 100 g.
+var grams = models.Measure{Name: "г", Dimension: models.DimensionMass, Factor: 1}
+
+// Portion is a quantity of a product in a measure.
+type Portion struct {
+	Product  models.Product
+	Measure  models.Measure
+	Quantity float64
+}
+
+// Nutrition sums the nutrition facts of the portions. The quantities are
+// converted to grams by measure.Convert. The products without nutrition
+// facts or which quantities can not be converted to grams are returned as
+// missing, each product once.
+func Nutrition(portions []Portion) (models.Nutrition, []models.Product) {
+	var (
+		total   models.Nutrition
+		missing = make([]models.Product, 0)
+		seen    = make(map[int]bool)
+	)
+	for _, portion := range portions {
+		product := portion.Product
+		weight, err := measure.Convert(portion.Quantity, portion.Measure, grams, product)
+		if product.Nutrition == nil || err != nil {
+			if !seen[product.ID] {
+				seen[product.ID] = true
+				missing = append(missing, product)
+			}
+			continue
+		}
+		total = AddNutrition(total, ScaleNutrition(*product.Nutrition, weight/100))
+	}
+	return RoundNutrition(total), missing
+}
+
+// AddNutrition returns the sum of the nutrition facts.
+func AddNutrition(a, b models.Nutrition) models.Nutrition {
+	result := models.Nutrition{
+		Energy:        a.Energy + b.Energy,
+		Protein:       a.Protein + b.Protein,
+		Fat:           a.Fat + b.Fat,
+		Carbohydrates: a.Carbohydrates + b.Carbohydrates,
+	}
+	for _, micronutrients := range []map[string]float64{a.Micronutrients, b.Micronutrients} {
+		for name, value := range micronutrients {
+			if result.Micronutrients == nil {
+				result.Micronutrients = make(map[string]float64)
+			}
+			result.Micronutrients[name] += value
+		}
+	}
+	return result
+}
+
+// ScaleNutrition returns the nutrition facts multiplied by k.
+func ScaleNutrition(n models.Nutrition, k float64) models.Nutrition {
+	result := models.Nutrition{
+		Energy:        n.Energy * k,
+		Protein:       n.Protein * k,
+		Fat:           n.Fat * k,
+		Carbohydrates: n.Carbohydrates * k,
+	}
+	if n.Micronutrients != nil {
+		result.Micronutrients = make(map[string]float64, len(n.Micronutrients))
+		for name, value := range n.Micronutrients {
+			result.Micronutrients[name] = value * k
+		}
+	}
+	return result
+}
+
+// RoundNutrition rounds the nutrition facts to tenths, the micronutrients
+// to hundredths.
+func RoundNutrition(n models.Nutrition) models.Nutrition {
+	result := models.Nutrition{
+		Energy:        round(n.Energy, 10),
+		Protein:       round(n.Protein, 10),
+		Fat:           round(n.Fat, 10),
+		Carbohydrates: round(n.Carbohydrates, 10),
+	}
+	if n.Micronutrients != nil {
+		result.Micronutrients = make(map[string]float64, len(n.Micronutrients))
+		for name, value := range n.Micronutrients {
+			result.Micronutrients[name] = round(value, 100)
+		}
+	}
+	return result
+}
+
+func round(value, precision float64) float64 {
+	return math.Round(value*precision) / precision
+}
@@ -0,0 +1,67 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Nutrition(t *testing.T) {
+	var (
+		density     = 1.03
+		pieceWeight = 50.0
+		gram        = models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+		milliliter  = models.Measure{ID: 2, Name: "мл", Dimension: models.DimensionVolume, Factor: 1}
+		piece       = models.Measure{ID: 3, Name: "шт", Dimension: models.DimensionCount, Factor: 1}
+		flour       = models.Product{ID: 1, Name: "Мука", Nutrition: &models.Nutrition{
+			Energy: 364, Protein: 10.3, Fat: 1.1, Carbohydrates: 76.1,
+			Micronutrients: map[string]float64{"fiber_g": 3.5},
+		}}
+		milk = models.Product{ID: 2, Name: "Молоко", Density: &density, Nutrition: &models.Nutrition{
+			Energy: 52, Protein: 2.8, Fat: 2.5, Carbohydrates: 4.7,
+		}}
+		egg  = models.Product{ID: 3, Name: "Яйцо", PieceWeight: &pieceWeight}
+		salt = models.Product{ID: 4, Name: "Соль", Nutrition: &models.Nutrition{}}
+	)
+	testCases := []struct {
+		name     string
+		portions []Portion
+		expected models.Nutrition
+		missing  []models.Product
+	}{
+		{
+			name: "grams and converted milliliters",
+			portions: []Portion{
+				{Product: flour, Measure: gram, Quantity: 200},
+				{Product: milk, Measure: milliliter, Quantity: 500},
+			},
+			expected: models.Nutrition{
+				Energy:         995.8,
+				Protein:        35,
+				Fat:            15.1,
+				Carbohydrates:  176.4,
+				Micronutrients: map[string]float64{"fiber_g": 7},
+			},
+			missing: []models.Product{},
+		},
+		{
+			name: "missing nutrition and not convertible",
+			portions: []Portion{
+				{Product: egg, Measure: piece, Quantity: 2},
+				{Product: egg, Measure: piece, Quantity: 1},
+				{Product: salt, Measure: piece, Quantity: 1},
+				{Product: milk, Measure: gram, Quantity: 100},
+			},
+			expected: models.Nutrition{Energy: 52, Protein: 2.8, Fat: 2.5, Carbohydrates: 4.7},
+			missing:  []models.Product{egg, salt},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, missing := Nutrition(tc.portions)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.missing, missing)
+		})
+	}
+}
@@ -18,7 +18,7 @@ type ProductServicer interface {
 	DeleteProduct(ctx context.Context, id int) error
 	RestoreProduct(ctx context.Context, id int) error
 	FindProductCategories(ctx context.Context, id int) ([]params.FindProductCategory, error)
-	FindProductRecipes(ctx context.Context, id int) ([]params.FindRecipe, error)
+	FindProductRecipes(ctx context.Context, id, userID int) ([]params.FindRecipe, error)
 	CreateCategory(
 		ctx context.Context,
 		productId int,
@@ -29,6 +29,10 @@ type ProductServicer interface {
 	CreateProductTip(ctx context.Context, productID, tipID int) (params.FindTip, error)
 	DeleteProductTip(ctx context.Context, productID, tipID int) error
 	Count(ctx context.Context, filter params.ProductFilter) (int, error)
+	FindProductByBarcode(ctx context.Context, code string) (params.FindBarcode, error)
+	ScanBarcode(ctx context.Context, image []byte) (params.FindBarcode, error)
+	AddBarcode(ctx context.Context, id int, payload *params.ProductBarcode) error
+	RemoveBarcode(ctx context.Context, id int, code string) error
 }
 
 type productService struct {
@@ -133,6 +137,11 @@ func (svc *productService) FindProducts(
 
 func (svc *productService) CreateProduct(ctx context.Context, payload *params.CreateProduct) error {
 	model := utils.CreateProductToModel(payload)
+	barcodes, err := normalizeBarcodes(model.Barcodes)
+	if err != nil {
+		return err
+	}
+	model.Barcodes = barcodes
 	if err := svc.repo.Create(ctx, model); err != nil {
 		return err
 	}
@@ -151,6 +160,15 @@ func (svc *productService) UpdateProduct(
 	if payload.Name != "" {
 		model.Name = payload.Name
 	}
+	if payload.Density != nil {
+		model.Density = payload.Density
+	}
+	if payload.PieceWeight != nil {
+		model.PieceWeight = payload.PieceWeight
+	}
+	if payload.Nutrition != nil {
+		model.Nutrition = utils.NutritionToModel(payload.Nutrition)
+	}
 	if err := svc.repo.Update(ctx, model); err != nil {
 		return err
 	}
@@ -185,9 +203,9 @@ func (svc *productService) FindProductCategories(
 
 func (svc *productService) FindProductRecipes(
 	ctx context.Context,
-	id int,
+	id, userID int,
 ) ([]params.FindRecipe, error) {
-	recipes, err := svc.repo.FindRecipes(ctx, id)
+	recipes, err := svc.repo.FindRecipes(ctx, id, userID)
 	if err != nil {
 		return nil, err
 	}
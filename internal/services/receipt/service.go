@@ -0,0 +1,385 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	apperrors "github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/receipt"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/receipt"
+)
+
+const (
+	// wordsLimit is the number of the longest words of a line the products
+	// are looked up by
+	wordsLimit = 3
+	// candidatesLimit is the number of products looked up by a word
+	candidatesLimit = 50
+	// pieces is the measure of the items sold by piece
+	pieces = "шт"
+	// weight is the measure of the items sold by weight
+	weight = "кг"
+	// defaultShelfLifeDays is the shelf life of the products which have no
+	// shelf lives yet
+	defaultShelfLifeDays = 7
+)
+
+var (
+	// ErrNoProvider is returned when no receipt provider is configured.
+	ErrNoProvider = errors.New("receipt provider is not configured")
+	// ErrInvalidEndDate is returned when the end date of a shelf life is not
+	// after the time of the receipt.
+	ErrInvalidEndDate = errors.New("end date must be after purchase date")
+)
+
+type ReceiptServicer interface {
+	// ScanReceipt decodes the QR code of the receipt from the image and
+	// proposes the products of its items.
+	ScanReceipt(ctx context.Context, image []byte) (params.FindReceipt, error)
+	// FindReceipt proposes the products of the items of the receipt.
+	FindReceipt(ctx context.Context, payload *params.ReceiptQR) (params.FindReceipt, error)
+	// ProposeReceipt proposes the products, the measures and the end dates
+	// of the items of the found receipt, such as a recognized paper receipt.
+	ProposeReceipt(ctx context.Context, found receipt.Receipt) (params.FindReceipt, error)
+	// ConfirmReceipt creates the shelf lives of the receipt confirmed by the
+	// user.
+	ConfirmReceipt(
+		ctx context.Context,
+		userID int,
+		payload *params.ConfirmReceipt,
+	) ([]params.FindShelfLife, error)
+	// CreateShelfLives creates the shelf lives of the receipt accepted by the
+	// user at once.
+	CreateShelfLives(
+		ctx context.Context,
+		userID int,
+		payload *params.CreateReceiptShelfLives,
+	) ([]params.FindShelfLife, error)
+}
+
+type receiptService struct {
+	repo     repository.ReceiptRepositorer
+	products product.ProductRepositorer
+	measures measure.MeasureRepositorer
+	provider receipt.ReceiptProvider
+}
+
+// New returns the receipt service, the provider may be nil if the receipts
+// are not available.
+func New(
+	repo repository.ReceiptRepositorer,
+	products product.ProductRepositorer,
+	measures measure.MeasureRepositorer,
+	provider receipt.ReceiptProvider,
+) ReceiptServicer {
+	return &receiptService{
+		repo:     repo,
+		products: products,
+		measures: measures,
+		provider: provider,
+	}
+}
+
+// ScanReceipt implements ReceiptServicer
+func (s *receiptService) ScanReceipt(ctx context.Context, image []byte) (params.FindReceipt, error) {
+	img, err := imaging.Decode(image)
+	if err != nil {
+		return params.FindReceipt{}, err
+	}
+	fiscal, err := receipt.DecodeQR(img)
+	if err != nil {
+		return params.FindReceipt{}, err
+	}
+	return s.propose(ctx, fiscal)
+}
+
+// FindReceipt implements ReceiptServicer
+func (s *receiptService) FindReceipt(
+	ctx context.Context,
+	payload *params.ReceiptQR,
+) (params.FindReceipt, error) {
+	fiscal, err := receipt.ParseQR(payload.QR)
+	if err != nil {
+		return params.FindReceipt{}, err
+	}
+	return s.propose(ctx, fiscal)
+}
+
+// ConfirmReceipt implements ReceiptServicer
+func (s *receiptService) ConfirmReceipt(
+	ctx context.Context,
+	userID int,
+	payload *params.ConfirmReceipt,
+) ([]params.FindShelfLife, error) {
+	fiscal, err := receipt.ParseQR(payload.QR)
+	if err != nil {
+		return nil, err
+	}
+	model := models.Receipt{
+		User:           models.User{ID: userID},
+		FiscalDrive:    fiscal.FN,
+		FiscalDocument: fiscal.FD,
+		FiscalSign:     fiscal.FP,
+		Sum:            fiscal.Sum,
+		PurchaseDate:   &fiscal.Time,
+		ShelfLives:     make([]models.ShelfLife, len(payload.Items)),
+	}
+	for i, item := range payload.Items {
+		if !item.EndDate.After(fiscal.Time) {
+			return nil, fmt.Errorf("%w: item %d", ErrInvalidEndDate, i)
+		}
+		model.ShelfLives[i] = models.ShelfLife{
+			Product:      models.Product{ID: item.ProductID},
+			Storage:      models.Vault{ID: item.StorageID},
+			Measure:      models.Measure{ID: item.MeasureID},
+			Quantity:     item.Quantity,
+			PurchaseDate: &fiscal.Time,
+			EndDate:      item.EndDate,
+		}
+	}
+	if err := s.checkStorages(ctx, userID, model.ShelfLives); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, &model); err != nil {
+		return nil, fmt.Errorf("create receipt: %w", err)
+	}
+	return shelfLivesToFind(model.ShelfLives), nil
+}
+
+// CreateShelfLives implements ReceiptServicer
+func (s *receiptService) CreateShelfLives(
+	ctx context.Context,
+	userID int,
+	payload *params.CreateReceiptShelfLives,
+) ([]params.FindShelfLife, error) {
+	shelfLives := make([]models.ShelfLife, len(payload.Items))
+	for i, item := range payload.Items {
+		shelfLives[i] = models.ShelfLife{
+			Product:      models.Product{ID: item.ProductID},
+			Storage:      models.Vault{ID: item.StorageID},
+			Measure:      models.Measure{ID: item.MeasureID},
+			Quantity:     item.Quantity,
+			PurchaseDate: item.PurchaseDate,
+			EndDate:      item.EndDate,
+		}
+	}
+	if err := s.checkStorages(ctx, userID, shelfLives); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateShelfLives(ctx, userID, shelfLives); err != nil {
+		return nil, fmt.Errorf("create shelf lives: %w", err)
+	}
+	return shelfLivesToFind(shelfLives), nil
+}
+
+// checkStorages returns errors.ErrNotOwner if a storage of the shelf lives is
+// not one of the user's storages.
+func (s *receiptService) checkStorages(
+	ctx context.Context,
+	userID int,
+	shelfLives []models.ShelfLife,
+) error {
+	var (
+		storages []int
+		seen     = make(map[int]bool)
+	)
+	for _, shelfLife := range shelfLives {
+		if !seen[shelfLife.Storage.ID] {
+			seen[shelfLife.Storage.ID] = true
+			storages = append(storages, shelfLife.Storage.ID)
+		}
+	}
+	ok, err := s.repo.HasStorages(ctx, userID, storages)
+	if err != nil {
+		return fmt.Errorf("check storages: %w", err)
+	}
+	if !ok {
+		return apperrors.ErrNotOwner
+	}
+	return nil
+}
+
+// propose requests the items of the receipt from the provider and matches
+// their products and measures.
+func (s *receiptService) propose(
+	ctx context.Context,
+	fiscal receipt.Fiscal,
+) (params.FindReceipt, error) {
+	if s.provider == nil {
+		return params.FindReceipt{}, ErrNoProvider
+	}
+	found, err := s.provider.Receipt(ctx, fiscal)
+	if err != nil {
+		return params.FindReceipt{}, fmt.Errorf("find receipt: %w", err)
+	}
+	found.Fiscal = fiscal
+	return s.ProposeReceipt(ctx, found)
+}
+
+// ProposeReceipt implements ReceiptServicer. The receipt without the time is
+// purchased now, the receipt without the sum costs the sum of its items.
+func (s *receiptService) ProposeReceipt(
+	ctx context.Context,
+	found receipt.Receipt,
+) (params.FindReceipt, error) {
+	result := params.FindReceipt{
+		PurchaseDate: found.Fiscal.Time,
+		Sum:          rubles(found.Fiscal.Sum),
+		Items:        make([]params.FindReceiptItem, len(found.Items)),
+	}
+	if found.Fiscal.FN != "" {
+		result.QR = found.Fiscal.String()
+	}
+	if result.PurchaseDate.IsZero() {
+		result.PurchaseDate = time.Now()
+	}
+	if found.Fiscal.Sum == 0 {
+		var sum int64
+		for _, item := range found.Items {
+			sum += item.Sum
+		}
+		result.Sum = rubles(sum)
+	}
+	var (
+		measures = make(map[string]*params.FindMeasure)
+		products []int
+		err      error
+	)
+	for i, item := range found.Items {
+		dto := params.FindReceiptItem{
+			Name:     item.Name,
+			Price:    rubles(item.Price),
+			Quantity: item.Quantity,
+			Sum:      rubles(item.Sum),
+		}
+		match, score, err := s.matchProduct(ctx, item.Name)
+		if err != nil {
+			return params.FindReceipt{}, err
+		}
+		if match != nil {
+			product := utils.ProductModelToFind(match)
+			dto.Product, dto.Score = &product, score
+			products = append(products, match.ID)
+		}
+		unit := itemUnit(item)
+		if _, ok := measures[unit]; !ok {
+			if measures[unit], err = s.matchMeasure(ctx, unit); err != nil {
+				return params.FindReceipt{}, err
+			}
+		}
+		dto.Measure = measures[unit]
+		result.Items[i] = dto
+	}
+	if len(products) == 0 {
+		return result, nil
+	}
+	days, err := s.repo.FindShelfLifeDays(ctx, products)
+	if err != nil {
+		return params.FindReceipt{}, fmt.Errorf("find shelf life days: %w", err)
+	}
+	for i, item := range result.Items {
+		if item.Product == nil {
+			continue
+		}
+		n, ok := days[item.Product.ID]
+		if !ok {
+			n = defaultShelfLifeDays
+		}
+		endDate := result.PurchaseDate.AddDate(0, 0, n)
+		result.Items[i].EndDate = &endDate
+	}
+	return result, nil
+}
+
+// matchProduct looks up the products by the longest words of the line and
+// returns the best match with its score, or nil.
+func (s *receiptService) matchProduct(
+	ctx context.Context,
+	line string,
+) (*models.Product, float64, error) {
+	words := receipt.Words(line)
+	if len(words) > wordsLimit {
+		words = words[:wordsLimit]
+	}
+	var (
+		candidates []models.Product
+		seen       = make(map[int]bool)
+	)
+	for _, word := range words {
+		products, err := s.products.FindMany(ctx, models.ProductFilter{
+			PageFilter: models.PageFilter{Limit: candidatesLimit},
+			Name:       word,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("find products: %w", err)
+		}
+		for _, product := range products {
+			if !seen[product.ID] {
+				seen[product.ID] = true
+				candidates = append(candidates, product)
+			}
+		}
+	}
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.Name
+	}
+	i, score := receipt.Match(line, names)
+	if i < 0 {
+		return nil, 0, nil
+	}
+	return &candidates[i], score, nil
+}
+
+// matchMeasure returns the measure named as the unit, or nil.
+func (s *receiptService) matchMeasure(ctx context.Context, unit string) (*params.FindMeasure, error) {
+	found, err := s.measures.FindMany(ctx, models.MeasureFilter{
+		PageFilter: models.PageFilter{Limit: candidatesLimit},
+		Name:       unit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("find measures: %w", err)
+	}
+	for i := range found {
+		if strings.EqualFold(found[i].Name, unit) {
+			dto := utils.MeasureModelToFind(&found[i])
+			return &dto, nil
+		}
+	}
+	return nil, nil
+}
+
+// itemUnit returns the unit of the item. The items without the unit are sold
+// by piece, unless the quantity is fractional.
+func itemUnit(item receipt.Item) string {
+	if item.Unit != "" {
+		return item.Unit
+	}
+	if item.Quantity != math.Trunc(item.Quantity) {
+		return weight
+	}
+	return pieces
+}
+
+func shelfLivesToFind(shelfLives []models.ShelfLife) []params.FindShelfLife {
+	result := make([]params.FindShelfLife, len(shelfLives))
+	for i := range shelfLives {
+		result[i] = utils.ShelfLifeModelToFind(&shelfLives[i])
+	}
+	return result
+}
+
+// rubles converts kopecks to rubles.
+func rubles(kopecks int64) float64 {
+	return float64(kopecks) / 100
+}
@@ -0,0 +1,248 @@
+package receipt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/receipt"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProducts struct {
+	product.ProductRepositorer
+	products []models.Product
+}
+
+func (r *fakeProducts) FindMany(
+	ctx context.Context,
+	filter models.ProductFilter,
+) ([]models.Product, error) {
+	var result []models.Product
+	for _, p := range r.products {
+		if strings.Contains(strings.ToLower(p.Name), filter.Name) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+type fakeMeasures struct {
+	measure.MeasureRepositorer
+}
+
+func (r *fakeMeasures) FindMany(
+	ctx context.Context,
+	filter models.MeasureFilter,
+) ([]models.Measure, error) {
+	measures := []models.Measure{{ID: 1, Name: "шт"}, {ID: 2, Name: "кг"}}
+	var result []models.Measure
+	for _, m := range measures {
+		if m.Name == filter.Name {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+type fakeRepository struct {
+	storages   []int
+	receipts   map[string]bool
+	shelfLives []models.ShelfLife
+}
+
+func (r *fakeRepository) HasStorages(ctx context.Context, userID int, storages []int) (bool, error) {
+	for _, id := range storages {
+		found := false
+		for _, storage := range r.storages {
+			found = found || storage == id
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *fakeRepository) Create(ctx context.Context, model *models.Receipt) error {
+	key := model.FiscalDrive + model.FiscalDocument + model.FiscalSign
+	if r.receipts[key] {
+		return assert.AnError
+	}
+	r.receipts[key] = true
+	for i := range model.ShelfLives {
+		model.ShelfLives[i].ID = i + 1
+		model.ShelfLives[i].PurchaseDate = model.PurchaseDate
+	}
+	return nil
+}
+
+func (r *fakeRepository) CreateShelfLives(
+	ctx context.Context,
+	userID int,
+	shelfLives []models.ShelfLife,
+) error {
+	r.shelfLives = append(r.shelfLives, shelfLives...)
+	return nil
+}
+
+func (r *fakeRepository) FindShelfLifeDays(ctx context.Context, products []int) (map[int]int, error) {
+	return map[int]int{2: 10}, nil
+}
+
+const (
+	qr          = "t=20230915T1830&s=341.18&fn=9960440300000000&i=12345&fp=1234567890&n=1"
+	receiptJSON = `{
+		"dateTime": "2023-09-15T18:30:00",
+		"totalSum": 34118,
+		"items": [
+			{"name": "МОЛОКО ПРОСТОКВАШИНО ПАСТ.3,2% 930МЛ", "price": 8990, "quantity": 2, "sum": 17980},
+			{"name": "БАНАНЫ", "price": 12990, "quantity": 1.25, "sum": 16238},
+			{"name": "ПАКЕТ МАЙКА", "price": 900, "quantity": 1, "sum": 900}
+		]
+	}`
+)
+
+func newService(t *testing.T, repo *fakeRepository) ReceiptServicer {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "9960440300000000_12345_1234567890.json")
+	assert.NoError(t, os.WriteFile(path, []byte(receiptJSON), 0o600))
+	products := &fakeProducts{products: []models.Product{
+		{ID: 1, Name: "Молоко"},
+		{ID: 2, Name: "Молоко пастеризованное"},
+		{ID: 3, Name: "Бананы"},
+	}}
+	return New(repo, products, &fakeMeasures{}, receipt.NewFileProvider(dir))
+}
+
+func Test_FindReceipt(t *testing.T) {
+	svc := newService(t, &fakeRepository{})
+	result, err := svc.FindReceipt(context.Background(), &params.ReceiptQR{QR: qr})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 9, 15, 18, 30, 0, 0, time.UTC), result.PurchaseDate)
+	assert.Equal(t, 341.18, result.Sum)
+	assert.Len(t, result.Items, 3)
+
+	milk := result.Items[0]
+	assert.Equal(t, 2, milk.Product.ID)
+	assert.Equal(t, 1.0, milk.Score)
+	assert.Equal(t, "шт", milk.Measure.Name)
+	assert.Equal(t, 89.9, milk.Price)
+	assert.Equal(t, time.Date(2023, 9, 25, 18, 30, 0, 0, time.UTC), *milk.EndDate)
+	bananas := result.Items[1]
+	assert.Equal(t, 3, bananas.Product.ID)
+	assert.Equal(t, "кг", bananas.Measure.Name)
+	assert.Equal(t, time.Date(2023, 9, 22, 18, 30, 0, 0, time.UTC), *bananas.EndDate)
+	assert.Nil(t, result.Items[2].Product)
+	assert.Nil(t, result.Items[2].EndDate)
+
+	_, err = svc.FindReceipt(context.Background(), &params.ReceiptQR{QR: strings.Replace(qr, "i=12345", "i=1", 1)})
+	assert.ErrorIs(t, err, receipt.ErrNotFound)
+
+	_, err = New(&fakeRepository{}, nil, nil, nil).FindReceipt(context.Background(), &params.ReceiptQR{QR: qr})
+	assert.ErrorIs(t, err, ErrNoProvider)
+}
+
+func Test_ConfirmReceipt(t *testing.T) {
+	endDate := time.Date(2023, 9, 25, 0, 0, 0, 0, time.UTC)
+	expired := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	item := params.ConfirmReceiptItem{
+		ProductID: 2,
+		StorageID: 1,
+		MeasureID: 1,
+		Quantity:  2,
+		EndDate:   &endDate,
+	}
+	testCases := []struct {
+		name  string
+		items []params.ConfirmReceiptItem
+		err   error
+	}{
+		{
+			name:  "created",
+			items: []params.ConfirmReceiptItem{item},
+		},
+		{
+			name: "end date before purchase date",
+			items: []params.ConfirmReceiptItem{
+				{ProductID: 2, StorageID: 1, MeasureID: 1, Quantity: 2, EndDate: &expired},
+			},
+			err: ErrInvalidEndDate,
+		},
+		{
+			name: "not owned storage",
+			items: []params.ConfirmReceiptItem{
+				item,
+				{ProductID: 3, StorageID: 2, MeasureID: 2, Quantity: 1, EndDate: &endDate},
+			},
+			err: errors.ErrNotOwner,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{storages: []int{1}, receipts: make(map[string]bool)}
+			svc := newService(t, repo)
+			payload := &params.ConfirmReceipt{QR: qr, Items: tc.items}
+			result, err := svc.ConfirmReceipt(context.Background(), 1, payload)
+			assert.ErrorIs(t, err, tc.err)
+			if tc.err != nil {
+				assert.Empty(t, repo.receipts)
+				return
+			}
+			assert.Len(t, result, len(tc.items))
+			assert.Equal(t, time.Date(2023, 9, 15, 18, 30, 0, 0, time.UTC), *result[0].PurchaseDate)
+		})
+	}
+}
+
+func Test_ProposeReceipt(t *testing.T) {
+	svc := newService(t, &fakeRepository{})
+	result, err := svc.ProposeReceipt(context.Background(), receipt.ParseText(
+		"МОЛОКО 3,2% 930МЛ 89,90\nБАНАНЫ\n1,250 кг х 129,90 = 162,38\nИТОГ 252,28",
+	))
+	assert.NoError(t, err)
+	assert.Empty(t, result.QR)
+	assert.WithinDuration(t, time.Now(), result.PurchaseDate, time.Minute)
+	assert.Equal(t, 252.28, result.Sum)
+	assert.Len(t, result.Items, 2)
+	assert.Equal(t, 1, result.Items[0].Product.ID)
+	assert.Equal(t, result.PurchaseDate.AddDate(0, 0, defaultShelfLifeDays), *result.Items[0].EndDate)
+	assert.Equal(t, 1.25, result.Items[1].Quantity)
+}
+
+func Test_CreateShelfLives(t *testing.T) {
+	purchaseDate := time.Date(2023, 9, 15, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 9, 25, 0, 0, 0, 0, time.UTC)
+	item := params.ReceiptShelfLife{
+		ProductID:    1,
+		StorageID:    1,
+		MeasureID:    1,
+		Quantity:     1,
+		PurchaseDate: &purchaseDate,
+		EndDate:      &endDate,
+	}
+	repo := &fakeRepository{storages: []int{1}}
+	svc := newService(t, repo)
+
+	result, err := svc.CreateShelfLives(context.Background(), 1, &params.CreateReceiptShelfLives{
+		Items: []params.ReceiptShelfLife{item, item},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Len(t, repo.shelfLives, 2)
+
+	item.StorageID = 2
+	_, err = svc.CreateShelfLives(context.Background(), 1, &params.CreateReceiptShelfLives{
+		Items: []params.ReceiptShelfLife{item},
+	})
+	assert.ErrorIs(t, err, errors.ErrNotOwner)
+	assert.Len(t, repo.shelfLives, 2)
+}
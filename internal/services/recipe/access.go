@@ -0,0 +1,45 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+)
+
+// ErrNotVisible is returned when the recipe does not exist or is not visible
+// to the user.
+var ErrNotVisible = errors.New("recipe not found")
+
+// Visible reports whether the recipe is visible to the user. The published
+// recipes are visible to everyone, the other recipes only to their owners,
+// the moderators and the admins.
+func Visible(recipe models.Recipe, userID int, moderator bool) bool {
+	return recipe.Status == models.RecipePublished ||
+		(userID != 0 && recipe.User.ID == userID) ||
+		moderator
+}
+
+// CheckVisible returns ErrNotVisible if the recipe is not visible to the
+// user, it is used before the recipe is read for the other features.
+func CheckVisible(
+	ctx context.Context,
+	repo recipes.RecipesRepositorer,
+	id, userID int,
+	moderator bool,
+) error {
+	recipe, err := repo.FindAccess(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrNotVisible, id)
+	}
+	if err != nil {
+		return fmt.Errorf("error finding recipe: %w", err)
+	}
+	if !Visible(recipe, userID, moderator) {
+		return fmt.Errorf("%w: %d is %s", ErrNotVisible, id, recipe.Status)
+	}
+	return nil
+}
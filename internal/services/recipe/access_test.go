@@ -0,0 +1,62 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAccess finds the recipe, the other recipes do not exist.
+type fakeAccess struct {
+	recipes.RecipesRepositorer
+	recipe models.Recipe
+}
+
+func (r *fakeAccess) FindAccess(_ context.Context, id int) (models.Recipe, error) {
+	if id != r.recipe.ID {
+		return models.Recipe{}, fmt.Errorf("failed to query recipe: %w", pgx.ErrNoRows)
+	}
+	return r.recipe, nil
+}
+
+func Test_CheckVisible(t *testing.T) {
+	testCases := []struct {
+		name      string
+		status    string
+		id        int
+		userID    int
+		moderator bool
+		visible   bool
+	}{
+		{name: "published", status: models.RecipePublished, id: 1, visible: true},
+		{name: "own private", status: models.RecipePrivate, id: 1, userID: 2, visible: true},
+		{name: "private of another user", status: models.RecipePrivate, id: 1, userID: 3},
+		{name: "pending of another user", status: models.RecipePending, id: 1, userID: 3},
+		{name: "anonymous pending", status: models.RecipePending, id: 1},
+		{
+			name:      "pending for moderator",
+			status:    models.RecipePending,
+			id:        1,
+			userID:    3,
+			moderator: true,
+			visible:   true,
+		},
+		{name: "unknown", status: models.RecipePublished, id: 4, userID: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeAccess{recipe: models.Recipe{ID: 1, Status: tc.status, User: models.User{ID: 2}}}
+			err := CheckVisible(context.Background(), repo, tc.id, tc.userID, tc.moderator)
+			if tc.visible {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrNotVisible)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/ingredient"
+	"github.com/romankravchuk/muerta/internal/pkg/schemaorg"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+const (
+	// defaultUnit is the measure of the ingredient lines without a unit,
+	// such as "2 яйца"
+	defaultUnit = "шт"
+	// candidatesLimit is the number of products looked up by a stem
+	candidatesLimit = 50
+	// stemsLimit is the number of the longest stems of an ingredient name
+	// looked up
+	stemsLimit = 3
+)
+
+// ImportRecipe implements RecipeServicer
+func (s *recipeService) ImportRecipe(
+	ctx context.Context,
+	userID int,
+	data []byte,
+	dryRun bool,
+) (params.FindImportedRecipe, error) {
+	doc, err := schemaorg.Extract(data)
+	if err != nil {
+		return params.FindImportedRecipe{}, err
+	}
+	if len([]rune(doc.Name)) < 2 {
+		return params.FindImportedRecipe{}, fmt.Errorf("%w: the recipe has no name", schemaorg.ErrNoRecipe)
+	}
+	recipe := models.Recipe{
+		User:        models.User{ID: userID},
+		Name:        truncate(doc.Name, 100),
+		Description: truncate(doc.Description, 200),
+		Servings:    doc.Yield,
+		Steps:       make([]models.Step, len(doc.Instructions)),
+	}
+	if recipe.Servings <= 0 {
+		recipe.Servings = 1
+	}
+	for i, instruction := range doc.Instructions {
+		recipe.Steps[i] = models.Step{Name: instruction, Place: i + 1}
+	}
+	unmatched := make([]string, 0)
+	products := make(map[int]bool)
+	for _, text := range doc.Ingredients {
+		item, ok, err := s.matchIngredient(ctx, text)
+		if err != nil {
+			return params.FindImportedRecipe{}, err
+		}
+		// an ingredient of the same product can not be added twice
+		if !ok || products[item.Product.ID] {
+			unmatched = append(unmatched, text)
+			continue
+		}
+		products[item.Product.ID] = true
+		recipe.Ingredients = append(recipe.Ingredients, item)
+	}
+	if dryRun {
+		return params.FindImportedRecipe{
+			Recipe:    utils.RecipeModelToFind(&recipe),
+			Unmatched: unmatched,
+		}, nil
+	}
+	if err := s.repo.Create(ctx, &recipe); err != nil {
+		return params.FindImportedRecipe{}, fmt.Errorf("import recipe: %w", err)
+	}
+	result, err := s.FindRecipeByID(ctx, recipe.ID, 0)
+	if err != nil {
+		return params.FindImportedRecipe{}, err
+	}
+	return params.FindImportedRecipe{Recipe: result, Unmatched: unmatched}, nil
+}
+
+// ExportRecipe implements RecipeServicer
+func (s *recipeService) ExportRecipe(ctx context.Context, id int) ([]byte, error) {
+	recipe, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("recipe not found by id: %w", err)
+	}
+	doc := schemaorg.Recipe{
+		Name:         recipe.Name,
+		Description:  recipe.Description,
+		Yield:        recipe.Servings,
+		Ingredients:  make([]string, len(recipe.Ingredients)),
+		Instructions: make([]string, len(recipe.Steps)),
+	}
+	for i, item := range recipe.Ingredients {
+		doc.Ingredients[i] = fmt.Sprintf("%s %s %s",
+			strconv.FormatFloat(item.Quantity, 'f', -1, 64),
+			item.Measure.Name,
+			item.Product.Name,
+		)
+	}
+	for i, step := range recipe.Steps {
+		doc.Instructions[i] = step.Name
+	}
+	data, err := schemaorg.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("export recipe: %w", err)
+	}
+	return data, nil
+}
+
+// matchIngredient parses the ingredient line and finds its product and
+// measure. The line is not matched if it has no quantity or the product or
+// the measure is not found.
+func (s *recipeService) matchIngredient(
+	ctx context.Context,
+	text string,
+) (models.RecipeIngredient, bool, error) {
+	line := ingredient.Parse(text)
+	if line.Quantity <= 0 || line.Name == "" {
+		return models.RecipeIngredient{}, false, nil
+	}
+	product, ok, err := s.matchProduct(ctx, line.Name)
+	if err != nil || !ok {
+		return models.RecipeIngredient{}, false, err
+	}
+	unit := line.Unit
+	if unit == "" {
+		unit = defaultUnit
+	}
+	found, ok, err := s.matchMeasure(ctx, unit)
+	if err != nil || !ok {
+		return models.RecipeIngredient{}, false, err
+	}
+	return models.RecipeIngredient{
+		Product:  product,
+		Measure:  found,
+		Quantity: line.Quantity,
+	}, true, nil
+}
+
+// matchProduct looks up the products by the longest stems of the name and
+// returns the best match.
+func (s *recipeService) matchProduct(
+	ctx context.Context,
+	name string,
+) (models.Product, bool, error) {
+	stems := ingredient.Stems(name)
+	sort.SliceStable(stems, func(i, j int) bool {
+		return len([]rune(stems[i])) > len([]rune(stems[j]))
+	})
+	if len(stems) > stemsLimit {
+		stems = stems[:stemsLimit]
+	}
+	for _, stem := range stems {
+		products, err := s.products.FindMany(ctx, models.ProductFilter{
+			PageFilter: models.PageFilter{Limit: candidatesLimit},
+			Name:       stem,
+		})
+		if err != nil {
+			return models.Product{}, false, fmt.Errorf("find products: %w", err)
+		}
+		names := make([]string, len(products))
+		for i, product := range products {
+			names[i] = product.Name
+		}
+		if i := ingredient.Match(name, names); i >= 0 {
+			return products[i], true, nil
+		}
+	}
+	return models.Product{}, false, nil
+}
+
+// matchMeasure returns the measure named as the unit.
+func (s *recipeService) matchMeasure(
+	ctx context.Context,
+	unit string,
+) (models.Measure, bool, error) {
+	measures, err := s.measures.FindMany(ctx, models.MeasureFilter{
+		PageFilter: models.PageFilter{Limit: candidatesLimit},
+		Name:       unit,
+	})
+	if err != nil {
+		return models.Measure{}, false, fmt.Errorf("find measures: %w", err)
+	}
+	for _, candidate := range measures {
+		if strings.EqualFold(candidate.Name, unit) {
+			return candidate, true, nil
+		}
+	}
+	return models.Measure{}, false, nil
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
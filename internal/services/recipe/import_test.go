@@ -0,0 +1,78 @@
+package recipe
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProducts struct {
+	product.ProductRepositorer
+	products []models.Product
+}
+
+func (r *fakeProducts) FindMany(
+	_ context.Context,
+	filter models.ProductFilter,
+) ([]models.Product, error) {
+	result := make([]models.Product, 0)
+	for _, p := range r.products {
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(filter.Name)) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+type fakeMeasures struct {
+	measure.MeasureRepositorer
+	measures []models.Measure
+}
+
+func (r *fakeMeasures) FindMany(
+	_ context.Context,
+	filter models.MeasureFilter,
+) ([]models.Measure, error) {
+	result := make([]models.Measure, 0)
+	for _, m := range r.measures {
+		if strings.Contains(m.Name, filter.Name) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+func Test_ImportRecipe(t *testing.T) {
+	svc := New(nil, &fakeProducts{products: []models.Product{
+		{ID: 1, Name: "Молоко"},
+		{ID: 2, Name: "Яйцо"},
+		{ID: 3, Name: "Мука"},
+	}}, &fakeMeasures{measures: []models.Measure{
+		{ID: 1, Name: "мл"},
+		{ID: 2, Name: "шт"},
+		{ID: 3, Name: "г"},
+	}})
+	data := `<script type="application/ld+json">{
+		"@type": "Recipe",
+		"name": "Блины",
+		"recipeYield": "4",
+		"recipeIngredient": ["500 мл молока", "2 яйца", "Мука — 200 г", "Соль по вкусу", "1 банан"],
+		"recipeInstructions": "Смешать.\nПожарить."
+	}</script>`
+	result, err := svc.ImportRecipe(context.Background(), 1, []byte(data), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Блины", result.Recipe.Name)
+	assert.Equal(t, 4, result.Recipe.Servings)
+	assert.Equal(t, []string{"Соль по вкусу", "1 банан"}, result.Unmatched)
+	actual := make([]string, len(result.Recipe.Ingredients))
+	for i, ingredient := range result.Recipe.Ingredients {
+		actual[i] = ingredient.Product.Name + " " + ingredient.Measure.Name
+	}
+	assert.Equal(t, []string{"Молоко мл", "Яйцо шт", "Мука г"}, actual)
+	assert.Len(t, result.Recipe.Steps, 2)
+}
@@ -0,0 +1,79 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// FindRecipeAccess implements RecipeServicer
+func (s *recipeService) FindRecipeAccess(
+	ctx context.Context,
+	id int,
+) (params.RecipeAccess, error) {
+	recipe, err := s.repo.FindAccess(ctx, id)
+	if err != nil {
+		return params.RecipeAccess{}, fmt.Errorf("recipe not found by id: %w", err)
+	}
+	return params.RecipeAccess{UserID: recipe.User.ID, Status: recipe.Status}, nil
+}
+
+// FindPendingRecipes implements RecipeServicer. It returns the recipes of
+// all the users waiting for moderation and their count.
+func (s *recipeService) FindPendingRecipes(
+	ctx context.Context,
+	filter *params.RecipeFilter,
+) ([]params.FindRecipe, int, error) {
+	model := recipeFilter(0, filter)
+	model.Pending = true
+	recipes, err := s.repo.FindMany(ctx, model)
+	if err != nil {
+		return nil, 0, fmt.Errorf("recipes not found: %w", err)
+	}
+	count, err := s.repo.Count(ctx, model)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting recipes: %w", err)
+	}
+	return utils.RecipeModelsToFinds(recipes), count, nil
+}
+
+// PublishRecipe implements RecipeServicer. The private or rejected recipe is
+// sent to moderation.
+func (s *recipeService) PublishRecipe(ctx context.Context, id int) error {
+	if err := s.repo.UpdateStatus(
+		ctx,
+		id,
+		[]string{models.RecipePrivate, models.RecipeRejected},
+		models.RecipePending,
+		"",
+	); err != nil {
+		return fmt.Errorf("publish recipe: %w", err)
+	}
+	return nil
+}
+
+// ModerateRecipe implements RecipeServicer. The pending recipe is published
+// or rejected with the note of the moderator.
+func (s *recipeService) ModerateRecipe(
+	ctx context.Context,
+	id int,
+	payload *params.ModerateRecipe,
+) error {
+	status := models.RecipeRejected
+	if payload.Decision == "approve" {
+		status = models.RecipePublished
+	}
+	if err := s.repo.UpdateStatus(
+		ctx,
+		id,
+		[]string{models.RecipePending},
+		status,
+		payload.Note,
+	); err != nil {
+		return fmt.Errorf("moderate recipe: %w", err)
+	}
+	return nil
+}
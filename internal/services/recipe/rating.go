@@ -0,0 +1,88 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+var (
+	// ErrNotPublished is returned when a recipe which is not published is
+	// rated.
+	ErrNotPublished = errors.New("recipe is not published")
+	// ErrOwnRecipe is returned when the user rates their own recipe.
+	ErrOwnRecipe = errors.New("user can not rate own recipe")
+)
+
+// FindRatings implements RecipeServicer
+func (s *recipeService) FindRatings(
+	ctx context.Context,
+	id int,
+	filter *params.RecipeRatingFilter,
+) ([]params.FindRecipeRating, error) {
+	ratings, err := s.repo.FindRatings(ctx, id, models.PageFilter{
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ratings not found: %w", err)
+	}
+	return utils.RecipeRatingModelsToFinds(ratings), nil
+}
+
+// RateRecipe implements RecipeServicer. Only the published recipes of the
+// other users can be rated, the rating replaces the previous one of the user.
+func (s *recipeService) RateRecipe(
+	ctx context.Context,
+	id, userID int,
+	payload *params.RateRecipe,
+) (params.FindRecipeRating, error) {
+	recipe, err := s.repo.FindAccess(ctx, id)
+	if err != nil {
+		return params.FindRecipeRating{}, fmt.Errorf("recipe not found by id: %w", err)
+	}
+	if recipe.Status != models.RecipePublished {
+		return params.FindRecipeRating{}, ErrNotPublished
+	}
+	if recipe.User.ID == userID {
+		return params.FindRecipeRating{}, ErrOwnRecipe
+	}
+	rating := models.RecipeRating{
+		Recipe: models.Recipe{ID: id},
+		User:   models.User{ID: userID},
+		Rating: payload.Rating,
+		Review: payload.Review,
+	}
+	if err := s.repo.Rate(ctx, &rating); err != nil {
+		return params.FindRecipeRating{}, fmt.Errorf("rate recipe: %w", err)
+	}
+	return utils.RecipeRatingModelToFind(rating), nil
+}
+
+// DeleteRating implements RecipeServicer
+func (s *recipeService) DeleteRating(ctx context.Context, id, userID int) error {
+	if err := s.repo.DeleteRating(ctx, id, userID); err != nil {
+		return fmt.Errorf("delete rating: %w", err)
+	}
+	return nil
+}
+
+// AddFavourite implements RecipeServicer
+func (s *recipeService) AddFavourite(ctx context.Context, id, userID int) error {
+	if err := s.repo.AddFavourite(ctx, id, userID); err != nil {
+		return fmt.Errorf("add favourite: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavourite implements RecipeServicer
+func (s *recipeService) RemoveFavourite(ctx context.Context, id, userID int) error {
+	if err := s.repo.RemoveFavourite(ctx, id, userID); err != nil {
+		return fmt.Errorf("remove favourite: %w", err)
+	}
+	return nil
+}
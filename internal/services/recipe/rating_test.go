@@ -0,0 +1,74 @@
+package recipe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRatings struct {
+	recipes.RecipesRepositorer
+	recipe models.Recipe
+	rated  []models.RecipeRating
+}
+
+func (r *fakeRatings) FindAccess(_ context.Context, id int) (models.Recipe, error) {
+	return r.recipe, nil
+}
+
+func (r *fakeRatings) Rate(_ context.Context, rating *models.RecipeRating) error {
+	r.rated = append(r.rated, *rating)
+	return nil
+}
+
+func Test_RateRecipe(t *testing.T) {
+	testCases := []struct {
+		name   string
+		recipe models.Recipe
+		userID int
+		err    error
+	}{
+		{
+			name:   "published recipe of another user",
+			recipe: models.Recipe{ID: 1, Status: models.RecipePublished, User: models.User{ID: 2}},
+			userID: 1,
+		},
+		{
+			name:   "pending recipe",
+			recipe: models.Recipe{ID: 1, Status: models.RecipePending, User: models.User{ID: 2}},
+			userID: 1,
+			err:    ErrNotPublished,
+		},
+		{
+			name:   "own recipe",
+			recipe: models.Recipe{ID: 1, Status: models.RecipePublished, User: models.User{ID: 1}},
+			userID: 1,
+			err:    ErrOwnRecipe,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRatings{recipe: tc.recipe}
+			svc := New(repo, nil, nil)
+			result, err := svc.RateRecipe(
+				context.Background(),
+				tc.recipe.ID,
+				tc.userID,
+				&params.RateRecipe{Rating: 5, Review: "Вкусно"},
+			)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Empty(t, repo.rated)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 5, result.Rating)
+			assert.Equal(t, tc.userID, result.UserID)
+			assert.Len(t, repo.rated, 1)
+		})
+	}
+}
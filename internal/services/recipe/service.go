@@ -2,19 +2,30 @@ package recipe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	productsvc "github.com/romankravchuk/muerta/internal/services/product"
 	"github.com/romankravchuk/muerta/internal/services/utils"
+	measures "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
 )
 
 type RecipeServicer interface {
-	CreateRecipe(ctx context.Context, payload *params.CreateRecipe) error
-	FindRecipeByID(ctx context.Context, id int) (params.FindRecipe, error)
-	FindRecipes(ctx context.Context, filter *params.RecipeFilter) ([]params.FindRecipe, error)
-	UpdateRecipe(ctx context.Context, id int, payload *params.UpdateRecipe) error
+	CreateRecipe(ctx context.Context, payload *params.CreateRecipe, publish bool) error
+	FindRecipeByID(ctx context.Context, id, servings int) (params.FindRecipe, error)
+	FindRecipeAccess(ctx context.Context, id int) (params.RecipeAccess, error)
+	FindRecipeNutrition(ctx context.Context, id, servings int) (params.FindRecipeNutrition, error)
+	FindRecipes(
+		ctx context.Context,
+		userID int,
+		filter *params.RecipeFilter,
+	) ([]params.FindRecipe, error)
+	UpdateRecipe(ctx context.Context, id int, payload *params.UpdateRecipe, review bool) error
 	DeleteRecipe(ctx context.Context, id int) error
 	RestoreRecipe(ctx context.Context, id int) error
 	FindRecipeIngredients(ctx context.Context, id int) ([]params.FindRecipeIngredient, error)
@@ -22,25 +33,80 @@ type RecipeServicer interface {
 		ctx context.Context,
 		id int,
 		payload *params.CreateIngredient,
+		review bool,
 	) (params.FindRecipeIngredient, error)
 	UpdateIngredient(
 		ctx context.Context,
 		id int,
 		payload *params.UpdateIngredient,
+		review bool,
 	) (params.FindRecipeIngredient, error)
-	DeleteIngredient(ctx context.Context, id int, payload *params.DeleteIngredient) error
+	DeleteIngredient(ctx context.Context, id int, payload *params.DeleteIngredient, review bool) error
 	FindRecipeSteps(ctx context.Context, recipeID int) ([]params.FindStep, error)
-	CreateRecipeStep(ctx context.Context, recipeID, stepID, place int) (params.FindStep, error)
-	DeleteRecipeStep(ctx context.Context, recipeID, stepID, place int) error
-	Count(ctx context.Context, filter params.RecipeFilter) (int, error)
+	CreateRecipeStep(
+		ctx context.Context,
+		recipeID, stepID int,
+		payload *params.CreateRecipeStep,
+		review bool,
+	) (params.FindStep, error)
+	UpdateRecipeStep(
+		ctx context.Context,
+		recipeID, stepID int,
+		payload *params.UpdateRecipeStep,
+		review bool,
+	) (params.FindStep, error)
+	DeleteRecipeStep(ctx context.Context, recipeID, stepID, place int, review bool) error
+	ReorderRecipeSteps(
+		ctx context.Context,
+		recipeID int,
+		payload *params.ReorderRecipeSteps,
+		review bool,
+	) ([]params.FindStep, error)
+	Count(ctx context.Context, userID int, filter params.RecipeFilter) (int, error)
+	FindPendingRecipes(
+		ctx context.Context,
+		filter *params.RecipeFilter,
+	) ([]params.FindRecipe, int, error)
+	PublishRecipe(ctx context.Context, id int) error
+	ModerateRecipe(ctx context.Context, id int, payload *params.ModerateRecipe) error
+	FindRatings(
+		ctx context.Context,
+		id int,
+		filter *params.RecipeRatingFilter,
+	) ([]params.FindRecipeRating, error)
+	RateRecipe(
+		ctx context.Context,
+		id, userID int,
+		payload *params.RateRecipe,
+	) (params.FindRecipeRating, error)
+	DeleteRating(ctx context.Context, id, userID int) error
+	AddFavourite(ctx context.Context, id, userID int) error
+	RemoveFavourite(ctx context.Context, id, userID int) error
+	ImportRecipe(
+		ctx context.Context,
+		userID int,
+		data []byte,
+		dryRun bool,
+	) (params.FindImportedRecipe, error)
+	ExportRecipe(ctx context.Context, id int) ([]byte, error)
 }
 
+// ErrUnknownIngredient is returned when a step references a product which is
+// not an ingredient of the recipe.
+var ErrUnknownIngredient = errors.New("product is not an ingredient of the recipe")
+
 type recipeService struct {
-	repo recipes.RecipesRepositorer
+	repo     recipes.RecipesRepositorer
+	products product.ProductRepositorer
+	measures measures.MeasureRepositorer
 }
 
-func (s *recipeService) Count(ctx context.Context, filter params.RecipeFilter) (int, error) {
-	count, err := s.repo.Count(ctx, models.RecipeFilter{Name: filter.Name})
+func (s *recipeService) Count(
+	ctx context.Context,
+	userID int,
+	filter params.RecipeFilter,
+) (int, error) {
+	count, err := s.repo.Count(ctx, recipeFilter(userID, &filter))
 	if err != nil {
 		return 0, fmt.Errorf("error counting recipes: %w", err)
 	}
@@ -52,23 +118,84 @@ func (s *recipeService) CreateRecipeStep(
 	ctx context.Context,
 	recipeID int,
 	stepID int,
-	place int,
+	payload *params.CreateRecipeStep,
+	review bool,
 ) (params.FindStep, error) {
-	model, err := s.repo.CreateStep(ctx, recipeID, stepID, place)
+	if err := s.checkIngredients(ctx, recipeID, payload.Ingredients); err != nil {
+		return params.FindStep{}, err
+	}
+	model := utils.CreateRecipeStepToModel(stepID, payload)
+	model, err := s.repo.CreateStep(ctx, recipeID, model, review)
 	if err != nil {
 		return params.FindStep{}, fmt.Errorf("create step: %w", err)
 	}
 	return utils.StepModelToFind(model), nil
 }
 
+// UpdateRecipeStep implements RecipeServicer
+func (s *recipeService) UpdateRecipeStep(
+	ctx context.Context,
+	recipeID int,
+	stepID int,
+	payload *params.UpdateRecipeStep,
+	review bool,
+) (params.FindStep, error) {
+	if err := s.checkIngredients(ctx, recipeID, payload.Ingredients); err != nil {
+		return params.FindStep{}, err
+	}
+	model := utils.UpdateRecipeStepToModel(stepID, payload)
+	model, err := s.repo.UpdateStep(ctx, recipeID, model, review)
+	if err != nil {
+		return params.FindStep{}, fmt.Errorf("update step: %w", err)
+	}
+	return utils.StepModelToFind(model), nil
+}
+
+// ReorderRecipeSteps implements RecipeServicer
+func (s *recipeService) ReorderRecipeSteps(
+	ctx context.Context,
+	recipeID int,
+	payload *params.ReorderRecipeSteps,
+	review bool,
+) ([]params.FindStep, error) {
+	steps, err := s.repo.ReorderSteps(ctx, recipeID, payload.Steps, review)
+	if err != nil {
+		return nil, fmt.Errorf("reorder steps: %w", err)
+	}
+	return utils.StepModelsToFinds(steps), nil
+}
+
+// checkIngredients checks that the products referenced by a step are the
+// ingredients of the recipe.
+func (s *recipeService) checkIngredients(ctx context.Context, recipeID int, products []int) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ingredients, err := s.repo.FindIngredients(ctx, recipeID)
+	if err != nil {
+		return fmt.Errorf("find ingredients: %w", err)
+	}
+	known := make(map[int]bool, len(ingredients))
+	for _, item := range ingredients {
+		known[item.Product.ID] = true
+	}
+	for _, id := range products {
+		if !known[id] {
+			return fmt.Errorf("%w: product %d", ErrUnknownIngredient, id)
+		}
+	}
+	return nil
+}
+
 // DeleteRecipeStep implements RecipeServicer
 func (s *recipeService) DeleteRecipeStep(
 	ctx context.Context,
 	recipeID int,
 	stepID int,
 	place int,
+	review bool,
 ) error {
-	if err := s.repo.DeleteStep(ctx, recipeID, stepID, place); err != nil {
+	if err := s.repo.DeleteStep(ctx, recipeID, stepID, place, review); err != nil {
 		return fmt.Errorf("delete step: %w", err)
 	}
 	return nil
@@ -91,9 +218,10 @@ func (s *recipeService) CreateIngredient(
 	ctx context.Context,
 	id int,
 	payload *params.CreateIngredient,
+	review bool,
 ) (params.FindRecipeIngredient, error) {
 	model := utils.CreateIngredientToModel(payload)
-	ingredient, err := s.repo.CreateIngredient(ctx, id, &model)
+	ingredient, err := s.repo.CreateIngredient(ctx, id, &model, review)
 	if err != nil {
 		return params.FindRecipeIngredient{}, fmt.Errorf("create recipe ingredient: %w", err)
 	}
@@ -106,8 +234,9 @@ func (s *recipeService) DeleteIngredient(
 	ctx context.Context,
 	id int,
 	payload *params.DeleteIngredient,
+	review bool,
 ) error {
-	if err := s.repo.DeleteIngredient(ctx, id, payload.ProductID); err != nil {
+	if err := s.repo.DeleteIngredient(ctx, id, payload.ProductID, review); err != nil {
 		return fmt.Errorf("delete recipe ingredient: %w", err)
 	}
 	return nil
@@ -131,9 +260,10 @@ func (s *recipeService) UpdateIngredient(
 	ctx context.Context,
 	id int,
 	payload *params.UpdateIngredient,
+	review bool,
 ) (params.FindRecipeIngredient, error) {
 	model := utils.UpdateIngredientToModel(payload)
-	ingredient, err := s.repo.UpdateIngredient(ctx, id, &model)
+	ingredient, err := s.repo.UpdateIngredient(ctx, id, &model, review)
 	if err != nil {
 		return params.FindRecipeIngredient{}, fmt.Errorf("update recipe ingredient: %w", err)
 	}
@@ -141,38 +271,119 @@ func (s *recipeService) UpdateIngredient(
 	return dto, nil
 }
 
-func New(repository recipes.RecipesRepositorer) RecipeServicer {
-	return &recipeService{repo: repository}
+func New(
+	repository recipes.RecipesRepositorer,
+	products product.ProductRepositorer,
+	measures measures.MeasureRepositorer,
+) RecipeServicer {
+	return &recipeService{
+		repo:     repository,
+		products: products,
+		measures: measures,
+	}
 }
 
-func (s *recipeService) CreateRecipe(ctx context.Context, payload *params.CreateRecipe) error {
+// CreateRecipe creates the recipe published or private to its user.
+func (s *recipeService) CreateRecipe(
+	ctx context.Context,
+	payload *params.CreateRecipe,
+	publish bool,
+) error {
 	model := utils.CreateRecipeToModel(payload)
+	model.Status = models.RecipePrivate
+	if publish {
+		model.Status = models.RecipePublished
+	}
 	if err := s.repo.Create(ctx, &model); err != nil {
 		return fmt.Errorf("create recipe: %w", err)
 	}
 	return nil
 }
 
-func (s *recipeService) FindRecipeByID(ctx context.Context, id int) (params.FindRecipe, error) {
+// FindRecipeByID returns the recipe with the ingredients scaled to the
+// servings, zero servings keeps the servings of the recipe.
+func (s *recipeService) FindRecipeByID(
+	ctx context.Context,
+	id, servings int,
+) (params.FindRecipe, error) {
 	recipe, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return params.FindRecipe{}, fmt.Errorf("recipe not found by id: %w", err)
 	}
+	if servings > 0 {
+		recipe.Ingredients = Scale(recipe.Ingredients, recipe.Servings, servings)
+		recipe.Servings = servings
+	}
 	result := utils.RecipeModelToFind(&recipe)
+	nutrition := Nutrition(recipe.Ingredients, recipe.Servings)
+	result.Nutrition = &nutrition
 	return result, nil
 }
 
+// FindRecipeNutrition returns the nutrition of the recipe for the servings,
+// zero servings keeps the servings of the recipe.
+func (s *recipeService) FindRecipeNutrition(
+	ctx context.Context,
+	id, servings int,
+) (params.FindRecipeNutrition, error) {
+	recipe, err := s.FindRecipeByID(ctx, id, servings)
+	if err != nil {
+		return params.FindRecipeNutrition{}, err
+	}
+	return *recipe.Nutrition, nil
+}
+
+// Nutrition computes the total and the per serving nutrition of the
+// ingredients. The quantities are not rounded, the ingredients scaled by
+// Scale should be passed.
+func Nutrition(ingredients []models.RecipeIngredient, servings int) params.FindRecipeNutrition {
+	if servings <= 0 {
+		servings = 1
+	}
+	portions := make([]productsvc.Portion, len(ingredients))
+	for i, item := range ingredients {
+		portions[i] = productsvc.Portion{
+			Product:  item.Product,
+			Measure:  item.Measure,
+			Quantity: item.Quantity,
+		}
+	}
+	total, missing := productsvc.Nutrition(portions)
+	perServing := productsvc.RoundNutrition(productsvc.ScaleNutrition(total, 1/float64(servings)))
+	return params.FindRecipeNutrition{
+		Servings:   servings,
+		Total:      *utils.NutritionModelToFind(&total),
+		PerServing: *utils.NutritionModelToFind(&perServing),
+		Missing:    utils.ProductModelsToFinds(missing),
+	}
+}
+
+// Scale scales the quantities of the ingredients from the servings of the
+// recipe to the given servings. The scaled quantities are rounded by
+// measure.Round, the ingredients are not modified.
+func Scale(ingredients []models.RecipeIngredient, from, to int) []models.RecipeIngredient {
+	if from <= 0 {
+		from = 1
+	}
+	scaled := make([]models.RecipeIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		scaled[i] = ingredient
+		if from != to {
+			quantity := ingredient.Quantity * float64(to) / float64(from)
+			scaled[i].Quantity = measure.Round(quantity, ingredient.Measure)
+		}
+	}
+	return scaled
+}
+
+// FindRecipes finds the recipes visible to the user, the published recipes
+// and the recipes of the user. The user is zero for an anonymous user.
 func (s *recipeService) FindRecipes(
 	ctx context.Context,
+	userID int,
 	filter *params.RecipeFilter,
 ) ([]params.FindRecipe, error) {
-	recipes, err := s.repo.FindMany(ctx, models.RecipeFilter{
-		PageFilter: models.PageFilter{
-			Limit:  filter.Limit,
-			Offset: filter.Offset,
-		},
-		Name: filter.Name,
-	})
+	recipes, err := s.repo.FindMany(ctx, recipeFilter(userID, filter))
 	if err != nil {
 		return nil, fmt.Errorf("recipes not found: %w", err)
 	}
@@ -180,10 +391,26 @@ func (s *recipeService) FindRecipes(
 	return result, nil
 }
 
+func recipeFilter(userID int, filter *params.RecipeFilter) models.RecipeFilter {
+	return models.RecipeFilter{
+		PageFilter: models.PageFilter{
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+		Name:       filter.Name,
+		UserID:     userID,
+		Mine:       filter.Mine,
+		Favourites: filter.Favourites,
+		MinRating:  filter.MinRating,
+		Sort:       filter.Sort,
+	}
+}
+
 func (s *recipeService) UpdateRecipe(
 	ctx context.Context,
 	id int,
 	payload *params.UpdateRecipe,
+	review bool,
 ) error {
 	recipe, err := s.repo.FindByID(ctx, id)
 	if err != nil {
@@ -195,7 +422,10 @@ func (s *recipeService) UpdateRecipe(
 	if payload.Description != "" {
 		recipe.Description = payload.Description
 	}
-	if err := s.repo.Update(ctx, &recipe); err != nil {
+	if payload.Servings != 0 {
+		recipe.Servings = payload.Servings
+	}
+	if err := s.repo.Update(ctx, &recipe, review); err != nil {
 		return fmt.Errorf("update recipe: %w", err)
 	}
 	return nil
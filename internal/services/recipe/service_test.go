@@ -0,0 +1,40 @@
+package recipe
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scale(t *testing.T) {
+	gram := models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+	piece := models.Measure{ID: 2, Name: "шт", Dimension: models.DimensionCount, Factor: 1}
+	ingredients := []models.RecipeIngredient{
+		{Product: models.Product{ID: 1, Name: "Мука"}, Measure: gram, Quantity: 250},
+		{Product: models.Product{ID: 2, Name: "Яйца"}, Measure: piece, Quantity: 2},
+		{Product: models.Product{ID: 3, Name: "Сахар"}, Measure: gram, Quantity: 12.5},
+	}
+	testCases := []struct {
+		name     string
+		from, to int
+		expected []float64
+	}{
+		{name: "same servings", from: 4, to: 4, expected: []float64{250, 2, 12.5}},
+		{name: "doubled", from: 4, to: 8, expected: []float64{500, 4, 25}},
+		{name: "one serving", from: 4, to: 1, expected: []float64{63, 1, 3.1}},
+		{name: "three servings", from: 4, to: 3, expected: []float64{190, 2, 9.4}},
+		{name: "no servings", from: 0, to: 2, expected: []float64{500, 4, 25}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaled := Scale(ingredients, tc.from, tc.to)
+			actual := make([]float64, len(scaled))
+			for i, ingredient := range scaled {
+				actual[i] = ingredient.Quantity
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+	assert.Equal(t, 250.0, ingredients[0].Quantity)
+}
@@ -1,75 +1,226 @@
 package shelflifedetector
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
+	"image"
+	"image/color"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
 
-	"github.com/otiai10/gosseract/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/imaging"
+	"github.com/romankravchuk/muerta/internal/pkg/label"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
+	"github.com/romankravchuk/muerta/internal/pkg/receipt"
+)
+
+var (
+	// ErrNoDates is returned when no dates are found on the label.
+	ErrNoDates = errors.New("no dates found")
+	// ErrNoItems is returned when no items are found on the receipt.
+	ErrNoItems = errors.New("no receipt items found")
 )
 
 type DateDetectorServicer interface {
-	Detect(image []byte) ([]time.Time, error)
+	// Detect recognizes the dates of the label of a product and suggests the
+	// purchase date and the end date of its shelf life. In the debug mode the
+	// result has the overlay of the boxes on the image.
+	Detect(ctx context.Context, image []byte, debug bool) (params.DetectedDates, error)
+	// DetectText finds the dates in the texts already recognized by the
+	// passes of the OCR, such as the raw text of a stored detection.
+	DetectText(ctx context.Context, texts []string) (params.DetectedDates, error)
+	// DetectReceipt recognizes the text of a paper receipt and parses its
+	// items and its time.
+	DetectReceipt(ctx context.Context, image []byte) (receipt.Receipt, error)
 }
 
 type DateDetectorService struct {
-	client *gosseract.Client
+	backend ocr.Backend
 }
 
-var reDate = regexp.MustCompile(`\b(?:0[1-9]|[1-2][0-9]|3[01])[\.\/\-](?:0[1-9]|1[0-2])[\.\/\-](?:\d{4}|\d{2})\b`)
+// New returns the service recognizing the images by the backend, the texts
+// of DetectText are read by the Text backend.
+func New(backend ocr.Backend) *DateDetectorService {
+	return &DateDetectorService{backend: backend}
+}
 
-func New(cl chan struct{}) *DateDetectorService {
-	client := gosseract.NewClient()
-	client.SetLanguage("eng", "rus")
-	go func() {
-		<-cl
-		client.Close()
-	}()
-	return &DateDetectorService{client: client}
+func (s *DateDetectorService) Detect(ctx context.Context, image []byte, debug bool) (params.DetectedDates, error) {
+	results, err := s.backend.Recognize(ctx, image)
+	if err != nil {
+		return params.DetectedDates{}, fmt.Errorf("failed to detect date: %w", err)
+	}
+	found, err := parse(results)
+	if err != nil {
+		return params.DetectedDates{}, err
+	}
+	result := detectedDates(found, results)
+	if debug {
+		if result.Overlay, err = overlay(image, found, results); err != nil {
+			return params.DetectedDates{}, fmt.Errorf("failed to draw overlay: %w", err)
+		}
+	}
+	return result, nil
 }
 
-func (s *DateDetectorService) Detect(image []byte) ([]time.Time, error) {
-	if err := s.client.SetImageFromBytes(image); err != nil {
-		return nil, fmt.Errorf("failed to set image: %w", err)
+func (s *DateDetectorService) DetectText(ctx context.Context, texts []string) (params.DetectedDates, error) {
+	results, err := ocr.Text{}.Recognize(ctx, []byte(strings.Join(texts, "\f")))
+	if err != nil {
+		return params.DetectedDates{}, fmt.Errorf("failed to read text: %w", err)
 	}
-	text, err := s.client.Text()
+	found, err := parse(results)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect date: %w", err)
-	}
-	cleanText := strings.Replace(text, "\n", " ", -1)
-	matches := reDate.FindAllString(cleanText, -1)
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("no matches found")
-	}
-	dates := make([]time.Time, 2)
-	replacer := strings.NewReplacer("/", "-", ".", "-")
-	for i, match := range matches {
-		fixedMatch := replacer.Replace(match)
-		pieces := strings.Split(fixedMatch, "-")
-		if len(pieces) != 3 {
+		return params.DetectedDates{}, err
+	}
+	return detectedDates(found, results), nil
+}
+
+// parse parses the text of every pass and merges the labels.
+func parse(results []ocr.Result) (label.Label, error) {
+	labels := make([]label.Label, 0, len(results))
+	for _, r := range results {
+		labels = append(labels, label.Parse(r.Text))
+	}
+	found := label.Merge(labels...)
+	if len(found.Dates) == 0 {
+		return label.Label{}, ErrNoDates
+	}
+	return found, nil
+}
+
+// detectedDates returns the unique dates in order with their most confident
+// roles and their boxes, and suggests the purchase date by the start of the
+// shelf life and the end date by its end.
+func detectedDates(found label.Label, results []ocr.Result) params.DetectedDates {
+	var (
+		result     params.DetectedDates
+		start, end *label.Date
+	)
+	for _, r := range results {
+		result.RawText = append(result.RawText, r.Text)
+	}
+	if d := found.ShelfLife; d != nil {
+		result.ShelfLife = &params.DetectedShelfLife{Amount: d.Amount, Unit: string(d.Unit), Role: string(d.Role)}
+	}
+	if t := found.Temperature; t != nil {
+		result.Temperature = &params.DetectedTemperature{Min: t.Min, Max: t.Max}
+	}
+	unique := make([]label.Date, 0, len(found.Dates))
+	for _, date := range found.Dates {
+		i := sort.Search(len(unique), func(i int) bool { return !unique[i].Time.Before(date.Time) })
+		if i < len(unique) && unique[i].Time.Equal(date.Time) {
+			if date.Confidence > unique[i].Confidence {
+				unique[i] = date
+			}
 			continue
 		}
-		if len(pieces[2]) == 2 {
-			pieces[2] = strconv.Itoa(time.Now().Year())[:2] + pieces[2]
+		unique = append(unique, label.Date{})
+		copy(unique[i+1:], unique[i:])
+		unique[i] = date
+	}
+	for i, date := range unique {
+		detected := params.DetectedDate{
+			Date:       date.Time,
+			Role:       string(date.Role),
+			Confidence: date.Confidence,
+			MonthOnly:  date.MonthOnly,
+			Computed:   date.Computed,
+			Format:     string(date.Format),
+			Boxes:      []params.DetectedBox{},
+		}
+		for _, box := range dateBoxes(date, results) {
+			detected.Boxes = append(detected.Boxes, params.DetectedBox{
+				X:      box.Min.X,
+				Y:      box.Min.Y,
+				Width:  box.Dx(),
+				Height: box.Dy(),
+			})
 		}
-		converted := make([]int, len(pieces))
-		var err error
-		for i, p := range pieces {
-			converted[i], err = strconv.Atoi(p)
-			if err != nil {
-				return nil, err
+		result.Dates = append(result.Dates, detected)
+		switch {
+		case date.Role.Start():
+			if start == nil || date.Confidence > start.Confidence {
+				start = &unique[i]
+			}
+		case date.Role.End():
+			// the use by date is stricter than the best before date
+			if end == nil || moreConfident(date, *end, label.RoleUseBy) {
+				end = &unique[i]
 			}
 		}
-		date := time.Date(converted[2], time.Month(converted[1]), converted[0], 0, 0, 0, 0, time.UTC)
-		dates[i] = date
 	}
-	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
-	return dates, nil
+	if start != nil {
+		result.PurchaseDate = &start.Time
+	}
+	if end != nil && (start == nil || end.Time.After(start.Time)) {
+		result.EndDate = &end.Time
+	}
+	return result
+}
+
+// dateBoxes returns the boxes of the words of the date in the result of the
+// pass it is kept from.
+func dateBoxes(date label.Date, results []ocr.Result) []image.Rectangle {
+	if date.Source >= len(results) {
+		return nil
+	}
+	return results[date.Source].Boxes(date.Start, date.End)
 }
 
-func (s *DateDetectorService) Close() error {
-	return s.client.Close()
+// roleColors are the colors of the boxes of the dates on the overlay.
+var roleColors = map[label.Role]color.Color{
+	label.RoleManufactured: color.NRGBA{G: 160, A: 255},
+	label.RolePacked:       color.NRGBA{G: 160, B: 160, A: 255},
+	label.RoleBestBefore:   color.NRGBA{R: 230, G: 120, A: 255},
+	label.RoleUseBy:        color.NRGBA{R: 220, A: 255},
+	label.RoleUnknown:      color.NRGBA{B: 220, A: 255},
+}
+
+// wordColor is the color of the boxes of all the recognized words.
+var wordColor = color.NRGBA{R: 128, G: 128, B: 128, A: 96}
+
+// overlay draws the boxes of the words of every pass and the boxes of the
+// dates colored by their roles on the image.
+func overlay(data []byte, found label.Label, results []ocr.Result) ([]byte, error) {
+	var boxes []imaging.Box
+	for _, r := range results {
+		for _, w := range r.Words {
+			boxes = append(boxes, imaging.Box{Rect: w.Box, Color: wordColor})
+		}
+	}
+	for _, date := range found.Dates {
+		for _, rect := range dateBoxes(date, results) {
+			boxes = append(boxes, imaging.Box{Rect: rect, Color: roleColors[date.Role]})
+		}
+	}
+	return imaging.Overlay(data, boxes)
+}
+
+// moreConfident reports whether the date a is preferred to b, the dates of
+// the preferred role come first.
+func moreConfident(a, b label.Date, preferred label.Role) bool {
+	if (a.Role == preferred) != (b.Role == preferred) {
+		return a.Role == preferred
+	}
+	return a.Confidence > b.Confidence
+}
+
+func (s *DateDetectorService) DetectReceipt(ctx context.Context, image []byte) (receipt.Receipt, error) {
+	results, err := s.backend.Recognize(ctx, image)
+	if err != nil {
+		return receipt.Receipt{}, fmt.Errorf("failed to recognize receipt: %w", err)
+	}
+	// the items can not be merged by their names, the pass which read the
+	// most of them wins
+	var result receipt.Receipt
+	for _, res := range results {
+		if r := receipt.ParseText(res.Text); len(r.Items) > len(result.Items) {
+			result = r
+		}
+	}
+	if len(result.Items) == 0 {
+		return receipt.Receipt{}, ErrNoItems
+	}
+	return result, nil
 }
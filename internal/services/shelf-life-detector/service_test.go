@@ -1,9 +1,20 @@
 package shelflifedetector
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/label"
+	"github.com/romankravchuk/muerta/internal/pkg/ocr"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,17 +33,202 @@ func Test_Detect(t *testing.T) {
 			},
 		},
 	}
-	cl := make(chan struct{})
-	detector := New(cl)
+	pool, err := ocr.NewPool(1, 0, 0, ocr.NewTesseract())
+	assert.NoError(t, err)
+	defer pool.Close()
+	detector := New(pool)
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			data, _ := os.ReadFile(tc.path)
-			dates, err := detector.Detect(data)
+			result, err := detector.Detect(context.Background(), data, false)
 			assert.Nil(t, err)
-			assert.NotNil(t, dates)
-			assert.NotEmpty(t, dates)
+			assert.NotEmpty(t, result.Dates)
+			var dates []string
+			for _, d := range result.Dates {
+				dates = append(dates, d.Date.Format("02.01.06"))
+			}
 			assert.Equal(t, tc.expected, dates)
 		})
 	}
-	cl <- struct{}{}
+}
+
+// textClient recognizes the image as its own bytes, or as the words if they
+// are set. The boxes of the words are 10 pixels wide and high, in the
+// columns and the rows of the text.
+type textClient struct {
+	image []byte
+	words string
+}
+
+func (c *textClient) SetImageFromBytes(data []byte) error {
+	c.image = data
+	return nil
+}
+
+func (c *textClient) SetPageSegMode(ocr.PageSegMode) error {
+	return nil
+}
+
+func (c *textClient) Text() (string, error) {
+	time.Sleep(time.Millisecond)
+	return string(c.image), nil
+}
+
+func (c *textClient) Words() ([]ocr.Word, error) {
+	time.Sleep(time.Millisecond)
+	text := c.words
+	if text == "" {
+		text = string(c.image)
+	}
+	var words []ocr.Word
+	for line, s := range strings.Split(text, "\n") {
+		for i, w := range strings.Fields(s) {
+			words = append(words, ocr.Word{
+				Text: w,
+				Box:  image.Rect(i*10, line*10, i*10+10, line*10+10),
+				Line: line,
+			})
+		}
+	}
+	return words, nil
+}
+
+func (c *textClient) Close() error {
+	return nil
+}
+
+func Test_DetectConcurrent(t *testing.T) {
+	pool, err := ocr.NewPool(4, 64, time.Second, func() (ocr.Client, error) {
+		return &textClient{}, nil
+	})
+	assert.NoError(t, err)
+	defer pool.Close()
+	pool.SetPasses([]ocr.Pass{{Mode: ocr.PageSegAuto}, {Mode: ocr.PageSegSparseText}})
+	detector := New(pool)
+	var wg sync.WaitGroup
+	for i := 2; i <= 28; i++ {
+		wg.Add(1)
+		go func(day int) {
+			defer wg.Done()
+			image := fmt.Sprintf("годен до %02d.09.22 изготовлен 01.09.22", day)
+			result, err := detector.Detect(context.Background(), []byte(image), false)
+			assert.NoError(t, err)
+			assert.Equal(t, time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC), *result.PurchaseDate)
+			assert.Equal(t, time.Date(2022, 9, day, 0, 0, 0, 0, time.UTC), *result.EndDate)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func Test_DetectBoxes(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 30))))
+	pool, err := ocr.NewPool(1, 0, 0, func() (ocr.Client, error) {
+		return &textClient{words: "изг 15.09.22\nгоден до 24.09.22"}, nil
+	})
+	assert.NoError(t, err)
+	defer pool.Close()
+	pool.SetPasses([]ocr.Pass{{Mode: ocr.PageSegAuto}})
+	detector := New(pool)
+	testCases := []struct {
+		name    string
+		debug   bool
+		overlay bool
+	}{
+		{name: "boxes"},
+		{name: "debug", debug: true, overlay: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := detector.Detect(context.Background(), buf.Bytes(), tc.debug)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"изг 15.09.22\nгоден до 24.09.22"}, result.RawText)
+			assert.Len(t, result.Dates, 2)
+			assert.Equal(t, []params.DetectedBox{{X: 10, Y: 0, Width: 10, Height: 10}}, result.Dates[0].Boxes)
+			assert.Equal(t, []params.DetectedBox{{X: 20, Y: 10, Width: 10, Height: 10}}, result.Dates[1].Boxes)
+			if !tc.overlay {
+				assert.Nil(t, result.Overlay)
+				return
+			}
+			overlay, err := png.Decode(bytes.NewReader(result.Overlay))
+			assert.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 40, 30), overlay.Bounds())
+		})
+	}
+}
+
+func Test_DetectedDates(t *testing.T) {
+	testCases := []struct {
+		name         string
+		text         string
+		purchaseDate string
+		endDate      string
+		dates        int
+	}{
+		{
+			name:         "manufactured and best before",
+			text:         "изг. 15.09.22 годен до 24.09.22",
+			purchaseDate: "15.09.22",
+			endDate:      "24.09.22",
+			dates:        2,
+		},
+		{
+			name:    "use by before best before",
+			text:    "Употребить до 20.09.22 BB 24.09.22",
+			endDate: "20.09.22",
+			dates:   2,
+		},
+		{
+			name:         "same date twice",
+			text:         "изг 15.09.22\n15.09.22 годен до 24.09.22",
+			purchaseDate: "15.09.22",
+			endDate:      "24.09.22",
+			dates:        2,
+		},
+		{
+			name:         "end before purchase",
+			text:         "изг 24.09.22 годен до 15.09.22",
+			purchaseDate: "24.09.22",
+			dates:        2,
+		},
+		{
+			name:  "unknown",
+			text:  "партия 15.09.22",
+			dates: 1,
+		},
+		{
+			name:         "shelf life",
+			text:         "изг 15.09.22 годен 30 суток при t +2...+6°C",
+			purchaseDate: "15.09.22",
+			endDate:      "15.10.22",
+			dates:        2,
+		},
+	}
+	format := func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+		return date.Format("02.01.06")
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := detectedDates(label.Parse(tc.text), nil)
+			assert.Len(t, result.Dates, tc.dates)
+			assert.Equal(t, tc.purchaseDate, format(result.PurchaseDate))
+			assert.Equal(t, tc.endDate, format(result.EndDate))
+		})
+	}
+}
+
+func Test_DetectText(t *testing.T) {
+	detector := New(nil)
+	result, err := detector.DetectText(context.Background(), []string{"изг 15.09.22", "годен до 24.09.22"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"изг 15.09.22", "годен до 24.09.22"}, result.RawText)
+	assert.Len(t, result.Dates, 2)
+	assert.Equal(t, time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC), *result.EndDate)
+	assert.Equal(t, "day-month-year", result.Dates[1].Format)
+
+	_, err = detector.DetectText(context.Background(), []string{"партия 123"})
+	assert.ErrorIs(t, err, ErrNoDates)
 }
@@ -6,7 +6,6 @@ import (
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/services/utils"
-	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 )
 
@@ -28,7 +27,7 @@ type shelfLifeSerivce struct {
 }
 
 func (s *shelfLifeSerivce) Count(ctx context.Context, filter params.ShelfLifeFilter) (int, error) {
-	count, err := s.repo.Count(ctx, models.ShelfLifeFilter{})
+	count, err := s.repo.Count(ctx, utils.ShelfLifeFilterToModel(&filter))
 	if err != nil {
 		return 0, fmt.Errorf("error counting shelf lives: %w", err)
 	}
@@ -90,12 +89,7 @@ func (svc *shelfLifeSerivce) FindShelfLifeByID(ctx context.Context, id int) (par
 
 // FindShelfLifes implements ShelfLifeServicer
 func (svc *shelfLifeSerivce) FindShelfLifes(ctx context.Context, filter *params.ShelfLifeFilter) ([]params.FindShelfLife, error) {
-	models, err := svc.repo.FindMany(ctx, models.ShelfLifeFilter{
-		PageFilter: models.PageFilter{
-			Limit:  filter.Limit,
-			Offset: filter.Offset,
-		},
-	})
+	models, err := svc.repo.FindMany(ctx, utils.ShelfLifeFilterToModel(filter))
 	dtos := utils.ShelfLifeModelsToFinds(models)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,415 @@
+package shoppinglist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	recipesvc "github.com/romankravchuk/muerta/internal/services/recipe"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+)
+
+type ShoppingListServicer interface {
+	FindShoppingLists(
+		ctx context.Context,
+		userID int,
+		filter *params.ShoppingListFilter,
+	) ([]params.FindShoppingList, error)
+	Count(ctx context.Context, userID int, filter params.ShoppingListFilter) (int, error)
+	FindShoppingListByID(ctx context.Context, userID, id int) (params.FindShoppingList, error)
+	CreateShoppingList(
+		ctx context.Context,
+		userID int,
+		payload *params.CreateShoppingList,
+	) (params.FindShoppingList, error)
+	UpdateShoppingList(
+		ctx context.Context,
+		userID, id int,
+		payload *params.UpdateShoppingList,
+	) (params.FindShoppingList, error)
+	DeleteShoppingList(ctx context.Context, userID, id int) error
+	AddItem(
+		ctx context.Context,
+		userID, id int,
+		payload *params.CreateShoppingListItem,
+	) (params.FindShoppingListItem, error)
+	UpdateItem(
+		ctx context.Context,
+		userID, id, itemID int,
+		payload *params.UpdateShoppingListItem,
+	) (params.FindShoppingListItem, error)
+	DeleteItem(ctx context.Context, userID, id, itemID int) error
+	CheckItem(
+		ctx context.Context,
+		userID, id, itemID int,
+		payload *params.CheckShoppingListItem,
+	) (params.FindShoppingListItem, error)
+	// GenerateFromRecipe adds the missing ingredients of the recipe visible
+	// to the user, the moderator sees all the recipes.
+	GenerateFromRecipe(
+		ctx context.Context,
+		userID, id int,
+		payload *params.GenerateShoppingListItems,
+		moderator bool,
+	) (params.FindShoppingList, error)
+	GenerateFromRestock(ctx context.Context, userID, id int) (params.FindShoppingList, error)
+}
+
+type shoppingListService struct {
+	repo    repository.ShoppingListRepositorer
+	recipes recipes.RecipesRepositorer
+}
+
+func New(
+	repo repository.ShoppingListRepositorer,
+	recipes recipes.RecipesRepositorer,
+) ShoppingListServicer {
+	return &shoppingListService{
+		repo:    repo,
+		recipes: recipes,
+	}
+}
+
+// FindShoppingLists implements ShoppingListServicer
+func (s *shoppingListService) FindShoppingLists(
+	ctx context.Context,
+	userID int,
+	filter *params.ShoppingListFilter,
+) ([]params.FindShoppingList, error) {
+	model := utils.ShoppingListFilterToModel(filter)
+	model.UserID = userID
+	result, err := s.repo.FindMany(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shopping lists: %w", err)
+	}
+	return utils.ShoppingListModelsToFinds(result), nil
+}
+
+// Count implements ShoppingListServicer
+func (s *shoppingListService) Count(
+	ctx context.Context,
+	userID int,
+	filter params.ShoppingListFilter,
+) (int, error) {
+	model := utils.ShoppingListFilterToModel(&filter)
+	model.UserID = userID
+	count, err := s.repo.Count(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("error counting shopping lists: %w", err)
+	}
+	return count, nil
+}
+
+// FindShoppingListByID implements ShoppingListServicer
+func (s *shoppingListService) FindShoppingListByID(
+	ctx context.Context,
+	userID, id int,
+) (params.FindShoppingList, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingList{}, err
+	}
+	result, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding shopping list: %w", err)
+	}
+	return utils.ShoppingListModelToFind(&result), nil
+}
+
+// CreateShoppingList implements ShoppingListServicer
+func (s *shoppingListService) CreateShoppingList(
+	ctx context.Context,
+	userID int,
+	payload *params.CreateShoppingList,
+) (params.FindShoppingList, error) {
+	if err := s.checkStorage(ctx, userID, payload.StorageID); err != nil {
+		return params.FindShoppingList{}, err
+	}
+	model := models.ShoppingList{
+		Name:    payload.Name,
+		User:    models.User{ID: userID},
+		Storage: models.Vault{ID: payload.StorageID},
+	}
+	if err := s.repo.Create(ctx, &model); err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error creating shopping list: %w", err)
+	}
+	return s.FindShoppingListByID(ctx, userID, model.ID)
+}
+
+// UpdateShoppingList implements ShoppingListServicer
+func (s *shoppingListService) UpdateShoppingList(
+	ctx context.Context,
+	userID, id int,
+	payload *params.UpdateShoppingList,
+) (params.FindShoppingList, error) {
+	model, err := s.findOwned(ctx, userID, id)
+	if err != nil {
+		return params.FindShoppingList{}, err
+	}
+	if payload.Name != "" {
+		model.Name = payload.Name
+	}
+	if payload.StorageID != nil {
+		if err := s.checkStorage(ctx, userID, *payload.StorageID); err != nil {
+			return params.FindShoppingList{}, err
+		}
+		model.Storage.ID = *payload.StorageID
+	}
+	if err := s.repo.Update(ctx, model); err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error updating shopping list: %w", err)
+	}
+	return s.FindShoppingListByID(ctx, userID, id)
+}
+
+// DeleteShoppingList implements ShoppingListServicer
+func (s *shoppingListService) DeleteShoppingList(ctx context.Context, userID, id int) error {
+	if _, err := s.findOwned(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting shopping list: %w", err)
+	}
+	return nil
+}
+
+// AddItem implements ShoppingListServicer
+func (s *shoppingListService) AddItem(
+	ctx context.Context,
+	userID, id int,
+	payload *params.CreateShoppingListItem,
+) (params.FindShoppingListItem, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingListItem{}, err
+	}
+	result, err := s.repo.AddItem(ctx, id, utils.CreateShoppingListItemToModel(payload))
+	if err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error adding shopping list item: %w", err)
+	}
+	return utils.ShoppingListItemModelToFind(&result), nil
+}
+
+// UpdateItem implements ShoppingListServicer
+func (s *shoppingListService) UpdateItem(
+	ctx context.Context,
+	userID, id, itemID int,
+	payload *params.UpdateShoppingListItem,
+) (params.FindShoppingListItem, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingListItem{}, err
+	}
+	model, err := s.repo.FindItem(ctx, id, itemID)
+	if err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error finding shopping list item: %w", err)
+	}
+	if payload.MeasureID != 0 {
+		model.Measure.ID = payload.MeasureID
+	}
+	if payload.Quantity != 0 {
+		model.Quantity = payload.Quantity
+	}
+	if payload.Note != nil {
+		model.Note = *payload.Note
+	}
+	if err := s.repo.UpdateItem(ctx, id, model); err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error updating shopping list item: %w", err)
+	}
+	result, err := s.repo.FindItem(ctx, id, itemID)
+	if err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error finding shopping list item: %w", err)
+	}
+	return utils.ShoppingListItemModelToFind(&result), nil
+}
+
+// DeleteItem implements ShoppingListServicer
+func (s *shoppingListService) DeleteItem(ctx context.Context, userID, id, itemID int) error {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteItem(ctx, id, itemID); err != nil {
+		return fmt.Errorf("error deleting shopping list item: %w", err)
+	}
+	return nil
+}
+
+// CheckItem implements ShoppingListServicer
+func (s *shoppingListService) CheckItem(
+	ctx context.Context,
+	userID, id, itemID int,
+	payload *params.CheckShoppingListItem,
+) (params.FindShoppingListItem, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingListItem{}, err
+	}
+	model, err := s.repo.FindItem(ctx, id, itemID)
+	if err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error finding shopping list item: %w", err)
+	}
+	var shelfLife *models.ShelfLife
+	// the shelf life is created only when the item is being checked off
+	if payload.Checked && !model.Checked && payload.StorageID != 0 {
+		if err := s.checkStorage(ctx, userID, payload.StorageID); err != nil {
+			return params.FindShoppingListItem{}, err
+		}
+		now := time.Now()
+		shelfLife = &models.ShelfLife{
+			Product:      model.Product,
+			Storage:      models.Vault{ID: payload.StorageID},
+			Measure:      model.Measure,
+			User:         models.User{ID: userID},
+			Quantity:     model.Quantity,
+			PurchaseDate: &now,
+			EndDate:      payload.EndDate,
+		}
+	}
+	model.Checked = payload.Checked
+	if err := s.repo.CheckItem(ctx, id, model, shelfLife); err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error checking shopping list item: %w", err)
+	}
+	return utils.ShoppingListItemModelToFind(&model), nil
+}
+
+// GenerateFromRecipe implements ShoppingListServicer
+func (s *shoppingListService) GenerateFromRecipe(
+	ctx context.Context,
+	userID, id int,
+	payload *params.GenerateShoppingListItems,
+	moderator bool,
+) (params.FindShoppingList, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingList{}, err
+	}
+	if err := recipesvc.CheckVisible(ctx, s.recipes, payload.RecipeID, userID, moderator); err != nil {
+		return params.FindShoppingList{}, err
+	}
+	ingredients, err := s.recipes.FindIngredients(ctx, payload.RecipeID)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding recipe ingredients: %w", err)
+	}
+	products := make([]int, len(ingredients))
+	for i, ingredient := range ingredients {
+		products[i] = ingredient.Product.ID
+	}
+	stock, err := s.repo.FindStock(ctx, userID, products)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding stock: %w", err)
+	}
+	if _, err := s.repo.AddItems(ctx, id, Missing(ingredients, stock)); err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error adding recipe ingredients: %w", err)
+	}
+	return s.FindShoppingListByID(ctx, userID, id)
+}
+
+// GenerateFromRestock implements ShoppingListServicer
+func (s *shoppingListService) GenerateFromRestock(
+	ctx context.Context,
+	userID, id int,
+) (params.FindShoppingList, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return params.FindShoppingList{}, err
+	}
+	if _, err := s.repo.AddRestockItems(ctx, id, userID); err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error adding restock items: %w", err)
+	}
+	return s.FindShoppingListByID(ctx, userID, id)
+}
+
+// Missing returns the items for the quantities of the ingredients which are
+// not in the stock. The stock is converted to the measure of the ingredient,
+// the shelf lives of incompatible measures are not counted.
+func Missing(
+	ingredients []models.RecipeIngredient,
+	stock []models.ShelfLife,
+) []models.ShoppingListItem {
+	left := make([]float32, len(stock))
+	for i, shelfLife := range stock {
+		left[i] = shelfLife.Quantity
+	}
+	items := make([]models.ShoppingListItem, 0)
+	for _, ingredient := range ingredients {
+		need := float64(ingredient.Quantity)
+		for i, shelfLife := range stock {
+			if need <= 0 {
+				break
+			}
+			if shelfLife.Product.ID != ingredient.Product.ID || left[i] <= 0 {
+				continue
+			}
+			available, err := measure.Convert(
+				float64(left[i]), shelfLife.Measure, ingredient.Measure, ingredient.Product,
+			)
+			if err != nil {
+				continue
+			}
+			if available <= need {
+				need -= available
+				left[i] = 0
+				continue
+			}
+			used, err := measure.Convert(
+				need, ingredient.Measure, shelfLife.Measure, ingredient.Product,
+			)
+			if err != nil {
+				continue
+			}
+			left[i] -= float32(used)
+			need = 0
+		}
+		if need > 0 {
+			items = append(items, models.ShoppingListItem{
+				Product:  ingredient.Product,
+				Measure:  ingredient.Measure,
+				Quantity: float32(need),
+			})
+		}
+	}
+	return items
+}
+
+// checkAccess returns errors.ErrNotOwner if the list is neither owned by the
+// user nor shared with the user through a storage.
+func (s *shoppingListService) checkAccess(ctx context.Context, userID, id int) error {
+	ok, err := s.repo.HasAccess(ctx, id, userID)
+	if err != nil {
+		return fmt.Errorf("error checking shopping list access: %w", err)
+	}
+	if !ok {
+		return errors.ErrNotOwner
+	}
+	return nil
+}
+
+// checkStorage returns errors.ErrNotOwner if the storage is not one of the
+// user's storages. The zero id means no storage.
+func (s *shoppingListService) checkStorage(ctx context.Context, userID, storageID int) error {
+	if storageID == 0 {
+		return nil
+	}
+	ok, err := s.repo.HasStorage(ctx, userID, storageID)
+	if err != nil {
+		return fmt.Errorf("error checking storage: %w", err)
+	}
+	if !ok {
+		return errors.ErrNotOwner
+	}
+	return nil
+}
+
+// findOwned returns the list if it is owned by the user.
+func (s *shoppingListService) findOwned(
+	ctx context.Context,
+	userID, id int,
+) (models.ShoppingList, error) {
+	model, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return models.ShoppingList{}, fmt.Errorf("error finding shopping list: %w", err)
+	}
+	if model.User.ID != userID {
+		return models.ShoppingList{}, errors.ErrNotOwner
+	}
+	return model, nil
+}
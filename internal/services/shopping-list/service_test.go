@@ -0,0 +1,193 @@
+package shoppinglist
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	repository.ShoppingListRepositorer
+	access    bool
+	missing   bool
+	item      models.ShoppingListItem
+	shelfLife *models.ShelfLife
+}
+
+func (r *fakeRepository) HasAccess(ctx context.Context, id, userID int) (bool, error) {
+	if r.missing {
+		return false, fmt.Errorf("%w: %d", repository.ErrNotFound, id)
+	}
+	return r.access, nil
+}
+
+func (r *fakeRepository) HasStorage(ctx context.Context, userID, storageID int) (bool, error) {
+	return storageID == 1, nil
+}
+
+func (r *fakeRepository) FindItem(
+	ctx context.Context,
+	id, itemID int,
+) (models.ShoppingListItem, error) {
+	return r.item, nil
+}
+
+func (r *fakeRepository) CheckItem(
+	ctx context.Context,
+	id int,
+	item models.ShoppingListItem,
+	shelfLife *models.ShelfLife,
+) error {
+	r.item, r.shelfLife = item, shelfLife
+	return nil
+}
+
+func Test_CheckItem(t *testing.T) {
+	endDate := time.Date(2023, time.May, 27, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		access    bool
+		checked   bool
+		payload   params.CheckShoppingListItem
+		shelfLife bool
+		err       error
+	}{
+		{
+			name:    "check without storage",
+			access:  true,
+			payload: params.CheckShoppingListItem{Checked: true},
+		},
+		{
+			name:      "check into storage",
+			access:    true,
+			payload:   params.CheckShoppingListItem{Checked: true, StorageID: 1, EndDate: &endDate},
+			shelfLife: true,
+		},
+		{
+			name:    "already checked",
+			access:  true,
+			checked: true,
+			payload: params.CheckShoppingListItem{Checked: true, StorageID: 1, EndDate: &endDate},
+		},
+		{
+			name:    "foreign storage",
+			access:  true,
+			payload: params.CheckShoppingListItem{Checked: true, StorageID: 2, EndDate: &endDate},
+			err:     errors.ErrNotOwner,
+		},
+		{
+			name:    "foreign list",
+			payload: params.CheckShoppingListItem{Checked: true},
+			err:     errors.ErrNotOwner,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				access: tc.access,
+				item: models.ShoppingListItem{
+					ID:       1,
+					Product:  models.Product{ID: 2, Name: "Молоко"},
+					Measure:  models.Measure{ID: 3, Name: "л"},
+					Quantity: 2,
+					Checked:  tc.checked,
+				},
+			}
+			result, err := New(repo, nil).CheckItem(context.Background(), 1, 1, 1, &tc.payload)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, result.Checked)
+			if !tc.shelfLife {
+				assert.Nil(t, repo.shelfLife)
+				return
+			}
+			assert.NotNil(t, repo.shelfLife)
+			assert.Equal(t, 1, repo.shelfLife.Storage.ID)
+			assert.Equal(t, 2, repo.shelfLife.Product.ID)
+			assert.Equal(t, float32(2), repo.shelfLife.Quantity)
+			assert.Equal(t, &endDate, repo.shelfLife.EndDate)
+		})
+	}
+}
+
+func Test_FindShoppingListByID(t *testing.T) {
+	testCases := []struct {
+		name string
+		repo *fakeRepository
+		err  error
+	}{
+		{name: "unknown list", repo: &fakeRepository{missing: true}, err: repository.ErrNotFound},
+		{name: "foreign list", repo: &fakeRepository{}, err: errors.ErrNotOwner},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.repo, nil).FindShoppingListByID(context.Background(), 1, 1)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func Test_Missing(t *testing.T) {
+	flour := models.Product{ID: 1, Name: "Мука"}
+	eggs := models.Product{ID: 2, Name: "Яйца"}
+	gram := models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+	kilogram := models.Measure{ID: 2, Name: "кг", Dimension: models.DimensionMass, Factor: 1000}
+	piece := models.Measure{ID: 3, Name: "шт", Dimension: models.DimensionCount, Factor: 1}
+	testCases := []struct {
+		name        string
+		ingredients []models.RecipeIngredient
+		stock       []models.ShelfLife
+		expected    []models.ShoppingListItem
+	}{
+		{
+			name:        "converted stock",
+			ingredients: []models.RecipeIngredient{{Product: flour, Measure: gram, Quantity: 500}},
+			stock: []models.ShelfLife{
+				{Product: flour, Measure: kilogram, Quantity: 0.25},
+			},
+			expected: []models.ShoppingListItem{{Product: flour, Measure: gram, Quantity: 250}},
+		},
+		{
+			name:        "enough stock",
+			ingredients: []models.RecipeIngredient{{Product: flour, Measure: gram, Quantity: 500}},
+			stock: []models.ShelfLife{
+				{Product: flour, Measure: kilogram, Quantity: 1},
+			},
+			expected: []models.ShoppingListItem{},
+		},
+		{
+			name: "stock is not counted twice",
+			ingredients: []models.RecipeIngredient{
+				{Product: flour, Measure: gram, Quantity: 300},
+				{Product: flour, Measure: kilogram, Quantity: 1},
+			},
+			stock: []models.ShelfLife{
+				{Product: flour, Measure: kilogram, Quantity: 1},
+			},
+			expected: []models.ShoppingListItem{{Product: flour, Measure: kilogram, Quantity: 0.3}},
+		},
+		{
+			name:        "incompatible stock",
+			ingredients: []models.RecipeIngredient{{Product: eggs, Measure: piece, Quantity: 3}},
+			stock: []models.ShelfLife{
+				{Product: eggs, Measure: gram, Quantity: 500},
+			},
+			expected: []models.ShoppingListItem{{Product: eggs, Measure: piece, Quantity: 3}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Missing(tc.ingredients, tc.stock))
+		})
+	}
+}
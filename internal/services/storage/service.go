@@ -20,7 +20,12 @@ type StorageServicer interface {
 	FindTips(ctx context.Context, id int) ([]params.FindTip, error)
 	CreateTip(ctx context.Context, id, tipID int) (params.FindTip, error)
 	DeleteTip(ctx context.Context, id, tipID int) error
-	FindShelfLives(ctx context.Context, id int) ([]params.FindShelfLife, error)
+	FindShelfLives(
+		ctx context.Context,
+		id int,
+		filter *params.ShelfLifeFilter,
+	) ([]params.FindShelfLife, error)
+	CountShelfLives(ctx context.Context, id int, filter params.ShelfLifeFilter) (int, error)
 	Count(ctx context.Context, filter params.StorageFilter) (int, error)
 }
 
@@ -31,14 +36,27 @@ type storageService struct {
 func (s *storageService) FindShelfLives(
 	ctx context.Context,
 	id int,
+	filter *params.ShelfLifeFilter,
 ) ([]params.FindShelfLife, error) {
-	result, err := s.repo.FindShelfLives(ctx, id)
+	result, err := s.repo.FindShelfLives(ctx, id, utils.ShelfLifeFilterToModel(filter))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find shelf lives: %w", err)
 	}
 	return utils.ShelfLifeModelsToFinds(result), nil
 }
 
+func (s *storageService) CountShelfLives(
+	ctx context.Context,
+	id int,
+	filter params.ShelfLifeFilter,
+) (int, error) {
+	count, err := s.repo.CountShelfLives(ctx, id, utils.ShelfLifeFilterToModel(&filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count shelf lives: %w", err)
+	}
+	return count, nil
+}
+
 func (s *storageService) Count(ctx context.Context, filter params.StorageFilter) (int, error) {
 	count, err := s.repo.Count(ctx, models.StorageFilter{Name: filter.Name})
 	if err != nil {
@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	productsvc "github.com/romankravchuk/muerta/internal/services/product"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// maxNutritionDays is the longest range of the nutrition summary.
+const maxNutritionDays = 92
+
+// ErrInvalidRange is returned when the range of the nutrition summary is
+// reversed or too long.
+var ErrInvalidRange = errors.New("invalid date range")
+
+// ConsumeShelfLife implements UserServicer
+func (svc *userService) ConsumeShelfLife(
+	ctx context.Context,
+	id, shelfLifeID int,
+	payload *params.ConsumeShelfLife,
+) (params.FindConsumption, error) {
+	model, err := svc.repo.ConsumeShelfLife(ctx, id, shelfLifeID, payload.Quantity)
+	if err != nil {
+		return params.FindConsumption{}, fmt.Errorf("error consuming shelf life: %w", err)
+	}
+	return utils.ConsumptionModelToFind(&model), nil
+}
+
+// FindNutrition implements UserServicer
+func (svc *userService) FindNutrition(
+	ctx context.Context,
+	id int,
+	filter *params.NutritionFilter,
+) ([]params.FindDailyNutrition, error) {
+	from, to, err := nutritionRange(filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	consumptions, err := svc.repo.FindConsumptions(
+		ctx, id, models.ConsumptionFilter{From: from, To: to},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error finding consumptions: %w", err)
+	}
+	return DailyNutrition(consumptions, from, to), nil
+}
+
+// nutritionRange returns the days of the filter, the week up to today by
+// default.
+func nutritionRange(filter *params.NutritionFilter, now time.Time) (time.Time, time.Time, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if filter.To != "" {
+		date, err := time.Parse(time.DateOnly, filter.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+		to = date
+	}
+	from := to.AddDate(0, 0, -6)
+	if filter.From != "" {
+		date, err := time.Parse(time.DateOnly, filter.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidRange, err)
+		}
+		from = date
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from is after to", ErrInvalidRange)
+	}
+	if to.Sub(from) >= maxNutritionDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf(
+			"%w: more than %d days", ErrInvalidRange, maxNutritionDays,
+		)
+	}
+	return from, to, nil
+}
+
+// DailyNutrition sums the nutrition of the consumptions by day. Every day of
+// the range is present, the days without consumptions have zero nutrition.
+func DailyNutrition(
+	consumptions []models.Consumption,
+	from, to time.Time,
+) []params.FindDailyNutrition {
+	portions := make(map[string][]productsvc.Portion)
+	for _, consumption := range consumptions {
+		date := consumption.ConsumedAt.Format(time.DateOnly)
+		portions[date] = append(portions[date], productsvc.Portion{
+			Product:  consumption.ShelfLife.Product,
+			Measure:  consumption.ShelfLife.Measure,
+			Quantity: consumption.Quantity,
+		})
+	}
+	result := make([]params.FindDailyNutrition, 0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		nutrition, missing := productsvc.Nutrition(portions[date])
+		result = append(result, params.FindDailyNutrition{
+			Date:      date,
+			Nutrition: *utils.NutritionModelToFind(&nutrition),
+			Missing:   utils.ProductModelsToFinds(missing),
+		})
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DailyNutrition(t *testing.T) {
+	var (
+		grams = models.Measure{ID: 1, Name: "г", Dimension: models.DimensionMass, Factor: 1}
+		milk  = models.Product{ID: 1, Name: "Молоко", Nutrition: &models.Nutrition{
+			Energy: 60, Protein: 3, Fat: 3.2, Carbohydrates: 4.7,
+		}}
+		salt = models.Product{ID: 2, Name: "Соль"}
+		day  = func(d, h int) time.Time { return time.Date(2023, 5, d, h, 0, 0, 0, time.UTC) }
+	)
+	consumptions := []models.Consumption{
+		{ShelfLife: models.ShelfLife{Product: milk, Measure: grams}, Quantity: 200, ConsumedAt: day(1, 8)},
+		{ShelfLife: models.ShelfLife{Product: milk, Measure: grams}, Quantity: 100, ConsumedAt: day(1, 20)},
+		{ShelfLife: models.ShelfLife{Product: salt, Measure: grams}, Quantity: 5, ConsumedAt: day(3, 12)},
+	}
+	result := DailyNutrition(consumptions, day(1, 0), day(3, 0))
+	assert.Len(t, result, 3)
+	assert.Equal(t, "2023-05-01", result[0].Date)
+	assert.Equal(t, params.Nutrition{Energy: 180, Protein: 9, Fat: 9.6, Carbohydrates: 14.1}, result[0].Nutrition)
+	assert.Empty(t, result[0].Missing)
+	assert.Equal(t, "2023-05-02", result[1].Date)
+	assert.Equal(t, params.Nutrition{}, result[1].Nutrition)
+	assert.Equal(t, params.Nutrition{}, result[2].Nutrition)
+	assert.Equal(t, []params.FindProduct{{ID: 2, Name: "Соль"}}, result[2].Missing)
+}
+
+func Test_NutritionRange(t *testing.T) {
+	now := time.Date(2023, 5, 10, 15, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		filter params.NutritionFilter
+		from   string
+		to     string
+		err    bool
+	}{
+		{name: "last week by default", from: "2023-05-04", to: "2023-05-10"},
+		{
+			name:   "explicit range",
+			filter: params.NutritionFilter{From: "2023-04-01", To: "2023-04-02"},
+			from:   "2023-04-01",
+			to:     "2023-04-02",
+		},
+		{name: "reversed", filter: params.NutritionFilter{From: "2023-05-11"}, err: true},
+		{name: "too long", filter: params.NutritionFilter{From: "2023-01-01"}, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := nutritionRange(&tc.filter, now)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrInvalidRange)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.from, from.Format(time.DateOnly))
+			assert.Equal(t, tc.to, to.Format(time.DateOnly))
+		})
+	}
+}
@@ -30,7 +30,12 @@ type UserServicer interface {
 	) (params.FindStorage, error)
 	RemoveStorage(ctx context.Context, id, storageID int) error
 	FindStorages(ctx context.Context, id int) ([]params.FindStorage, error)
-	FindShelfLives(ctx context.Context, id int) ([]params.FindShelfLife, error)
+	FindShelfLives(
+		ctx context.Context,
+		id int,
+		filter *params.ShelfLifeFilter,
+	) ([]params.FindShelfLife, error)
+	CountShelfLives(ctx context.Context, id int, filter params.ShelfLifeFilter) (int, error)
 	CreateShelfLife(
 		ctx context.Context,
 		id int,
@@ -47,6 +52,21 @@ type UserServicer interface {
 	) (params.FindShelfLife, error)
 	DeleteShelfLife(ctx context.Context, id, shelfLifeID int) error
 	Count(ctx context.Context, filter params.UserFilter) (int, error)
+	FindRecipeSuggestions(
+		ctx context.Context,
+		id int,
+		filter *params.RecipeSuggestionFilter,
+	) ([]params.FindRecipeSuggestion, int, error)
+	ConsumeShelfLife(
+		ctx context.Context,
+		id, shelfLifeID int,
+		payload *params.ConsumeShelfLife,
+	) (params.FindConsumption, error)
+	FindNutrition(
+		ctx context.Context,
+		id int,
+		filter *params.NutritionFilter,
+	) ([]params.FindDailyNutrition, error)
 }
 
 type userService struct {
@@ -97,14 +117,28 @@ func (svc *userService) DeleteShelfLife(
 func (svc *userService) FindShelfLives(
 	ctx context.Context,
 	id int,
+	filter *params.ShelfLifeFilter,
 ) ([]params.FindShelfLife, error) {
-	models, err := svc.repo.FindShelfLives(ctx, id)
+	models, err := svc.repo.FindShelfLives(ctx, id, utils.ShelfLifeFilterToModel(filter))
 	if err != nil {
 		return nil, fmt.Errorf("error finding shelf lives: %w", err)
 	}
 	return utils.ShelfLifeModelsToFinds(models), nil
 }
 
+// CountShelfLives implements UserServicer
+func (svc *userService) CountShelfLives(
+	ctx context.Context,
+	id int,
+	filter params.ShelfLifeFilter,
+) (int, error) {
+	count, err := svc.repo.CountShelfLives(ctx, id, utils.ShelfLifeFilterToModel(&filter))
+	if err != nil {
+		return 0, fmt.Errorf("error counting shelf lives: %w", err)
+	}
+	return count, nil
+}
+
 // RestoreShelfLife implements UserServicer
 func (svc *userService) RestoreShelfLife(
 	ctx context.Context,
@@ -145,6 +179,9 @@ func (svc *userService) UpdateShelfLife(
 	if payload.EndDate != nil {
 		model.EndDate = payload.EndDate
 	}
+	if payload.Restock != nil {
+		model.Restock = *payload.Restock
+	}
 	result, err := svc.repo.UpdateShelfLife(ctx, id, model)
 	if err != nil {
 		return params.FindShelfLife{}, fmt.Errorf("error updating shelf life: %w", err)
@@ -291,3 +328,18 @@ func (svc *userService) RestoreUser(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+// FindRecipeSuggestions implements UserServicer
+func (svc *userService) FindRecipeSuggestions(
+	ctx context.Context,
+	id int,
+	filter *params.RecipeSuggestionFilter,
+) ([]params.FindRecipeSuggestion, int, error) {
+	result, count, err := svc.repo.FindRecipeSuggestions(
+		ctx, id, utils.RecipeSuggestionFilterToModel(filter),
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error finding recipe suggestions: %w", err)
+	}
+	return utils.RecipeSuggestionModelsToFinds(result), count, nil
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/pkg/auth"
@@ -8,19 +10,18 @@ import (
 )
 
 func RecipeModelToFind(model *models.Recipe) params.FindRecipe {
-	steps := make([]params.FindStep, len(model.Steps))
-	for i, step := range model.Steps {
-		steps[i] = params.FindStep{
-			ID:    step.ID,
-			Name:  step.Name,
-			Place: step.Place,
-		}
-	}
 	return params.FindRecipe{
-		ID:          model.ID,
-		Name:        model.Name,
-		Description: model.Description,
-		Steps:       steps,
+		ID:             model.ID,
+		UserID:         model.User.ID,
+		Name:           model.Name,
+		Description:    model.Description,
+		Servings:       model.Servings,
+		Status:         model.Status,
+		ModerationNote: model.ModerationNote,
+		Rating:         model.Rating,
+		Ratings:        model.Ratings,
+		Steps:          StepModelsToFinds(model.Steps),
+		Ingredients:    RecipeIngredientModelsToFinds(model.Ingredients),
 	}
 }
 
@@ -29,13 +30,37 @@ func RecipeModelsToFinds(models []models.Recipe) []params.FindRecipe {
 	for i, recipe := range models {
 		result[i] = params.FindRecipe{
 			ID:          recipe.ID,
+			UserID:      recipe.User.ID,
 			Name:        recipe.Name,
 			Description: recipe.Description,
+			Servings:    recipe.Servings,
+			Status:      recipe.Status,
+			Rating:      recipe.Rating,
+			Ratings:     recipe.Ratings,
 		}
 	}
 	return result
 }
 
+func RecipeRatingModelsToFinds(models []models.RecipeRating) []params.FindRecipeRating {
+	result := make([]params.FindRecipeRating, len(models))
+	for i, rating := range models {
+		result[i] = RecipeRatingModelToFind(rating)
+	}
+	return result
+}
+
+func RecipeRatingModelToFind(model models.RecipeRating) params.FindRecipeRating {
+	return params.FindRecipeRating{
+		UserID:    model.User.ID,
+		Username:  model.User.Name,
+		Rating:    model.Rating,
+		Review:    model.Review,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
+
 func UserModelToFind(model *models.User) params.FindUser {
 	settings := make([]params.FindSetting, len(model.Settings))
 	for i, setting := range model.Settings {
@@ -100,12 +125,17 @@ func CreateRecipeToModel(dto *params.CreateRecipe) models.Recipe {
 		ingredients[i].Measure.ID = ingredient.MeasureID
 		ingredients[i].Quantity = ingredient.Quantity
 	}
+	servings := dto.Servings
+	if servings == 0 {
+		servings = 1
+	}
 	return models.Recipe{
 		User: models.User{
 			ID: dto.UserID,
 		},
 		Name:        dto.Name,
 		Description: dto.Description,
+		Servings:    servings,
 		Steps:       steps,
 		Ingredients: ingredients,
 	}
@@ -172,8 +202,38 @@ func CreateStorageToModel(dto *params.CreateStorage) models.Vault {
 
 func ProductModelToFind(model *models.Product) params.FindProduct {
 	return params.FindProduct{
-		ID:   model.ID,
-		Name: model.Name,
+		ID:          model.ID,
+		Name:        model.Name,
+		Density:     model.Density,
+		PieceWeight: model.PieceWeight,
+		Nutrition:   NutritionModelToFind(model.Nutrition),
+		Barcodes:    model.Barcodes,
+	}
+}
+
+func NutritionModelToFind(model *models.Nutrition) *params.Nutrition {
+	if model == nil {
+		return nil
+	}
+	return &params.Nutrition{
+		Energy:         model.Energy,
+		Protein:        model.Protein,
+		Fat:            model.Fat,
+		Carbohydrates:  model.Carbohydrates,
+		Micronutrients: model.Micronutrients,
+	}
+}
+
+func NutritionToModel(dto *params.Nutrition) *models.Nutrition {
+	if dto == nil {
+		return nil
+	}
+	return &models.Nutrition{
+		Energy:         dto.Energy,
+		Protein:        dto.Protein,
+		Fat:            dto.Fat,
+		Carbohydrates:  dto.Carbohydrates,
+		Micronutrients: dto.Micronutrients,
 	}
 }
 
@@ -187,7 +247,11 @@ func ProductModelsToFinds(models []models.Product) []params.FindProduct {
 
 func CreateProductToModel(dto *params.CreateProduct) models.Product {
 	return models.Product{
-		Name: dto.Name,
+		Name:        dto.Name,
+		Density:     dto.Density,
+		PieceWeight: dto.PieceWeight,
+		Nutrition:   NutritionToModel(dto.Nutrition),
+		Barcodes:    dto.Barcodes,
 	}
 }
 
@@ -257,14 +321,18 @@ func TipModelsToFinds(models []models.Tip) []params.FindTip {
 
 func CreateMeasureToModel(dto *params.CreateMeasure) models.Measure {
 	return models.Measure{
-		Name: dto.Name,
+		Name:      dto.Name,
+		Dimension: dto.Dimension,
+		Factor:    dto.Factor,
 	}
 }
 
 func MeasureModelToFind(model *models.Measure) params.FindMeasure {
 	return params.FindMeasure{
-		ID:   model.ID,
-		Name: model.Name,
+		ID:        model.ID,
+		Name:      model.Name,
+		Dimension: model.Dimension,
+		Factor:    model.Factor,
 	}
 }
 
@@ -317,6 +385,16 @@ func CreateShelfLifeToModel(dto *params.CreateShelfLife) models.ShelfLife {
 	}
 }
 
+func ConsumptionModelToFind(model *models.Consumption) params.FindConsumption {
+	return params.FindConsumption{
+		ID:          model.ID,
+		ShelfLifeID: model.ShelfLife.ID,
+		Quantity:    model.Quantity,
+		Remaining:   model.ShelfLife.Quantity,
+		ConsumedAt:  model.ConsumedAt,
+	}
+}
+
 func ShelfLifeModelToFind(model *models.ShelfLife) params.FindShelfLife {
 	return params.FindShelfLife{
 		ID: model.ID,
@@ -338,6 +416,7 @@ func ShelfLifeModelToFind(model *models.ShelfLife) params.FindShelfLife {
 		Quantity:     model.Quantity,
 		PurchaseDate: model.PurchaseDate,
 		EndDate:      model.EndDate,
+		Restock:      model.Restock,
 	}
 }
 
@@ -349,6 +428,38 @@ func ShelfLifeModelsToFinds(models []models.ShelfLife) []params.FindShelfLife {
 	return dtos
 }
 
+func ShelfLifeFilterToModel(filter *params.ShelfLifeFilter) models.ShelfLifeFilter {
+	model := models.ShelfLifeFilter{
+		PageFilter: models.PageFilter{
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+		StorageID:     filter.StorageID,
+		ProductID:     filter.ProductID,
+		CategoryID:    filter.CategoryID,
+		StatusID:      filter.StatusID,
+		EndBefore:     parseDate(filter.EndBefore),
+		EndAfter:      parseDate(filter.EndAfter),
+		ExpiresWithin: filter.ExpiresIn,
+		Sort:          filter.Sort,
+	}
+	if len(filter.PurchasedBetween) == 2 {
+		model.PurchasedFrom = parseDate(filter.PurchasedBetween[0])
+		model.PurchasedTo = parseDate(filter.PurchasedBetween[1])
+	}
+	return model
+}
+
+// parseDate parses the validated YYYY-MM-DD date, an empty or malformed value
+// results in nil.
+func parseDate(value string) *time.Time {
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
 func CreateShelfLifeStatusToModel(dto *params.CreateShelfLifeStatus) models.ShelfLifeStatus {
 	return models.ShelfLifeStatus{
 		Name: dto.Name,
@@ -399,8 +510,10 @@ func RecipeIngredientModelToFind(entity *models.RecipeIngredient) params.FindRec
 			Name: entity.Product.Name,
 		},
 		Measure: params.FindMeasure{
-			ID:   entity.Measure.ID,
-			Name: entity.Measure.Name,
+			ID:        entity.Measure.ID,
+			Name:      entity.Measure.Name,
+			Dimension: entity.Measure.Dimension,
+			Factor:    entity.Measure.Factor,
 		},
 		Quantity: entity.Quantity,
 	}
@@ -430,9 +543,35 @@ func UserStorageToModel(payload *params.UserStorage) models.Vault {
 
 func StepModelToFind(model models.Step) params.FindStep {
 	return params.FindStep{
-		ID:    model.ID,
-		Name:  model.Name,
-		Place: model.Place,
+		ID:          model.ID,
+		Name:        model.Name,
+		Place:       model.Place,
+		Duration:    model.Duration,
+		Timer:       model.Timer,
+		Temperature: model.Temperature,
+		Ingredients: model.Ingredients,
+	}
+}
+
+func CreateRecipeStepToModel(stepID int, dto *params.CreateRecipeStep) models.Step {
+	return models.Step{
+		ID:          stepID,
+		Place:       dto.Place,
+		Duration:    dto.Duration,
+		Timer:       dto.Timer,
+		Temperature: dto.Temperature,
+		Ingredients: dto.Ingredients,
+	}
+}
+
+func UpdateRecipeStepToModel(stepID int, dto *params.UpdateRecipeStep) models.Step {
+	return models.Step{
+		ID:          stepID,
+		Place:       dto.Place,
+		Duration:    dto.Duration,
+		Timer:       dto.Timer,
+		Temperature: dto.Temperature,
+		Ingredients: dto.Ingredients,
 	}
 }
 
@@ -449,3 +588,169 @@ func CreateToStepModel(dto *params.CreateStep) models.Step {
 		Name: dto.Name,
 	}
 }
+
+func ShoppingListFilterToModel(filter *params.ShoppingListFilter) models.ShoppingListFilter {
+	return models.ShoppingListFilter{
+		PageFilter: models.PageFilter{
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+		Name: filter.Name,
+	}
+}
+
+func ShoppingListModelToFind(model *models.ShoppingList) params.FindShoppingList {
+	dto := params.FindShoppingList{
+		ID:        model.ID,
+		Name:      model.Name,
+		Items:     ShoppingListItemModelsToFinds(model.Items),
+		CreatedAt: model.CreatedAt,
+	}
+	if model.Storage.ID != 0 {
+		storage := StorageModelToFind(&model.Storage)
+		dto.Storage = &storage
+	}
+	return dto
+}
+
+func ShoppingListModelsToFinds(models []models.ShoppingList) []params.FindShoppingList {
+	dtos := make([]params.FindShoppingList, len(models))
+	for i, model := range models {
+		dtos[i] = ShoppingListModelToFind(&model)
+	}
+	return dtos
+}
+
+func CreateShoppingListItemToModel(dto *params.CreateShoppingListItem) models.ShoppingListItem {
+	return models.ShoppingListItem{
+		Product:  models.Product{ID: dto.ProductID},
+		Measure:  models.Measure{ID: dto.MeasureID},
+		Quantity: dto.Quantity,
+		Note:     dto.Note,
+	}
+}
+
+func ShoppingListItemModelToFind(model *models.ShoppingListItem) params.FindShoppingListItem {
+	return params.FindShoppingListItem{
+		ID:       model.ID,
+		Product:  ProductModelToFind(&model.Product),
+		Measure:  MeasureModelToFind(&model.Measure),
+		Quantity: model.Quantity,
+		Checked:  model.Checked,
+		Note:     model.Note,
+	}
+}
+
+func ShoppingListItemModelsToFinds(models []models.ShoppingListItem) []params.FindShoppingListItem {
+	if models == nil {
+		return nil
+	}
+	dtos := make([]params.FindShoppingListItem, len(models))
+	for i, model := range models {
+		dtos[i] = ShoppingListItemModelToFind(&model)
+	}
+	return dtos
+}
+
+func RecipeSuggestionFilterToModel(filter *params.RecipeSuggestionFilter) models.RecipeSuggestionFilter {
+	model := models.RecipeSuggestionFilter{
+		PageFilter: models.PageFilter{
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+		MaxMissing: -1,
+	}
+	if filter.MaxMissing != nil {
+		model.MaxMissing = *filter.MaxMissing
+	}
+	return model
+}
+
+func RecipeSuggestionModelToFind(model *models.RecipeSuggestion) params.FindRecipeSuggestion {
+	missing := make([]params.FindMissingIngredient, len(model.Missing))
+	for i, ingredient := range model.Missing {
+		missing[i] = params.FindMissingIngredient{
+			Product:  ProductModelToFind(&ingredient.Product),
+			Measure:  MeasureModelToFind(&ingredient.Measure),
+			Quantity: ingredient.Quantity,
+		}
+	}
+	return params.FindRecipeSuggestion{
+		Recipe: params.FindRecipe{
+			ID:          model.Recipe.ID,
+			Name:        model.Recipe.Name,
+			Description: model.Recipe.Description,
+		},
+		Matched:   model.Matched,
+		Total:     model.Total,
+		ExpiresAt: model.ExpiresAt,
+		Missing:   missing,
+	}
+}
+
+func RecipeSuggestionModelsToFinds(models []models.RecipeSuggestion) []params.FindRecipeSuggestion {
+	dtos := make([]params.FindRecipeSuggestion, len(models))
+	for i, model := range models {
+		dtos[i] = RecipeSuggestionModelToFind(&model)
+	}
+	return dtos
+}
+
+func MealPlanFilterToModel(filter *params.MealPlanFilter) models.MealPlanFilter {
+	return models.MealPlanFilter{
+		PageFilter: models.PageFilter{
+			Limit:  filter.Limit,
+			Offset: filter.Offset,
+		},
+		From: parseDate(filter.From),
+		To:   parseDate(filter.To),
+	}
+}
+
+func MealPlanModelToFind(
+	model *models.MealPlan,
+	warnings []models.MealPlanWarning,
+) params.FindMealPlan {
+	dto := params.FindMealPlan{
+		ID: model.ID,
+		Recipe: params.FindRecipe{
+			ID:   model.Recipe.ID,
+			Name: model.Recipe.Name,
+		},
+		Date:     model.Date,
+		Servings: model.Servings,
+		CookedAt: model.CookedAt,
+	}
+	for _, reservation := range model.Reservations {
+		dto.Reservations = append(dto.Reservations, params.FindReservation{
+			ShelfLife: ShelfLifeModelToFind(&reservation.ShelfLife),
+			Quantity:  reservation.Quantity,
+		})
+	}
+	for _, warning := range warnings {
+		dto.Warnings = append(dto.Warnings, params.FindMealPlanWarning{
+			Kind:     warning.Kind,
+			Product:  ProductModelToFind(&warning.Product),
+			Measure:  MeasureModelToFind(&warning.Measure),
+			Quantity: warning.Quantity,
+			EndDate:  warning.EndDate,
+		})
+	}
+	return dto
+}
+
+func ImportStatsModelToFind(model *models.ImportStats) params.ImportStats {
+	return params.ImportStats{
+		Records:            model.Records,
+		Invalid:            model.Invalid,
+		Skipped:            model.Skipped,
+		InvalidBarcodes:    model.InvalidBarcodes,
+		Created:            model.Created,
+		MatchedBarcode:     model.MatchedBarcode,
+		MatchedName:        model.MatchedName,
+		Duplicates:         model.Duplicates,
+		BarcodesAdded:      model.BarcodesAdded,
+		CategoriesLinked:   model.CategoriesLinked,
+		UnmappedCategories: model.UnmappedCategories,
+	}
+}
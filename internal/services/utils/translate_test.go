@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RecipeSuggestionModelToFind(t *testing.T) {
+	expires := time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		model    models.RecipeSuggestion
+		expected string
+	}{
+		{
+			name: "missing ingredients",
+			model: models.RecipeSuggestion{
+				Recipe:    models.Recipe{ID: 1, Name: "Crepes", Description: "Thin pancakes"},
+				Matched:   1,
+				Total:     3,
+				ExpiresAt: &expires,
+				Urgency:   0.5,
+				Missing: []models.MissingIngredient{{
+					Product:  models.Product{ID: 2, Name: "Flour"},
+					Measure:  models.Measure{ID: 1, Name: "g"},
+					Quantity: 150,
+				}},
+			},
+			expected: `"missing":[{"product":{"id":2,"name":"Flour"`,
+		},
+		{
+			name: "none missing",
+			model: models.RecipeSuggestion{
+				Recipe:  models.Recipe{ID: 2, Name: "Omelette"},
+				Matched: 2,
+				Total:   2,
+				Missing: []models.MissingIngredient{},
+			},
+			expected: `"missing":[]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := RecipeSuggestionModelToFind(&tc.model)
+			assert.Equal(t, tc.model.Recipe.ID, result.Recipe.ID)
+			assert.Equal(t, tc.model.Recipe.Name, result.Recipe.Name)
+			assert.Equal(t, tc.model.Matched, result.Matched)
+			assert.Equal(t, tc.model.Total, result.Total)
+			assert.Equal(t, tc.model.ExpiresAt, result.ExpiresAt)
+			assert.Len(t, result.Missing, len(tc.model.Missing))
+			for i, ingredient := range tc.model.Missing {
+				assert.Equal(t, params.FindMissingIngredient{
+					Product:  ProductModelToFind(&ingredient.Product),
+					Measure:  MeasureModelToFind(&ingredient.Measure),
+					Quantity: ingredient.Quantity,
+				}, result.Missing[i])
+			}
+			data, err := json.Marshal(result)
+			assert.NoError(t, err)
+			assert.Contains(t, string(data), tc.expected)
+		})
+	}
+}
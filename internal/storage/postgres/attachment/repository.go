@@ -0,0 +1,105 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+)
+
+// ErrNotFound is returned when the shelf life of the user has no attachment.
+var ErrNotFound = errors.New("attachment not found")
+
+type AttachmentRepositorer interface {
+	// HasStorage reports whether the storage is the user's storage.
+	HasStorage(ctx context.Context, userID, storageID int) (bool, error)
+	// CreateShelfLife creates the shelf life of the user with the attachment
+	// in one transaction.
+	CreateShelfLife(
+		ctx context.Context,
+		userID int,
+		shelfLife *models.ShelfLife,
+		attachment *models.Attachment,
+	) error
+	// FindByShelfLife returns the attachment of the shelf life of the user.
+	FindByShelfLife(ctx context.Context, userID, shelfLifeID int) (models.Attachment, error)
+}
+
+type attachmentRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) AttachmentRepositorer {
+	return &attachmentRepository{client: client}
+}
+
+// HasStorage implements AttachmentRepositorer
+func (r *attachmentRepository) HasStorage(ctx context.Context, userID, storageID int) (bool, error) {
+	return shelflife.HasStorages(ctx, r.client, userID, storageID)
+}
+
+// CreateShelfLife implements AttachmentRepositorer
+func (r *attachmentRepository) CreateShelfLife(
+	ctx context.Context,
+	userID int,
+	shelfLife *models.ShelfLife,
+	attachment *models.Attachment,
+) error {
+	query := `
+		INSERT INTO shelf_life_attachments
+			(id_shelf_life, content_type, data)
+		VALUES
+			($1, $2, $3)
+		RETURNING id, created_at
+	`
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := shelflife.Insert(ctx, tx, userID, shelfLife); err != nil {
+		return err
+	}
+	attachment.ShelfLifeID = shelfLife.ID
+	if err := tx.QueryRow(ctx, query, attachment.ShelfLifeID, attachment.ContentType, attachment.Data).
+		Scan(&attachment.ID, &attachment.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FindByShelfLife implements AttachmentRepositorer
+func (r *attachmentRepository) FindByShelfLife(
+	ctx context.Context,
+	userID, shelfLifeID int,
+) (models.Attachment, error) {
+	var (
+		query = `
+			SELECT a.id, a.id_shelf_life, a.content_type, a.data, a.created_at
+			FROM shelf_life_attachments a
+			JOIN shelf_lives sl ON sl.id = a.id_shelf_life
+			WHERE sl.id_user = $1 AND sl.id = $2 AND sl.deleted_at IS NULL
+			ORDER BY a.id DESC
+			LIMIT 1
+		`
+		attachment models.Attachment
+	)
+	err := r.client.QueryRow(ctx, query, userID, shelfLifeID).Scan(
+		&attachment.ID, &attachment.ShelfLifeID, &attachment.ContentType,
+		&attachment.Data, &attachment.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Attachment{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Attachment{}, fmt.Errorf("failed to find attachment: %w", err)
+	}
+	return attachment, nil
+}
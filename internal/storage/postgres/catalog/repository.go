@@ -0,0 +1,506 @@
+// Package catalog imports the products of external catalogs. A chunk of the
+// products is copied into a temporary table and merged into the products, the
+// barcodes and the product categories at once.
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrImportNotFound is returned when the source was not imported before.
+var ErrImportNotFound = errors.New("import not found")
+
+type CatalogRepositorer interface {
+	FindImport(ctx context.Context, source string) (models.ProductImport, error)
+	// ImportChunk merges the products and saves the checkpoint with the stats
+	// of the chunk added in one transaction.
+	ImportChunk(
+		ctx context.Context,
+		checkpoint models.ProductImport,
+		products []models.CatalogProduct,
+	) (models.ProductImport, error)
+	// DryRun runs fn with the repository merging every chunk in a
+	// transaction which is rolled back.
+	DryRun(ctx context.Context, fn func(repo CatalogRepositorer) error) error
+}
+
+type catalogRepository struct {
+	client postgres.Client
+	// dryRun rolls back the chunks, the client is the session of the dry run
+	dryRun bool
+}
+
+func New(client postgres.Client) CatalogRepositorer {
+	return &catalogRepository{client: client}
+}
+
+// normalized is the SQL expression of the normalized name the products are
+// deduplicated by: trimmed, lower-cased, with single spaces and ё as е.
+func normalized(column string) string {
+	return fmt.Sprintf(
+		`lower(regexp_replace(translate(btrim(%s), 'ёЁ', 'еЕ'), '\s+', ' ', 'g'))`,
+		column,
+	)
+}
+
+var (
+	createStaging = `
+		CREATE TEMP TABLE IF NOT EXISTS catalog_staging (
+			position bigint NOT NULL,
+			barcode text,
+			name text NOT NULL,
+			nutrition jsonb,
+			categories text[],
+			id_product integer,
+			matched text
+		) ON COMMIT DROP
+	`
+	truncateStaging = `TRUNCATE catalog_staging`
+	matchBarcodes   = `
+		UPDATE catalog_staging s
+		SET id_product = pb.id_product,
+			matched = 'barcode'
+		FROM products_barcodes pb
+		JOIN products p ON p.id = pb.id_product
+		WHERE pb.barcode = s.barcode AND p.deleted_at IS NULL
+	`
+	matchNames = fmt.Sprintf(`
+		UPDATE catalog_staging s
+		SET id_product = p.id,
+			matched = $1
+		FROM products p
+		WHERE s.id_product IS NULL AND
+			p.deleted_at IS NULL AND
+			%s = %s
+	`, normalized("p.name"), normalized("s.name"))
+	createProducts = fmt.Sprintf(`
+		INSERT INTO products (name, nutrition)
+		SELECT DISTINCT ON (%[1]s) name, nutrition
+		FROM catalog_staging
+		WHERE id_product IS NULL
+		ORDER BY %[1]s, position
+	`, normalized("name"))
+	fillNutrition = `
+		UPDATE products p
+		SET nutrition = s.nutrition,
+			updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (id_product) id_product, nutrition
+			FROM catalog_staging
+			WHERE nutrition IS NOT NULL
+			ORDER BY id_product, position
+		) s
+		WHERE p.id = s.id_product AND p.nutrition IS NULL
+	`
+	addBarcodes = `
+		INSERT INTO products_barcodes (barcode, id_product)
+		SELECT DISTINCT ON (barcode) barcode, id_product
+		FROM catalog_staging
+		WHERE barcode IS NOT NULL
+		ORDER BY barcode, position
+		ON CONFLICT (barcode) DO NOTHING
+	`
+	linkCategories = fmt.Sprintf(`
+		INSERT INTO products_categories (id_product, id_category)
+		SELECT DISTINCT s.id_product, c.id
+		FROM catalog_staging s
+		CROSS JOIN LATERAL UNNEST(s.categories) AS t(name)
+		JOIN categories c ON c.deleted_at IS NULL AND %s = %s
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM products_categories pc
+			WHERE pc.id_product = s.id_product AND pc.id_category = c.id
+		)
+	`, normalized("c.name"), normalized("t.name"))
+	countStaging = fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE matched = 'barcode'),
+			COUNT(*) FILTER (WHERE matched = 'name'),
+			COUNT(*) FILTER (WHERE matched = 'created'),
+			(
+				SELECT COUNT(*)
+				FROM catalog_staging s
+				CROSS JOIN LATERAL UNNEST(s.categories) AS t(name)
+				WHERE NOT EXISTS (
+					SELECT 1
+					FROM categories c
+					WHERE c.deleted_at IS NULL AND %s = %s
+				)
+			)
+		FROM catalog_staging
+	`, normalized("c.name"), normalized("t.name"))
+	// the rows the rolled back chunks of a dry run would have added are kept
+	// in the tables of the session and restored for the later chunks matching
+	// them, the products are restored with their ids
+	createDryRun = `
+		CREATE TEMP TABLE IF NOT EXISTS catalog_dry_products (
+			key text PRIMARY KEY,
+			id integer NOT NULL,
+			name text NOT NULL
+		) ON COMMIT PRESERVE ROWS;
+		CREATE TEMP TABLE IF NOT EXISTS catalog_dry_barcodes (
+			barcode text PRIMARY KEY,
+			id_product integer NOT NULL
+		) ON COMMIT PRESERVE ROWS;
+		CREATE TEMP TABLE IF NOT EXISTS catalog_dry_categories (
+			id_product integer NOT NULL,
+			id_category integer NOT NULL,
+			PRIMARY KEY (id_product, id_category)
+		) ON COMMIT PRESERVE ROWS;
+		TRUNCATE catalog_dry_products, catalog_dry_barcodes, catalog_dry_categories
+	`
+	dropDryRun      = `DROP TABLE IF EXISTS catalog_dry_products, catalog_dry_barcodes, catalog_dry_categories`
+	restoreProducts = fmt.Sprintf(`
+		INSERT INTO products (id, name)
+		SELECT dp.id, dp.name
+		FROM catalog_dry_products dp
+		WHERE dp.key IN (SELECT %s FROM catalog_staging) OR
+			dp.id IN (
+				SELECT db.id_product
+				FROM catalog_dry_barcodes db
+				JOIN catalog_staging s ON s.barcode = db.barcode
+			)
+	`, normalized("name"))
+	restoreBarcodes = `
+		INSERT INTO products_barcodes (barcode, id_product)
+		SELECT db.barcode, db.id_product
+		FROM catalog_dry_barcodes db
+		WHERE db.barcode IN (SELECT barcode FROM catalog_staging)
+		ON CONFLICT (barcode) DO NOTHING
+	`
+	restoreCategories = `
+		INSERT INTO products_categories (id_product, id_category)
+		SELECT dc.id_product, dc.id_category
+		FROM catalog_dry_categories dc
+		WHERE dc.id_product IN (SELECT id_product FROM catalog_staging) AND
+			NOT EXISTS (
+				SELECT 1
+				FROM products_categories pc
+				WHERE pc.id_product = dc.id_product AND pc.id_category = dc.id_category
+			)
+	`
+	findDryProducts = fmt.Sprintf(`
+		SELECT DISTINCT ON (s.id_product) %s, s.id_product, p.name
+		FROM catalog_staging s
+		JOIN products p ON p.id = s.id_product
+		WHERE s.matched = 'created'
+		ORDER BY s.id_product
+	`, normalized("p.name"))
+	findDryBarcodes = `
+		SELECT pb.barcode, pb.id_product
+		FROM products_barcodes pb
+		WHERE pb.barcode IN (SELECT barcode FROM catalog_staging)
+	`
+	findDryCategories = `
+		SELECT pc.id_product, pc.id_category
+		FROM products_categories pc
+		WHERE pc.id_product IN (SELECT id_product FROM catalog_staging)
+	`
+	saveDryProducts = `
+		INSERT INTO catalog_dry_products (key, id, name)
+		SELECT * FROM UNNEST($1::text[], $2::integer[], $3::text[])
+		ON CONFLICT DO NOTHING
+	`
+	saveDryBarcodes = `
+		INSERT INTO catalog_dry_barcodes (barcode, id_product)
+		SELECT * FROM UNNEST($1::text[], $2::integer[])
+		ON CONFLICT DO NOTHING
+	`
+	saveDryCategories = `
+		INSERT INTO catalog_dry_categories (id_product, id_category)
+		SELECT * FROM UNNEST($1::integer[], $2::integer[])
+		ON CONFLICT DO NOTHING
+	`
+	saveCheckpoint = `
+		INSERT INTO products_imports (source, position, stats, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (source) DO UPDATE
+		SET position = EXCLUDED.position,
+			stats = EXCLUDED.stats,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+)
+
+// FindImport implements CatalogRepositorer
+func (r *catalogRepository) FindImport(
+	ctx context.Context,
+	source string,
+) (models.ProductImport, error) {
+	var (
+		query = `
+			SELECT source, position, stats, updated_at
+			FROM products_imports
+			WHERE source = $1
+		`
+		checkpoint models.ProductImport
+	)
+	err := r.client.QueryRow(ctx, query, source).Scan(
+		&checkpoint.Source, &checkpoint.Position, &checkpoint.Stats, &checkpoint.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ProductImport{}, fmt.Errorf("%w: %s", ErrImportNotFound, source)
+	}
+	if err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to find import: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// ImportChunk implements CatalogRepositorer
+func (r *catalogRepository) ImportChunk(
+	ctx context.Context,
+	checkpoint models.ProductImport,
+	products []models.CatalogProduct,
+) (models.ProductImport, error) {
+	if r.dryRun {
+		return r.dryRunChunk(ctx, checkpoint, products)
+	}
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	stats, err := merge(ctx, tx, products, false)
+	if err != nil {
+		return models.ProductImport{}, err
+	}
+	checkpoint.Stats = addStats(checkpoint.Stats, stats)
+	if err := tx.QueryRow(
+		ctx, saveCheckpoint, checkpoint.Source, checkpoint.Position, checkpoint.Stats,
+	).Scan(&checkpoint.UpdatedAt); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// DryRun implements CatalogRepositorer. No transaction is open for the
+// whole dump, every chunk is rolled back on its own. The rows the earlier
+// chunks would have added are kept in the temporary tables of the session,
+// so the stats are the same as of the import.
+func (r *catalogRepository) DryRun(
+	ctx context.Context,
+	fn func(repo CatalogRepositorer) error,
+) error {
+	session, release, err := acquire(ctx, r.client)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if _, err := session.Exec(ctx, createDryRun); err != nil {
+		return fmt.Errorf("failed to create dry run tables: %w", err)
+	}
+	// the connection of the pool is reused, the tables are dropped even if
+	// the dry run is canceled
+	defer session.Exec(context.Background(), dropDryRun)
+	return fn(&catalogRepository{client: session, dryRun: true})
+}
+
+// acquire returns a connection of the pool the temporary tables live on
+// between the transactions, any other client is a session already.
+func acquire(ctx context.Context, client postgres.Client) (postgres.Client, func(), error) {
+	pool, ok := client.(*pgxpool.Pool)
+	if !ok {
+		return client, func() {}, nil
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	return conn, conn.Release, nil
+}
+
+// dryRows are the rows a chunk of a dry run would have added.
+type dryRows struct {
+	keys, names      []string
+	ids              []int
+	barcodes         []string
+	barcodeProducts  []int
+	categoryProducts []int
+	categories       []int
+}
+
+// dryRunChunk merges the chunk in a transaction which is rolled back and
+// keeps the rows the chunk would have added for the later chunks.
+func (r *catalogRepository) dryRunChunk(
+	ctx context.Context,
+	checkpoint models.ProductImport,
+	products []models.CatalogProduct,
+) (models.ProductImport, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	stats, err := merge(ctx, tx, products, true)
+	if err != nil {
+		return models.ProductImport{}, err
+	}
+	rows, err := findDryRows(ctx, tx)
+	if err != nil {
+		return models.ProductImport{}, err
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	if _, err := r.client.Exec(ctx, saveDryProducts, rows.keys, rows.ids, rows.names); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to save dry run products: %w", err)
+	}
+	if _, err := r.client.Exec(ctx, saveDryBarcodes, rows.barcodes, rows.barcodeProducts); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to save dry run barcodes: %w", err)
+	}
+	if _, err := r.client.Exec(ctx, saveDryCategories, rows.categoryProducts, rows.categories); err != nil {
+		return models.ProductImport{}, fmt.Errorf("failed to save dry run categories: %w", err)
+	}
+	checkpoint.Stats = addStats(checkpoint.Stats, stats)
+	now := time.Now()
+	checkpoint.UpdatedAt = &now
+	return checkpoint, nil
+}
+
+// findDryRows finds the created products, and the barcodes and the category
+// links of the products of the chunk.
+func findDryRows(ctx context.Context, tx pgx.Tx) (dryRows, error) {
+	var rows dryRows
+	created, err := tx.Query(ctx, findDryProducts)
+	if err != nil {
+		return rows, fmt.Errorf("failed to find dry run products: %w", err)
+	}
+	var (
+		key, name string
+		id        int
+	)
+	if _, err := pgx.ForEachRow(created, []any{&key, &id, &name}, func() error {
+		rows.keys, rows.ids, rows.names = append(rows.keys, key), append(rows.ids, id), append(rows.names, name)
+		return nil
+	}); err != nil {
+		return rows, fmt.Errorf("failed to find dry run products: %w", err)
+	}
+	barcodes, err := tx.Query(ctx, findDryBarcodes)
+	if err != nil {
+		return rows, fmt.Errorf("failed to find dry run barcodes: %w", err)
+	}
+	var code string
+	if _, err := pgx.ForEachRow(barcodes, []any{&code, &id}, func() error {
+		rows.barcodes, rows.barcodeProducts = append(rows.barcodes, code), append(rows.barcodeProducts, id)
+		return nil
+	}); err != nil {
+		return rows, fmt.Errorf("failed to find dry run barcodes: %w", err)
+	}
+	links, err := tx.Query(ctx, findDryCategories)
+	if err != nil {
+		return rows, fmt.Errorf("failed to find dry run categories: %w", err)
+	}
+	var category int
+	if _, err := pgx.ForEachRow(links, []any{&id, &category}, func() error {
+		rows.categoryProducts, rows.categories = append(rows.categoryProducts, id), append(rows.categories, category)
+		return nil
+	}); err != nil {
+		return rows, fmt.Errorf("failed to find dry run categories: %w", err)
+	}
+	return rows, nil
+}
+
+// merge copies the products into the staging table and merges them. The
+// records are matched by barcode first, then by normalized name, the rest are
+// created once per normalized name. The dry run restores the rows of the
+// earlier chunks the products may match first.
+func merge(
+	ctx context.Context,
+	tx pgx.Tx,
+	products []models.CatalogProduct,
+	dryRun bool,
+) (models.ImportStats, error) {
+	var stats models.ImportStats
+	if _, err := tx.Exec(ctx, createStaging); err != nil {
+		return stats, fmt.Errorf("failed to create staging table: %w", err)
+	}
+	if _, err := tx.Exec(ctx, truncateStaging); err != nil {
+		return stats, fmt.Errorf("failed to truncate staging table: %w", err)
+	}
+	if _, err := tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"catalog_staging"},
+		[]string{"position", "barcode", "name", "nutrition", "categories"},
+		pgx.CopyFromSlice(len(products), func(i int) ([]any, error) {
+			p := products[i]
+			return []any{p.Position, p.Barcode, p.Name, p.Nutrition, p.Categories}, nil
+		}),
+	); err != nil {
+		return stats, fmt.Errorf("failed to copy products: %w", err)
+	}
+	if dryRun {
+		if _, err := tx.Exec(ctx, restoreProducts); err != nil {
+			return stats, fmt.Errorf("failed to restore dry run products: %w", err)
+		}
+		if _, err := tx.Exec(ctx, restoreBarcodes); err != nil {
+			return stats, fmt.Errorf("failed to restore dry run barcodes: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, matchBarcodes); err != nil {
+		return stats, fmt.Errorf("failed to match barcodes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, matchNames, "name"); err != nil {
+		return stats, fmt.Errorf("failed to match names: %w", err)
+	}
+	tag, err := tx.Exec(ctx, createProducts)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create products: %w", err)
+	}
+	stats.Created = tag.RowsAffected()
+	if _, err := tx.Exec(ctx, matchNames, "created"); err != nil {
+		return stats, fmt.Errorf("failed to match created products: %w", err)
+	}
+	if _, err := tx.Exec(ctx, fillNutrition); err != nil {
+		return stats, fmt.Errorf("failed to fill nutrition: %w", err)
+	}
+	if tag, err = tx.Exec(ctx, addBarcodes); err != nil {
+		return stats, fmt.Errorf("failed to add barcodes: %w", err)
+	}
+	stats.BarcodesAdded = tag.RowsAffected()
+	if dryRun {
+		if _, err := tx.Exec(ctx, restoreCategories); err != nil {
+			return stats, fmt.Errorf("failed to restore dry run categories: %w", err)
+		}
+	}
+	if tag, err = tx.Exec(ctx, linkCategories); err != nil {
+		return stats, fmt.Errorf("failed to link categories: %w", err)
+	}
+	stats.CategoriesLinked = tag.RowsAffected()
+	var created int64
+	if err := tx.QueryRow(ctx, countStaging).Scan(
+		&stats.MatchedBarcode, &stats.MatchedName, &created, &stats.UnmappedCategories,
+	); err != nil {
+		return stats, fmt.Errorf("failed to count staging table: %w", err)
+	}
+	stats.Duplicates = created - stats.Created
+	return stats, nil
+}
+
+// addStats returns the sum of the counters.
+func addStats(a, b models.ImportStats) models.ImportStats {
+	return models.ImportStats{
+		Records:            a.Records + b.Records,
+		Invalid:            a.Invalid + b.Invalid,
+		Skipped:            a.Skipped + b.Skipped,
+		InvalidBarcodes:    a.InvalidBarcodes + b.InvalidBarcodes,
+		Created:            a.Created + b.Created,
+		MatchedBarcode:     a.MatchedBarcode + b.MatchedBarcode,
+		MatchedName:        a.MatchedName + b.MatchedName,
+		Duplicates:         a.Duplicates + b.Duplicates,
+		BarcodesAdded:      a.BarcodesAdded + b.BarcodesAdded,
+		CategoriesLinked:   a.CategoriesLinked + b.CategoriesLinked,
+		UnmappedCategories: a.UnmappedCategories + b.UnmappedCategories,
+	}
+}
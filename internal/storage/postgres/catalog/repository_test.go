@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogTables are the tables of the import created as the temporary
+// tables, they shadow the tables of the database for the session.
+const catalogTables = `
+	CREATE TEMP TABLE products (
+		id serial PRIMARY KEY, name text NOT NULL, nutrition jsonb,
+		updated_at timestamp, deleted_at timestamp
+	);
+	CREATE TEMP TABLE products_barcodes (
+		barcode text PRIMARY KEY, id_product int NOT NULL
+	);
+	CREATE TEMP TABLE categories (
+		id int PRIMARY KEY, name text NOT NULL, deleted_at timestamp
+	);
+	CREATE TEMP TABLE products_categories (
+		id_product int NOT NULL, id_category int NOT NULL
+	);
+	INSERT INTO categories (id, name) VALUES (1, 'Dairy');
+`
+
+// Test_DryRun runs the dry run on the database of TEST_DATABASE_URL, it is
+// skipped if the variable is not set.
+func Test_DryRun(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, catalogTables)
+	require.NoError(t, err)
+	repo := New(conn)
+
+	barcode := "4600000000017"
+	chunks := [][]models.CatalogProduct{
+		{
+			{Position: 1, Barcode: &barcode, Name: "Milk", Categories: []string{"Dairy"}},
+		},
+		{
+			{Position: 2, Name: " MILK ", Categories: []string{"Dairy"}},
+			{Position: 3, Barcode: &barcode, Name: "Milk 3.2%"},
+			{Position: 4, Name: "Kefir"},
+		},
+	}
+	var checkpoint models.ProductImport
+	err = repo.DryRun(ctx, func(repo CatalogRepositorer) error {
+		for _, chunk := range chunks {
+			if checkpoint, err = repo.ImportChunk(ctx, checkpoint, chunk); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ImportStats{
+		Created:          2,
+		MatchedBarcode:   1,
+		MatchedName:      1,
+		BarcodesAdded:    1,
+		CategoriesLinked: 1,
+	}, checkpoint.Stats)
+	var products, barcodes, links int
+	require.NoError(t, conn.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM products),
+			(SELECT COUNT(*) FROM products_barcodes),
+			(SELECT COUNT(*) FROM products_categories)
+	`).Scan(&products, &barcodes, &links))
+	assert.Zero(t, products)
+	assert.Zero(t, barcodes)
+	assert.Zero(t, links)
+}
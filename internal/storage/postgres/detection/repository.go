@@ -0,0 +1,114 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrNotFound is returned when the detection of the user does not exist or
+// the shelf life saved for it is not of the user.
+var ErrNotFound = errors.New("detection not found")
+
+type DetectionRepositorer interface {
+	// Create stores the detection and sets its id and creation time.
+	Create(ctx context.Context, detection *models.Detection) error
+	// SaveDates sets the dates the user saved for the detection of the user,
+	// the shelf life must be of the user too.
+	SaveDates(ctx context.Context, detection *models.Detection) error
+	// EachLabelled calls fn for the detections with the saved dates by their
+	// ids after the id while the rows are read, fn errors stop the reading.
+	EachLabelled(ctx context.Context, after, limit int, fn func(models.Detection) error) error
+}
+
+type detectionRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) DetectionRepositorer {
+	return &detectionRepository{client: client}
+}
+
+// Create implements DetectionRepositorer
+func (r *detectionRepository) Create(ctx context.Context, detection *models.Detection) error {
+	query := `
+		INSERT INTO detections
+			(id_user, image_hash, image, content_type, raw_text, proposed)
+		VALUES
+			($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, created_at
+	`
+	if err := r.client.QueryRow(ctx, query,
+		detection.User.ID, detection.ImageHash, detection.Image, detection.ContentType,
+		detection.RawText, detection.Proposed,
+	).Scan(&detection.ID, &detection.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert detection: %w", err)
+	}
+	return nil
+}
+
+// SaveDates implements DetectionRepositorer
+func (r *detectionRepository) SaveDates(ctx context.Context, detection *models.Detection) error {
+	query := `
+		UPDATE detections
+		SET purchase_date = $3, end_date = $4, id_shelf_life = NULLIF($5, 0), saved_at = NOW()
+		WHERE id = $1 AND id_user = $2 AND ($5::int = 0 OR EXISTS (
+			SELECT 1 FROM shelf_lives sl
+			WHERE sl.id = $5 AND sl.id_user = $2 AND sl.deleted_at IS NULL
+		))
+		RETURNING saved_at
+	`
+	err := r.client.QueryRow(ctx, query,
+		detection.ID, detection.User.ID, detection.PurchaseDate, detection.EndDate,
+		detection.ShelfLifeID,
+	).Scan(&detection.SavedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save detection dates: %w", err)
+	}
+	return nil
+}
+
+// EachLabelled implements DetectionRepositorer
+func (r *detectionRepository) EachLabelled(
+	ctx context.Context,
+	after, limit int,
+	fn func(models.Detection) error,
+) error {
+	query := `
+		SELECT id, image_hash, image, COALESCE(content_type, ''), raw_text, proposed,
+			purchase_date, end_date, created_at, saved_at
+		FROM detections
+		WHERE saved_at IS NOT NULL AND id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+	rows, err := r.client.Query(ctx, query, after, limit)
+	if err != nil {
+		return fmt.Errorf("failed to query detections: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var detection models.Detection
+		if err := rows.Scan(
+			&detection.ID, &detection.ImageHash, &detection.Image, &detection.ContentType,
+			&detection.RawText, &detection.Proposed, &detection.PurchaseDate,
+			&detection.EndDate, &detection.CreatedAt, &detection.SavedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan detection: %w", err)
+		}
+		if err := fn(detection); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read detections: %w", err)
+	}
+	return nil
+}
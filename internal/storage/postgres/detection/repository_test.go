@@ -0,0 +1,73 @@
+package detection
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// detectionTables are the tables of the detections created as the temporary
+// tables, they shadow the tables of the database for the session.
+const detectionTables = `
+	CREATE TEMP TABLE detections (
+		id int PRIMARY KEY, id_user int, purchase_date date, end_date date,
+		id_shelf_life int, saved_at timestamp
+	);
+	CREATE TEMP TABLE shelf_lives (id int PRIMARY KEY, id_user int, deleted_at timestamp);
+	INSERT INTO detections (id, id_user) VALUES (1, 1), (2, 2);
+	INSERT INTO shelf_lives (id, id_user, deleted_at) VALUES (1, 1, NULL), (2, 2, NULL), (3, 1, NOW());
+`
+
+// Test_SaveDates saves the dates on the database of TEST_DATABASE_URL, it is
+// skipped if the variable is not set.
+func Test_SaveDates(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, detectionTables)
+	require.NoError(t, err)
+
+	end := time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		id        int
+		shelfLife int
+		found     bool
+	}{
+		{name: "without shelf life", id: 1, found: true},
+		{name: "own shelf life", id: 1, shelfLife: 1, found: true},
+		{name: "shelf life of another user", id: 1, shelfLife: 2},
+		{name: "deleted shelf life", id: 1, shelfLife: 3},
+		{name: "unknown shelf life", id: 1, shelfLife: 4},
+		{name: "detection of another user", id: 2},
+	}
+	repo := New(conn)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detection := models.Detection{
+				ID:          tc.id,
+				User:        models.User{ID: 1},
+				EndDate:     &end,
+				ShelfLifeID: tc.shelfLife,
+			}
+			err := repo.SaveDates(ctx, &detection)
+			if tc.found {
+				assert.NoError(t, err)
+				assert.NotNil(t, detection.SavedAt)
+				return
+			}
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package mealplan
+
+import (
+	"sort"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// Converter converts the quantity of the product from one measure to another.
+type Converter func(
+	quantity float64,
+	from, to models.Measure,
+	product models.Product,
+) (float64, error)
+
+// Allocate reserves the needed quantities from the stock. The quantity of a
+// stock shelf life is the quantity available for reservation. The shelf lives
+// which end on or after the date of the meal are used first, the soonest
+// ending first. The ones which end before the date are used last, the latest
+// ending first. A shelf life is used for a need of the same product if its
+// measure can be converted to the measure of the need, the reserved quantity
+// is in the measure of the shelf life. The stock is not modified.
+func Allocate(
+	needs []models.MealPlanNeed,
+	stock []models.ShelfLife,
+	date time.Time,
+	convert Converter,
+) []models.Reservation {
+	candidates := make([]models.ShelfLife, 0, len(stock))
+	for _, shelfLife := range stock {
+		if shelfLife.Quantity > 0 {
+			candidates = append(candidates, shelfLife)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		aFresh, bFresh := !endsBefore(a, date), !endsBefore(b, date)
+		if aFresh != bFresh {
+			return aFresh
+		}
+		if a.EndDate == nil || b.EndDate == nil {
+			return a.EndDate != nil
+		}
+		if aFresh {
+			return a.EndDate.Before(*b.EndDate)
+		}
+		return a.EndDate.After(*b.EndDate)
+	})
+	reservations := make([]models.Reservation, 0)
+	for _, need := range needs {
+		left := need.Quantity
+		for i := range candidates {
+			if left <= 0 {
+				break
+			}
+			candidate := &candidates[i]
+			if candidate.Product.ID != need.Product.ID || candidate.Quantity <= 0 {
+				continue
+			}
+			available, err := convert(
+				float64(candidate.Quantity), candidate.Measure, need.Measure, need.Product,
+			)
+			if err != nil {
+				continue
+			}
+			taken := min(left, float32(available))
+			quantity := candidate.Quantity
+			if taken < float32(available) {
+				reserved, err := convert(
+					float64(taken), need.Measure, candidate.Measure, need.Product,
+				)
+				if err != nil {
+					continue
+				}
+				quantity = float32(reserved)
+			}
+			candidate.Quantity -= quantity
+			left -= taken
+			shelfLife := *candidate
+			shelfLife.Quantity = 0
+			reservations = append(reservations, models.Reservation{
+				ShelfLife: shelfLife,
+				Quantity:  quantity,
+			})
+		}
+	}
+	return reservations
+}
+
+func endsBefore(shelfLife models.ShelfLife, date time.Time) bool {
+	return shelfLife.EndDate != nil && shelfLife.EndDate.Before(date)
+}
+
+func min(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
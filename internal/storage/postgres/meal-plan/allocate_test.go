@@ -0,0 +1,120 @@
+package mealplan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/services/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Allocate(t *testing.T) {
+	date := time.Date(2023, time.May, 20, 0, 0, 0, 0, time.UTC)
+	day := func(n int) *time.Time {
+		d := date.AddDate(0, 0, n)
+		return &d
+	}
+	milk := models.MealPlanNeed{
+		Product:  models.Product{ID: 1},
+		Measure:  models.Measure{ID: 1},
+		Quantity: 1.5,
+	}
+	shelfLife := func(id, product int, quantity float32, end *time.Time) models.ShelfLife {
+		return models.ShelfLife{
+			ID:       id,
+			Product:  models.Product{ID: product},
+			Measure:  models.Measure{ID: 1},
+			Quantity: quantity,
+			EndDate:  end,
+		}
+	}
+	testCases := []struct {
+		name     string
+		needs    []models.MealPlanNeed
+		stock    []models.ShelfLife
+		expected map[int]float32
+	}{
+		{
+			name:  "soonest ending first",
+			needs: []models.MealPlanNeed{milk},
+			stock: []models.ShelfLife{
+				shelfLife(1, 1, 1, day(5)),
+				shelfLife(2, 1, 1, day(1)),
+				shelfLife(3, 1, 1, day(3)),
+			},
+			expected: map[int]float32{2: 1, 3: 0.5},
+		},
+		{
+			name:  "ended before the meal last",
+			needs: []models.MealPlanNeed{milk},
+			stock: []models.ShelfLife{
+				shelfLife(1, 1, 1, day(-3)),
+				shelfLife(2, 1, 1, day(-1)),
+				shelfLife(3, 1, 1, day(0)),
+			},
+			expected: map[int]float32{3: 1, 2: 0.5},
+		},
+		{
+			name:  "other products and empty stock are skipped",
+			needs: []models.MealPlanNeed{milk},
+			stock: []models.ShelfLife{
+				shelfLife(1, 2, 5, day(1)),
+				shelfLife(2, 1, 0, day(1)),
+				shelfLife(3, 1, 1, day(2)),
+			},
+			expected: map[int]float32{3: 1},
+		},
+		{
+			name:  "shared stock is not reserved twice",
+			needs: []models.MealPlanNeed{milk, milk},
+			stock: []models.ShelfLife{
+				shelfLife(1, 1, 2, day(1)),
+				shelfLife(2, 1, 2, day(2)),
+			},
+			expected: map[int]float32{1: 2, 2: 1},
+		},
+		{
+			name: "converted to the measure of the shelf life",
+			needs: []models.MealPlanNeed{{
+				Product:  models.Product{ID: 1},
+				Measure:  models.Measure{ID: 2, Dimension: models.DimensionVolume, Factor: 1},
+				Quantity: 750,
+			}},
+			stock: []models.ShelfLife{
+				{
+					ID:       1,
+					Product:  models.Product{ID: 1},
+					Measure:  models.Measure{ID: 3, Dimension: models.DimensionVolume, Factor: 1000},
+					Quantity: 0.5,
+					EndDate:  day(1),
+				},
+				{
+					ID:       2,
+					Product:  models.Product{ID: 1},
+					Measure:  models.Measure{ID: 3, Dimension: models.DimensionVolume, Factor: 1000},
+					Quantity: 1,
+					EndDate:  day(2),
+				},
+				{
+					ID:       3,
+					Product:  models.Product{ID: 1},
+					Measure:  models.Measure{ID: 4, Dimension: models.DimensionCount, Factor: 1},
+					Quantity: 2,
+					EndDate:  day(0),
+				},
+			},
+			expected: map[int]float32{1: 0.5, 2: 0.25},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reservations := Allocate(tc.needs, tc.stock, date, measure.Convert)
+			actual := make(map[int]float32)
+			for _, reservation := range reservations {
+				actual[reservation.ShelfLife.ID] += reservation.Quantity
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package mealplan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrCooked is returned when a cooked meal plan is changed.
+var ErrCooked = errors.New("meal plan is already cooked")
+
+type MealPlanRepositorer interface {
+	FindByID(ctx context.Context, userID, id int) (models.MealPlan, error)
+	FindMany(ctx context.Context, filter models.MealPlanFilter) ([]models.MealPlan, error)
+	Count(ctx context.Context, filter models.MealPlanFilter) (int, error)
+	Create(ctx context.Context, plan *models.MealPlan, needs []models.MealPlanNeed) error
+	Update(ctx context.Context, plan *models.MealPlan, needs []models.MealPlanNeed) error
+	Delete(ctx context.Context, userID, id int) error
+	Cook(ctx context.Context, userID, id int) error
+}
+
+// findStock selects the user's shelf lives of the products with the
+// quantity not reserved by the other not cooked meal plans. The rows are
+// locked until the end of the transaction, so concurrent reservations
+// do not exceed the stock.
+const findStock = `
+	SELECT sl.id, sl.id_product, sl.id_measure, m.dimension, m.factor, sl.id_storage, sl.end_date,
+		sl.quantity - COALESCE((
+			SELECT SUM(r.quantity)
+			FROM meal_plans_reservations r
+			JOIN meal_plans mp ON mp.id = r.id_meal_plan
+			WHERE r.id_shelf_life = sl.id AND
+				mp.id <> $3 AND
+				mp.cooked_at IS NULL AND
+				mp.deleted_at IS NULL
+		), 0)
+	FROM shelf_lives sl
+	JOIN measures m ON m.id = sl.id_measure
+	WHERE sl.id_user = $1 AND
+		sl.id_product = ANY($2) AND
+		sl.deleted_at IS NULL
+	ORDER BY sl.id
+	FOR UPDATE OF sl
+`
+
+type mealPlanRepository struct {
+	client  postgres.Client
+	convert Converter
+}
+
+func New(client postgres.Client, convert Converter) MealPlanRepositorer {
+	return &mealPlanRepository{
+		client:  client,
+		convert: convert,
+	}
+}
+
+// FindByID implements MealPlanRepositorer
+func (r *mealPlanRepository) FindByID(
+	ctx context.Context,
+	userID, id int,
+) (models.MealPlan, error) {
+	var (
+		query = `
+			SELECT mp.id, mp.id_user, mp.id_recipe, r.name, mp.date, mp.servings,
+				mp.cooked_at, mp.created_at
+			FROM meal_plans mp
+			JOIN recipes r ON r.id = mp.id_recipe
+			WHERE mp.id = $1 AND mp.id_user = $2 AND mp.deleted_at IS NULL
+			LIMIT 1
+		`
+		reservationsQuery = `
+			SELECT sl.id, sl.id_product, p.name, sl.id_measure, m.name, m.dimension, m.factor,
+				sl.id_storage, s.name, sl.end_date, r.quantity
+			FROM meal_plans_reservations r
+			JOIN shelf_lives sl ON sl.id = r.id_shelf_life
+			JOIN products p ON p.id = sl.id_product
+			JOIN measures m ON m.id = sl.id_measure
+			JOIN storages s ON s.id = sl.id_storage
+			WHERE r.id_meal_plan = $1
+			ORDER BY sl.end_date, sl.id
+		`
+		plan models.MealPlan
+	)
+	if err := r.client.QueryRow(ctx, query, id, userID).Scan(
+		&plan.ID, &plan.User.ID, &plan.Recipe.ID, &plan.Recipe.Name, &plan.Date, &plan.Servings,
+		&plan.CookedAt, &plan.CreatedAt,
+	); err != nil {
+		return models.MealPlan{}, fmt.Errorf("failed to find meal plan: %w", err)
+	}
+	rows, err := r.client.Query(ctx, reservationsQuery, id)
+	if err != nil {
+		return models.MealPlan{}, fmt.Errorf("failed to find reservations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reservation models.Reservation
+		shelfLife := &reservation.ShelfLife
+		if err := rows.Scan(
+			&shelfLife.ID, &shelfLife.Product.ID, &shelfLife.Product.Name,
+			&shelfLife.Measure.ID, &shelfLife.Measure.Name,
+			&shelfLife.Measure.Dimension, &shelfLife.Measure.Factor,
+			&shelfLife.Storage.ID, &shelfLife.Storage.Name, &shelfLife.EndDate,
+			&reservation.Quantity,
+		); err != nil {
+			return models.MealPlan{}, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		plan.Reservations = append(plan.Reservations, reservation)
+	}
+	return plan, nil
+}
+
+// FindMany implements MealPlanRepositorer
+func (r *mealPlanRepository) FindMany(
+	ctx context.Context,
+	filter models.MealPlanFilter,
+) ([]models.MealPlan, error) {
+	query := `
+		SELECT mp.id, mp.id_user, mp.id_recipe, r.name, mp.date, mp.servings,
+			mp.cooked_at, mp.created_at
+		FROM meal_plans mp
+		JOIN recipes r ON r.id = mp.id_recipe
+		WHERE mp.id_user = $1 AND
+			mp.deleted_at IS NULL AND
+			($2::date IS NULL OR mp.date >= $2) AND
+			($3::date IS NULL OR mp.date <= $3)
+		ORDER BY mp.date, mp.id
+		LIMIT $4
+		OFFSET $5
+	`
+	plans := make([]models.MealPlan, 0, filter.Limit)
+	rows, err := r.client.Query(
+		ctx, query, filter.UserID, filter.From, filter.To, filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find meal plans: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var plan models.MealPlan
+		if err := rows.Scan(
+			&plan.ID, &plan.User.ID, &plan.Recipe.ID, &plan.Recipe.Name, &plan.Date,
+			&plan.Servings, &plan.CookedAt, &plan.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan meal plan: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Count implements MealPlanRepositorer
+func (r *mealPlanRepository) Count(
+	ctx context.Context,
+	filter models.MealPlanFilter,
+) (int, error) {
+	var (
+		query = `
+			SELECT COUNT(*)
+			FROM meal_plans mp
+			WHERE mp.id_user = $1 AND
+				mp.deleted_at IS NULL AND
+				($2::date IS NULL OR mp.date >= $2) AND
+				($3::date IS NULL OR mp.date <= $3)
+		`
+		count int
+	)
+	if err := r.client.QueryRow(ctx, query, filter.UserID, filter.From, filter.To).
+		Scan(&count); err != nil {
+		return 0, errors.ErrFailedToCountModels.With(err)
+	}
+	return count, nil
+}
+
+// Create implements MealPlanRepositorer
+func (r *mealPlanRepository) Create(
+	ctx context.Context,
+	plan *models.MealPlan,
+	needs []models.MealPlanNeed,
+) error {
+	query := `
+		INSERT INTO meal_plans (id_user, id_recipe, date, servings)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, query, plan.User.ID, plan.Recipe.ID, plan.Date, plan.Servings).
+		Scan(&plan.ID, &plan.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create meal plan: %w", err)
+	}
+	if err := r.reserve(ctx, tx, plan, needs); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// Update implements MealPlanRepositorer
+func (r *mealPlanRepository) Update(
+	ctx context.Context,
+	plan *models.MealPlan,
+	needs []models.MealPlanNeed,
+) error {
+	var (
+		query = `
+			UPDATE meal_plans
+			SET date = $1,
+				servings = $2,
+				updated_at = NOW()
+			WHERE id = $3 AND id_user = $4 AND cooked_at IS NULL AND deleted_at IS NULL
+		`
+		release = `
+			DELETE FROM meal_plans_reservations
+			WHERE id_meal_plan = $1
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, query, plan.Date, plan.Servings, plan.ID, plan.User.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update meal plan: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCooked
+	}
+	if _, err := tx.Exec(ctx, release, plan.ID); err != nil {
+		return fmt.Errorf("failed to release reservations: %w", err)
+	}
+	if err := r.reserve(ctx, tx, plan, needs); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// Delete implements MealPlanRepositorer
+func (r *mealPlanRepository) Delete(ctx context.Context, userID, id int) error {
+	query := `
+		UPDATE meal_plans
+		SET deleted_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND id_user = $2
+	`
+	if _, err := r.client.Exec(ctx, query, id, userID); err != nil {
+		return fmt.Errorf("failed to delete meal plan: %w", err)
+	}
+	return nil
+}
+
+// Cook implements MealPlanRepositorer
+func (r *mealPlanRepository) Cook(ctx context.Context, userID, id int) error {
+	var (
+		cook = `
+			UPDATE meal_plans
+			SET cooked_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $1 AND id_user = $2 AND cooked_at IS NULL AND deleted_at IS NULL
+		`
+		// the consumptions are recorded for the nutrition summary before the
+		// quantities are decreased
+		record = `
+			INSERT INTO shelf_lives_consumptions (id_shelf_life, quantity)
+			SELECT sl.id, LEAST(sl.quantity, r.quantity)
+			FROM (
+				SELECT id_shelf_life, SUM(quantity) AS quantity
+				FROM meal_plans_reservations
+				WHERE id_meal_plan = $1
+				GROUP BY id_shelf_life
+			) r
+			JOIN shelf_lives sl ON sl.id = r.id_shelf_life
+			WHERE LEAST(sl.quantity, r.quantity) > 0
+		`
+		// the fully consumed shelf lives are deleted
+		consume = `
+			UPDATE shelf_lives sl
+			SET quantity = GREATEST(sl.quantity - r.quantity, 0),
+				deleted_at = CASE
+					WHEN sl.quantity - r.quantity <= 0 THEN NOW()
+					ELSE sl.deleted_at
+				END,
+				updated_at = NOW()
+			FROM (
+				SELECT id_shelf_life, SUM(quantity) AS quantity
+				FROM meal_plans_reservations
+				WHERE id_meal_plan = $1
+				GROUP BY id_shelf_life
+			) r
+			WHERE sl.id = r.id_shelf_life
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	tag, err := tx.Exec(ctx, cook, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cook meal plan: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCooked
+	}
+	if _, err := tx.Exec(ctx, record, id); err != nil {
+		return fmt.Errorf("failed to record consumptions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, consume, id); err != nil {
+		return fmt.Errorf("failed to consume reservations: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// reserve allocates the needs from the locked stock of the user and inserts
+// the reservations of the plan.
+func (r *mealPlanRepository) reserve(
+	ctx context.Context,
+	tx pgx.Tx,
+	plan *models.MealPlan,
+	needs []models.MealPlanNeed,
+) error {
+	products := make([]int, len(needs))
+	for i, need := range needs {
+		products[i] = need.Product.ID
+	}
+	rows, err := tx.Query(ctx, findStock, plan.User.ID, products, plan.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find stock: %w", err)
+	}
+	stock := make([]models.ShelfLife, 0)
+	for rows.Next() {
+		var shelfLife models.ShelfLife
+		if err := rows.Scan(
+			&shelfLife.ID, &shelfLife.Product.ID, &shelfLife.Measure.ID,
+			&shelfLife.Measure.Dimension, &shelfLife.Measure.Factor, &shelfLife.Storage.ID,
+			&shelfLife.EndDate, &shelfLife.Quantity,
+		); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan stock: %w", err)
+		}
+		stock = append(stock, shelfLife)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to find stock: %w", err)
+	}
+	plan.Reservations = Allocate(needs, stock, plan.Date, r.convert)
+	if _, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"meal_plans_reservations"},
+		[]string{"id_meal_plan", "id_shelf_life", "quantity"},
+		pgx.CopyFromSlice(len(plan.Reservations), func(i int) ([]any, error) {
+			reservation := plan.Reservations[i]
+			return []any{plan.ID, reservation.ShelfLife.ID, reservation.Quantity}, nil
+		}),
+	); err != nil {
+		return errors.ErrFailedToCoopyModels.With(err)
+	}
+	return nil
+}
@@ -37,10 +37,10 @@ func (r *measureRepository) Count(ctx context.Context, filter models.MeasureFilt
 // Create implements MeasureRepositorer
 func (r *measureRepository) Create(ctx context.Context, measure models.Measure) error {
 	query := `
-			INSERT INTO measures (name)
-			VALUES ($1)
+			INSERT INTO measures (name, dimension, factor)
+			VALUES ($1, $2, $3)
 		`
-	if _, err := r.client.Exec(ctx, query, measure.Name); err != nil {
+	if _, err := r.client.Exec(ctx, query, measure.Name, measure.Dimension, measure.Factor); err != nil {
 		return fmt.Errorf("failed to create measure: %w", err)
 	}
 	return nil
@@ -62,14 +62,16 @@ func (r *measureRepository) Delete(ctx context.Context, id int) error {
 func (r *measureRepository) FindByID(ctx context.Context, id int) (models.Measure, error) {
 	var (
 		query = `
-			SELECT id, name
+			SELECT id, name, dimension, factor
 			FROM measures
 			WHERE id = $1
 			LIMIT 1	
 		`
 		measure models.Measure
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&measure.ID, &measure.Name); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&measure.ID, &measure.Name, &measure.Dimension, &measure.Factor,
+	); err != nil {
 		return models.Measure{}, fmt.Errorf("failed to find measure: %w", err)
 	}
 	return measure, nil
@@ -82,7 +84,7 @@ func (r *measureRepository) FindMany(
 ) ([]models.Measure, error) {
 	var (
 		query = `
-			SELECT id, name
+			SELECT id, name, dimension, factor
 			FROM measures
 			WHERE name ILIKE $1
 			LIMIT $2
@@ -97,7 +99,9 @@ func (r *measureRepository) FindMany(
 	defer rows.Close()
 	for rows.Next() {
 		var measure models.Measure
-		if err := rows.Scan(&measure.ID, &measure.Name); err != nil {
+		if err := rows.Scan(
+			&measure.ID, &measure.Name, &measure.Dimension, &measure.Factor,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan measure: %w", err)
 		}
 		measures = append(measures, measure)
@@ -109,10 +113,14 @@ func (r *measureRepository) FindMany(
 func (r *measureRepository) Update(ctx context.Context, measure models.Measure) error {
 	query := `
 			UPDATE measures
-			SET name = $1
-			WHERE id = $2
+			SET name = $1,
+				dimension = $2,
+				factor = $3
+			WHERE id = $4
 		`
-	if _, err := r.client.Exec(ctx, query, measure.Name, measure.ID); err != nil {
+	if _, err := r.client.Exec(
+		ctx, query, measure.Name, measure.Dimension, measure.Factor, measure.ID,
+	); err != nil {
 		return fmt.Errorf("failed to update measure: %w", err)
 	}
 	return nil
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Attachment is a file attached to a shelf life, such as the photo of the
+// label its dates are detected on.
+type Attachment struct {
+	ID          int       `db:"id"`
+	ShelfLifeID int       `db:"id_shelf_life"`
+	ContentType string    `db:"content_type"`
+	Data        []byte    `db:"data"`
+	CreatedAt   time.Time `db:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// CatalogProduct is a product of an external catalog to import.
+type CatalogProduct struct {
+	// Position is the number of the record in the source
+	Position int64
+	// Barcode is the EAN-13 code, nil if the record has no valid one
+	Barcode    *string
+	Name       string
+	Nutrition  *Nutrition
+	Categories []string
+}
+
+// ProductImport is the checkpoint of the import of a catalog source. The
+// records before the position are imported.
+type ProductImport struct {
+	Source    string      `db:"source"`
+	Position  int64       `db:"position"`
+	Stats     ImportStats `db:"stats"`
+	UpdatedAt *time.Time  `db:"updated_at"`
+}
+
+// ImportStats are the counters of an import. It is stored as JSON.
+type ImportStats struct {
+	// Records is the number of the records read
+	Records int64 `json:"records"`
+	// Invalid is the number of the records which can not be parsed
+	Invalid int64 `json:"invalid"`
+	// Skipped is the number of the records without a name
+	Skipped int64 `json:"skipped"`
+	// InvalidBarcodes is the number of the records with an invalid barcode
+	InvalidBarcodes int64 `json:"invalid_barcodes"`
+	// Created is the number of the products created
+	Created int64 `json:"created"`
+	// MatchedBarcode is the number of the records matched by barcode
+	MatchedBarcode int64 `json:"matched_barcode"`
+	// MatchedName is the number of the records matched by normalized name
+	MatchedName int64 `json:"matched_name"`
+	// Duplicates is the number of the records of the products created from
+	// the previous records of the same chunk
+	Duplicates int64 `json:"duplicates"`
+	// BarcodesAdded is the number of the barcodes added
+	BarcodesAdded int64 `json:"barcodes_added"`
+	// CategoriesLinked is the number of the product categories added
+	CategoriesLinked int64 `json:"categories_linked"`
+	// UnmappedCategories is the number of the categories of the records
+	// without a matching category
+	UnmappedCategories int64 `json:"unmapped_categories"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Detection is a detection of the dates on a photo stored with the dates
+// the user finally saved, the labelled detections make the dataset the
+// detector is evaluated on. The image is kept only with the consent of the
+// user, the proposed dates are JSON.
+type Detection struct {
+	ID           int        `db:"id"`
+	User         User       `db:"user"`
+	ImageHash    string     `db:"image_hash"`
+	Image        []byte     `db:"image"`
+	ContentType  string     `db:"content_type"`
+	RawText      []string   `db:"raw_text"`
+	Proposed     []byte     `db:"proposed"`
+	PurchaseDate *time.Time `db:"purchase_date"`
+	EndDate      *time.Time `db:"end_date"`
+	ShelfLifeID  int        `db:"id_shelf_life"`
+	CreatedAt    time.Time  `db:"created_at"`
+	SavedAt      *time.Time `db:"saved_at"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type PageFilter struct {
 	Limit  int
 	Offset int
@@ -15,9 +17,24 @@ type MeasureFilter struct {
 	Name string
 }
 
+// The orders of the recipes.
+const (
+	RecipeSortID     = "id"
+	RecipeSortRating = "rating"
+)
+
 type RecipeFilter struct {
 	PageFilter
 	Name string
+	// UserID is the user viewing the recipes, zero for an anonymous user.
+	// The published recipes and the recipes of the user are visible.
+	UserID     int
+	Mine       bool
+	Favourites bool
+	MinRating  float64
+	Sort       string
+	// Pending selects the recipes waiting for moderation of all the users
+	Pending bool
 }
 
 type StepFilter struct {
@@ -37,6 +54,17 @@ type ShelfLifeStatusFilter struct {
 
 type ShelfLifeFilter struct {
 	PageFilter
+	UserID        int
+	StorageID     int
+	ProductID     int
+	CategoryID    int
+	StatusID      int
+	EndBefore     *time.Time
+	EndAfter      *time.Time
+	PurchasedFrom *time.Time
+	PurchasedTo   *time.Time
+	ExpiresWithin int
+	Sort          string
 }
 
 type StorageTypeFilter struct {
@@ -54,6 +82,12 @@ type TipFilter struct {
 	Description string
 }
 
+type ShoppingListFilter struct {
+	PageFilter
+	UserID int
+	Name   string
+}
+
 type UserFilter struct {
 	PageFilter
 	Name string
@@ -67,3 +101,23 @@ type ProductFilter struct {
 	PageFilter
 	Name string
 }
+
+type RecipeSuggestionFilter struct {
+	PageFilter
+	// MaxMissing limits the number of missing ingredients, -1 means no limit
+	MaxMissing int
+}
+
+type MealPlanFilter struct {
+	PageFilter
+	UserID int
+	From   *time.Time
+	To     *time.Time
+}
+
+// ConsumptionFilter selects the consumptions from one date to another,
+// inclusive.
+type ConsumptionFilter struct {
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type MealPlan struct {
+	ID           int `db:"id"`
+	User         User
+	Recipe       Recipe
+	Date         time.Time  `db:"date"`
+	Servings     int        `db:"servings"`
+	CookedAt     *time.Time `db:"cooked_at"`
+	CreatedAt    *time.Time `db:"created_at"`
+	Reservations []Reservation
+}
+
+// Reservation is the quantity of a shelf life held for a planned meal.
+type Reservation struct {
+	ShelfLife ShelfLife
+	Quantity  float32 `db:"quantity"`
+}
+
+// MealPlanNeed is the quantity of a product required by a planned meal.
+type MealPlanNeed struct {
+	Product  Product
+	Measure  Measure
+	Quantity float32
+}
+
+const (
+	// MealPlanWarningExpired means a reserved shelf life ends before the meal
+	MealPlanWarningExpired = "expired"
+	// MealPlanWarningShortage means there is not enough stock for the meal
+	MealPlanWarningShortage = "shortage"
+)
+
+type MealPlanWarning struct {
+	Kind     string
+	Product  Product
+	Measure  Measure
+	Quantity float32
+	EndDate  *time.Time
+}
@@ -1,6 +1,16 @@
 package models
 
+// The dimensions of the measures. The factor of a measure converts its
+// quantity to the base unit of the dimension: grams, milliliters or pieces.
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
 type Measure struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID        int     `db:"id"`
+	Name      string  `db:"name"`
+	Dimension string  `db:"dimension"`
+	Factor    float64 `db:"factor"`
 }
@@ -3,10 +3,29 @@ package models
 import "time"
 
 type Product struct {
-	ID        int        `db:"id"`
-	Name      string     `db:"name"`
-	UpdatedAt *time.Time `db:"updated_at"`
-	DeletedAt *time.Time `db:"deleted_at"`
+	ID          int        `db:"id"`
+	Name        string     `db:"name"`
+	Density     *float64   `db:"density"`
+	PieceWeight *float64   `db:"piece_weight"`
+	Nutrition   *Nutrition `db:"nutrition"`
+	UpdatedAt   *time.Time `db:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	// Barcodes are the EAN-13 codes of the packages of the product
+	Barcodes []string `db:"barcodes"`
+}
+
+// Nutrition is the nutrition facts per 100 g of a product or the totals of
+// the products. It is stored as JSON.
+type Nutrition struct {
+	// Energy is in kilocalories
+	Energy float64 `json:"energy"`
+	// Protein, Fat and Carbohydrates are in grams
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	// Micronutrients are the optional nutrients by name, the unit is a part
+	// of the name, such as "fiber_g" or "sodium_mg"
+	Micronutrients map[string]float64 `json:"micronutrients,omitempty"`
 }
 
 type ProductCategory struct {
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Receipt is a fiscal receipt added by a user with the shelf lives of its
+// items. A receipt is identified by its fiscal drive, fiscal document and
+// fiscal sign, and is added once. The sum is in kopecks.
+type Receipt struct {
+	ID             int `db:"id"`
+	User           User
+	FiscalDrive    string     `db:"fiscal_drive"`
+	FiscalDocument string     `db:"fiscal_document"`
+	FiscalSign     string     `db:"fiscal_sign"`
+	Sum            int64      `db:"sum"`
+	PurchaseDate   *time.Time `db:"purchase_date"`
+	ShelfLives     []ShelfLife
+	CreatedAt      *time.Time `db:"created_at"`
+}
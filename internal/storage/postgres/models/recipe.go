@@ -2,26 +2,82 @@ package models
 
 import "time"
 
+// The statuses of a recipe. A recipe of a user is private until it is
+// published for moderation and approved by a moderator.
+const (
+	RecipePrivate   = "private"
+	RecipePending   = "pending"
+	RecipePublished = "published"
+	RecipeRejected  = "rejected"
+)
+
 type Recipe struct {
 	ID          int    `db:"id"`
 	Name        string `db:"name"`
 	Description string `db:"description"`
+	Servings    int    `db:"servings"`
+	Status      string `db:"status"`
 	User        User
 	UpdatedAt   *time.Time `db:"updated_at"`
 	DeletedAt   *time.Time `db:"deleted_at"`
 	Ingredients []RecipeIngredient
 	Steps       []Step
+	// ModerationNote is the reason given by the moderator
+	ModerationNote string `db:"moderation_note"`
+	// Rating is the average rating, zero if the recipe is not rated
+	Rating float64 `db:"rating"`
+	// Ratings is the number of ratings
+	Ratings int `db:"ratings"`
 }
 
 type Step struct {
 	ID    int    `db:"id,id_step"`
 	Name  string `db:"name"`
 	Place int    `db:"place"`
+	// Duration is the time the step takes in seconds
+	Duration *int `db:"duration"`
+	// Timer is the countdown in seconds started at the step
+	Timer *int `db:"timer"`
+	// Temperature is the cooking temperature in degrees Celsius
+	Temperature *int `db:"temperature"`
+	// Ingredients are the IDs of the products of the recipe used at the step
+	Ingredients []int `db:"ingredients"`
 }
 
 type RecipeIngredient struct {
 	Recipe   Recipe
 	Product  Product
 	Measure  Measure
-	Quantity int `db:"quantity"`
+	Quantity float64 `db:"quantity"`
+}
+
+// RecipeSuggestion is a recipe ranked by the ingredients in the user's stock.
+type RecipeSuggestion struct {
+	Recipe Recipe
+	// Matched is the number of ingredients the user has enough of
+	Matched int `db:"matched"`
+	// Total is the number of ingredients of the recipe
+	Total int `db:"total"`
+	// ExpiresAt is the nearest end date of the matched ingredients
+	ExpiresAt *time.Time `db:"expires_at"`
+	// Urgency grows with the number of matched ingredients expiring soon
+	Urgency float64 `db:"urgency"`
+	Missing []MissingIngredient
+}
+
+// MissingIngredient is the quantity of the ingredient the user lacks.
+type MissingIngredient struct {
+	Product  Product
+	Measure  Measure
+	Quantity float32 `db:"quantity"`
+}
+
+// RecipeRating is the rating and the review of a recipe by a user.
+type RecipeRating struct {
+	Recipe    Recipe
+	User      User
+	Rating    int        `db:"rating"`
+	Review    string     `db:"review"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
 }
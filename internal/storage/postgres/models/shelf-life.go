@@ -11,6 +11,7 @@ type ShelfLife struct {
 	Quantity     float32    `db:"quantity"`
 	PurchaseDate *time.Time `db:"purchase_date"`
 	EndDate      *time.Time `db:"end_date"`
+	Restock      bool       `db:"restock"`
 	CreatedAt    *time.Time `db:"created_at"`
 }
 
@@ -18,3 +19,12 @@ type ShelfLifeStatus struct {
 	ID   int    `db:"id"`
 	Name string `db:"name"`
 }
+
+// Consumption is the quantity of a shelf life consumed by the user, in the
+// measure of the shelf life.
+type Consumption struct {
+	ID         int `db:"id"`
+	ShelfLife  ShelfLife
+	Quantity   float64   `db:"quantity"`
+	ConsumedAt time.Time `db:"consumed_at"`
+}
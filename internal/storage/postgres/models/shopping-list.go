@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type ShoppingList struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+	User User
+	// Storage is the household the list is shared with, ID is 0 for the
+	// lists of a single user
+	Storage   Vault
+	Items     []ShoppingListItem
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+type ShoppingListItem struct {
+	ID       int `db:"id"`
+	Product  Product
+	Measure  Measure
+	Quantity float32 `db:"quantity"`
+	Checked  bool    `db:"checked"`
+	Note     string  `db:"note"`
+}
@@ -0,0 +1,95 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+var (
+	// ErrUnknownBarcode is returned when no product has the barcode.
+	ErrUnknownBarcode = errors.New("unknown barcode")
+	// ErrBarcodeTaken is returned when the barcode belongs to another product.
+	ErrBarcodeTaken = errors.New("barcode is taken")
+)
+
+// uniqueViolation is the SQLSTATE of the unique constraint violation.
+const uniqueViolation = "23505"
+
+type ProductBarcodesRepositorer interface {
+	FindByBarcode(ctx context.Context, barcode string) (models.Product, error)
+	AddBarcode(ctx context.Context, productID int, barcode string) error
+	RemoveBarcode(ctx context.Context, productID int, barcode string) error
+}
+
+// FindByBarcode implements ProductRepositorer
+func (r *productRepository) FindByBarcode(
+	ctx context.Context,
+	barcode string,
+) (models.Product, error) {
+	var (
+		query = `
+			SELECT p.id
+			FROM products p
+			JOIN products_barcodes pb ON pb.id_product = p.id
+			WHERE pb.barcode = $1 AND p.deleted_at IS NULL
+		`
+		id int
+	)
+	err := r.client.QueryRow(ctx, query, barcode).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Product{}, fmt.Errorf("%w: %s", ErrUnknownBarcode, barcode)
+	}
+	if err != nil {
+		return models.Product{}, fmt.Errorf("failed to find product by barcode: %w", err)
+	}
+	return r.FindByID(ctx, id)
+}
+
+// AddBarcode implements ProductRepositorer
+func (r *productRepository) AddBarcode(ctx context.Context, productID int, barcode string) error {
+	query := `
+		INSERT INTO products_barcodes (barcode, id_product)
+		VALUES ($1, $2)
+		ON CONFLICT (barcode) DO UPDATE
+		SET id_product = EXCLUDED.id_product
+		WHERE products_barcodes.id_product = EXCLUDED.id_product
+	`
+	tag, err := r.client.Exec(ctx, query, barcode, productID)
+	if err != nil {
+		return fmt.Errorf("failed to add product barcode: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrBarcodeTaken, barcode)
+	}
+	return nil
+}
+
+// RemoveBarcode implements ProductRepositorer
+func (r *productRepository) RemoveBarcode(
+	ctx context.Context,
+	productID int,
+	barcode string,
+) error {
+	query := `
+		DELETE FROM products_barcodes
+		WHERE id_product = $1 AND barcode = $2
+	`
+	if _, err := r.client.Exec(ctx, query, productID, barcode); err != nil {
+		return fmt.Errorf("failed to remove product barcode: %w", err)
+	}
+	return nil
+}
+
+// barcodeError marks the unique violation of a barcode as ErrBarcodeTaken.
+func barcodeError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %s", ErrBarcodeTaken, pgErr.Detail)
+	}
+	return err
+}
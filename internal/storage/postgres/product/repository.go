@@ -16,13 +16,16 @@ type ProductRepositorer interface {
 	Delete(ctx context.Context, id int) error
 	Restore(ctx context.Context, id int) error
 	FindCategories(ctx context.Context, id int) ([]models.ProductCategory, error)
-	FindRecipes(ctx context.Context, id int) ([]models.Recipe, error)
+	// FindRecipes returns the recipes of the product visible to the user, the
+	// published recipes and the recipes of the user.
+	FindRecipes(ctx context.Context, id, userID int) ([]models.Recipe, error)
 	CreateCategory(ctx context.Context, productID, categoryID int) (models.ProductCategory, error)
 	DeleteCategory(ctx context.Context, productID, categoryID int) error
 	FindTips(ctx context.Context, id int) ([]models.Tip, error)
 	CreateTip(ctx context.Context, productID, tipID int) (models.Tip, error)
 	DeleteTip(ctx context.Context, productID, tipID int) error
 	Count(ctx context.Context, filter models.ProductFilter) (int, error)
+	ProductBarcodesRepositorer
 }
 
 type productRepository struct {
@@ -184,17 +187,19 @@ func (r *productRepository) FindCategories(
 }
 
 // FindRecipes implements ProductRepositorer
-func (r *productRepository) FindRecipes(ctx context.Context, id int) ([]models.Recipe, error) {
+func (r *productRepository) FindRecipes(ctx context.Context, id, userID int) ([]models.Recipe, error) {
 	var (
 		query = `
 			SELECT r.id, r.name
 			FROM recipes r
 			JOIN products_recipes_measures prm ON prm.id_recipe = r.id
-			WHERE prm.id_product = $1 AND r.deleted_at IS NULL
+			WHERE prm.id_product = $1 AND
+				r.deleted_at IS NULL AND
+				(r.status = 'published' OR r.id_user = $2)
 		`
 		recipes []models.Recipe
 	)
-	rows, err := r.client.Query(ctx, query, id)
+	rows, err := r.client.Query(ctx, query, id, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find categories: %w", err)
 	}
@@ -212,14 +217,23 @@ func (r *productRepository) FindRecipes(ctx context.Context, id int) ([]models.R
 func (repo *productRepository) FindByID(ctx context.Context, id int) (models.Product, error) {
 	var (
 		query = `
-			SELECT id, name
-			FROM products
+			SELECT id, name, density, piece_weight, nutrition,
+				ARRAY(
+					SELECT barcode
+					FROM products_barcodes
+					WHERE id_product = p.id
+					ORDER BY barcode
+				)
+			FROM products p
 			WHERE id = $1
 			LIMIT 1
 		`
 		product models.Product
 	)
-	if err := repo.client.QueryRow(ctx, query, id).Scan(&product.ID, &product.Name); err != nil {
+	if err := repo.client.QueryRow(ctx, query, id).Scan(
+		&product.ID, &product.Name, &product.Density, &product.PieceWeight, &product.Nutrition,
+		&product.Barcodes,
+	); err != nil {
 		return models.Product{}, fmt.Errorf("failed to find product: %w", err)
 	}
 	return product, nil
@@ -231,7 +245,7 @@ func (repo *productRepository) FindMany(
 ) ([]models.Product, error) {
 	var (
 		query = `
-			SELECT id, name
+			SELECT id, name, density, piece_weight, nutrition
 			FROM products
 			WHERE name ILIKE $3 AND 
 				deleted_at IS NULL
@@ -248,7 +262,9 @@ func (repo *productRepository) FindMany(
 	defer rows.Close()
 	for rows.Next() {
 		var product models.Product
-		if err := rows.Scan(&product.ID, &product.Name); err != nil {
+		if err := rows.Scan(
+			&product.ID, &product.Name, &product.Density, &product.PieceWeight, &product.Nutrition,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 		products = append(products, product)
@@ -258,11 +274,23 @@ func (repo *productRepository) FindMany(
 
 func (repo *productRepository) Create(ctx context.Context, product models.Product) error {
 	query := `
-			INSERT INTO products (name)
-			VALUES ($1)
+			WITH inserted AS (
+				INSERT INTO products (name, density, piece_weight, nutrition)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id
+			)
+			INSERT INTO products_barcodes (barcode, id_product)
+			SELECT UNNEST($5::text[]), id
+			FROM inserted
 		`
-	if _, err := repo.client.Exec(ctx, query, product.Name); err != nil {
-		return fmt.Errorf("failed to create product: %w", err)
+	if product.Barcodes == nil {
+		product.Barcodes = []string{}
+	}
+	if _, err := repo.client.Exec(
+		ctx, query, product.Name, product.Density, product.PieceWeight, product.Nutrition,
+		product.Barcodes,
+	); err != nil {
+		return barcodeError(fmt.Errorf("failed to create product: %w", err))
 	}
 	return nil
 }
@@ -271,10 +299,15 @@ func (repo *productRepository) Update(ctx context.Context, product models.Produc
 	query := `
 			UPDATE products
 			SET name = $1,
+				density = $2,
+				piece_weight = $3,
+				nutrition = $4,
 				updated_at = NOW()
-			WHERE id = $2
+			WHERE id = $5
 		`
-	if _, err := repo.client.Exec(ctx, query, product.Name, product.ID); err != nil {
+	if _, err := repo.client.Exec(
+		ctx, query, product.Name, product.Density, product.PieceWeight, product.Nutrition, product.ID,
+	); err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 	return nil
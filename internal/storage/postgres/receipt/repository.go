@@ -0,0 +1,150 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+)
+
+// ErrReceiptAdded is returned when the receipt is already added.
+var ErrReceiptAdded = errors.New("receipt is already added")
+
+type ReceiptRepositorer interface {
+	// HasStorages reports whether all the storages are the user's storages.
+	HasStorages(ctx context.Context, userID int, storages []int) (bool, error)
+	// Create adds the receipt and creates its shelf lives in one transaction.
+	Create(ctx context.Context, receipt *models.Receipt) error
+	// CreateShelfLives creates the shelf lives of the user in one transaction.
+	CreateShelfLives(ctx context.Context, userID int, shelfLives []models.ShelfLife) error
+	// FindShelfLifeDays returns the median number of days between the
+	// purchase date and the end date of the shelf lives of the products.
+	FindShelfLifeDays(ctx context.Context, products []int) (map[int]int, error)
+}
+
+type receiptRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) ReceiptRepositorer {
+	return &receiptRepository{client: client}
+}
+
+// HasStorages implements ReceiptRepositorer
+func (r *receiptRepository) HasStorages(
+	ctx context.Context,
+	userID int,
+	storages []int,
+) (bool, error) {
+	return shelflife.HasStorages(ctx, r.client, userID, storages...)
+}
+
+// Create implements ReceiptRepositorer
+func (r *receiptRepository) Create(ctx context.Context, receipt *models.Receipt) error {
+	query := `
+		INSERT INTO receipts
+			(id_user, fiscal_drive, fiscal_document, fiscal_sign, sum, purchase_date)
+		VALUES
+			($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (fiscal_drive, fiscal_document, fiscal_sign) DO NOTHING
+		RETURNING id, created_at
+	`
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	err = tx.QueryRow(ctx, query,
+		receipt.User.ID, receipt.FiscalDrive, receipt.FiscalDocument, receipt.FiscalSign,
+		receipt.Sum, receipt.PurchaseDate,
+	).Scan(&receipt.ID, &receipt.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %s-%s-%s", ErrReceiptAdded,
+			receipt.FiscalDrive, receipt.FiscalDocument, receipt.FiscalSign)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert receipt: %w", err)
+	}
+	if err := insertShelfLives(ctx, tx, receipt.User.ID, receipt.ShelfLives); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// CreateShelfLives implements ReceiptRepositorer
+func (r *receiptRepository) CreateShelfLives(
+	ctx context.Context,
+	userID int,
+	shelfLives []models.ShelfLife,
+) error {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := insertShelfLives(ctx, tx, userID, shelfLives); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FindShelfLifeDays implements ReceiptRepositorer
+func (r *receiptRepository) FindShelfLifeDays(
+	ctx context.Context,
+	products []int,
+) (map[int]int, error) {
+	query := `
+		SELECT
+			id_product,
+			ROUND(percentile_cont(0.5) WITHIN GROUP (
+				ORDER BY end_date::date - purchase_date::date
+			))::integer
+		FROM shelf_lives
+		WHERE id_product = ANY($1) AND
+			purchase_date IS NOT NULL AND
+			end_date::date > purchase_date::date
+		GROUP BY id_product
+	`
+	rows, err := r.client.Query(ctx, query, products)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shelf life days: %w", err)
+	}
+	defer rows.Close()
+	result := make(map[int]int)
+	for rows.Next() {
+		var product, days int
+		if err := rows.Scan(&product, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan shelf life days: %w", err)
+		}
+		result[product] = days
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shelf life days: %w", err)
+	}
+	return result, nil
+}
+
+// insertShelfLives inserts the shelf lives and sets their ids and names.
+func insertShelfLives(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+	shelfLives []models.ShelfLife,
+) error {
+	for i := range shelfLives {
+		if err := shelflife.Insert(ctx, tx, userID, &shelfLives[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
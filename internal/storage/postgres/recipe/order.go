@@ -0,0 +1,38 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrInvalidOrder is returned when the order is not a permutation of the
+// steps of the recipe.
+var ErrInvalidOrder = errors.New("invalid order of steps")
+
+// Reorder returns the current places of the steps in the given order of the
+// step IDs. The order must list every step of the recipe once, a step
+// repeated in the recipe is listed as many times as it is repeated and its
+// occurrences keep their relative order.
+func Reorder(steps []models.Step, order []int) ([]int, error) {
+	if len(order) != len(steps) {
+		return nil, fmt.Errorf(
+			"%w: expected %d steps, got %d",
+			ErrInvalidOrder, len(steps), len(order),
+		)
+	}
+	places := make(map[int][]int, len(steps))
+	for _, step := range steps {
+		places[step.ID] = append(places[step.ID], step.Place)
+	}
+	result := make([]int, len(order))
+	for i, id := range order {
+		queue := places[id]
+		if len(queue) == 0 {
+			return nil, fmt.Errorf("%w: unexpected step %d", ErrInvalidOrder, id)
+		}
+		result[i], places[id] = queue[0], queue[1:]
+	}
+	return result, nil
+}
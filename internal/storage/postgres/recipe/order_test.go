@@ -0,0 +1,60 @@
+package recipes
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reorder(t *testing.T) {
+	steps := []models.Step{
+		{ID: 3, Place: 1},
+		{ID: 5, Place: 2},
+		{ID: 3, Place: 3},
+		{ID: 7, Place: 4},
+	}
+	testCases := []struct {
+		name     string
+		order    []int
+		expected []int
+		err      error
+	}{
+		{
+			name:     "same order",
+			order:    []int{3, 5, 3, 7},
+			expected: []int{1, 2, 3, 4},
+		},
+		{
+			name:     "reversed",
+			order:    []int{7, 3, 5, 3},
+			expected: []int{4, 1, 2, 3},
+		},
+		{
+			name:  "missing step",
+			order: []int{3, 5, 7},
+			err:   ErrInvalidOrder,
+		},
+		{
+			name:  "unknown step",
+			order: []int{3, 5, 3, 9},
+			err:   ErrInvalidOrder,
+		},
+		{
+			name:  "step repeated too often",
+			order: []int{3, 3, 3, 7},
+			err:   ErrInvalidOrder,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Reorder(steps, tc.order)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
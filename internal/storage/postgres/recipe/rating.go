@@ -0,0 +1,175 @@
+package recipes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrUnexpectedStatus is returned when the status of the recipe does not
+// allow the transition.
+var ErrUnexpectedStatus = errors.New("unexpected recipe status")
+
+type RecipeRatingsRepositorer interface {
+	FindRatings(
+		ctx context.Context,
+		recipeID int,
+		filter models.PageFilter,
+	) ([]models.RecipeRating, error)
+	Rate(ctx context.Context, rating *models.RecipeRating) error
+	DeleteRating(ctx context.Context, recipeID, userID int) error
+	AddFavourite(ctx context.Context, recipeID, userID int) error
+	RemoveFavourite(ctx context.Context, recipeID, userID int) error
+}
+
+type RecipeModerationRepositorer interface {
+	UpdateStatus(ctx context.Context, id int, from []string, to, note string) error
+}
+
+// FindAccess implements RecipesRepositorer. It finds the owner and the
+// status of the recipe.
+func (r *recipesRepository) FindAccess(ctx context.Context, id int) (models.Recipe, error) {
+	query := `
+		SELECT id, id_user, status
+		FROM recipes
+		WHERE id = $1
+	`
+	var recipe models.Recipe
+	if err := r.client.QueryRow(ctx, query, id).Scan(&recipe.ID, &recipe.User.ID, &recipe.Status); err != nil {
+		return models.Recipe{}, fmt.Errorf("failed to query recipe: %w", err)
+	}
+	return recipe, nil
+}
+
+// FindRatings implements RecipesRepositorer
+func (r *recipesRepository) FindRatings(
+	ctx context.Context,
+	recipeID int,
+	filter models.PageFilter,
+) ([]models.RecipeRating, error) {
+	query := `
+		SELECT u.id, u.name, rr.rating, COALESCE(rr.review, ''), rr.created_at, rr.updated_at
+		FROM recipes_ratings rr
+		JOIN users u ON u.id = rr.id_user
+		WHERE rr.id_recipe = $1
+		ORDER BY COALESCE(rr.updated_at, rr.created_at) DESC
+		LIMIT $2
+		OFFSET $3
+	`
+	rows, err := r.client.Query(ctx, query, recipeID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ratings: %w", err)
+	}
+	defer rows.Close()
+	result := make([]models.RecipeRating, 0)
+	for rows.Next() {
+		rating := models.RecipeRating{Recipe: models.Recipe{ID: recipeID}}
+		if err := rows.Scan(
+			&rating.User.ID,
+			&rating.User.Name,
+			&rating.Rating,
+			&rating.Review,
+			&rating.CreatedAt,
+			&rating.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rating: %w", err)
+		}
+		result = append(result, rating)
+	}
+	return result, nil
+}
+
+// Rate implements RecipesRepositorer. The rating of the user replaces the
+// previous one.
+func (r *recipesRepository) Rate(ctx context.Context, rating *models.RecipeRating) error {
+	query := `
+		INSERT INTO recipes_ratings (id_recipe, id_user, rating, review)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id_recipe, id_user) DO UPDATE
+		SET rating = EXCLUDED.rating,
+			review = EXCLUDED.review,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+	if err := r.client.QueryRow(
+		ctx,
+		query,
+		rating.Recipe.ID,
+		rating.User.ID,
+		rating.Rating,
+		rating.Review,
+	).Scan(&rating.CreatedAt, &rating.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to rate recipe: %w", err)
+	}
+	return nil
+}
+
+// DeleteRating implements RecipesRepositorer
+func (r *recipesRepository) DeleteRating(ctx context.Context, recipeID, userID int) error {
+	query := `
+		DELETE FROM recipes_ratings
+		WHERE id_recipe = $1 AND
+			id_user = $2
+	`
+	if _, err := r.client.Exec(ctx, query, recipeID, userID); err != nil {
+		return fmt.Errorf("failed to delete rating: %w", err)
+	}
+	return nil
+}
+
+// AddFavourite implements RecipesRepositorer
+func (r *recipesRepository) AddFavourite(ctx context.Context, recipeID, userID int) error {
+	query := `
+		INSERT INTO users_favourite_recipes (id_recipe, id_user)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := r.client.Exec(ctx, query, recipeID, userID); err != nil {
+		return fmt.Errorf("failed to add favourite: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavourite implements RecipesRepositorer
+func (r *recipesRepository) RemoveFavourite(ctx context.Context, recipeID, userID int) error {
+	query := `
+		DELETE FROM users_favourite_recipes
+		WHERE id_recipe = $1 AND
+			id_user = $2
+	`
+	if _, err := r.client.Exec(ctx, query, recipeID, userID); err != nil {
+		return fmt.Errorf("failed to remove favourite: %w", err)
+	}
+	return nil
+}
+
+// UpdateStatus implements RecipesRepositorer. The status is changed only if
+// the current status is one of from.
+func (r *recipesRepository) UpdateStatus(
+	ctx context.Context,
+	id int,
+	from []string,
+	to, note string,
+) error {
+	query := `
+		UPDATE recipes
+		SET status = $2,
+			moderation_note = NULLIF($3, ''),
+			updated_at = NOW()
+		WHERE id = $1 AND
+			deleted_at IS NULL AND
+			status = ANY($4)
+		RETURNING id
+	`
+	err := r.client.QueryRow(ctx, query, id, to, note, from).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: can not change status to %s", ErrUnexpectedStatus, to)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package recipes
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
@@ -15,30 +16,41 @@ type RecipeIngredientsRepositorer interface {
 		ctx context.Context,
 		id int,
 		entity *models.RecipeIngredient,
+		review bool,
 	) (models.RecipeIngredient, error)
 	UpdateIngredient(
 		ctx context.Context,
 		id int,
 		entity *models.RecipeIngredient,
+		review bool,
 	) (models.RecipeIngredient, error)
-	DeleteIngredient(ctx context.Context, recipeId, productId int) error
+	DeleteIngredient(ctx context.Context, recipeId, productId int, review bool) error
 }
 
+// ErrStepNotFound is returned when the recipe has no step with the ID at the
+// place.
+var ErrStepNotFound = errors.New("recipe step not found")
+
 type RecipeStepsRepositorer interface {
 	FindSteps(ctx context.Context, recipeID int) ([]models.Step, error)
-	CreateStep(ctx context.Context, recipeID, stepID, place int) (models.Step, error)
-	DeleteStep(ctx context.Context, recipeID, stepID, place int) error
+	CreateStep(ctx context.Context, recipeID int, step models.Step, review bool) (models.Step, error)
+	UpdateStep(ctx context.Context, recipeID int, step models.Step, review bool) (models.Step, error)
+	DeleteStep(ctx context.Context, recipeID, stepID, place int, review bool) error
+	ReorderSteps(ctx context.Context, recipeID int, order []int, review bool) ([]models.Step, error)
 }
 
 type RecipesRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.Recipe, error)
 	FindMany(ctx context.Context, filter models.RecipeFilter) ([]models.Recipe, error)
 	Create(ctx context.Context, recipe *models.Recipe) error
-	Update(ctx context.Context, recipe *models.Recipe) error
+	Update(ctx context.Context, recipe *models.Recipe, review bool) error
 	Delete(ctx context.Context, id int) error
 	Restore(ctx context.Context, id int) error
+	FindAccess(ctx context.Context, id int) (models.Recipe, error)
 	RecipeIngredientsRepositorer
 	RecipeStepsRepositorer
+	RecipeRatingsRepositorer
+	RecipeModerationRepositorer
 	Count(ctx context.Context, filter models.RecipeFilter) (int, error)
 }
 
@@ -50,77 +62,298 @@ func (r *recipesRepository) Count(ctx context.Context, filter models.RecipeFilte
 	var (
 		query = `
 			SELECT COUNT(*) 
-			FROM recipes 
-			WHERE deleted_at IS NULL AND
-				name ILIKE $1
-		`
+			FROM recipes r` + joinRatings + `
+			WHERE` + whereRecipes
 		count int
 	)
-	if err := r.client.QueryRow(ctx, query, "%"+filter.Name+"%").Scan(&count); err != nil {
+	if err := r.client.QueryRow(ctx, query, filterArgs(filter)...).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count recipes: %w", err)
 	}
 	return count, nil
 }
 
-// CreateStep implements RecipesRepositorer
+const (
+	// joinRatings joins the average rating and the number of ratings of the
+	// recipes as rt
+	joinRatings = `
+			LEFT JOIN (
+				SELECT id_recipe, AVG(rating)::float8 AS rating, COUNT(*) AS ratings
+				FROM recipes_ratings
+				GROUP BY id_recipe
+			) rt ON rt.id_recipe = r.id`
+	// whereRecipes filters the recipes by the arguments of filterArgs. The
+	// published recipes and the recipes of the user are visible, the pending
+	// recipes of all the users are visible to the moderators.
+	whereRecipes = `
+				r.deleted_at IS NULL AND
+				r.name ILIKE $1 AND
+				CASE WHEN $6::boolean THEN r.status = 'pending'
+					ELSE r.status = 'published' OR r.id_user = $2
+				END AND
+				(NOT $3::boolean OR r.id_user = $2) AND
+				(NOT $4::boolean OR EXISTS (
+					SELECT 1
+					FROM users_favourite_recipes f
+					WHERE f.id_recipe = r.id AND f.id_user = $2
+				)) AND
+				COALESCE(rt.rating, 0) >= $5`
+)
+
+func filterArgs(filter models.RecipeFilter) []any {
+	return []any{
+		"%" + filter.Name + "%",
+		filter.UserID,
+		filter.Mine,
+		filter.Favourites,
+		filter.MinRating,
+		filter.Pending,
+	}
+}
+
+// selectSteps selects the steps of the recipe in order.
+const selectSteps = `
+	SELECT s.id, s.name, rs.place, rs.duration, rs.timer, rs.temperature, rs.ingredients
+	FROM recipes_steps rs
+	JOIN steps s ON s.id = rs.id_step
+	WHERE rs.id_recipe = $1
+	ORDER BY rs.place ASC
+`
+
+func scanStep(row pgx.Row, step *models.Step) error {
+	return row.Scan(
+		&step.ID,
+		&step.Name,
+		&step.Place,
+		&step.Duration,
+		&step.Timer,
+		&step.Temperature,
+		&step.Ingredients,
+	)
+}
+
+// CreateStep implements RecipesRepositorer. The step is inserted at its
+// place and the later steps are shifted, the step is appended if the place
+// is zero or past the last step.
 func (r *recipesRepository) CreateStep(
 	ctx context.Context,
-	recipeID, stepID, place int,
+	recipeID int,
+	step models.Step,
+	review bool,
 ) (models.Step, error) {
 	var (
-		query = `
-			WITH inserted AS (
-				INSERT INTO recipes_steps (id_recipe, id_step, place) 
-				VALUES ($1, $2, $3)
-				RETURNING id_recipe, id_step, place
-			)
-			SELECT s.id, s.name, i.place
-			FROM steps s
-			JOIN inserted i ON i.id_step = s.id
-			WHERE s.id = $2
-			LIMIT 1
+		queryCount = `
+			SELECT COUNT(*)
+			FROM recipes_steps
+			WHERE id_recipe = $1
+		`
+		queryInsert = `
+			INSERT INTO recipes_steps
+				(id_recipe, id_step, place, duration, timer, temperature, ingredients)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 		`
-		result models.Step
+		count int
 	)
-	if err := r.client.QueryRow(ctx, query, recipeID, stepID, place).Scan(&result.ID, &result.Name, &result.Place); err != nil {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.Step{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, recipeID, review); err != nil {
+		return models.Step{}, err
+	}
+	if err := tx.QueryRow(ctx, queryCount, recipeID).Scan(&count); err != nil {
+		return models.Step{}, fmt.Errorf("failed to count steps: %w", err)
+	}
+	place := step.Place
+	if place <= 0 || place > count {
+		place = count + 1
+	}
+	if err := shiftSteps(ctx, tx, recipeID, place, 1); err != nil {
+		return models.Step{}, err
+	}
+	if _, err := tx.Exec(
+		ctx,
+		queryInsert,
+		recipeID,
+		step.ID,
+		place,
+		step.Duration,
+		step.Timer,
+		step.Temperature,
+		productIDs(step.Ingredients),
+	); err != nil {
 		return models.Step{}, fmt.Errorf("failed to create step: %w", err)
 	}
+	result, err := findStep(ctx, tx, recipeID, place)
+	if err != nil {
+		return models.Step{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Step{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// UpdateStep implements RecipesRepositorer. The step is found by its ID and
+// place, the first step with the ID is updated if the place is zero.
+func (r *recipesRepository) UpdateStep(
+	ctx context.Context,
+	recipeID int,
+	step models.Step,
+	review bool,
+) (models.Step, error) {
+	query := `
+		UPDATE recipes_steps
+		SET duration = $3,
+			timer = $4,
+			temperature = $5,
+			ingredients = $6
+		WHERE id_recipe = $1 AND
+			place = $2
+	`
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.Step{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, recipeID, review); err != nil {
+		return models.Step{}, err
+	}
+	place, err := findPlace(ctx, tx, recipeID, step.ID, step.Place)
+	if err != nil {
+		return models.Step{}, err
+	}
+	if _, err := tx.Exec(
+		ctx,
+		query,
+		recipeID,
+		place,
+		step.Duration,
+		step.Timer,
+		step.Temperature,
+		productIDs(step.Ingredients),
+	); err != nil {
+		return models.Step{}, fmt.Errorf("failed to update step: %w", err)
+	}
+	result, err := findStep(ctx, tx, recipeID, place)
+	if err != nil {
+		return models.Step{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Step{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return result, nil
 }
 
-// DeleteStep implements RecipesRepositorer
-func (r *recipesRepository) DeleteStep(ctx context.Context, recipeID, stepID, place int) error {
+// DeleteStep implements RecipesRepositorer. The first step with the ID is
+// deleted if the place is zero, the later steps are shifted to close the gap.
+func (r *recipesRepository) DeleteStep(ctx context.Context, recipeID, stepID, place int, review bool) error {
 	query := `
 		DELETE FROM recipes_steps 
 		WHERE id_recipe = $1 AND 
-			id_step = $2 AND 
-			place = $3
+			place = $2
 	`
-	if _, err := r.client.Exec(ctx, query, recipeID, stepID, place); err != nil {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, recipeID, review); err != nil {
+		return err
+	}
+	place, err = findPlace(ctx, tx, recipeID, stepID, place)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, recipeID, place); err != nil {
 		return fmt.Errorf("failed to delete step: %w", err)
 	}
+	if err := shiftSteps(ctx, tx, recipeID, place+1, -1); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
-// FindSteps implements RecipesRepositorer
-func (r *recipesRepository) FindSteps(ctx context.Context, recipeID int) ([]models.Step, error) {
+// ReorderSteps implements RecipesRepositorer. The order is the full list of
+// the step IDs of the recipe, the steps are renumbered from one.
+func (r *recipesRepository) ReorderSteps(
+	ctx context.Context,
+	recipeID int,
+	order []int,
+	review bool,
+) ([]models.Step, error) {
 	var (
-		query = `
-			SELECT s.id, s.name, rs.place
-			FROM steps s
-			JOIN recipes_steps rs ON rs.id_step = s.id
-			WHERE rs.id_recipe = $1
+		queryNegate = `
+			UPDATE recipes_steps
+			SET place = -place
+			WHERE id_recipe = $1
+		`
+		queryPlace = `
+			UPDATE recipes_steps
+			SET place = $2
+			WHERE id_recipe = $1 AND
+				place = $3
 		`
-		result []models.Step
 	)
-	rows, err := r.client.Query(ctx, query, recipeID)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, recipeID, review); err != nil {
+		return nil, err
+	}
+	steps, err := findSteps(ctx, tx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	places, err := Reorder(steps, order)
+	if err != nil {
+		return nil, err
+	}
+	// the places are negated first so the new places do not collide with
+	// the old ones
+	if _, err := tx.Exec(ctx, queryNegate, recipeID); err != nil {
+		return nil, fmt.Errorf("failed to reorder steps: %w", err)
+	}
+	for i, place := range places {
+		if _, err := tx.Exec(ctx, queryPlace, recipeID, i+1, -place); err != nil {
+			return nil, fmt.Errorf("failed to reorder steps: %w", err)
+		}
+	}
+	result, err := findSteps(ctx, tx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// FindSteps implements RecipesRepositorer
+func (r *recipesRepository) FindSteps(ctx context.Context, recipeID int) ([]models.Step, error) {
+	return findSteps(ctx, r.client, recipeID)
+}
+
+// querier is implemented by the client and the transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func findSteps(ctx context.Context, q querier, recipeID int) ([]models.Step, error) {
+	rows, err := q.Query(ctx, selectSteps, recipeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find steps: %w", err)
 	}
 	defer rows.Close()
+	result := make([]models.Step, 0)
 	for rows.Next() {
 		var step models.Step
-		if err := rows.Scan(&step.ID, &step.Name, &step.Place); err != nil {
+		if err := scanStep(rows, &step); err != nil {
 			return nil, fmt.Errorf("failed to scan step: %w", err)
 		}
 		result = append(result, step)
@@ -128,11 +361,101 @@ func (r *recipesRepository) FindSteps(ctx context.Context, recipeID int) ([]mode
 	return result, nil
 }
 
+func findStep(ctx context.Context, tx pgx.Tx, recipeID, place int) (models.Step, error) {
+	query := `
+		SELECT s.id, s.name, rs.place, rs.duration, rs.timer, rs.temperature, rs.ingredients
+		FROM recipes_steps rs
+		JOIN steps s ON s.id = rs.id_step
+		WHERE rs.id_recipe = $1 AND
+			rs.place = $2
+	`
+	var step models.Step
+	if err := scanStep(tx.QueryRow(ctx, query, recipeID, place), &step); err != nil {
+		return models.Step{}, fmt.Errorf("failed to find step: %w", err)
+	}
+	return step, nil
+}
+
+// findPlace returns the place of the step, the place of the first step with
+// the ID if the place is zero.
+func findPlace(ctx context.Context, tx pgx.Tx, recipeID, stepID, place int) (int, error) {
+	query := `
+		SELECT place
+		FROM recipes_steps
+		WHERE id_recipe = $1 AND
+			id_step = $2 AND
+			($3 = 0 OR place = $3)
+		ORDER BY place ASC
+		LIMIT 1
+	`
+	var result int
+	err := tx.QueryRow(ctx, query, recipeID, stepID, place).Scan(&result)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrStepNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find step: %w", err)
+	}
+	return result, nil
+}
+
+// lockRecipe locks the recipe so its changes are serialized. The published
+// recipe edited for review is moved back to moderation, the edit is visible
+// only after it is approved again.
+func lockRecipe(ctx context.Context, tx pgx.Tx, recipeID int, review bool) error {
+	query := `
+		UPDATE recipes
+		SET status = CASE WHEN $2 AND status = 'published' THEN 'pending' ELSE status END,
+			moderation_note = CASE WHEN $2 AND status = 'published' THEN NULL ELSE moderation_note END
+		WHERE id = $1
+		RETURNING id
+	`
+	var id int
+	if err := tx.QueryRow(ctx, query, recipeID, review).Scan(&id); err != nil {
+		return fmt.Errorf("failed to lock recipe: %w", err)
+	}
+	return nil
+}
+
+// shiftSteps moves the steps from the place on by the offset. The places are
+// negated first so the shifted places do not collide with each other.
+func shiftSteps(ctx context.Context, tx pgx.Tx, recipeID, from, offset int) error {
+	var (
+		queryNegate = `
+			UPDATE recipes_steps
+			SET place = -(place + $3)
+			WHERE id_recipe = $1 AND
+				place >= $2
+		`
+		queryRestore = `
+			UPDATE recipes_steps
+			SET place = -place
+			WHERE id_recipe = $1 AND
+				place < 0
+		`
+	)
+	if _, err := tx.Exec(ctx, queryNegate, recipeID, from, offset); err != nil {
+		return fmt.Errorf("failed to shift steps: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryRestore, recipeID); err != nil {
+		return fmt.Errorf("failed to shift steps: %w", err)
+	}
+	return nil
+}
+
+func productIDs(products []int) []int {
+	if products == nil {
+		return []int{}
+	}
+	return products
+}
+
 // CreateIngredient implements RecipesRepositorer
 func (r *recipesRepository) CreateIngredient(
 	ctx context.Context,
 	id int,
 	entity *models.RecipeIngredient,
+	review bool,
 ) (models.RecipeIngredient, error) {
 	var (
 		queryInsert = `
@@ -152,12 +475,23 @@ func (r *recipesRepository) CreateIngredient(
 			LIMIT 1
 		`
 	)
-	if _, err := r.client.Exec(ctx, queryInsert, entity.Product.ID, id, entity.Measure.ID, entity.Quantity); err != nil {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.RecipeIngredient{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, id, review); err != nil {
+		return models.RecipeIngredient{}, err
+	}
+	if _, err := tx.Exec(ctx, queryInsert, entity.Product.ID, id, entity.Measure.ID, entity.Quantity); err != nil {
 		return models.RecipeIngredient{}, fmt.Errorf("failed to create recipe ingredient: %w", err)
 	}
-	if err := r.client.QueryRow(ctx, querySelect, id, entity.Product.ID, entity.Measure.ID).Scan(&entity.Product.Name, &entity.Measure.Name); err != nil {
+	if err := tx.QueryRow(ctx, querySelect, id, entity.Product.ID, entity.Measure.ID).Scan(&entity.Product.Name, &entity.Measure.Name); err != nil {
 		return models.RecipeIngredient{}, fmt.Errorf("failed to query recipe ingredient: %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.RecipeIngredient{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return *entity, nil
 }
 
@@ -166,14 +500,26 @@ func (r *recipesRepository) DeleteIngredient(
 	ctx context.Context,
 	recipeId int,
 	productId int,
+	review bool,
 ) error {
 	query := `
 			DELETE FROM products_recipes_measures
 			WHERE id_recipe = $1 AND id_product = $2
 		`
-	if _, err := r.client.Exec(ctx, query, recipeId, productId); err != nil {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, recipeId, review); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, query, recipeId, productId); err != nil {
 		return fmt.Errorf("failed to delete recipe ingredient: %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -184,7 +530,8 @@ func (r *recipesRepository) FindIngredients(
 ) ([]models.RecipeIngredient, error) {
 	var (
 		query = `
-		SELECT p.id, p.name, m.id, m.name, prm.quantity
+		SELECT p.id, p.name, p.density, p.piece_weight, p.nutrition,
+			m.id, m.name, m.dimension, m.factor, prm.quantity
 			FROM products_recipes_measures prm
 			JOIN products p ON p.id = prm.id_product
 			JOIN measures m ON m.id = prm.id_measure
@@ -199,7 +546,11 @@ func (r *recipesRepository) FindIngredients(
 	defer rows.Close()
 	for rows.Next() {
 		var entity models.RecipeIngredient
-		if err := rows.Scan(&entity.Product.ID, &entity.Product.Name, &entity.Measure.ID, &entity.Measure.Name, &entity.Quantity); err != nil {
+		if err := rows.Scan(
+			&entity.Product.ID, &entity.Product.Name, &entity.Product.Density,
+			&entity.Product.PieceWeight, &entity.Product.Nutrition, &entity.Measure.ID, &entity.Measure.Name,
+			&entity.Measure.Dimension, &entity.Measure.Factor, &entity.Quantity,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan ingredient: %w", err)
 		}
 		entities = append(entities, entity)
@@ -212,6 +563,7 @@ func (r *recipesRepository) UpdateIngredient(
 	ctx context.Context,
 	id int,
 	entity *models.RecipeIngredient,
+	review bool,
 ) (models.RecipeIngredient, error) {
 	var (
 		query = `
@@ -232,12 +584,23 @@ func (r *recipesRepository) UpdateIngredient(
 			LIMIT 1
 		`
 	)
-	if _, err := r.client.Exec(ctx, query, entity.Product.ID, entity.Measure.ID, entity.Quantity, id); err != nil {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.RecipeIngredient{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if err := lockRecipe(ctx, tx, id, review); err != nil {
+		return models.RecipeIngredient{}, err
+	}
+	if _, err := tx.Exec(ctx, query, entity.Product.ID, entity.Measure.ID, entity.Quantity, id); err != nil {
 		return models.RecipeIngredient{}, fmt.Errorf("failed to update recipe ingredient: %w", err)
 	}
-	if err := r.client.QueryRow(ctx, querySelect, id, entity.Product.ID, entity.Measure.ID).Scan(&entity.Measure.Name, &entity.Quantity); err != nil {
+	if err := tx.QueryRow(ctx, querySelect, id, entity.Product.ID, entity.Measure.ID).Scan(&entity.Measure.Name, &entity.Quantity); err != nil {
 		return models.RecipeIngredient{}, fmt.Errorf("failed to query recipe ingredient: %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.RecipeIngredient{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return *entity, nil
 }
 
@@ -248,34 +611,36 @@ func New(client postgres.Client) RecipesRepositorer {
 func (r *recipesRepository) FindByID(ctx context.Context, id int) (models.Recipe, error) {
 	var (
 		query = `
-			SELECT id, name, description 
-			FROM recipes 
-			WHERE id = $1
-		`
-		querySteps = `
-			SELECT rs.id_step, rs.place, s.name
-			FROM recipes_steps rs
-			JOIN steps s ON s.id = rs.id_step
-			WHERE rs.id_recipe = $1
-			ORDER BY rs.place ASC
+			SELECT r.id, r.name, r.description, r.servings, r.id_user, r.status,
+				COALESCE(r.moderation_note, ''), COALESCE(rt.rating, 0), COALESCE(rt.ratings, 0)
+			FROM recipes r` + joinRatings + `
+			WHERE r.id = $1
 		`
 		recipe models.Recipe
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&recipe.ID, &recipe.Name, &recipe.Description); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&recipe.ID,
+		&recipe.Name,
+		&recipe.Description,
+		&recipe.Servings,
+		&recipe.User.ID,
+		&recipe.Status,
+		&recipe.ModerationNote,
+		&recipe.Rating,
+		&recipe.Ratings,
+	); err != nil {
 		return models.Recipe{}, fmt.Errorf("failed to query recipe: %w", err)
 	}
-	rows, err := r.client.Query(ctx, querySteps, id)
+	ingredients, err := r.FindIngredients(ctx, id)
 	if err != nil {
-		return models.Recipe{}, fmt.Errorf("failed to query steps: %w", err)
+		return models.Recipe{}, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var step models.Step
-		if err := rows.Scan(&step.ID, &step.Place, &step.Name); err != nil {
-			return models.Recipe{}, fmt.Errorf("failed to scan step: %w", err)
-		}
-		recipe.Steps = append(recipe.Steps, step)
+	recipe.Ingredients = ingredients
+	steps, err := r.FindSteps(ctx, id)
+	if err != nil {
+		return models.Recipe{}, err
 	}
+	recipe.Steps = steps
 	return recipe, nil
 }
 
@@ -285,24 +650,37 @@ func (r *recipesRepository) FindMany(
 ) ([]models.Recipe, error) {
 	var (
 		query = `
-			SELECT id, name, description
-			FROM recipes
-			WHERE name LIKE $1 AND 
-				deleted_at IS NULL
-			ORDER BY id ASC
-			LIMIT $2
-			OFFSET $3
+			SELECT r.id, r.name, r.description, r.servings, r.id_user, r.status,
+				COALESCE(rt.rating, 0), COALESCE(rt.ratings, 0)
+			FROM recipes r` + joinRatings + `
+			WHERE` + whereRecipes + `
+			ORDER BY
+				CASE WHEN $7::text = 'rating' THEN COALESCE(rt.rating, 0) END DESC,
+				CASE WHEN $7::text = 'rating' THEN COALESCE(rt.ratings, 0) END DESC,
+				r.id ASC
+			LIMIT $8
+			OFFSET $9
 		`
 		recipes []models.Recipe
 	)
-	rows, err := r.client.Query(ctx, query, "%"+filter.Name+"%", filter.Limit, filter.Offset)
+	args := append(filterArgs(filter), filter.Sort, filter.Limit, filter.Offset)
+	rows, err := r.client.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recipes: %w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var recipe models.Recipe
-		if err := rows.Scan(&recipe.ID, &recipe.Name, &recipe.Description); err != nil {
+		if err := rows.Scan(
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.Description,
+			&recipe.Servings,
+			&recipe.User.ID,
+			&recipe.Status,
+			&recipe.Rating,
+			&recipe.Ratings,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan recipe: %w", err)
 		}
 		recipes = append(recipes, recipe)
@@ -311,21 +689,41 @@ func (r *recipesRepository) FindMany(
 }
 
 func (r *recipesRepository) Create(ctx context.Context, recipe *models.Recipe) error {
-	query := `
+	var (
+		query = `
 			INSERT INTO recipes
-				(id_user, name, description)
+				(id_user, name, description, servings, status)
 			VALUES
-				($1, $2, $3)
+				($1, $2, $3, $4, $5)
 			RETURNING id
 		`
+		queryStep = `
+			INSERT INTO steps (name)
+			VALUES ($1)
+			RETURNING id
+		`
+	)
 	tx, err := r.client.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
-	if err := tx.QueryRow(ctx, query, recipe.User.ID, recipe.Name, recipe.Description).Scan(&recipe.ID); err != nil {
+	if recipe.Status == "" {
+		recipe.Status = models.RecipePublished
+	}
+	if err := tx.QueryRow(ctx, query, recipe.User.ID, recipe.Name, recipe.Description, recipe.Servings, recipe.Status).Scan(&recipe.ID); err != nil {
 		return fmt.Errorf("failed to create recipe: %w", err)
 	}
+	// the steps without id are created by name, as the imported ones
+	for i := range recipe.Steps {
+		step := &recipe.Steps[i]
+		if step.ID != 0 {
+			continue
+		}
+		if err := tx.QueryRow(ctx, queryStep, step.Name).Scan(&step.ID); err != nil {
+			return fmt.Errorf("failed to create step: %w", err)
+		}
+	}
 	if _, err = tx.CopyFrom(ctx,
 		pgx.Identifier{"recipes_steps"},
 		[]string{"id_recipe", "id_step", "place"},
@@ -350,15 +748,20 @@ func (r *recipesRepository) Create(ctx context.Context, recipe *models.Recipe) e
 	return nil
 }
 
-func (r *recipesRepository) Update(ctx context.Context, recipe *models.Recipe) error {
+// Update implements RecipesRepositorer. The published recipe updated for
+// review is moved back to moderation.
+func (r *recipesRepository) Update(ctx context.Context, recipe *models.Recipe, review bool) error {
 	query := `
 			UPDATE recipes
 			SET name = $1,
 				description = $2,
+				servings = $3,
+				status = CASE WHEN $5 AND status = 'published' THEN 'pending' ELSE status END,
+				moderation_note = CASE WHEN $5 AND status = 'published' THEN NULL ELSE moderation_note END,
 				updated_at = NOW()
-			WHERE id = $3
+			WHERE id = $4
 		`
-	if _, err := r.client.Exec(ctx, query, recipe.Name, recipe.Description, recipe.ID); err != nil {
+	if _, err := r.client.Exec(ctx, query, recipe.Name, recipe.Description, recipe.Servings, recipe.ID, review); err != nil {
 		return fmt.Errorf("failed to update recipe: %w", err)
 	}
 	return nil
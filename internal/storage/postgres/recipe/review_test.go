@@ -0,0 +1,100 @@
+package recipes
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reviewTables are the tables of the recipe edits created as the temporary
+// tables, they shadow the tables of the database for the session.
+const reviewTables = `
+	CREATE TEMP TABLE recipes (
+		id int PRIMARY KEY, name text, description text, servings int,
+		status text, moderation_note text, updated_at timestamp
+	);
+	CREATE TEMP TABLE products_recipes_measures (
+		id_recipe int, id_product int, id_measure int, quantity real
+	);
+`
+
+// Test_Review runs the edits on the database of TEST_DATABASE_URL, it is
+// skipped if the variable is not set.
+func Test_Review(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, reviewTables)
+	require.NoError(t, err)
+	repo := New(conn)
+
+	testCases := []struct {
+		name     string
+		status   string
+		review   bool
+		edit     func(id int, review bool) error
+		expected string
+	}{
+		{
+			name:   "published updated by user",
+			status: models.RecipePublished,
+			review: true,
+			edit: func(id int, review bool) error {
+				return repo.Update(ctx, &models.Recipe{ID: id, Name: "Crepes"}, review)
+			},
+			expected: models.RecipePending,
+		},
+		{
+			name:   "published updated by moderator",
+			status: models.RecipePublished,
+			edit: func(id int, review bool) error {
+				return repo.Update(ctx, &models.Recipe{ID: id, Name: "Crepes"}, review)
+			},
+			expected: models.RecipePublished,
+		},
+		{
+			name:   "private updated by user",
+			status: models.RecipePrivate,
+			review: true,
+			edit: func(id int, review bool) error {
+				return repo.Update(ctx, &models.Recipe{ID: id, Name: "Crepes"}, review)
+			},
+			expected: models.RecipePrivate,
+		},
+		{
+			name:   "ingredient removed by user",
+			status: models.RecipePublished,
+			review: true,
+			edit: func(id int, review bool) error {
+				return repo.DeleteIngredient(ctx, id, 1, review)
+			},
+			expected: models.RecipePending,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id := i + 1
+			_, err := conn.Exec(ctx,
+				`INSERT INTO recipes (id, name, status, moderation_note) VALUES ($1, 'Recipe', $2, 'note')`,
+				id, tc.status)
+			require.NoError(t, err)
+			require.NoError(t, tc.edit(id, tc.review))
+			var status string
+			var note *string
+			require.NoError(t, conn.QueryRow(ctx,
+				`SELECT status, moderation_note FROM recipes WHERE id = $1`, id).Scan(&status, &note))
+			assert.Equal(t, tc.expected, status)
+			assert.Equal(t, tc.expected == models.RecipePending, note == nil)
+		})
+	}
+}
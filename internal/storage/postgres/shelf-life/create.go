@@ -0,0 +1,58 @@
+package shelflife
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+const insertShelfLife = `
+	WITH inserted AS (
+		INSERT INTO shelf_lives
+			(id_user, id_product, id_storage, id_measure, quantity, purchase_date, end_date)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, id_product, id_storage, id_measure
+	)
+	SELECT i.id, p.name, s.name, m.name
+	FROM inserted i
+	JOIN products p ON p.id = i.id_product
+	JOIN storages s ON s.id = i.id_storage
+	JOIN measures m ON m.id = i.id_measure
+`
+
+// Insert inserts the shelf life of the user and sets its id and the names of
+// its product, storage and measure. The client is the transaction the shelf
+// life is created in with the rows depending on it.
+func Insert(ctx context.Context, client postgres.Client, userID int, shelfLife *models.ShelfLife) error {
+	if err := client.QueryRow(ctx, insertShelfLife,
+		userID, shelfLife.Product.ID, shelfLife.Storage.ID, shelfLife.Measure.ID,
+		shelfLife.Quantity, shelfLife.PurchaseDate, shelfLife.EndDate,
+	).Scan(
+		&shelfLife.ID, &shelfLife.Product.Name, &shelfLife.Storage.Name, &shelfLife.Measure.Name,
+	); err != nil {
+		return fmt.Errorf("failed to insert shelf life: %w", err)
+	}
+	shelfLife.User = models.User{ID: userID}
+	return nil
+}
+
+// HasStorages reports whether all the storages are the user's storages which
+// are not deleted.
+func HasStorages(ctx context.Context, client postgres.Client, userID int, storages ...int) (bool, error) {
+	var (
+		query = `
+			SELECT COUNT(DISTINCT us.id_storage) = cardinality(ARRAY(SELECT DISTINCT unnest($2::int[])))
+			FROM users_storages us
+			JOIN storages s ON s.id = us.id_storage
+			WHERE us.id_user = $1 AND us.id_storage = ANY($2) AND s.deleted_at IS NULL
+		`
+		ok bool
+	)
+	if err := client.QueryRow(ctx, query, userID, storages).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check user storages: %w", err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,55 @@
+package shelflife
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storageTables are the tables of the storages created as the temporary
+// tables, they shadow the tables of the database for the session.
+const storageTables = `
+	CREATE TEMP TABLE storages (id int PRIMARY KEY, deleted_at timestamp);
+	CREATE TEMP TABLE users_storages (id_user int, id_storage int);
+	INSERT INTO storages (id, deleted_at) VALUES (1, NULL), (2, NULL), (3, NOW()), (4, NULL);
+	INSERT INTO users_storages (id_user, id_storage) VALUES (1, 1), (1, 2), (1, 3), (2, 4);
+`
+
+// Test_HasStorages runs the check on the database of TEST_DATABASE_URL, it
+// is skipped if the variable is not set.
+func Test_HasStorages(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, storageTables)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		storages []int
+		expected bool
+	}{
+		{name: "one", storages: []int{1}, expected: true},
+		{name: "all", storages: []int{1, 2}, expected: true},
+		{name: "repeated", storages: []int{1, 1, 2}, expected: true},
+		{name: "deleted", storages: []int{1, 3}},
+		{name: "other user", storages: []int{4}},
+		{name: "unknown", storages: []int{5}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := HasStorages(ctx, conn, 1, tc.storages...)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ok)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package shelflife
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// sortColumns is the whitelist of sort keys accepted by the shelf life
+// listings mapped onto the columns of the listing queries.
+var sortColumns = map[string]string{
+	"end_date":      "sl.end_date",
+	"purchase_date": "sl.purchase_date",
+	"product":       "p.name",
+	"quantity":      "sl.quantity",
+}
+
+// Where builds the WHERE clause for the shelf lives aliased as `sl` from the
+// filter. Placeholders are numbered after the given args, which are returned
+// together with the filter values.
+func Where(filter models.ShelfLifeFilter, args ...any) (string, []any) {
+	conds := []string{"sl.deleted_at IS NULL"}
+	add := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.UserID != 0 {
+		add("sl.id_user = $%d", filter.UserID)
+	}
+	if filter.StorageID != 0 {
+		add("sl.id_storage = $%d", filter.StorageID)
+	}
+	if filter.ProductID != 0 {
+		add("sl.id_product = $%d", filter.ProductID)
+	}
+	if filter.CategoryID != 0 {
+		add(
+			"EXISTS (SELECT 1 FROM products_categories pc WHERE pc.id_product = sl.id_product AND pc.id_category = $%d)",
+			filter.CategoryID,
+		)
+	}
+	if filter.StatusID != 0 {
+		add(
+			"EXISTS (SELECT 1 FROM shelf_lives_statuses sls WHERE sls.id_shelf_life = sl.id AND sls.id_status = $%d)",
+			filter.StatusID,
+		)
+	}
+	if filter.EndBefore != nil {
+		add("sl.end_date < $%d", filter.EndBefore)
+	}
+	if filter.EndAfter != nil {
+		add("sl.end_date > $%d", filter.EndAfter)
+	}
+	if filter.PurchasedFrom != nil {
+		add("sl.purchase_date >= $%d", filter.PurchasedFrom)
+	}
+	if filter.PurchasedTo != nil {
+		add("sl.purchase_date <= $%d", filter.PurchasedTo)
+	}
+	if filter.ExpiresWithin != 0 {
+		conds = append(conds, "sl.end_date >= CURRENT_DATE")
+		add("sl.end_date <= CURRENT_DATE + $%d::int", filter.ExpiresWithin)
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// OrderBy builds the ORDER BY clause from the whitelisted sort key. A leading
+// "-" sorts descending. Unknown or empty keys fall back to the given order.
+func OrderBy(sort, fallback string) string {
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		direction = "DESC"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+	column, ok := sortColumns[sort]
+	if !ok {
+		return "ORDER BY " + fallback
+	}
+	return fmt.Sprintf("ORDER BY %s %s, sl.id ASC", column, direction)
+}
@@ -0,0 +1,76 @@
+package shelflife
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Where(t *testing.T) {
+	date := time.Date(2023, time.January, 31, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		filter   models.ShelfLifeFilter
+		args     []any
+		expected string
+		values   []any
+	}{
+		{
+			name:     "empty filter",
+			filter:   models.ShelfLifeFilter{},
+			expected: "WHERE sl.deleted_at IS NULL",
+		},
+		{
+			name:     "placeholders after paging",
+			filter:   models.ShelfLifeFilter{UserID: 1, EndBefore: &date},
+			args:     []any{10, 0},
+			expected: "WHERE sl.deleted_at IS NULL AND sl.id_user = $3 AND sl.end_date < $4",
+			values:   []any{10, 0, 1, &date},
+		},
+		{
+			name:   "expiring within days",
+			filter: models.ShelfLifeFilter{ExpiresWithin: 3},
+			expected: "WHERE sl.deleted_at IS NULL AND sl.end_date >= CURRENT_DATE AND " +
+				"sl.end_date <= CURRENT_DATE + $1::int",
+			values: []any{3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			where, values := Where(tc.filter, tc.args...)
+			assert.Equal(t, tc.expected, where)
+			assert.Equal(t, tc.values, values)
+		})
+	}
+}
+
+func Test_OrderBy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sort     string
+		expected string
+	}{
+		{
+			name:     "ascending",
+			sort:     "product",
+			expected: "ORDER BY p.name ASC, sl.id ASC",
+		},
+		{
+			name:     "descending",
+			sort:     "-end_date",
+			expected: "ORDER BY sl.end_date DESC, sl.id ASC",
+		},
+		{
+			name:     "not whitelisted",
+			sort:     "sl.id; DROP TABLE shelf_lives",
+			expected: "ORDER BY sl.created_at DESC",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, OrderBy(tc.sort, "sl.created_at DESC"))
+		})
+	}
+}
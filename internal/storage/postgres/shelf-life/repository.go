@@ -30,12 +30,13 @@ func (r *shelfLifeRepository) Count(
 	filter models.ShelfLifeFilter,
 ) (int, error) {
 	var (
-		query = `
-			SELECT COUNT(*) FROM shelf_lives WHERE deleted_at IS NULL
-		`
+		where, args = Where(filter)
+		query       = `
+			SELECT COUNT(*) FROM shelf_lives sl
+		` + where
 		count int
 	)
-	if err := r.client.QueryRow(ctx, query).Scan(&count); err != nil {
+	if err := r.client.QueryRow(ctx, query, args...).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count shelf lives: %w", err)
 	}
 	return count, nil
@@ -165,7 +166,8 @@ func (r *shelfLifeRepository) FindByID(ctx context.Context, id int) (models.Shel
 // FindMany implements ShelfLifeRepositorer
 func (r *shelfLifeRepository) FindMany(ctx context.Context, filter models.ShelfLifeFilter) ([]models.ShelfLife, error) {
 	var (
-		query = `
+		where, args = Where(filter, filter.Limit, filter.Offset)
+		query       = `
 			SELECT sl.id, 
 				sl.id_product, p.name,
 				sl.id_storage, s.name,
@@ -175,14 +177,14 @@ func (r *shelfLifeRepository) FindMany(ctx context.Context, filter models.ShelfL
 			JOIN products p ON p.id = sl.id_product
 			JOIN storages s ON s.id = sl.id_storage
 			JOIN measures m ON m.id = sl.id_measure
-			WHERE sl.deleted_at IS NULL
-			ORDER BY sl.created_at DESC
+		` + where + `
+		` + OrderBy(filter.Sort, "sl.created_at DESC") + `
 			LIMIT $1 
 			OFFSET $2
 		`
 		shelfLives = make([]models.ShelfLife, 0, filter.Limit)
 	)
-	rows, err := r.client.Query(ctx, query, filter.Limit, filter.Offset)
+	rows, err := r.client.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find shelf lives: %w", err)
 	}
@@ -0,0 +1,537 @@
+package shoppinglist
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+)
+
+var (
+	// ErrNotFound is returned when the shopping list does not exist or is
+	// deleted.
+	ErrNotFound = stderrors.New("shopping list not found")
+	// ErrItemNotFound is returned when the item is not in the shopping list.
+	ErrItemNotFound = stderrors.New("shopping list item not found")
+)
+
+type ShoppingListRepositorer interface {
+	FindByID(ctx context.Context, id int) (models.ShoppingList, error)
+	FindMany(ctx context.Context, filter models.ShoppingListFilter) ([]models.ShoppingList, error)
+	Count(ctx context.Context, filter models.ShoppingListFilter) (int, error)
+	Create(ctx context.Context, list *models.ShoppingList) error
+	Update(ctx context.Context, list models.ShoppingList) error
+	Delete(ctx context.Context, id int) error
+	// HasAccess reports whether the list is accessible to the user, it
+	// returns ErrNotFound if the list does not exist.
+	HasAccess(ctx context.Context, id, userID int) (bool, error)
+	HasStorage(ctx context.Context, userID, storageID int) (bool, error)
+	FindItems(ctx context.Context, id int) ([]models.ShoppingListItem, error)
+	FindItem(ctx context.Context, id, itemID int) (models.ShoppingListItem, error)
+	AddItem(ctx context.Context, id int, item models.ShoppingListItem) (models.ShoppingListItem, error)
+	UpdateItem(ctx context.Context, id int, item models.ShoppingListItem) error
+	DeleteItem(ctx context.Context, id, itemID int) error
+	CheckItem(
+		ctx context.Context,
+		id int,
+		item models.ShoppingListItem,
+		shelfLife *models.ShelfLife,
+	) error
+	FindStock(ctx context.Context, userID int, products []int) ([]models.ShelfLife, error)
+	AddItems(ctx context.Context, id int, items []models.ShoppingListItem) (int, error)
+	AddRestockItems(ctx context.Context, id, userID int) (int, error)
+}
+
+// accessible filters the lists owned by the user or shared with the user
+// through a storage, the $1 parameter is the id of the user.
+const accessible = `
+	(l.id_user = $1 OR EXISTS (
+		SELECT 1
+		FROM users_storages us
+		WHERE us.id_storage = l.id_storage AND us.id_user = $1
+	))
+`
+
+// upsertItem adds the quantity to the unchecked item of the same product and
+// measure or inserts a new item if there is no such item.
+const upsertItem = `
+	WITH updated AS (
+		UPDATE shopping_lists_items
+		SET quantity = quantity + $4
+		WHERE id_shopping_list = $1 AND
+			id_product = $2 AND
+			id_measure = $3 AND
+			NOT checked
+		RETURNING id
+	), inserted AS (
+		INSERT INTO shopping_lists_items
+			(id_shopping_list, id_product, id_measure, quantity, note)
+		SELECT $1::int, $2::int, $3::int, $4::real, $5::text
+		WHERE NOT EXISTS (SELECT 1 FROM updated)
+		RETURNING id
+	)
+	SELECT id FROM updated
+	UNION ALL
+	SELECT id FROM inserted
+`
+
+type shoppingListRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) ShoppingListRepositorer {
+	return &shoppingListRepository{
+		client: client,
+	}
+}
+
+// FindByID implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindByID(
+	ctx context.Context,
+	id int,
+) (models.ShoppingList, error) {
+	var (
+		query = `
+			SELECT l.id, l.name, l.id_user, COALESCE(l.id_storage, 0), COALESCE(s.name, ''), l.created_at
+			FROM shopping_lists l
+			LEFT JOIN storages s ON s.id = l.id_storage
+			WHERE l.id = $1 AND l.deleted_at IS NULL
+			LIMIT 1
+		`
+		list models.ShoppingList
+	)
+	err := r.client.QueryRow(ctx, query, id).Scan(
+		&list.ID, &list.Name, &list.User.ID, &list.Storage.ID, &list.Storage.Name, &list.CreatedAt,
+	)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return models.ShoppingList{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return models.ShoppingList{}, fmt.Errorf("failed to find shopping list: %w", err)
+	}
+	items, err := r.FindItems(ctx, id)
+	if err != nil {
+		return models.ShoppingList{}, err
+	}
+	list.Items = items
+	return list, nil
+}
+
+// FindMany implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindMany(
+	ctx context.Context,
+	filter models.ShoppingListFilter,
+) ([]models.ShoppingList, error) {
+	query := `
+		SELECT l.id, l.name, l.id_user, COALESCE(l.id_storage, 0), COALESCE(s.name, ''), l.created_at
+		FROM shopping_lists l
+		LEFT JOIN storages s ON s.id = l.id_storage
+		WHERE l.deleted_at IS NULL AND
+			l.name ILIKE $2 AND
+	` + accessible + `
+		ORDER BY l.created_at DESC
+		LIMIT $3
+		OFFSET $4
+	`
+	lists := make([]models.ShoppingList, 0, filter.Limit)
+	rows, err := r.client.Query(ctx, query, filter.UserID, "%"+filter.Name+"%", filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shopping lists: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var list models.ShoppingList
+		if err := rows.Scan(
+			&list.ID, &list.Name, &list.User.ID, &list.Storage.ID, &list.Storage.Name, &list.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// Count implements ShoppingListRepositorer
+func (r *shoppingListRepository) Count(
+	ctx context.Context,
+	filter models.ShoppingListFilter,
+) (int, error) {
+	var (
+		query = `
+			SELECT COUNT(*)
+			FROM shopping_lists l
+			WHERE l.deleted_at IS NULL AND
+				l.name ILIKE $2 AND
+		` + accessible
+		count int
+	)
+	if err := r.client.QueryRow(ctx, query, filter.UserID, "%"+filter.Name+"%").Scan(&count); err != nil {
+		return 0, errors.ErrFailedToCountModels.With(err)
+	}
+	return count, nil
+}
+
+// Create implements ShoppingListRepositorer
+func (r *shoppingListRepository) Create(ctx context.Context, list *models.ShoppingList) error {
+	query := `
+		INSERT INTO shopping_lists (id_user, id_storage, name)
+		VALUES ($1, NULLIF($2, 0), $3)
+		RETURNING id, created_at
+	`
+	if err := r.client.QueryRow(ctx, query, list.User.ID, list.Storage.ID, list.Name).
+		Scan(&list.ID, &list.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create shopping list: %w", err)
+	}
+	return nil
+}
+
+// Update implements ShoppingListRepositorer
+func (r *shoppingListRepository) Update(ctx context.Context, list models.ShoppingList) error {
+	query := `
+		UPDATE shopping_lists
+		SET name = $1,
+			id_storage = NULLIF($2, 0),
+			updated_at = NOW()
+		WHERE id = $3
+	`
+	if _, err := r.client.Exec(ctx, query, list.Name, list.Storage.ID, list.ID); err != nil {
+		return fmt.Errorf("failed to update shopping list: %w", err)
+	}
+	return nil
+}
+
+// Delete implements ShoppingListRepositorer
+func (r *shoppingListRepository) Delete(ctx context.Context, id int) error {
+	query := `
+		UPDATE shopping_lists
+		SET deleted_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete shopping list: %w", err)
+	}
+	return nil
+}
+
+// HasAccess implements ShoppingListRepositorer
+func (r *shoppingListRepository) HasAccess(ctx context.Context, id, userID int) (bool, error) {
+	var (
+		query = `
+			SELECT ` + accessible + `
+			FROM shopping_lists l
+			WHERE l.id = $2 AND l.deleted_at IS NULL
+		`
+		ok bool
+	)
+	err := r.client.QueryRow(ctx, query, userID, id).Scan(&ok)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check shopping list access: %w", err)
+	}
+	return ok, nil
+}
+
+// HasStorage implements ShoppingListRepositorer
+func (r *shoppingListRepository) HasStorage(ctx context.Context, userID, storageID int) (bool, error) {
+	return shelflife.HasStorages(ctx, r.client, userID, storageID)
+}
+
+// FindItems implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindItems(
+	ctx context.Context,
+	id int,
+) ([]models.ShoppingListItem, error) {
+	query := `
+		SELECT i.id, i.id_product, p.name, i.id_measure, m.name, i.quantity, i.checked, i.note
+		FROM shopping_lists_items i
+		JOIN products p ON p.id = i.id_product
+		JOIN measures m ON m.id = i.id_measure
+		WHERE i.id_shopping_list = $1
+		ORDER BY i.checked, p.name, i.id
+	`
+	items := make([]models.ShoppingListItem, 0)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shopping list items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.ShoppingListItem
+		if err := rows.Scan(
+			&item.ID, &item.Product.ID, &item.Product.Name, &item.Measure.ID, &item.Measure.Name,
+			&item.Quantity, &item.Checked, &item.Note,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// FindItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindItem(
+	ctx context.Context,
+	id, itemID int,
+) (models.ShoppingListItem, error) {
+	var (
+		query = `
+			SELECT i.id, i.id_product, p.name, i.id_measure, m.name, i.quantity, i.checked, i.note
+			FROM shopping_lists_items i
+			JOIN products p ON p.id = i.id_product
+			JOIN measures m ON m.id = i.id_measure
+			WHERE i.id_shopping_list = $1 AND i.id = $2
+			LIMIT 1
+		`
+		item models.ShoppingListItem
+	)
+	err := r.client.QueryRow(ctx, query, id, itemID).Scan(
+		&item.ID, &item.Product.ID, &item.Product.Name, &item.Measure.ID, &item.Measure.Name,
+		&item.Quantity, &item.Checked, &item.Note,
+	)
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		return models.ShoppingListItem{}, fmt.Errorf("%w: %d", ErrItemNotFound, itemID)
+	}
+	if err != nil {
+		return models.ShoppingListItem{}, fmt.Errorf("failed to find shopping list item: %w", err)
+	}
+	return item, nil
+}
+
+// AddItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) AddItem(
+	ctx context.Context,
+	id int,
+	item models.ShoppingListItem,
+) (models.ShoppingListItem, error) {
+	var itemID int
+	if err := r.client.QueryRow(
+		ctx, upsertItem, id, item.Product.ID, item.Measure.ID, item.Quantity, item.Note,
+	).Scan(&itemID); err != nil {
+		return models.ShoppingListItem{}, fmt.Errorf("failed to add shopping list item: %w", err)
+	}
+	return r.FindItem(ctx, id, itemID)
+}
+
+// UpdateItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) UpdateItem(
+	ctx context.Context,
+	id int,
+	item models.ShoppingListItem,
+) error {
+	query := `
+		UPDATE shopping_lists_items
+		SET id_measure = $1,
+			quantity = $2,
+			note = $3
+		WHERE id_shopping_list = $4 AND id = $5
+	`
+	if _, err := r.client.Exec(
+		ctx, query, item.Measure.ID, item.Quantity, item.Note, id, item.ID,
+	); err != nil {
+		return fmt.Errorf("failed to update shopping list item: %w", err)
+	}
+	return nil
+}
+
+// DeleteItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) DeleteItem(ctx context.Context, id, itemID int) error {
+	query := `
+		DELETE FROM shopping_lists_items
+		WHERE id_shopping_list = $1 AND id = $2
+	`
+	if _, err := r.client.Exec(ctx, query, id, itemID); err != nil {
+		return fmt.Errorf("failed to delete shopping list item: %w", err)
+	}
+	return nil
+}
+
+// CheckItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) CheckItem(
+	ctx context.Context,
+	id int,
+	item models.ShoppingListItem,
+	shelfLife *models.ShelfLife,
+) error {
+	var (
+		check = `
+			UPDATE shopping_lists_items
+			SET checked = $1
+			WHERE id_shopping_list = $2 AND id = $3
+		`
+		createShelfLife = `
+			INSERT INTO shelf_lives
+				(id_user, id_product, id_storage, id_measure, quantity, purchase_date, end_date)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, check, item.Checked, id, item.ID); err != nil {
+		return fmt.Errorf("failed to check shopping list item: %w", err)
+	}
+	if shelfLife != nil {
+		if _, err := tx.Exec(ctx, createShelfLife,
+			shelfLife.User.ID, shelfLife.Product.ID, shelfLife.Storage.ID, shelfLife.Measure.ID,
+			shelfLife.Quantity, shelfLife.PurchaseDate, shelfLife.EndDate,
+		); err != nil {
+			return errors.ErrFailedToInsertShelfLife.With(err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindStock implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindStock(
+	ctx context.Context,
+	userID int,
+	products []int,
+) ([]models.ShelfLife, error) {
+	// the stock is the not expired shelf lives of the products in the
+	// storages of the user
+	query := `
+		SELECT sl.id, sl.id_product, sl.id_measure, m.dimension, m.factor, sl.quantity
+		FROM shelf_lives sl
+		JOIN measures m ON m.id = sl.id_measure
+		WHERE sl.id_product = ANY($2) AND
+			sl.deleted_at IS NULL AND
+			sl.end_date >= CURRENT_DATE AND
+			(sl.id_user = $1 OR sl.id_storage IN (
+				SELECT id_storage
+				FROM users_storages
+				WHERE id_user = $1
+			))
+		ORDER BY sl.end_date, sl.id
+	`
+	rows, err := r.client.Query(ctx, query, userID, products)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stock: %w", err)
+	}
+	defer rows.Close()
+	stock := make([]models.ShelfLife, 0)
+	for rows.Next() {
+		var shelfLife models.ShelfLife
+		if err := rows.Scan(
+			&shelfLife.ID, &shelfLife.Product.ID, &shelfLife.Measure.ID,
+			&shelfLife.Measure.Dimension, &shelfLife.Measure.Factor, &shelfLife.Quantity,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stock: %w", err)
+		}
+		stock = append(stock, shelfLife)
+	}
+	return stock, nil
+}
+
+// AddItems implements ShoppingListRepositorer
+func (r *shoppingListRepository) AddItems(
+	ctx context.Context,
+	id int,
+	items []models.ShoppingListItem,
+) (int, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return 0, errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := upsertItems(ctx, tx, id, items); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return len(items), nil
+}
+
+// AddRestockItems implements ShoppingListRepositorer
+func (r *shoppingListRepository) AddRestockItems(
+	ctx context.Context,
+	id, userID int,
+) (int, error) {
+	// the flag is reset so the same shelf lives are not added twice
+	query := `
+		WITH flagged AS (
+			UPDATE shelf_lives
+			SET restock = FALSE,
+				updated_at = NOW()
+			WHERE id_user = $1 AND
+				restock AND
+				(deleted_at IS NOT NULL OR end_date < CURRENT_DATE)
+			RETURNING id_product, id_measure, quantity
+		)
+		SELECT id_product, id_measure, SUM(quantity)
+		FROM flagged
+		GROUP BY id_product, id_measure
+	`
+	return r.addItems(ctx, id, query, userID)
+}
+
+// addItems adds the items selected by the query as (id_product, id_measure,
+// quantity) rows to the list in a single transaction. It returns the number
+// of added items.
+func (r *shoppingListRepository) addItems(
+	ctx context.Context,
+	id int,
+	query string,
+	args ...any,
+) (int, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return 0, errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find shopping list items: %w", err)
+	}
+	items := make([]models.ShoppingListItem, 0)
+	for rows.Next() {
+		var item models.ShoppingListItem
+		if err := rows.Scan(&item.Product.ID, &item.Measure.ID, &item.Quantity); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan shopping list item: %w", err)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find shopping list items: %w", err)
+	}
+	if err := upsertItems(ctx, tx, id, items); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return len(items), nil
+}
+
+// upsertItems adds the items to the list within the transaction.
+func upsertItems(
+	ctx context.Context,
+	tx pgx.Tx,
+	id int,
+	items []models.ShoppingListItem,
+) error {
+	for _, item := range items {
+		if _, err := tx.Exec(
+			ctx, upsertItem, id, item.Product.ID, item.Measure.ID, item.Quantity, item.Note,
+		); err != nil {
+			return fmt.Errorf("failed to add shopping list item: %w", err)
+		}
+	}
+	return nil
+}
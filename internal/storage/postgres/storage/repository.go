@@ -6,6 +6,7 @@ import (
 
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 )
 
 type StorageRepositorer interface {
@@ -18,7 +19,8 @@ type StorageRepositorer interface {
 	CreateTip(ctx context.Context, id, tipID int) (models.Tip, error)
 	DeleteTip(ctx context.Context, id, tipID int) error
 	FindTips(ctx context.Context, id int) ([]models.Tip, error)
-	FindShelfLives(ctx context.Context, id int) ([]models.ShelfLife, error)
+	FindShelfLives(ctx context.Context, id int, filter models.ShelfLifeFilter) ([]models.ShelfLife, error)
+	CountShelfLives(ctx context.Context, id int, filter models.ShelfLifeFilter) (int, error)
 	Count(ctx context.Context, filter models.StorageFilter) (int, error)
 }
 
@@ -29,19 +31,24 @@ type storageRepository struct {
 func (r *storageRepository) FindShelfLives(
 	ctx context.Context,
 	id int,
+	filter models.ShelfLifeFilter,
 ) ([]models.ShelfLife, error) {
+	filter.StorageID = id
 	var (
-		query = `
+		where, args = shelflife.Where(filter, filter.Limit, filter.Offset)
+		query       = `
 			SELECT sl.id, p.id, p.name, m.id, m.name, sl.quantity, sl.purchase_date, sl.end_date
 			FROM shelf_lives sl
 			JOIN products p ON p.id = sl.id_product
 			JOIN measures m ON m.id = sl.id_measure
-			WHERE sl.deleted_at IS NULL AND sl.id_storage = $1
-			ORDER BY sl.end_date DESC, sl.purchase_date DESC
+		` + where + `
+		` + shelflife.OrderBy(filter.Sort, "sl.end_date DESC, sl.purchase_date DESC") + `
+			LIMIT $1
+			OFFSET $2
 		`
-		result []models.ShelfLife
+		result = make([]models.ShelfLife, 0, filter.Limit)
 	)
-	rows, err := r.client.Query(ctx, query, id)
+	rows, err := r.client.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("find shelf lives: %w", err)
 	}
@@ -65,6 +72,25 @@ func (r *storageRepository) FindShelfLives(
 	return result, nil
 }
 
+func (r *storageRepository) CountShelfLives(
+	ctx context.Context,
+	id int,
+	filter models.ShelfLifeFilter,
+) (int, error) {
+	filter.StorageID = id
+	var (
+		where, args = shelflife.Where(filter)
+		query       = `
+			SELECT COUNT(*) FROM shelf_lives sl
+		` + where
+		count int
+	)
+	if err := r.client.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count shelf lives: %w", err)
+	}
+	return count, nil
+}
+
 func (r *storageRepository) Count(ctx context.Context, filter models.StorageFilter) (int, error) {
 	var (
 		query = `
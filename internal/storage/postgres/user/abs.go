@@ -20,6 +20,9 @@ type UserStorage interface {
 	UserVaultStorage
 	UserShelfLifeStorage
 	UserSettingStorage
+	UserRecipeStorage
+	UserNutritionStorage
+	UserFeedStorage
 }
 
 type UserPasswordStorage interface {
@@ -44,8 +47,38 @@ type UserSettingStorage interface {
 type UserShelfLifeStorage interface {
 	CreateShelfLife(ctx context.Context, userId int, model models.ShelfLife) (models.ShelfLife, error)
 	FindShelfLife(ctx context.Context, userId int, shelfLifeId int) (models.ShelfLife, error)
-	FindShelfLives(ctx context.Context, userId int) ([]models.ShelfLife, error)
+	FindShelfLives(ctx context.Context, userId int, filter models.ShelfLifeFilter) ([]models.ShelfLife, error)
+	CountShelfLives(ctx context.Context, userId int, filter models.ShelfLifeFilter) (int, error)
 	UpdateShelfLife(ctx context.Context, userId int, model models.ShelfLife) (models.ShelfLife, error)
 	DeleteShelfLife(ctx context.Context, userId int, shelfLifeId int) error
 	RestoreShelfLife(ctx context.Context, userId int, shelfLifeId int) (models.ShelfLife, error)
+	ConsumeShelfLife(
+		ctx context.Context,
+		userId int,
+		shelfLifeId int,
+		quantity float64,
+	) (models.Consumption, error)
+}
+
+type UserRecipeStorage interface {
+	FindRecipeSuggestions(
+		ctx context.Context,
+		userId int,
+		filter models.RecipeSuggestionFilter,
+	) ([]models.RecipeSuggestion, int, error)
+}
+
+type UserNutritionStorage interface {
+	FindConsumptions(
+		ctx context.Context,
+		userId int,
+		filter models.ConsumptionFilter,
+	) ([]models.Consumption, error)
+}
+
+// UserFeedStorage keeps the versions of the feed tokens of the users, the
+// tokens of the previous versions are revoked.
+type UserFeedStorage interface {
+	FindFeedVersion(ctx context.Context, id int) (int, error)
+	ResetFeedVersion(ctx context.Context, id int) (int, error)
 }
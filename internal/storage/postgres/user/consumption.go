@@ -0,0 +1,69 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ErrNothingToConsume is returned when the user has no shelf life with the ID
+// or it is consumed already.
+var ErrNothingToConsume = errors.New("nothing to consume")
+
+// ConsumeShelfLife implements UserRepositorer
+func (s *userStorage) ConsumeShelfLife(
+	ctx context.Context,
+	id int,
+	shelfLifeId int,
+	quantity float64,
+) (models.Consumption, error) {
+	var consumption models.Consumption
+	err := s.c.QueryRow(ctx, consumeShelfLife, id, shelfLifeId, quantity).Scan(
+		&consumption.ID, &consumption.ShelfLife.ID, &consumption.Quantity,
+		&consumption.ConsumedAt, &consumption.ShelfLife.Quantity,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Consumption{}, fmt.Errorf("%w: shelf life %d", ErrNothingToConsume, shelfLifeId)
+	}
+	if err != nil {
+		return models.Consumption{}, fmt.Errorf("failed to consume shelf life: %w", err)
+	}
+	return consumption, nil
+}
+
+// FindConsumptions implements UserRepositorer
+func (s *userStorage) FindConsumptions(
+	ctx context.Context,
+	id int,
+	filter models.ConsumptionFilter,
+) ([]models.Consumption, error) {
+	rows, err := s.c.Query(ctx, findConsumptions, id, filter.From, filter.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consumptions: %w", err)
+	}
+	defer rows.Close()
+	consumptions := make([]models.Consumption, 0)
+	for rows.Next() {
+		var (
+			consumption models.Consumption
+			product     = &consumption.ShelfLife.Product
+			measure     = &consumption.ShelfLife.Measure
+		)
+		if err := rows.Scan(
+			&consumption.ID, &consumption.Quantity, &consumption.ConsumedAt,
+			&consumption.ShelfLife.ID, &product.ID, &product.Name, &product.Density,
+			&product.PieceWeight, &product.Nutrition, &measure.ID, &measure.Name,
+			&measure.Dimension, &measure.Factor,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan consumption: %w", err)
+		}
+		consumptions = append(consumptions, consumption)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query consumptions: %w", err)
+	}
+	return consumptions, nil
+}
@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoFeed is returned when the user of the feeds does not exist or is
+// deleted.
+var ErrNoFeed = errors.New("feed not found")
+
+// FindFeedVersion implements UserRepositorer
+func (s *userStorage) FindFeedVersion(ctx context.Context, id int) (int, error) {
+	var version int
+	err := s.c.QueryRow(ctx, findFeedVersion, id).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user %d", ErrNoFeed, id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query feed version: %w", err)
+	}
+	return version, nil
+}
+
+// ResetFeedVersion implements UserRepositorer
+func (s *userStorage) ResetFeedVersion(ctx context.Context, id int) (int, error) {
+	var version int
+	err := s.c.QueryRow(ctx, resetFeedVersion, id).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%w: user %d", ErrNoFeed, id)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset feed version: %w", err)
+	}
+	return version, nil
+}
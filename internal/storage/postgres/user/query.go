@@ -32,7 +32,7 @@ const (
 	findShelfLife = `
 		SELECT 
 			sl.id, sl.id_product, sl.id_storage, sl.id_measure, 
-			sl.quantity, sl.purchase_date, sl.end_date,
+			sl.quantity, sl.purchase_date, sl.end_date, sl.restock,
 			p.name, s.name, m.name
 		FROM shelf_lives sl
 		JOIN products p ON sl.id_product = p.id
@@ -52,9 +52,10 @@ const (
 		JOIN products p ON sl.id_product = p.id
 		JOIN storages s ON sl.id_storage = s.id
 		JOIN measures m ON sl.id_measure = m.id
-		WHERE sl.id_user = $1 AND 
-			sl.deleted_at IS NULL
-		ORDER BY sl.end_date DESC
+	`
+	countShelfLives = `
+		SELECT COUNT(*)
+		FROM shelf_lives sl
 	`
 	restoreShelfLife = `
 		WITH updated AS (
@@ -85,20 +86,21 @@ const (
 				quantity = $6,
 				purchase_date = $7,
 				end_date = $8,
+				restock = $9,
 				updated_at = NOW()
 			WHERE id_user = $1 AND id = $2
-			RETURNING id_product, id_storage, id_measure, quantity, purchase_date, end_date
+			RETURNING id_product, id_storage, id_measure, quantity, purchase_date, end_date, restock
 		)
 		SELECT 
 			u.id_product, u.id_storage, u.id_measure, 
-			u.quantity, u.purchase_date, u.end_date,
+			u.quantity, u.purchase_date, u.end_date, u.restock,
 			p.name, s.name, m.name
 		FROM updated u
 		JOIN products p ON u.id_product = p.id
 		JOIN storages s ON u.id_storage = s.id
 		JOIN measures m ON u.id_measure = m.id
 		WHERE p.deleted_at IS NULL AND
-			s.deleted_at IS NULL AND
+			s.deleted_at IS NULL
 		LIMIT 1
 	`
 	addVault = `
@@ -201,4 +203,132 @@ const (
 		JOIN settings_categories sc ON s.id_category = sc.id
 		WHERE us.id_user = $1
 	`
+	// consumeShelfLife records the consumption of the shelf life and decreases
+	// its quantity, the shelf life is deleted once it is consumed completely.
+	// The consumed quantity is capped by the quantity left.
+	consumeShelfLife = `
+		WITH locked AS (
+			SELECT id, LEAST(quantity, $3) AS quantity
+			FROM shelf_lives
+			WHERE id_user = $1 AND id = $2 AND deleted_at IS NULL AND quantity > 0
+			FOR UPDATE
+		), consumed AS (
+			INSERT INTO shelf_lives_consumptions (id_shelf_life, quantity)
+			SELECT id, quantity FROM locked
+			RETURNING id, id_shelf_life, quantity, consumed_at
+		), updated AS (
+			UPDATE shelf_lives sl
+			SET quantity = sl.quantity - c.quantity,
+				deleted_at = CASE WHEN sl.quantity - c.quantity <= 0 THEN NOW() END,
+				updated_at = NOW()
+			FROM consumed c
+			WHERE sl.id = c.id_shelf_life
+			RETURNING sl.quantity
+		)
+		SELECT c.id, c.id_shelf_life, c.quantity, c.consumed_at, u.quantity
+		FROM consumed c, updated u
+	`
+	// findConsumptions selects the consumptions of the user between the dates
+	// inclusive with everything needed to convert the quantity to grams.
+	findConsumptions = `
+		SELECT c.id, c.quantity, c.consumed_at, sl.id,
+			p.id, p.name, p.density, p.piece_weight, p.nutrition,
+			m.id, m.name, m.dimension, m.factor
+		FROM shelf_lives_consumptions c
+		JOIN shelf_lives sl ON sl.id = c.id_shelf_life
+		JOIN products p ON p.id = sl.id_product
+		JOIN measures m ON m.id = sl.id_measure
+		WHERE sl.id_user = $1 AND
+			c.consumed_at >= $2::date AND
+			c.consumed_at < $3::date + 1
+		ORDER BY c.consumed_at
+	`
+	// findRecipeSuggestions ranks the recipes by the number of ingredients
+	// covered by the user's not expired shelf lives and by how soon the covering
+	// shelf lives expire. The stock is converted to the measure of the
+	// ingredient the same way as measure.Convert does: through the base unit of
+	// the dimension, or through grams by the density or the piece weight of the
+	// product. The missing ingredients are aggregated into JSON. Only the
+	// published recipes and the recipes of the user are suggested.
+	findRecipeSuggestions = `
+		WITH stock AS (
+			SELECT sl.id_product, m.dimension,
+				SUM(sl.quantity * m.factor) AS quantity,
+				MIN(sl.end_date) AS end_date
+			FROM shelf_lives sl
+			JOIN measures m ON m.id = sl.id_measure
+			WHERE sl.id_user = $1 AND
+				sl.deleted_at IS NULL AND
+				sl.end_date >= CURRENT_DATE
+			GROUP BY sl.id_product, m.dimension
+		), converted AS (
+			SELECT prm.id_recipe, prm.id_product, prm.id_measure, prm.quantity, st.end_date,
+				CASE
+					WHEN st.dimension = m.dimension THEN st.quantity / NULLIF(m.factor, 0)
+					ELSE st.quantity * (CASE st.dimension
+						WHEN 'mass' THEN 1
+						WHEN 'volume' THEN p.density
+						WHEN 'count' THEN p.piece_weight
+					END) / NULLIF(m.factor * (CASE m.dimension
+						WHEN 'mass' THEN 1
+						WHEN 'volume' THEN p.density
+						WHEN 'count' THEN p.piece_weight
+					END), 0)
+				END AS stocked
+			FROM products_recipes_measures prm
+			JOIN measures m ON m.id = prm.id_measure
+			JOIN products p ON p.id = prm.id_product
+			LEFT JOIN stock st ON st.id_product = prm.id_product
+		), ingredients AS (
+			SELECT id_recipe, id_product, id_measure,
+				COALESCE(SUM(stocked), 0) >= quantity AS available,
+				GREATEST(quantity - COALESCE(SUM(stocked), 0), 0)::real AS missing,
+				MIN(end_date) FILTER (WHERE stocked IS NOT NULL) AS end_date
+			FROM converted
+			GROUP BY id_recipe, id_product, id_measure, quantity
+		)
+		SELECT r.id, r.name, r.description,
+			COUNT(*) FILTER (WHERE i.available) AS matched,
+			COUNT(*) AS total,
+			MIN(i.end_date) FILTER (WHERE i.available) AS expires_at,
+			COALESCE(
+				SUM(1.0 / (1 + (i.end_date::date - CURRENT_DATE))) FILTER (WHERE i.available),
+				0
+			)::float8 AS urgency,
+			COALESCE(
+				json_agg(json_build_object(
+					'id_product', p.id, 'product', p.name,
+					'id_measure', m.id, 'measure', m.name,
+					'quantity', i.missing
+				) ORDER BY p.name) FILTER (WHERE NOT i.available),
+				'[]'
+			) AS missing,
+			COUNT(*) OVER () AS count
+		FROM recipes r
+		JOIN ingredients i ON i.id_recipe = r.id
+		JOIN products p ON p.id = i.id_product
+		JOIN measures m ON m.id = i.id_measure
+		WHERE r.deleted_at IS NULL AND
+			(r.status = 'published' OR r.id_user = $1)
+		GROUP BY r.id, r.name, r.description
+		HAVING COUNT(*) FILTER (WHERE i.available) > 0 AND
+			($4 < 0 OR COUNT(*) FILTER (WHERE NOT i.available) <= $4)
+		ORDER BY matched DESC,
+			total - matched ASC,
+			urgency DESC,
+			r.id ASC
+		LIMIT $2
+		OFFSET $3
+	`
+	findFeedVersion = `
+		SELECT feed_version
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	resetFeedVersion = `
+		UPDATE users
+		SET feed_version = feed_version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING feed_version
+	`
 )
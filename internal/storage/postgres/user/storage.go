@@ -8,6 +8,7 @@ import (
 	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 )
 
 type userStorage struct {
@@ -52,7 +53,7 @@ func (s *userStorage) FindShelfLife(ctx context.Context, id int, shelfLifeId int
 	if err := s.c.QueryRow(ctx, findShelfLife, id, shelfLifeId).Scan(
 		&shelfLife.ID, &shelfLife.Product.ID, &shelfLife.Storage.ID,
 		&shelfLife.Measure.ID, &shelfLife.Quantity, &shelfLife.PurchaseDate,
-		&shelfLife.EndDate, &shelfLife.Product.Name, &shelfLife.Storage.Name,
+		&shelfLife.EndDate, &shelfLife.Restock, &shelfLife.Product.Name, &shelfLife.Storage.Name,
 		&shelfLife.Measure.Name,
 	); err != nil {
 		return models.ShelfLife{}, errors.ErrFailedToSelectShelfLife.With(err)
@@ -61,9 +62,17 @@ func (s *userStorage) FindShelfLife(ctx context.Context, id int, shelfLifeId int
 }
 
 // FindShelfLives implements UserRepositorer
-func (s *userStorage) FindShelfLives(ctx context.Context, id int) ([]models.ShelfLife, error) {
-	shelfLives := make([]models.ShelfLife, 0)
-	rows, err := s.c.Query(ctx, findShelfLives, id)
+func (s *userStorage) FindShelfLives(
+	ctx context.Context,
+	id int,
+	filter models.ShelfLifeFilter,
+) ([]models.ShelfLife, error) {
+	filter.UserID = id
+	where, args := shelflife.Where(filter, filter.Limit, filter.Offset)
+	query := findShelfLives + where + "\n" +
+		shelflife.OrderBy(filter.Sort, "sl.end_date DESC") + "\nLIMIT $1 OFFSET $2"
+	shelfLives := make([]models.ShelfLife, 0, filter.Limit)
+	rows, err := s.c.Query(ctx, query, args...)
 	if err != nil {
 		return nil, errors.ErrFailedToSelectShelfLives.With(err)
 	}
@@ -83,6 +92,21 @@ func (s *userStorage) FindShelfLives(ctx context.Context, id int) ([]models.Shel
 	return shelfLives, nil
 }
 
+// CountShelfLives implements UserRepositorer
+func (s *userStorage) CountShelfLives(
+	ctx context.Context,
+	id int,
+	filter models.ShelfLifeFilter,
+) (int, error) {
+	filter.UserID = id
+	where, args := shelflife.Where(filter)
+	count := 0
+	if err := s.c.QueryRow(ctx, countShelfLives+where, args...).Scan(&count); err != nil {
+		return 0, errors.ErrFailedToCountModels.With(err)
+	}
+	return count, nil
+}
+
 // RestoreShelfLife implements UserRepositorer
 func (s *userStorage) RestoreShelfLife(ctx context.Context, id, shelfLifeId int) (models.ShelfLife, error) {
 	shelfLife := models.ShelfLife{}
@@ -107,7 +131,7 @@ func (s *userStorage) RestoreShelfLife(ctx context.Context, id, shelfLifeId int)
 // UpdateShelfLife implements UserRepositorer
 func (s *userStorage) UpdateShelfLife(ctx context.Context, id int, params models.ShelfLife,
 ) (models.ShelfLife, error) {
-	if err := s.c.QueryRow(ctx, updateShelfLife, id, params.ID, params.Product.ID, params.Storage.ID, params.Measure.ID, params.Quantity, params.PurchaseDate, params.EndDate).
+	if err := s.c.QueryRow(ctx, updateShelfLife, id, params.ID, params.Product.ID, params.Storage.ID, params.Measure.ID, params.Quantity, params.PurchaseDate, params.EndDate, params.Restock).
 		Scan(
 			&params.Product.ID,
 			&params.Storage.ID,
@@ -115,6 +139,7 @@ func (s *userStorage) UpdateShelfLife(ctx context.Context, id int, params models
 			&params.Quantity,
 			&params.PurchaseDate,
 			&params.EndDate,
+			&params.Restock,
 			&params.Product.Name,
 			&params.Storage.Name,
 			&params.Measure.Name,
@@ -342,3 +367,43 @@ func (repo *userStorage) Restore(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+// FindRecipeSuggestions implements UserRepositorer
+func (s *userStorage) FindRecipeSuggestions(
+	ctx context.Context,
+	id int,
+	filter models.RecipeSuggestionFilter,
+) ([]models.RecipeSuggestion, int, error) {
+	rows, err := s.c.Query(
+		ctx, findRecipeSuggestions, id, filter.Limit, filter.Offset, filter.MaxMissing,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query recipe suggestions: %w", err)
+	}
+	defer rows.Close()
+	var (
+		suggestions = make([]models.RecipeSuggestion, 0, filter.Limit)
+		count       int
+	)
+	for rows.Next() {
+		var (
+			suggestion models.RecipeSuggestion
+			missing    []byte
+		)
+		if err := rows.Scan(
+			&suggestion.Recipe.ID, &suggestion.Recipe.Name, &suggestion.Recipe.Description,
+			&suggestion.Matched, &suggestion.Total, &suggestion.ExpiresAt, &suggestion.Urgency,
+			&missing, &count,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan recipe suggestion: %w", err)
+		}
+		if suggestion.Missing, err = missingIngredients(missing); err != nil {
+			return nil, 0, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to query recipe suggestions: %w", err)
+	}
+	return suggestions, count, nil
+}
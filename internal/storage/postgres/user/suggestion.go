@@ -0,0 +1,36 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// missingIngredient is the missing ingredient aggregated into JSON by
+// findRecipeSuggestions.
+type missingIngredient struct {
+	ProductID   int     `json:"id_product"`
+	ProductName string  `json:"product"`
+	MeasureID   int     `json:"id_measure"`
+	MeasureName string  `json:"measure"`
+	Quantity    float32 `json:"quantity"`
+}
+
+// missingIngredients decodes the JSON array of the missing ingredients of a
+// suggestion.
+func missingIngredients(data []byte) ([]models.MissingIngredient, error) {
+	var ingredients []missingIngredient
+	if err := json.Unmarshal(data, &ingredients); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal missing ingredients: %w", err)
+	}
+	result := make([]models.MissingIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		result[i] = models.MissingIngredient{
+			Product:  models.Product{ID: ingredient.ProductID, Name: ingredient.ProductName},
+			Measure:  models.Measure{ID: ingredient.MeasureID, Name: ingredient.MeasureName},
+			Quantity: ingredient.Quantity,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package user
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MissingIngredients(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected []models.MissingIngredient
+		err      bool
+	}{
+		{
+			name:     "none missing",
+			data:     `[]`,
+			expected: []models.MissingIngredient{},
+		},
+		{
+			name: "missing",
+			data: `[
+				{"id_product": 2, "product": "Flour", "id_measure": 1, "measure": "g", "quantity": 150.5},
+				{"id_product": 5, "product": "Milk", "id_measure": 3, "measure": "ml", "quantity": 200}
+			]`,
+			expected: []models.MissingIngredient{
+				{
+					Product:  models.Product{ID: 2, Name: "Flour"},
+					Measure:  models.Measure{ID: 1, Name: "g"},
+					Quantity: 150.5,
+				},
+				{
+					Product:  models.Product{ID: 5, Name: "Milk"},
+					Measure:  models.Measure{ID: 3, Name: "ml"},
+					Quantity: 200,
+				},
+			},
+		},
+		{
+			name: "invalid",
+			data: `{"id_product": 2}`,
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := missingIngredients([]byte(tc.data))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+// suggestionTables are the tables of findRecipeSuggestions created as the
+// temporary tables, they shadow the tables of the database for the session.
+// The end dates are the timestamps as the API exposes them.
+const suggestionTables = `
+	CREATE TEMP TABLE measures (
+		id int PRIMARY KEY, name text, dimension text, factor real
+	);
+	CREATE TEMP TABLE products (
+		id int PRIMARY KEY, name text, density real, piece_weight real
+	);
+	CREATE TEMP TABLE recipes (
+		id int PRIMARY KEY, name text, description text, id_user int,
+		status text DEFAULT 'published', deleted_at timestamp
+	);
+	CREATE TEMP TABLE products_recipes_measures (
+		id_recipe int, id_product int, id_measure int, quantity real
+	);
+	CREATE TEMP TABLE shelf_lives (
+		id serial PRIMARY KEY, id_user int, id_product int, id_measure int,
+		quantity real, end_date timestamp, deleted_at timestamp
+	);
+	INSERT INTO measures VALUES (1, 'g', 'mass', 1), (2, 'kg', 'mass', 1000);
+	INSERT INTO products VALUES
+		(1, 'Eggs', NULL, NULL), (2, 'Flour', NULL, NULL),
+		(3, 'Milk', NULL, NULL), (4, 'Salt', NULL, NULL);
+	INSERT INTO recipes (id, name, description, id_user, status) VALUES
+		(1, 'Crepes', '', 2, 'published'),
+		(2, 'Omelette', '', 2, 'published'),
+		(3, 'Boiled eggs', '', 2, 'published'),
+		(4, 'Porridge', '', 2, 'published'),
+		(5, 'Salted flour', '', 2, 'published'),
+		(6, 'Draft', '', 2, 'pending');
+	INSERT INTO products_recipes_measures VALUES
+		(1, 1, 1, 100), (1, 2, 1, 200), (1, 3, 1, 300),
+		(2, 1, 1, 100), (2, 3, 1, 100),
+		(3, 1, 1, 100),
+		(4, 3, 1, 100),
+		(5, 2, 1, 100), (5, 4, 1, 10),
+		(6, 1, 1, 100);
+	INSERT INTO shelf_lives (id_user, id_product, id_measure, quantity, end_date) VALUES
+		(1, 1, 2, 1, CURRENT_DATE + interval '1 day 12 hours'),
+		(1, 3, 1, 100, CURRENT_DATE + interval '10 days'),
+		(1, 2, 1, 50, CURRENT_DATE + interval '5 days'),
+		(1, 4, 1, 10, CURRENT_DATE - interval '1 day');
+`
+
+// Test_FindRecipeSuggestions runs the ranking query on the database of
+// TEST_DATABASE_URL, it is skipped if the variable is not set.
+func Test_FindRecipeSuggestions(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, url)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, suggestionTables)
+	require.NoError(t, err)
+	repo := New(conn)
+
+	testCases := []struct {
+		name       string
+		maxMissing int
+		expected   []string
+	}{
+		{
+			// the omelette matches the most ingredients, the eggs expire
+			// sooner than the milk of the porridge, the crepes lack the flour
+			// and the milk, the salt of the salted flour is expired and the
+			// draft is not published
+			name:       "ranked",
+			maxMissing: -1,
+			expected:   []string{"Omelette", "Boiled eggs", "Porridge", "Crepes"},
+		},
+		{
+			name:       "max missing",
+			maxMissing: 0,
+			expected:   []string{"Omelette", "Boiled eggs", "Porridge"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter := models.RecipeSuggestionFilter{
+				PageFilter: models.PageFilter{Limit: 10},
+				MaxMissing: tc.maxMissing,
+			}
+			result, count, err := repo.FindRecipeSuggestions(ctx, 1, filter)
+			require.NoError(t, err)
+			names := make([]string, len(result))
+			for i, suggestion := range result {
+				names[i] = suggestion.Recipe.Name
+			}
+			assert.Equal(t, tc.expected, names)
+			assert.Equal(t, len(tc.expected), count)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Package job stores the detection jobs and their queue in Redis. A job is
+// moved from the queue to the processing list while a worker runs it, so
+// the jobs of a stopped worker are not lost and are queued again. The worker
+// holds the lease of the job, a key expiring unless the worker renews it.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/romankravchuk/muerta/internal/storage/redis"
+)
+
+// ErrNotFound is returned when the job does not exist or is expired.
+var ErrNotFound = errors.New("job not found")
+
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Job is a detection of the dates of an image uploaded by a user.
+type Job struct {
+	ID     string `json:"id"`
+	UserID int    `json:"user_id"`
+	Status Status `json:"status"`
+	// Consent allows to keep the image in the dataset of the detections
+	Consent bool `json:"consent,omitempty"`
+	// Attempts is the number of the runs of the job
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+	// Result is the JSON of the detected dates of the done job
+	Result    json.RawMessage `json:"result,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+const (
+	queueKey      = "detector:jobs:queued"
+	processingKey = "detector:jobs:processing"
+)
+
+func jobKey(id string) string   { return "detector:job:" + id }
+func imageKey(id string) string { return "detector:job:" + id + ":image" }
+func leaseKey(id string) string { return "detector:job:" + id + ":lease" }
+
+var (
+	// take moves the first queued job to the processing list and sets its
+	// lease at once
+	take = goredis.NewScript(`
+		local id = redis.call('LMOVE', KEYS[1], KEYS[2], 'LEFT', 'RIGHT')
+		if not id then
+			return false
+		end
+		redis.call('SET', ARGV[1] .. id .. ARGV[2], 1, 'PX', ARGV[3])
+		return id
+	`)
+	// requeue queues again the processing job whose lease is expired. The
+	// finished and the expired jobs are only removed from the processing list.
+	requeue = goredis.NewScript(`
+		if redis.call('EXISTS', KEYS[4]) == 1 then
+			return 0
+		end
+		if redis.call('LREM', KEYS[1], 0, ARGV[1]) == 0 then
+			return 0
+		end
+		local data = redis.call('GET', KEYS[3])
+		if not data then
+			return 0
+		end
+		local job = cjson.decode(data)
+		if job.status == 'done' or job.status == 'failed' then
+			return 0
+		end
+		job.status, job.updated_at = 'queued', ARGV[2]
+		redis.call('SET', KEYS[3], cjson.encode(job))
+		redis.call('RPUSH', KEYS[2], ARGV[1])
+		return 1
+	`)
+)
+
+type JobRepositorer interface {
+	// Create stores the job with its image and queues it.
+	Create(ctx context.Context, job *Job, image []byte) error
+	// FindByID returns the job.
+	FindByID(ctx context.Context, id string) (*Job, error)
+	// FindImage returns the image of the job which is not finished.
+	FindImage(ctx context.Context, id string) ([]byte, error)
+	// Next moves the first queued job to the processing list, sets its lease
+	// and returns its ID. It waits for a job up to the timeout and returns an
+	// empty ID if there is none.
+	Next(ctx context.Context, timeout, lease time.Duration) (string, error)
+	// Renew extends the lease of the job being processed. The expired lease
+	// is not renewed, the job may be queued again already.
+	Renew(ctx context.Context, id string, lease time.Duration) error
+	// Update saves the job being processed.
+	Update(ctx context.Context, job *Job) error
+	// Retry saves the job, releases its lease and moves it from the
+	// processing list back to the queue.
+	Retry(ctx context.Context, job *Job) error
+	// Finish saves the finished job which expires after the ttl, deletes its
+	// image and its lease and removes it from the processing list.
+	Finish(ctx context.Context, job *Job, ttl time.Duration) error
+	// Recover queues again the processing jobs whose lease is expired, their
+	// workers are stopped. It returns the number of the jobs.
+	Recover(ctx context.Context) (int, error)
+}
+
+type jobRepository struct {
+	client redis.Client
+}
+
+func New(client redis.Client) JobRepositorer {
+	return &jobRepository{client: client}
+}
+
+// Create implements JobRepositorer
+func (r *jobRepository) Create(ctx context.Context, job *Job, image []byte) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if _, err := r.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, imageKey(job.ID), image, 0)
+		pipe.Set(ctx, jobKey(job.ID), data, 0)
+		pipe.RPush(ctx, queueKey, job.ID)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	return nil
+}
+
+// FindByID implements JobRepositorer
+func (r *jobRepository) FindByID(ctx context.Context, id string) (*Job, error) {
+	data, err := r.client.Get(ctx, jobKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	job := new(Job)
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+	return job, nil
+}
+
+// FindImage implements JobRepositorer
+func (r *jobRepository) FindImage(ctx context.Context, id string) ([]byte, error) {
+	image, err := r.client.Get(ctx, imageKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image: %w", err)
+	}
+	return image, nil
+}
+
+// Next implements JobRepositorer
+func (r *jobRepository) Next(ctx context.Context, timeout, lease time.Duration) (string, error) {
+	// the job is moved within the queue only to wait for it, the other worker
+	// may take it first
+	err := r.client.BLMove(ctx, queueKey, queueKey, "LEFT", "LEFT", timeout).Err()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for job: %w", err)
+	}
+	id, err := take.Run(
+		ctx, r.client, []string{queueKey, processingKey},
+		jobKey(""), ":lease", lease.Milliseconds(),
+	).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to move job: %w", err)
+	}
+	return id, nil
+}
+
+// Renew implements JobRepositorer
+func (r *jobRepository) Renew(ctx context.Context, id string, lease time.Duration) error {
+	if err := r.client.SetXX(ctx, leaseKey(id), 1, lease).Err(); err != nil {
+		return fmt.Errorf("failed to renew lease: %w", err)
+	}
+	return nil
+}
+
+// Update implements JobRepositorer
+func (r *jobRepository) Update(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := r.client.Set(ctx, jobKey(job.ID), data, 0).Err(); err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	return nil
+}
+
+// Retry implements JobRepositorer
+func (r *jobRepository) Retry(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if _, err := r.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, jobKey(job.ID), data, 0)
+		pipe.Del(ctx, leaseKey(job.ID))
+		pipe.LRem(ctx, processingKey, 0, job.ID)
+		pipe.RPush(ctx, queueKey, job.ID)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+// Finish implements JobRepositorer
+func (r *jobRepository) Finish(ctx context.Context, job *Job, ttl time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if _, err := r.client.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.Set(ctx, jobKey(job.ID), data, ttl)
+		pipe.Del(ctx, imageKey(job.ID), leaseKey(job.ID))
+		pipe.LRem(ctx, processingKey, 0, job.ID)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+	return nil
+}
+
+// Recover implements JobRepositorer
+func (r *jobRepository) Recover(ctx context.Context) (int, error) {
+	ids, err := r.client.LRange(ctx, processingKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list processing jobs: %w", err)
+	}
+	recovered := 0
+	for _, id := range ids {
+		// the job is checked and queued in the script, the worker may finish
+		// it meanwhile
+		n, err := requeue.Run(
+			ctx, r.client, []string{processingKey, queueKey, jobKey(id), leaseKey(id)},
+			id, time.Now().UTC().Format(time.RFC3339Nano),
+		).Int()
+		if err != nil {
+			return recovered, fmt.Errorf("failed to recover job: %w", err)
+		}
+		recovered += n
+	}
+	return recovered, nil
+}
@@ -0,0 +1,131 @@
+package job
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lease = time.Minute
+
+// newRepository returns the repository of a new client of the server, as
+// after a restart of the API.
+func newRepository(t *testing.T, server *miniredis.Miniredis) JobRepositorer {
+	client := goredis.NewClient(&goredis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client)
+}
+
+// createJob creates the job and takes it as a worker.
+func createJob(t *testing.T, repo JobRepositorer, id string) *Job {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	job := &Job{ID: id, UserID: 1, Status: StatusQueued, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, job, []byte("image")))
+	next, err := repo.Next(ctx, time.Second, lease)
+	require.NoError(t, err)
+	require.Equal(t, id, next)
+	job.Status, job.Attempts = StatusRunning, 1
+	require.NoError(t, repo.Update(ctx, job))
+	return job
+}
+
+func Test_Recover(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stopped worker", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		createJob(t, newRepository(t, server), "1")
+		repo := newRepository(t, server)
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "the lease is not expired")
+
+		server.FastForward(lease)
+		n, err = repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		job, err := repo.FindByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, StatusQueued, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		image, err := repo.FindImage(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("image"), image)
+		next, err := repo.Next(ctx, time.Second, lease)
+		require.NoError(t, err)
+		assert.Equal(t, "1", next)
+	})
+
+	t.Run("renewed lease", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		repo := newRepository(t, server)
+		createJob(t, repo, "1")
+		for i := 0; i < 3; i++ {
+			server.FastForward(lease / 2)
+			require.NoError(t, repo.Renew(ctx, "1", lease))
+		}
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("expired lease is not renewed", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		repo := newRepository(t, server)
+		createJob(t, repo, "1")
+		server.FastForward(lease)
+		require.NoError(t, repo.Renew(ctx, "1", lease))
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("queued job", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		repo := newRepository(t, server)
+		now := time.Now().UTC()
+		require.NoError(t, repo.Create(ctx, &Job{ID: "1", Status: StatusQueued, CreatedAt: now, UpdatedAt: now}, nil))
+		server.FastForward(2 * lease)
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		queue, err := server.List(queueKey)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, queue)
+	})
+
+	t.Run("finished job", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		repo := newRepository(t, server)
+		job := createJob(t, repo, "1")
+		server.FastForward(lease)
+		job.Status = StatusDone
+		require.NoError(t, repo.Finish(ctx, job, time.Hour))
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		job, err = repo.FindByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, StatusDone, job.Status)
+		assert.False(t, server.Exists(queueKey))
+		assert.False(t, server.Exists(processingKey))
+	})
+
+	t.Run("expired job", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		repo := newRepository(t, server)
+		createJob(t, repo, "1")
+		server.Del(jobKey("1"))
+		server.FastForward(lease)
+		n, err := repo.Recover(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.False(t, server.Exists(processingKey))
+	})
+}
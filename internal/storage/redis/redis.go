@@ -13,8 +13,18 @@ type Client interface {
 	Get(context.Context, string) *redis.StringCmd
 	Set(context.Context, string, interface{}, time.Duration) *redis.StatusCmd
 	Del(context.Context, ...string) *redis.IntCmd
+	RPush(context.Context, string, ...interface{}) *redis.IntCmd
+	BLMove(context.Context, string, string, string, string, time.Duration) *redis.StringCmd
+	LRem(context.Context, string, int64, interface{}) *redis.IntCmd
+	LRange(context.Context, string, int64, int64) *redis.StringSliceCmd
+	SetXX(context.Context, string, interface{}, time.Duration) *redis.BoolCmd
+	TxPipelined(context.Context, func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	redis.Scripter
 }
 
+// Nil is returned when the key does not exist.
+const Nil = redis.Nil
+
 func New(cfg *config.Config) (Client, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.Cache.Host, cfg.Cache.Port),